@@ -163,6 +163,10 @@ type AgentCatalogStatus struct {
 	// For managed resources: set by RegistryDeployment
 	// +optional
 	Deployment *DeploymentRef `json:"deployment,omitempty"`
+	// UnresolvedTools lists, per MCP server, the referenced tools the server did not expose
+	// when it was last introspected
+	// +optional
+	UnresolvedTools []AgentToolRef `json:"unresolvedTools,omitempty"`
 	// Conditions represent the latest available observations of the agent's state
 	// +optional
 	Conditions []CatalogCondition `json:"conditions,omitempty"`
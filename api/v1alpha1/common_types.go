@@ -122,6 +122,13 @@ const (
 	CatalogConditionReady CatalogConditionType = "Ready"
 	// CatalogConditionPublished indicates whether the catalog entry is published
 	CatalogConditionPublished CatalogConditionType = "Published"
+	// CatalogConditionToolsResolved indicates whether the tools an agent references are exposed by its MCP servers
+	CatalogConditionToolsResolved CatalogConditionType = "ToolsResolved"
+	// CatalogConditionSourceAvailable is false while the source of a discovered entry is missing;
+	// its transition time is when the source went missing
+	CatalogConditionSourceAvailable CatalogConditionType = "SourceAvailable"
+	// CatalogConditionPreflight indicates whether a deployment passed the checks run before it is applied
+	CatalogConditionPreflight CatalogConditionType = "Preflight"
 )
 
 // Common label keys used across all catalog resources
@@ -139,6 +146,35 @@ const (
 	LabelResourceSource = "agentregistry.dev/resource-source"
 	// LabelManagedBy indicates the managing component
 	LabelManagedBy = "agentregistry.dev/managed-by"
+	// LabelEnvironment is the DiscoveryConfig environment a discovered entry was found in
+	LabelEnvironment = "agentregistry.dev/environment"
+	// LabelCluster is the cluster a discovered entry was found in
+	LabelCluster = "agentregistry.dev/cluster"
+	// LabelDiscoveryConfig is the DiscoveryConfig that discovered an entry
+	LabelDiscoveryConfig = "agentregistry.dev/discovery-config"
+	// LabelEnrolledBy is the DiscoveryConfig whose clusterAPI enrollment generated a child
+	// DiscoveryConfig
+	LabelEnrolledBy = "agentregistry.dev/enrolled-by"
+	// LabelDeploymentConfig set to "true" on a Secret allows RegistryDeployments to read config
+	// from it. Unlabeled Secrets are never read as deployment config.
+	LabelDeploymentConfig = "agentregistry.dev/deployment-config"
+)
+
+// AnnotationTriggerDiscovery on a DiscoveryConfig requests a full relist of all its
+// environments. The controller removes it once the resync has run.
+const AnnotationTriggerDiscovery = "agentregistry.dev/trigger-discovery"
+
+// Annotations that mark plain Services and Deployments as MCP servers for discovery.
+// AnnotationMCPPath is required; the others are optional.
+const (
+	// AnnotationMCPPath is the HTTP path the MCP endpoint is served on (e.g. "/mcp")
+	AnnotationMCPPath = "agentregistry.dev/mcp-path"
+	// AnnotationMCPTransport is the MCP transport: "streamable-http" (default) or "sse"
+	AnnotationMCPTransport = "agentregistry.dev/mcp-transport"
+	// AnnotationMCPName overrides the catalog name (default "{namespace}/{name}")
+	AnnotationMCPName = "agentregistry.dev/mcp-name"
+	// AnnotationMCPPort selects the Service port by name or number (default: the first port)
+	AnnotationMCPPort = "agentregistry.dev/mcp-port"
 )
 
 // ResourceSource values for LabelResourceSource
@@ -8,6 +8,178 @@ import (
 type DiscoveryConfigSpec struct {
 	// Environments is a list of environments to discover resources from
 	Environments []Environment `json:"environments"`
+
+	// Prune deletes the catalog entries discovered by an informer when its environment,
+	// namespace or resource type is removed from this spec (or the DiscoveryConfig is deleted).
+	// When false (default), those entries are left in place.
+	// +optional
+	Prune bool `json:"prune,omitempty"`
+
+	// CustomResources maps additional resource types to catalog entries. A mapping is
+	// discovered in every environment that lists its name in ResourceTypes.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	CustomResources []CustomResourceMapping `json:"customResources,omitempty"`
+
+	// ClusterAPI enrolls Cluster API workload clusters. Every ready Cluster it selects gets a
+	// child DiscoveryConfig with one environment that reaches the cluster through its
+	// generated kubeconfig Secret; the child is deleted with the Cluster. Requires the
+	// controller to run with --enable-cluster-api-enrollment.
+	// +optional
+	ClusterAPI *ClusterAPIEnrollment `json:"clusterAPI,omitempty"`
+
+	// GitSources index the MCP servers referenced by the MCP client configs and
+	// .agentregistry.yaml manifests committed to git repositories
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	GitSources []GitSource `json:"gitSources,omitempty"`
+}
+
+// GitSource is a set of git repositories scanned for .vscode/mcp.json, .cursor/mcp.json,
+// claude_desktop_config.json, mcp.json and .agentregistry.yaml files. Every server they
+// reference gets an imported MCPServerCatalog entry recording which repositories use it.
+type GitSource struct {
+	// Name identifies the source
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Repositories are paths or file:// URLs of clones or bare repositories mounted into the
+	// controller, e.g. from a volume kept up to date by a git-sync sidecar
+	// +kubebuilder:validation:MinItems=1
+	Repositories []string `json:"repositories"`
+
+	// Ref is the branch, tag or commit scanned in every repository. Defaults to HEAD.
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// Interval between scans. Defaults to 5m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Labels are additional labels to apply to the catalog entries of this source
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ClusterAPIEnrollment selects Cluster API clusters and describes the environment generated
+// for each. Name, Namespaces, ExcludeNamespaces and label values are Go templates evaluated
+// against the Cluster, e.g. "team-{{ index .Labels "team" }}". Templates see .Name,
+// .Namespace, .Labels and .Annotations of the Cluster.
+type ClusterAPIEnrollment struct {
+	// Selector restricts enrollment to Clusters whose labels match. Empty selects all Clusters.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// ClusterNamespaces lists the namespaces of the management cluster to look for Clusters in.
+	// Empty means all namespaces.
+	// +optional
+	ClusterNamespaces []string `json:"clusterNamespaces,omitempty"`
+
+	// EnvironmentName is the name of the generated environment. Defaults to
+	// "{{ .Namespace }}-{{ .Name }}", so equally named Clusters in different namespaces get
+	// different environments.
+	// +optional
+	EnvironmentName string `json:"environmentName,omitempty"`
+
+	// Namespaces of the workload cluster to discover in; see Environment.Namespaces
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// AllNamespaces discovers in every namespace of the workload cluster except ExcludeNamespaces
+	// +optional
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// ExcludeNamespaces of the workload cluster are never discovered in
+	// +optional
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+
+	// ResourceTypes specifies which types to discover. Empty means all types.
+	// +optional
+	ResourceTypes []string `json:"resourceTypes,omitempty"`
+
+	// DeployEnabled allows deploying catalog items to enrolled clusters
+	// +optional
+	DeployEnabled bool `json:"deployEnabled,omitempty"`
+
+	// Labels are additional labels to apply to resources discovered in enrolled clusters
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// CustomResourceMapping describes how to turn instances of an arbitrary resource into
+// catalog entries
+type CustomResourceMapping struct {
+	// Name identifies the mapping. List it in Environment.ResourceTypes to discover it.
+	// Names of built-in resource types (MCPServer, Agent, ...) take precedence.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`
+	Name string `json:"name"`
+
+	// Group is the API group of the watched resource (empty for the core group)
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Version is the API version of the watched resource
+	Version string `json:"version"`
+
+	// Resource is the plural resource name (e.g. "inferenceservices")
+	Resource string `json:"resource"`
+
+	// CatalogKind is the kind of catalog entry produced for each resource
+	// +kubebuilder:validation:Enum=MCPServer;Agent;Model
+	CatalogKind string `json:"catalogKind"`
+
+	// Fields extract catalog fields from each resource
+	// +optional
+	Fields CustomResourceFields `json:"fields,omitempty"`
+}
+
+// CustomResourceFields holds one expression per catalog field. An expression is either
+// JSONPath (e.g. "{.status.url}" or ".status.url") or CEL when prefixed with "cel:"
+// (e.g. "cel:object.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')").
+// CEL expressions see the resource as `object`.
+type CustomResourceFields struct {
+	// Name is the catalog name. Defaults to "{namespace}/{name}".
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Version defaults to the app.kubernetes.io/version label, then "latest"
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Description of the entry
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// URL is the endpoint: the remote of an MCP server, the endpoint of an agent, or the
+	// base URL of a model
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Ready evaluates to true or "True" when the resource is ready. Defaults to ready.
+	// +optional
+	Ready string `json:"ready,omitempty"`
+
+	// Image is the container image of an agent
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Provider is the provider of a model
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// Model is the model identifier of a model
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// Runtime is the serving runtime of a model
+	// +optional
+	Runtime string `json:"runtime,omitempty"`
 }
 
 // Environment represents a target cluster/namespace for resource discovery
@@ -18,7 +190,10 @@ type Environment struct {
 	// Cluster contains cluster connection information
 	Cluster ClusterConfig `json:"cluster"`
 
-	// Provider is the cloud provider (gcp, aws, azure)
+	// Provider selects how the cluster is reached: gcp, aws, azure or aks with workload
+	// identity, static, kubeconfig, in-cluster, or satellite for clusters that pull their
+	// deployments. When empty it is inferred from the cluster settings.
+	// +kubebuilder:validation:Enum=aks;aws;azure;gcp;in-cluster;kubeconfig;satellite;static
 	// +optional
 	Provider string `json:"provider,omitempty"`
 
@@ -36,10 +211,30 @@ type Environment struct {
 	// +optional
 	DeployEnabled bool `json:"deployEnabled,omitempty"`
 
-	// Namespaces is a list of namespaces to discover in. Empty means all namespaces.
+	// Namespaces is a list of namespaces to discover in. Entries may be exact names or
+	// glob patterns (e.g. "team-*"); patterns are matched against the namespaces that
+	// exist in the cluster and followed as namespaces are created or deleted.
 	// +optional
 	Namespaces []string `json:"namespaces,omitempty"`
 
+	// NamespaceRegex lists regular expressions matched against the namespaces that exist
+	// in the cluster, in addition to Namespaces
+	// +optional
+	NamespaceRegex []string `json:"namespaceRegex,omitempty"`
+
+	// AllNamespaces discovers in every namespace of the cluster except ExcludeNamespaces
+	// +optional
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// ExcludeNamespaces lists namespaces (exact names or glob patterns) never discovered in,
+	// even when matched by Namespaces, NamespaceRegex or AllNamespaces
+	// +optional
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+
+	// LabelSelector restricts discovery to resources whose labels match
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
 	// ResourceTypes specifies which types to discover (MCPServer, Agent, Skill, ModelConfig)
 	// Empty means all types
 	// +optional
@@ -74,6 +269,14 @@ type ClusterConfig struct {
 	// +optional
 	ProjectID string `json:"projectId,omitempty"`
 
+	// SubscriptionID is the Azure subscription of an AKS cluster
+	// +optional
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+
+	// ResourceGroup is the Azure resource group of an AKS cluster
+	// +optional
+	ResourceGroup string `json:"resourceGroup,omitempty"`
+
 	// Zone is the cluster zone/region
 	// +optional
 	Zone string `json:"zone,omitempty"`
@@ -100,6 +303,27 @@ type ClusterConfig struct {
 	// ServiceAccount is the service account to use for workload identity
 	// +optional
 	ServiceAccount string `json:"serviceAccount,omitempty"`
+
+	// CredentialsSecretRef references a Secret with credentials for the cluster: a kubeconfig
+	// (key "kubeconfig"), a bearer token ("token") or a client certificate and key
+	// ("tls.crt" and "tls.key"). "ca.crt" overrides CAData. Takes precedence over workload identity.
+	// +optional
+	CredentialsSecretRef *ClusterCredentialsSecretRef `json:"credentialsSecretRef,omitempty"`
+}
+
+// ClusterCredentialsSecretRef references a Secret holding credentials for a remote cluster
+type ClusterCredentialsSecretRef struct {
+	// Name is the Secret name
+	Name string `json:"name"`
+
+	// Namespace is the Secret namespace; defaults to the controller's namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Key holds a kubeconfig under a custom key, such as "value" in the kubeconfig Secrets
+	// generated by Cluster API. When empty the well-known keys are read.
+	// +optional
+	Key string `json:"key,omitempty"`
 }
 
 // RegistryConfig contains container registry information
@@ -130,8 +354,69 @@ type DiscoveryConfigStatus struct {
 	// LastSyncTime is the last time discovery was synced
 	// +optional
 	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// LastResync reports the outcome of the most recent forced resync
+	// +optional
+	LastResync *ResyncReport `json:"lastResync,omitempty"`
+
+	// GitSources contains the status of each git source
+	// +optional
+	GitSources []GitSourceStatus `json:"gitSources,omitempty"`
+}
+
+// GitSourceStatus reports the last scan of a git source
+type GitSourceStatus struct {
+	// Name is the git source name
+	Name string `json:"name"`
+
+	// LastScanTime is when the repositories were last scanned
+	// +optional
+	LastScanTime *metav1.Time `json:"lastScanTime,omitempty"`
+
+	// Servers is the number of distinct MCP servers referenced in the repositories
+	// +optional
+	Servers int `json:"servers,omitempty"`
+
+	// Errors lists the repositories and files that could not be read or parsed
+	// +optional
+	Errors []string `json:"errors,omitempty"`
 }
 
+// ResyncReport summarizes a forced relist of every environment of a DiscoveryConfig
+type ResyncReport struct {
+	// CompletedAt is when the resync finished
+	CompletedAt metav1.Time `json:"completedAt"`
+
+	// Added is the number of catalog entries created by the resync
+	// +optional
+	Added int `json:"added,omitempty"`
+
+	// Updated is the number of existing catalog entries recomputed by the resync
+	// +optional
+	Updated int `json:"updated,omitempty"`
+
+	// Removed is the number of catalog entries deleted because their source no longer exists
+	// +optional
+	Removed int `json:"removed,omitempty"`
+
+	// Errors lists the environments or resources that could not be resynced
+	// +optional
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ConnectionState is the health of the connection to an environment's cluster
+type ConnectionState string
+
+const (
+	// ConnectionStateHealthy indicates the cluster answered its last probe
+	ConnectionStateHealthy ConnectionState = "Healthy"
+	// ConnectionStateDegraded indicates recent probes failed but the cluster is still used
+	ConnectionStateDegraded ConnectionState = "Degraded"
+	// ConnectionStateOpen indicates the circuit breaker is open and the cluster is not used
+	// until the next retry
+	ConnectionStateOpen ConnectionState = "Open"
+)
+
 // EnvironmentStatus represents the status of discovery for a specific environment
 type EnvironmentStatus struct {
 	// Name is the environment name
@@ -155,6 +440,19 @@ type EnvironmentStatus struct {
 	// Error contains error information if connection failed
 	// +optional
 	Error string `json:"error,omitempty"`
+
+	// ConnectionState is the health of the connection to the cluster, as seen by periodic probes
+	// +kubebuilder:validation:Enum=Healthy;Degraded;Open
+	// +optional
+	ConnectionState ConnectionState `json:"connectionState,omitempty"`
+
+	// ConsecutiveFailures is the number of failed probes since the cluster last answered
+	// +optional
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+
+	// NextRetryTime is when the cluster is tried again while the circuit breaker is open
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
 }
 
 // DiscoveredResourceCounts tracks the number of resources discovered
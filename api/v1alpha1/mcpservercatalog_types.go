@@ -82,9 +82,18 @@ type MCPServerCatalogStatus struct {
 	// For managed resources: set by RegistryDeployment
 	// +optional
 	Deployment *DeploymentRef `json:"deployment,omitempty"`
+	// Gateway records the gateway route exposing a server discovered as a gateway backend
+	// +optional
+	Gateway *GatewayExposure `json:"gateway,omitempty"`
+	// GitReferences lists the files in git repositories that reference an imported server
+	// +optional
+	GitReferences []GitReference `json:"gitReferences,omitempty"`
 	// UsedBy lists the agents that reference this MCP server
 	// +optional
 	UsedBy []MCPServerUsageRef `json:"usedBy,omitempty"`
+	// Introspection records what the live server exposed when it was last probed
+	// +optional
+	Introspection *MCPServerIntrospection `json:"introspection,omitempty"`
 	// Conditions represent the latest available observations of the server's state
 	// +optional
 	Conditions []CatalogCondition `json:"conditions,omitempty"`
@@ -115,6 +124,164 @@ type DeploymentRef struct {
 	Message string `json:"message,omitempty"`
 }
 
+// GatewayExposure describes how an agentgateway or kgateway MCP backend is reached
+type GatewayExposure struct {
+	// Gateway is the namespace/name of the Gateway the route is attached to
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+	// Listener is the name of the Gateway listener exposing the route
+	// +optional
+	Listener string `json:"listener,omitempty"`
+	// Route is the namespace/name of the HTTPRoute routing to the backend
+	// +optional
+	Route string `json:"route,omitempty"`
+	// URL is the gateway-fronted endpoint of the server
+	// +optional
+	URL string `json:"url,omitempty"`
+	// Targets are the upstream MCP servers the backend proxies to
+	// +optional
+	Targets []GatewayMCPTarget `json:"targets,omitempty"`
+}
+
+// GatewayMCPTarget is an upstream MCP server behind a gateway backend
+type GatewayMCPTarget struct {
+	// Name of the target
+	Name string `json:"name"`
+	// URL of a static target
+	// +optional
+	URL string `json:"url,omitempty"`
+	// Selector is the label selector of a target that selects Services, as JSON
+	// +optional
+	Selector string `json:"selector,omitempty"`
+}
+
+// GitReference is a file in a git repository that references an MCP server
+type GitReference struct {
+	// Repository is the path or URL of the repository, as listed in the git source
+	Repository string `json:"repository"`
+	// Path of the file in the repository
+	Path string `json:"path"`
+	// Server is the name the server is configured under in the file
+	// +optional
+	Server string `json:"server,omitempty"`
+	// Commit is the commit the file was read from
+	// +optional
+	Commit string `json:"commit,omitempty"`
+}
+
+// MCPServerIntrospection is the result of connecting to a server's MCP endpoint and listing
+// its tools, prompts and resources
+type MCPServerIntrospection struct {
+	// URL is the endpoint that was probed
+	URL string `json:"url"`
+	// Transport is the transport used to connect (streamable-http or sse)
+	// +optional
+	Transport string `json:"transport,omitempty"`
+	// ServerInfo is the implementation the server reported during initialization
+	// +optional
+	ServerInfo *MCPImplementation `json:"serverInfo,omitempty"`
+	// ProtocolVersion is the MCP protocol version negotiated with the server
+	// +optional
+	ProtocolVersion string `json:"protocolVersion,omitempty"`
+	// Capabilities lists the capabilities the server advertised (tools, prompts, resources, logging, completions)
+	// +optional
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Tools are the tools returned by tools/list
+	// +optional
+	Tools []MCPTool `json:"tools,omitempty"`
+	// Prompts are the prompts returned by prompts/list
+	// +optional
+	Prompts []MCPPrompt `json:"prompts,omitempty"`
+	// Resources are the resources returned by resources/list
+	// +optional
+	Resources []MCPResource `json:"resources,omitempty"`
+	// LastProbed is when the server was last probed
+	// +optional
+	LastProbed *metav1.Time `json:"lastProbed,omitempty"`
+	// LastSuccess is when the server was last probed successfully; the lists above are from that probe
+	// +optional
+	LastSuccess *metav1.Time `json:"lastSuccess,omitempty"`
+	// Error is the reason the last probe failed, empty if it succeeded
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// MCPImplementation identifies an MCP server implementation
+type MCPImplementation struct {
+	// Name of the implementation
+	Name string `json:"name"`
+	// Title is a human-readable name
+	// +optional
+	Title string `json:"title,omitempty"`
+	// Version of the implementation
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// MCPTool is a tool exposed by an MCP server
+type MCPTool struct {
+	// Name of the tool
+	Name string `json:"name"`
+	// Title is a human-readable name
+	// +optional
+	Title string `json:"title,omitempty"`
+	// Description of the tool
+	// +optional
+	Description string `json:"description,omitempty"`
+	// InputSchema is the JSON Schema of the tool's arguments
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Type=object
+	InputSchema *apiextensionsv1.JSON `json:"inputSchema,omitempty"`
+	// OutputSchema is the JSON Schema of the tool's structured result
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Type=object
+	OutputSchema *apiextensionsv1.JSON `json:"outputSchema,omitempty"`
+}
+
+// MCPPrompt is a prompt exposed by an MCP server
+type MCPPrompt struct {
+	// Name of the prompt
+	Name string `json:"name"`
+	// Title is a human-readable name
+	// +optional
+	Title string `json:"title,omitempty"`
+	// Description of the prompt
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Arguments the prompt accepts
+	// +optional
+	Arguments []MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+// MCPPromptArgument is an argument of an MCP prompt
+type MCPPromptArgument struct {
+	// Name of the argument
+	Name string `json:"name"`
+	// Description of the argument
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Required indicates the argument must be provided
+	// +optional
+	Required bool `json:"required,omitempty"`
+}
+
+// MCPResource is a resource exposed by an MCP server
+type MCPResource struct {
+	// URI of the resource
+	URI string `json:"uri"`
+	// Name of the resource
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Description of the resource
+	// +optional
+	Description string `json:"description,omitempty"`
+	// MIMEType of the resource
+	// +optional
+	MIMEType string `json:"mimeType,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=mcscat;mcservercat
@@ -38,6 +38,10 @@ type ModelCatalogSpec struct {
 	// BaseURL is the API endpoint URL
 	// +optional
 	BaseURL string `json:"baseUrl,omitempty"`
+	// Runtime is the serving runtime of a self-hosted model (e.g. "kserve", "llm-d", "vllm").
+	// Empty for models reached through a provider config.
+	// +optional
+	Runtime string `json:"runtime,omitempty"`
 	// Description of the model configuration
 	// +optional
 	Description string `json:"description,omitempty"`
@@ -84,6 +88,7 @@ type ModelCatalogStatus struct {
 // +kubebuilder:printcolumn:name="Name",type=string,JSONPath=`.spec.name`
 // +kubebuilder:printcolumn:name="Provider",type=string,JSONPath=`.spec.provider`
 // +kubebuilder:printcolumn:name="Model",type=string,JSONPath=`.spec.model`
+// +kubebuilder:printcolumn:name="Runtime",type=string,JSONPath=`.spec.runtime`,priority=1
 // +kubebuilder:printcolumn:name="Published",type=boolean,JSONPath=`.status.published`
 // +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=`.status.ready`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -50,6 +51,11 @@ type RegistryDeploymentSpec struct {
 	// Config contains deployment configuration (environment variables, etc.)
 	// +optional
 	Config map[string]string `json:"config,omitempty"`
+	// ConfigFrom contains deployment configuration read from Secrets and ConfigMaps in the
+	// deployment's namespace, for values such as API keys that should not be stored in the
+	// spec. An entry overrides a Config entry of the same name.
+	// +optional
+	ConfigFrom []ConfigValueFrom `json:"configFrom,omitempty"`
 	// Namespace is the target namespace for Kubernetes deployments
 	// +optional
 	Namespace string `json:"namespace,omitempty"`
@@ -59,6 +65,26 @@ type RegistryDeploymentSpec struct {
 	Environment string `json:"environment,omitempty"`
 }
 
+// ConfigValueFrom is a deployment config entry whose value is read from a Secret or ConfigMap
+type ConfigValueFrom struct {
+	// Name is the config key: an environment variable, header or argument name
+	Name string `json:"name"`
+	// ValueFrom is the source of the value
+	ValueFrom ConfigValueSource `json:"valueFrom"`
+}
+
+// ConfigValueSource selects the key a config value is read from. Exactly one of its fields
+// must be set.
+type ConfigValueSource struct {
+	// SecretKeyRef selects a key of a Secret. The value is copied to a Secret in the target
+	// namespace and referenced from there, never inlined into the deployed resources.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// ConfigMapKeyRef selects a key of a ConfigMap. The value is used like a Config entry.
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
 // RegistryDeploymentStatus defines the observed state of RegistryDeployment
 type RegistryDeploymentStatus struct {
 	// Phase is the current deployment phase
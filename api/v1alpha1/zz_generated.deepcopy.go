@@ -6,6 +6,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
@@ -140,6 +141,13 @@ func (in *AgentCatalogStatus) DeepCopyInto(out *AgentCatalogStatus) {
 		*out = new(DeploymentRef)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.UnresolvedTools != nil {
+		in, out := &in.UnresolvedTools, &out.UnresolvedTools
+		*out = make([]AgentToolRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]CatalogCondition, len(*in))
@@ -245,9 +253,61 @@ func (in *CatalogCondition) DeepCopy() *CatalogCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAPIEnrollment) DeepCopyInto(out *ClusterAPIEnrollment) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterNamespaces != nil {
+		in, out := &in.ClusterNamespaces, &out.ClusterNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeNamespaces != nil {
+		in, out := &in.ExcludeNamespaces, &out.ExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceTypes != nil {
+		in, out := &in.ResourceTypes, &out.ResourceTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAPIEnrollment.
+func (in *ClusterAPIEnrollment) DeepCopy() *ClusterAPIEnrollment {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAPIEnrollment)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterConfig) DeepCopyInto(out *ClusterConfig) {
 	*out = *in
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(ClusterCredentialsSecretRef)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterConfig.
@@ -260,6 +320,62 @@ func (in *ClusterConfig) DeepCopy() *ClusterConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCredentialsSecretRef) DeepCopyInto(out *ClusterCredentialsSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCredentialsSecretRef.
+func (in *ClusterCredentialsSecretRef) DeepCopy() *ClusterCredentialsSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCredentialsSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigValueFrom) DeepCopyInto(out *ConfigValueFrom) {
+	*out = *in
+	in.ValueFrom.DeepCopyInto(&out.ValueFrom)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigValueFrom.
+func (in *ConfigValueFrom) DeepCopy() *ConfigValueFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigValueFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigValueSource) DeepCopyInto(out *ConfigValueSource) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigValueSource.
+func (in *ConfigValueSource) DeepCopy() *ConfigValueSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigValueSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentRef) DeepCopyInto(out *DeploymentRef) {
 	*out = *in
@@ -279,6 +395,37 @@ func (in *DeploymentRef) DeepCopy() *DeploymentRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceFields) DeepCopyInto(out *CustomResourceFields) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceFields.
+func (in *CustomResourceFields) DeepCopy() *CustomResourceFields {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceFields)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomResourceMapping) DeepCopyInto(out *CustomResourceMapping) {
+	*out = *in
+	out.Fields = in.Fields
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomResourceMapping.
+func (in *CustomResourceMapping) DeepCopy() *CustomResourceMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomResourceMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DiscoveredResourceCounts) DeepCopyInto(out *DiscoveredResourceCounts) {
 	*out = *in
@@ -363,6 +510,23 @@ func (in *DiscoveryConfigSpec) DeepCopyInto(out *DiscoveryConfigSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CustomResources != nil {
+		in, out := &in.CustomResources, &out.CustomResources
+		*out = make([]CustomResourceMapping, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterAPI != nil {
+		in, out := &in.ClusterAPI, &out.ClusterAPI
+		*out = new(ClusterAPIEnrollment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GitSources != nil {
+		in, out := &in.GitSources, &out.GitSources
+		*out = make([]GitSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryConfigSpec.
@@ -396,6 +560,18 @@ func (in *DiscoveryConfigStatus) DeepCopyInto(out *DiscoveryConfigStatus) {
 		in, out := &in.LastSyncTime, &out.LastSyncTime
 		*out = (*in).DeepCopy()
 	}
+	if in.LastResync != nil {
+		in, out := &in.LastResync, &out.LastResync
+		*out = new(ResyncReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GitSources != nil {
+		in, out := &in.GitSources, &out.GitSources
+		*out = make([]GitSourceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryConfigStatus.
@@ -411,13 +587,28 @@ func (in *DiscoveryConfigStatus) DeepCopy() *DiscoveryConfigStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Environment) DeepCopyInto(out *Environment) {
 	*out = *in
-	out.Cluster = in.Cluster
+	in.Cluster.DeepCopyInto(&out.Cluster)
 	out.Registry = in.Registry
 	if in.Namespaces != nil {
 		in, out := &in.Namespaces, &out.Namespaces
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NamespaceRegex != nil {
+		in, out := &in.NamespaceRegex, &out.NamespaceRegex
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeNamespaces != nil {
+		in, out := &in.ExcludeNamespaces, &out.ExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ResourceTypes != nil {
 		in, out := &in.ResourceTypes, &out.ResourceTypes
 		*out = make([]string, len(*in))
@@ -450,6 +641,10 @@ func (in *EnvironmentStatus) DeepCopyInto(out *EnvironmentStatus) {
 		*out = (*in).DeepCopy()
 	}
 	out.DiscoveredResources = in.DiscoveredResources
+	if in.NextRetryTime != nil {
+		in, out := &in.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentStatus.
@@ -462,6 +657,112 @@ func (in *EnvironmentStatus) DeepCopy() *EnvironmentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayExposure) DeepCopyInto(out *GatewayExposure) {
+	*out = *in
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]GatewayMCPTarget, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayExposure.
+func (in *GatewayExposure) DeepCopy() *GatewayExposure {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayExposure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayMCPTarget) DeepCopyInto(out *GatewayMCPTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayMCPTarget.
+func (in *GatewayMCPTarget) DeepCopy() *GatewayMCPTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayMCPTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitReference) DeepCopyInto(out *GitReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitReference.
+func (in *GitReference) DeepCopy() *GitReference {
+	if in == nil {
+		return nil
+	}
+	out := new(GitReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSource) DeepCopyInto(out *GitSource) {
+	*out = *in
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSource.
+func (in *GitSource) DeepCopy() *GitSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSourceStatus) DeepCopyInto(out *GitSourceStatus) {
+	*out = *in
+	if in.LastScanTime != nil {
+		in, out := &in.LastScanTime, &out.LastScanTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSourceStatus.
+func (in *GitSourceStatus) DeepCopy() *GitSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeyValueInput) DeepCopyInto(out *KeyValueInput) {
 	*out = *in
@@ -477,6 +778,71 @@ func (in *KeyValueInput) DeepCopy() *KeyValueInput {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPImplementation) DeepCopyInto(out *MCPImplementation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPImplementation.
+func (in *MCPImplementation) DeepCopy() *MCPImplementation {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPImplementation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPPrompt) DeepCopyInto(out *MCPPrompt) {
+	*out = *in
+	if in.Arguments != nil {
+		in, out := &in.Arguments, &out.Arguments
+		*out = make([]MCPPromptArgument, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPPrompt.
+func (in *MCPPrompt) DeepCopy() *MCPPrompt {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPPrompt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPPromptArgument) DeepCopyInto(out *MCPPromptArgument) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPPromptArgument.
+func (in *MCPPromptArgument) DeepCopy() *MCPPromptArgument {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPPromptArgument)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPResource) DeepCopyInto(out *MCPResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPResource.
+func (in *MCPResource) DeepCopy() *MCPResource {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MCPServerCatalog) DeepCopyInto(out *MCPServerCatalog) {
 	*out = *in
@@ -592,6 +958,16 @@ func (in *MCPServerCatalogStatus) DeepCopyInto(out *MCPServerCatalogStatus) {
 		*out = new(DeploymentRef)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(GatewayExposure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GitReferences != nil {
+		in, out := &in.GitReferences, &out.GitReferences
+		*out = make([]GitReference, len(*in))
+		copy(*out, *in)
+	}
 	if in.UsedBy != nil {
 		in, out := &in.UsedBy, &out.UsedBy
 		*out = make([]MCPServerUsageRef, len(*in))
@@ -599,6 +975,11 @@ func (in *MCPServerCatalogStatus) DeepCopyInto(out *MCPServerCatalogStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Introspection != nil {
+		in, out := &in.Introspection, &out.Introspection
+		*out = new(MCPServerIntrospection)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]CatalogCondition, len(*in))
@@ -618,6 +999,58 @@ func (in *MCPServerCatalogStatus) DeepCopy() *MCPServerCatalogStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPServerIntrospection) DeepCopyInto(out *MCPServerIntrospection) {
+	*out = *in
+	if in.ServerInfo != nil {
+		in, out := &in.ServerInfo, &out.ServerInfo
+		*out = new(MCPImplementation)
+		**out = **in
+	}
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tools != nil {
+		in, out := &in.Tools, &out.Tools
+		*out = make([]MCPTool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Prompts != nil {
+		in, out := &in.Prompts, &out.Prompts
+		*out = make([]MCPPrompt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]MCPResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastProbed != nil {
+		in, out := &in.LastProbed, &out.LastProbed
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccess != nil {
+		in, out := &in.LastSuccess, &out.LastSuccess
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPServerIntrospection.
+func (in *MCPServerIntrospection) DeepCopy() *MCPServerIntrospection {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPServerIntrospection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MCPServerUsageRef) DeepCopyInto(out *MCPServerUsageRef) {
 	*out = *in
@@ -638,6 +1071,31 @@ func (in *MCPServerUsageRef) DeepCopy() *MCPServerUsageRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MCPTool) DeepCopyInto(out *MCPTool) {
+	*out = *in
+	if in.InputSchema != nil {
+		in, out := &in.InputSchema, &out.InputSchema
+		*out = new(v1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutputSchema != nil {
+		in, out := &in.OutputSchema, &out.OutputSchema
+		*out = new(v1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MCPTool.
+func (in *MCPTool) DeepCopy() *MCPTool {
+	if in == nil {
+		return nil
+	}
+	out := new(MCPTool)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagedResource) DeepCopyInto(out *ManagedResource) {
 	*out = *in
@@ -925,6 +1383,13 @@ func (in *RegistryDeploymentSpec) DeepCopyInto(out *RegistryDeploymentSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ConfigFrom != nil {
+		in, out := &in.ConfigFrom, &out.ConfigFrom
+		*out = make([]ConfigValueFrom, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryDeploymentSpec.
@@ -987,6 +1452,27 @@ func (in *Repository) DeepCopy() *Repository {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResyncReport) DeepCopyInto(out *ResyncReport) {
+	*out = *in
+	in.CompletedAt.DeepCopyInto(&out.CompletedAt)
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResyncReport.
+func (in *ResyncReport) DeepCopy() *ResyncReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ResyncReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SkillCatalog) DeepCopyInto(out *SkillCatalog) {
 	*out = *in
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"io/fs"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -12,13 +13,18 @@ import (
 	"github.com/go-logr/zerologr"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
@@ -42,6 +48,12 @@ var (
 	scheme = runtime.NewScheme()
 )
 
+// uncachedSecrets reads Secrets from the API server, so the data of Secrets the controller
+// doesn't use is never cached
+var uncachedSecrets = client.Options{
+	Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}}},
+}
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(agentregistryv1alpha1.AddToScheme(scheme))
@@ -64,6 +76,20 @@ func main() {
 		mcpAddr              string
 		enableHTTPAPI        bool
 		logLevel             string
+
+		introspectionInterval time.Duration
+		clusterProbeInterval  time.Duration
+		catalogDeleteAfter    time.Duration
+		catalogRemoveAfter    time.Duration
+
+		enableClusterAPIEnrollment bool
+		enableDiscoverySharding    bool
+
+		mode                  string
+		registryURL           string
+		satelliteEnvironment  string
+		registryTokenFile     string
+		satellitePollInterval time.Duration
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8081", "The address the metric endpoint binds to.")
@@ -75,6 +101,29 @@ func main() {
 	flag.StringVar(&mcpAddr, "mcp-address", ":8083", "The address the MCP server binds to.")
 	flag.BoolVar(&enableHTTPAPI, "enable-http-api", true, "Enable the HTTP API server.")
 	flag.StringVar(&logLevel, "log-level", "info", "Log level (trace, debug, info, warn, error)")
+	flag.DurationVar(&introspectionInterval, "mcp-introspection-interval", 5*time.Minute,
+		"How often to connect to discovered and deployed MCP servers and record their tools, prompts and resources. 0 disables introspection.")
+	flag.DurationVar(&clusterProbeInterval, "cluster-probe-interval", cluster.DefaultProbeInterval,
+		"How often remote clusters are probed for health. Failing clusters are retried with exponential backoff.")
+	flag.DurationVar(&catalogDeleteAfter, "catalog-delete-after", controller.DefaultCatalogDeleteAfter,
+		"How long the source of a discovered catalog entry is missing before the deprecated entry is marked deleted. 0 keeps it deprecated.")
+	flag.DurationVar(&catalogRemoveAfter, "catalog-remove-after", controller.DefaultCatalogRemoveAfter,
+		"How long a deleted catalog entry is kept before it is removed, unless a deployment or agent references it. 0 keeps it.")
+	flag.BoolVar(&enableClusterAPIEnrollment, "enable-cluster-api-enrollment", false,
+		"Enroll Cluster API clusters selected by DiscoveryConfig spec.clusterAPI. Requires the Cluster API CRDs.")
+	flag.BoolVar(&enableDiscoverySharding, "enable-discovery-sharding", false,
+		"Partition discovery across all replicas instead of running it on the leader. "+
+			"Replicas are identified by the POD_NAME environment variable or the hostname.")
+
+	flag.StringVar(&mode, "mode", "registry",
+		"Run as the registry, or as a satellite that pulls deployments from a registry (registry, satellite).")
+	flag.StringVar(&registryURL, "registry-url", "", "Satellite mode: base URL of the registry HTTP API.")
+	flag.StringVar(&satelliteEnvironment, "satellite-environment", "",
+		"Satellite mode: the satellite environment this cluster serves.")
+	flag.StringVar(&registryTokenFile, "registry-token-file", "",
+		"Satellite mode: file holding the registry API token. It is re-read before every poll.")
+	flag.DurationVar(&satellitePollInterval, "satellite-poll-interval", controller.DefaultSatellitePollInterval,
+		"Satellite mode: how often deployments are pulled from the registry.")
 
 	// Parse flags (controller-runtime adds --kubeconfig flag automatically)
 	flag.Parse()
@@ -125,11 +174,33 @@ func main() {
 		Str("mcp-addr", mcpAddr).
 		Bool("enable-http-api", enableHTTPAPI).
 		Str("log-level", logLevel).
+		Str("mode", mode).
 		Msg("starting agent registry controller")
 
 	// Get Kubernetes config (uses --kubeconfig flag or KUBECONFIG env var or in-cluster)
 	config := ctrl.GetConfigOrDie()
 
+	switch mode {
+	case "registry":
+	case "satellite":
+		if registryURL == "" || satelliteEnvironment == "" {
+			log.Error().Msg("satellite mode requires --registry-url and --satellite-environment")
+			os.Exit(1)
+		}
+		runSatellite(config, &controller.Satellite{
+			Scheme:      scheme,
+			Logger:      log.Logger.With().Str("component", "satellite").Logger(),
+			RegistryURL: registryURL,
+			Environment: satelliteEnvironment,
+			TokenFile:   registryTokenFile,
+			Interval:    satellitePollInterval,
+		}, metricsAddr, probeAddr, enableLeaderElection)
+		return
+	default:
+		log.Error().Str("mode", mode).Msg("unknown mode, expected registry or satellite")
+		os.Exit(1)
+	}
+
 	// Watch namespace for controller resources (catalogs, etc.)
 	watchNamespace := os.Getenv("WATCH_NAMESPACE")
 	if watchNamespace == "" {
@@ -140,11 +211,26 @@ func main() {
 	// While AgentRegistry resources (Catalogs, RegistryDeployments, DiscoveryConfig) live in agentregistry namespace,
 	// the managed resources (MCPServer, Agent, etc.) can be deployed to any namespace via RegistryDeployment.spec.namespace
 	// We need to watch all namespaces so the reconciler can check and recreate deleted managed resources
-	cacheOpts := cache.Options{}
+	cacheOpts := cache.Options{
+		// Secrets are only cached for the watches, which see their metadata. The cache holds every
+		// Secret of the controller namespace, where API tokens, cluster credentials and deployment
+		// config usually live, and the Secrets created for deployments in other namespaces.
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.Secret{}: {
+				Namespaces: map[string]cache.Config{
+					arconfig.GetNamespace(): {},
+					cache.AllNamespaces: {
+						LabelSelector: labels.SelectorFromSet(labels.Set{agentregistryv1alpha1.LabelManagedBy: "agentregistry"}),
+					},
+				},
+			},
+		},
+	}
 
 	mgr, err := ctrl.NewManager(config, ctrl.Options{
 		Scheme: scheme,
 		Cache:  cacheOpts,
+		Client: uncachedSecrets,
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
@@ -166,11 +252,45 @@ func main() {
 	// Create controller logger
 	ctrlLogger := log.Logger.With().Str("component", "controller").Logger()
 
+	// Discovered resources are shared between the DiscoveryConfig informers and catalog reconcilers
+	discoveredCache := controller.NewDiscoveredResourceCache()
+	ctrlmetrics.Registry.MustRegister(discoveredCache)
+
+	// Partition discovery across replicas by environment, with membership kept in Leases
+	var discoveryShards *controller.DiscoveryShards
+	if enableDiscoverySharding {
+		identity := os.Getenv("POD_NAME")
+		if identity == "" {
+			if identity, err = os.Hostname(); err != nil {
+				log.Error().Err(err).Msg("unable to determine the replica identity for discovery sharding")
+				os.Exit(1)
+			}
+		}
+		discoveryShards = &controller.DiscoveryShards{
+			Client:    mgr.GetClient(),
+			Reader:    mgr.GetAPIReader(),
+			Logger:    ctrlLogger.With().Str("component", "discoveryshards").Logger(),
+			Namespace: arconfig.GetNamespace(),
+			Identity:  identity,
+		}
+		log.Info().Str("identity", identity).Msg("discovery sharding enabled")
+	}
+
+	// Discovered entries whose source is gone are deprecated, then deleted, then removed
+	catalogLifecycle := controller.CatalogLifecycle{
+		DeleteAfter: catalogDeleteAfter,
+		RemoveAfter: catalogRemoveAfter,
+	}
+
 	// Set up MCPServerCatalog reconciler
 	if err := (&controller.MCPServerCatalogReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: ctrlLogger.With().Str("controller", "mcpservercatalog").Logger(),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Logger:          ctrlLogger.With().Str("controller", "mcpservercatalog").Logger(),
+		DiscoveredCache: discoveredCache,
+		Shards:          discoveryShards,
+		Recorder:        mgr.GetEventRecorderFor("mcpservercatalog-controller"),
+		Lifecycle:       catalogLifecycle,
 	}).SetupWithManager(mgr); err != nil {
 		log.Error().Err(err).Str("controller", "MCPServerCatalog").Msg("unable to create controller")
 		os.Exit(1)
@@ -178,14 +298,32 @@ func main() {
 
 	// Set up AgentCatalog reconciler
 	if err := (&controller.AgentCatalogReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: ctrlLogger.With().Str("controller", "agentcatalog").Logger(),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Logger:          ctrlLogger.With().Str("controller", "agentcatalog").Logger(),
+		DiscoveredCache: discoveredCache,
+		Shards:          discoveryShards,
+		Recorder:        mgr.GetEventRecorderFor("agentcatalog-controller"),
+		Lifecycle:       catalogLifecycle,
 	}).SetupWithManager(mgr); err != nil {
 		log.Error().Err(err).Str("controller", "AgentCatalog").Msg("unable to create controller")
 		os.Exit(1)
 	}
 
+	// Set up ModelCatalog reconciler
+	if err := (&controller.ModelCatalogReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Logger:          ctrlLogger.With().Str("controller", "modelcatalog").Logger(),
+		DiscoveredCache: discoveredCache,
+		Shards:          discoveryShards,
+		Recorder:        mgr.GetEventRecorderFor("modelcatalog-controller"),
+		Lifecycle:       catalogLifecycle,
+	}).SetupWithManager(mgr); err != nil {
+		log.Error().Err(err).Str("controller", "ModelCatalog").Msg("unable to create controller")
+		os.Exit(1)
+	}
+
 	// Set up SkillCatalog reconciler
 	if err := (&controller.SkillCatalogReconciler{
 		Client: mgr.GetClient(),
@@ -198,8 +336,20 @@ func main() {
 
 	// Initialize remote client factory for multi-cluster support (discovery + deployment)
 	clusterFactory := cluster.NewFactory(mgr.GetClient(), ctrlLogger)
-	remoteClientFactory := clusterFactory.CreateClientFunc()
+	createClient := clusterFactory.CreateClientFunc()
+	remoteClientFactory := func(scope controller.DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+		return createClient(cluster.Scope(scope), env, scheme)
+	}
 	controller.RemoteClientFactory = remoteClientFactory
+
+	// Probe remote clusters so unreachable ones trip their circuit breaker
+	clusterConnections := clusterFactory.Connections()
+	clusterConnections.ProbeInterval = clusterProbeInterval
+	ctrlmetrics.Registry.MustRegister(clusterConnections)
+	if err := mgr.Add(clusterConnections); err != nil {
+		log.Error().Err(err).Msg("unable to add cluster connection manager")
+		os.Exit(1)
+	}
 	log.Info().Msg("initialized remote client factory for multi-cluster support")
 
 	// Set up RegistryDeployment reconciler
@@ -215,14 +365,41 @@ func main() {
 
 	// Set up DiscoveryConfig reconciler (discovers resources from target clusters)
 	if err := (&controller.DiscoveryConfigReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: ctrlLogger.With().Str("controller", "discoveryconfig").Logger(),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Logger:          ctrlLogger.With().Str("controller", "discoveryconfig").Logger(),
+		DiscoveredCache: discoveredCache,
+		Connections:     clusterConnections,
+		Shards:          discoveryShards,
 	}).SetupWithManager(mgr); err != nil {
 		log.Error().Err(err).Str("controller", "DiscoveryConfig").Msg("unable to create controller")
 		os.Exit(1)
 	}
 
+	// Set up Cluster API enrollment (generates DiscoveryConfigs for workload clusters)
+	if enableClusterAPIEnrollment {
+		if err := (&controller.ClusterEnrollmentReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+			Logger: ctrlLogger.With().Str("controller", "clusterenrollment").Logger(),
+		}).SetupWithManager(mgr); err != nil {
+			log.Error().Err(err).Str("controller", "ClusterEnrollment").Msg("unable to create controller")
+			os.Exit(1)
+		}
+	}
+
+	// Set up the MCP server prober (records tools, prompts and resources of cataloged servers)
+	if introspectionInterval > 0 {
+		if err := (&controller.MCPServerIntrospector{
+			Client:   mgr.GetClient(),
+			Logger:   ctrlLogger.With().Str("controller", "mcpintrospection").Logger(),
+			Interval: introspectionInterval,
+		}).SetupWithManager(mgr); err != nil {
+			log.Error().Err(err).Msg("unable to set up MCP server introspection")
+			os.Exit(1)
+		}
+	}
+
 	// Set up HTTP API server if enabled
 	if enableHTTPAPI {
 		// Set up embedded UI files
@@ -239,6 +416,27 @@ func main() {
 			mgr.GetClient(),
 			mgr.GetCache(),
 			apiLogger,
+			httpapi.WithInventoryIngester(controller.NewInventoryIngester(
+				mgr.GetClient(),
+				mgr.GetScheme(),
+				discoveredCache,
+				apiLogger.With().Str("component", "inventory").Logger(),
+			)),
+			httpapi.WithSatelliteHub(controller.NewSatelliteHub(
+				mgr.GetClient(),
+				mgr.GetScheme(),
+				apiLogger.With().Str("component", "satellite").Logger(),
+			)),
+			httpapi.WithDiscoveryPreviewer(controller.NewDiscoveryPreviewer(
+				mgr.GetClient(),
+				mgr.GetScheme(),
+				apiLogger.With().Str("component", "discovery-preview").Logger(),
+			)),
+			httpapi.WithDeploymentValidator(controller.NewDeploymentValidator(
+				mgr.GetClient(),
+				mgr.GetScheme(),
+				apiLogger.With().Str("component", "deployment-validation").Logger(),
+			)),
 		)
 		if err := mgr.Add(httpServer.Runnable(httpAPIAddr)); err != nil {
 			log.Error().Err(err).Msg("unable to add HTTP API server")
@@ -275,3 +473,47 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runSatellite runs the controller as a satellite: it applies the deployments the registry
+// serves for its environment to the local cluster and reports their status back. None of the
+// registry controllers, APIs or CRDs are needed in the satellite cluster.
+func runSatellite(config *rest.Config, satellite *controller.Satellite, metricsAddr, probeAddr string, enableLeaderElection bool) {
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme: scheme,
+		Metrics: server.Options{
+			BindAddress: metricsAddr,
+		},
+		HealthProbeBindAddress: probeAddr,
+		LeaderElection:         enableLeaderElection,
+		LeaderElectionID:       "satellite.agentregistry.dev",
+		Client:                 uncachedSecrets,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("unable to create manager")
+		os.Exit(1)
+	}
+
+	satellite.Client = mgr.GetClient()
+	if err := satellite.SetupWithManager(mgr); err != nil {
+		log.Error().Err(err).Msg("unable to set up satellite")
+		os.Exit(1)
+	}
+
+	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
+		log.Error().Err(err).Msg("unable to set up health check")
+		os.Exit(1)
+	}
+	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+		log.Error().Err(err).Msg("unable to set up ready check")
+		os.Exit(1)
+	}
+
+	log.Info().
+		Str("registry-url", satellite.RegistryURL).
+		Str("environment", satellite.Environment).
+		Msg("starting satellite")
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		log.Error().Err(err).Msg("problem running satellite")
+		os.Exit(1)
+	}
+}
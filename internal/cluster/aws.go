@@ -0,0 +1,175 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"golang.org/x/oauth2"
+	"k8s.io/client-go/rest"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+const (
+	// eksTokenPrefix marks a bearer token as a presigned STS GetCallerIdentity URL
+	eksTokenPrefix = "k8s-aws-v1."
+	// eksClusterIDHeader binds the presigned URL to one cluster
+	eksClusterIDHeader = "x-k8s-aws-id"
+	// eksTokenLifetime is how long the EKS authenticator accepts a token after it was signed
+	eksTokenLifetime = 15 * time.Minute
+	// eksTokenRefreshMargin is how long before expiry a new token is minted
+	eksTokenRefreshMargin = 2 * time.Minute
+)
+
+// loadAWSConfig loads AWS credentials with the default chain, which picks up IRSA
+// (AWS_WEB_IDENTITY_TOKEN_FILE + AWS_ROLE_ARN) and EKS Pod Identity
+// (AWS_CONTAINER_CREDENTIALS_FULL_URI) in cluster, and `aws sso login` or profiles locally.
+func loadAWSConfig(ctx context.Context, region string) (aws.Config, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	return awsconfig.LoadDefaultConfig(ctx, opts...)
+}
+
+func init() {
+	RegisterProvider(ProviderAWS, eksProvider{})
+}
+
+// eksProvider reaches EKS clusters with tokens minted from the pod's AWS credentials
+type eksProvider struct{ noRefresh }
+
+// Validate implements Provider. The region may also come from the AWS environment.
+func (eksProvider) Validate(env *agentregistryv1alpha1.Environment) error {
+	return validateCAData(env)
+}
+
+// RESTConfig implements Provider
+func (eksProvider) RESTConfig(ctx context.Context, f *Factory, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	return f.createEKSConfig(ctx, env)
+}
+
+// createEKSConfig creates a rest.Config for an EKS cluster authenticated with short-lived
+// tokens minted from the pod's AWS credentials. The endpoint and CA come from ClusterConfig
+// when both are set, otherwise from the EKS API.
+func (f *Factory) createEKSConfig(ctx context.Context, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	region := env.Cluster.Region
+	if region == "" {
+		region = env.Cluster.Zone
+	}
+
+	loadConfig := f.loadAWSConfig
+	if loadConfig == nil {
+		loadConfig = loadAWSConfig
+	}
+	awsCfg, err := loadConfig(ctx, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("cluster region is required for EKS")
+	}
+
+	endpoint := env.Cluster.Endpoint
+	caData := env.Cluster.CAData
+	if endpoint == "" || caData == "" {
+		f.logger.Debug().
+			Str("environment", env.Name).
+			Str("cluster", env.Cluster.Name).
+			Str("region", awsCfg.Region).
+			Msg("fetching EKS cluster info")
+
+		out, err := eks.NewFromConfig(awsCfg).DescribeCluster(ctx, &eks.DescribeClusterInput{
+			Name: aws.String(env.Cluster.Name),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe EKS cluster %s: %w", env.Cluster.Name, err)
+		}
+		if out.Cluster == nil || out.Cluster.Endpoint == nil {
+			return nil, fmt.Errorf("EKS cluster %s has no endpoint yet", env.Cluster.Name)
+		}
+		endpoint = aws.ToString(out.Cluster.Endpoint)
+		if out.Cluster.CertificateAuthority != nil {
+			caData = aws.ToString(out.Cluster.CertificateAuthority.Data)
+		}
+	}
+
+	ca, err := base64.StdEncoding.DecodeString(caData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode CA certificate: %w", err)
+	}
+	if len(endpoint) > 4 && endpoint[0:4] != "http" {
+		endpoint = "https://" + endpoint
+	}
+
+	config := &rest.Config{
+		Host: endpoint,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData: ca,
+		},
+	}
+
+	// Tokens expire after 15 minutes; the reuse source mints a new one shortly before
+	ts := oauth2.ReuseTokenSourceWithExpiry(nil, &eksTokenSource{
+		presign:   sts.NewPresignClient(sts.NewFromConfig(awsCfg)),
+		clusterID: env.Cluster.Name,
+		now:       time.Now,
+	}, eksTokenRefreshMargin)
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return &oauth2.Transport{
+			Source: ts,
+			Base:   rt,
+		}
+	}
+
+	// Set reasonable defaults
+	config.QPS = 50
+	config.Burst = 100
+
+	f.logger.Info().
+		Str("environment", env.Name).
+		Str("cluster", env.Cluster.Name).
+		Str("endpoint", endpoint).
+		Msg("successfully created EKS cluster config")
+
+	return config, nil
+}
+
+// eksTokenSource mints EKS bearer tokens: an STS GetCallerIdentity request presigned with
+// the pod's credentials and bound to the cluster name, which the cluster's authenticator
+// replays to learn the caller's IAM identity.
+type eksTokenSource struct {
+	presign   *sts.PresignClient
+	clusterID string
+	now       func() time.Time
+}
+
+// Token presigns a new GetCallerIdentity request
+func (s *eksTokenSource) Token() (*oauth2.Token, error) {
+	signedAt := s.now()
+	req, err := s.presign.PresignGetCallerIdentity(context.Background(), &sts.GetCallerIdentityInput{},
+		func(o *sts.PresignOptions) {
+			o.ClientOptions = append(o.ClientOptions, func(o *sts.Options) {
+				o.APIOptions = append(o.APIOptions,
+					smithyhttp.SetHeaderValue(eksClusterIDHeader, s.clusterID),
+					smithyhttp.SetHeaderValue("X-Amz-Expires", "60"),
+				)
+			})
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign STS GetCallerIdentity: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(req.URL)),
+		TokenType:   "Bearer",
+		Expiry:      signedAt.Add(eksTokenLifetime),
+	}, nil
+}
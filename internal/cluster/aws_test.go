@@ -0,0 +1,277 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+const testCAData = "dGVzdC1jYQ==" // "test-ca"
+
+// fakeAWS serves the EKS DescribeCluster and STS GetCallerIdentity APIs. It answers
+// GetCallerIdentity only when the request carries the cluster ID header, as the EKS
+// authenticator does when it replays a token.
+type fakeAWS struct {
+	*httptest.Server
+	describeCalls atomic.Int32
+
+	// endpoint and caData are returned for the "prod" cluster
+	endpoint string
+	caData   string
+}
+
+func newFakeAWS(t *testing.T) *fakeAWS {
+	f := &fakeAWS{endpoint: "https://ABCDEF.gr7.us-west-2.eks.amazonaws.com", caData: testCAData}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/clusters/"):
+			f.describeCalls.Add(1)
+			name := strings.TrimPrefix(r.URL.Path, "/clusters/")
+			if name != "prod" {
+				w.Header().Set("X-Amzn-Errortype", "ResourceNotFoundException")
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = fmt.Fprintf(w, `{"message":"No cluster found for name: %s."}`, name)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"cluster": map[string]interface{}{
+					"name":                 name,
+					"endpoint":             f.endpoint,
+					"certificateAuthority": map[string]string{"data": f.caData},
+				},
+			})
+		case r.URL.Query().Get("Action") == "GetCallerIdentity":
+			if r.Header.Get(eksClusterIDHeader) != "prod" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "text/xml")
+			_, _ = fmt.Fprint(w, `<GetCallerIdentityResponse><GetCallerIdentityResult>`+
+				`<Arn>arn:aws:sts::123456789012:assumed-role/agentregistry/session</Arn>`+
+				`</GetCallerIdentityResult></GetCallerIdentityResponse>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// config returns AWS config that sends every API call to the fake server
+func (f *fakeAWS) config() aws.Config {
+	return aws.Config{
+		Region:       "us-west-2",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", "session-token"),
+		BaseEndpoint: aws.String(f.URL),
+	}
+}
+
+func newTestEKSFactory(fake *fakeAWS) *Factory {
+	factory := NewFactory(nil, zerolog.Nop())
+	factory.loadAWSConfig = func(_ context.Context, region string) (aws.Config, error) {
+		cfg := fake.config()
+		if region != "" {
+			cfg.Region = region
+		}
+		return cfg, nil
+	}
+	return factory
+}
+
+func eksEnv(cluster agentregistryv1alpha1.ClusterConfig) *agentregistryv1alpha1.Environment {
+	cluster.UseWorkloadIdentity = true
+	return &agentregistryv1alpha1.Environment{Name: "prod", Provider: "aws", Cluster: cluster}
+}
+
+// decodeEKSToken returns the presigned URL inside a token
+func decodeEKSToken(t *testing.T, token string) *url.URL {
+	require.True(t, strings.HasPrefix(token, eksTokenPrefix))
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, eksTokenPrefix))
+	require.NoError(t, err)
+	u, err := url.Parse(string(raw))
+	require.NoError(t, err)
+	return u
+}
+
+func TestCreateEKSConfig_FromEKSAPI(t *testing.T) {
+	fake := newFakeAWS(t)
+	factory := newTestEKSFactory(fake)
+
+	config, err := factory.createEKSConfig(context.Background(), eksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:   "prod",
+		Region: "us-west-2",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "https://ABCDEF.gr7.us-west-2.eks.amazonaws.com", config.Host)
+	assert.Equal(t, []byte("test-ca"), config.TLSClientConfig.CAData)
+	assert.NotNil(t, config.WrapTransport)
+	assert.Equal(t, int32(1), fake.describeCalls.Load())
+}
+
+func TestCreateEKSConfig_Offline(t *testing.T) {
+	fake := newFakeAWS(t)
+	factory := newTestEKSFactory(fake)
+
+	config, err := factory.createEKSConfig(context.Background(), eksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:     "prod",
+		Region:   "us-west-2",
+		Endpoint: "10.0.0.1",
+		CAData:   testCAData,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "https://10.0.0.1", config.Host)
+	assert.Equal(t, []byte("test-ca"), config.TLSClientConfig.CAData)
+	assert.Zero(t, fake.describeCalls.Load(), "EKS API should not be called when endpoint and CA are set")
+}
+
+func TestCreateEKSConfig_Errors(t *testing.T) {
+	fake := newFakeAWS(t)
+	factory := newTestEKSFactory(fake)
+
+	_, err := factory.createEKSConfig(context.Background(), eksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:   "missing",
+		Region: "us-west-2",
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to describe EKS cluster missing")
+
+	factory.loadAWSConfig = func(context.Context, string) (aws.Config, error) {
+		cfg := fake.config()
+		cfg.Region = ""
+		return cfg, nil
+	}
+	_, err = factory.createEKSConfig(context.Background(), eksEnv(agentregistryv1alpha1.ClusterConfig{Name: "prod"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "region is required")
+}
+
+func TestEKSTokenSource_Token(t *testing.T) {
+	fake := newFakeAWS(t)
+	signedAt := time.Now()
+	ts := &eksTokenSource{
+		presign:   sts.NewPresignClient(sts.NewFromConfig(fake.config())),
+		clusterID: "prod",
+		now:       func() time.Time { return signedAt },
+	}
+
+	token, err := ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, signedAt.Add(eksTokenLifetime), token.Expiry)
+
+	u := decodeEKSToken(t, token.AccessToken)
+	query := u.Query()
+	assert.Equal(t, "GetCallerIdentity", query.Get("Action"))
+	assert.Equal(t, "60", query.Get("X-Amz-Expires"))
+	assert.Equal(t, "session-token", query.Get("X-Amz-Security-Token"))
+	assert.NotEmpty(t, query.Get("X-Amz-Signature"))
+	assert.Contains(t, strings.Split(query.Get("X-Amz-SignedHeaders"), ";"), eksClusterIDHeader)
+
+	// The authenticator replays the URL with the cluster ID header to learn the caller
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	require.NoError(t, err)
+	req.Header.Set(eksClusterIDHeader, "prod")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestEKSTokenSource_RefreshesBeforeExpiry(t *testing.T) {
+	fake := newFakeAWS(t)
+	var minted atomic.Int32
+	// Each token is signed one second later than the previous one, so tokens differ
+	start := time.Now()
+	source := &eksTokenSource{
+		presign:   sts.NewPresignClient(sts.NewFromConfig(fake.config())),
+		clusterID: "prod",
+		now: func() time.Time {
+			return start.Add(time.Duration(minted.Add(1)) * time.Second)
+		},
+	}
+
+	ts := oauth2.ReuseTokenSourceWithExpiry(nil, source, eksTokenRefreshMargin)
+	first, err := ts.Token()
+	require.NoError(t, err)
+	again, err := ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, first.AccessToken, again.AccessToken, "a fresh token should be reused")
+	assert.Equal(t, int32(1), minted.Load())
+
+	// A token that expires within the refresh margin is replaced
+	expiring := *first
+	expiring.Expiry = time.Now().Add(eksTokenRefreshMargin / 2)
+	ts = oauth2.ReuseTokenSourceWithExpiry(&expiring, source, eksTokenRefreshMargin)
+	refreshed, err := ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), minted.Load())
+	assert.True(t, refreshed.Expiry.After(expiring.Expiry))
+}
+
+func TestCreateEKSConfig_AuthorizesRequests(t *testing.T) {
+	fake := newFakeAWS(t)
+	factory := newTestEKSFactory(fake)
+
+	var authorization string
+	apiserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer apiserver.Close()
+
+	config, err := factory.createEKSConfig(context.Background(), eksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:     "prod",
+		Region:   "us-west-2",
+		Endpoint: apiserver.URL,
+		CAData:   testCAData,
+	}))
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: config.WrapTransport(http.DefaultTransport)}
+	resp, err := client.Get(apiserver.URL + "/version")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.True(t, strings.HasPrefix(authorization, "Bearer "+eksTokenPrefix))
+	u := decodeEKSToken(t, strings.TrimPrefix(authorization, "Bearer "))
+	assert.Equal(t, "GetCallerIdentity", u.Query().Get("Action"))
+}
+
+func TestCreateClient_AWSProvider(t *testing.T) {
+	apiserver := httptest.NewTLSServer(http.NotFoundHandler())
+	defer apiserver.Close()
+
+	fake := newFakeAWS(t)
+	fake.endpoint = apiserver.URL
+	fake.caData = base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: apiserver.Certificate().Raw,
+	}))
+	factory := newTestEKSFactory(fake)
+
+	_, _, err := factory.createClient(context.Background(), eksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:   "prod",
+		Region: "us-west-2",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), fake.describeCalls.Load(), "endpoint should be resolved through the EKS API")
+}
@@ -0,0 +1,311 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+const (
+	// defaultAzureAuthorityHost is the Entra ID endpoint of the public cloud
+	defaultAzureAuthorityHost = "https://login.microsoftonline.com/"
+	// defaultAzureResourceManager is the ARM endpoint of the public cloud
+	defaultAzureResourceManager = "https://management.azure.com/"
+	// azureResourceManagerScope is the token scope for ARM calls
+	azureResourceManagerScope = "https://management.azure.com/.default"
+	// aksServerScope is the token scope accepted by AKS clusters with Entra ID integration.
+	// The ID is the AKS AAD server application, the same in every tenant.
+	aksServerScope = "6dae42f8-4368-4678-94ff-3960e28e3630/.default"
+	// aksAPIVersion is the managedClusters API version used to fetch cluster credentials
+	aksAPIVersion = "2024-02-01"
+	// azureTokenRefreshMargin is how long before expiry a new Entra ID token is requested
+	azureTokenRefreshMargin = 5 * time.Minute
+)
+
+// azureWorkloadIdentity holds what the Azure workload identity webhook injects into pods
+// whose service account is labelled azure.workload.identity/use=true, plus the cloud
+// endpoints to talk to.
+type azureWorkloadIdentity struct {
+	TenantID        string
+	ClientID        string
+	TokenFile       string
+	AuthorityHost   string
+	ResourceManager string
+	HTTPClient      *http.Client
+}
+
+// azureWorkloadIdentityFromEnv reads the workload identity settings from the environment
+func azureWorkloadIdentityFromEnv() (*azureWorkloadIdentity, error) {
+	id := &azureWorkloadIdentity{
+		TenantID:        os.Getenv("AZURE_TENANT_ID"),
+		ClientID:        os.Getenv("AZURE_CLIENT_ID"),
+		TokenFile:       os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		AuthorityHost:   os.Getenv("AZURE_AUTHORITY_HOST"),
+		ResourceManager: defaultAzureResourceManager,
+		HTTPClient:      http.DefaultClient,
+	}
+	if id.AuthorityHost == "" {
+		id.AuthorityHost = defaultAzureAuthorityHost
+	}
+	if id.TenantID == "" || id.ClientID == "" || id.TokenFile == "" {
+		return nil, fmt.Errorf("Azure workload identity is not configured: AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_FEDERATED_TOKEN_FILE must be set")
+	}
+	return id, nil
+}
+
+func init() {
+	RegisterProvider(ProviderAzure, aksProvider{})
+	RegisterProvider(ProviderAKS, aksProvider{})
+}
+
+// aksProvider reaches AKS clusters with Azure workload identity federation
+type aksProvider struct{ noRefresh }
+
+// Validate implements Provider
+func (aksProvider) Validate(env *agentregistryv1alpha1.Environment) error {
+	if env.Cluster.Endpoint != "" && env.Cluster.CAData != "" {
+		return validateCAData(env)
+	}
+	if env.Cluster.SubscriptionID == "" || env.Cluster.ResourceGroup == "" {
+		return fmt.Errorf("cluster subscriptionId and resourceGroup are required for AKS")
+	}
+	return nil
+}
+
+// RESTConfig implements Provider
+func (aksProvider) RESTConfig(ctx context.Context, f *Factory, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	return f.createAKSConfig(ctx, env)
+}
+
+// createAKSConfig creates a rest.Config for an AKS cluster using Azure workload identity
+// federation. The endpoint and CA come from ClusterConfig when both are set, otherwise
+// from the cluster's user credentials in Azure Resource Manager.
+func (f *Factory) createAKSConfig(ctx context.Context, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	loadIdentity := f.loadAzureIdentity
+	if loadIdentity == nil {
+		loadIdentity = azureWorkloadIdentityFromEnv
+	}
+	id, err := loadIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := env.Cluster.Endpoint
+	var caData []byte
+	if endpoint != "" && env.Cluster.CAData != "" {
+		caData, err = base64.StdEncoding.DecodeString(env.Cluster.CAData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode CA certificate: %w", err)
+		}
+	} else {
+		if env.Cluster.SubscriptionID == "" || env.Cluster.ResourceGroup == "" {
+			return nil, fmt.Errorf("cluster subscriptionId and resourceGroup are required for AKS")
+		}
+
+		f.logger.Debug().
+			Str("environment", env.Name).
+			Str("cluster", env.Cluster.Name).
+			Str("resource_group", env.Cluster.ResourceGroup).
+			Msg("fetching AKS cluster info")
+
+		armToken, err := (&azureFederatedTokenSource{identity: id, scope: azureResourceManagerScope}).Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get Azure Resource Manager token: %w", err)
+		}
+		endpoint, caData, err = fetchAKSClusterInfo(ctx, id, armToken.AccessToken, env.Cluster)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(endpoint) > 4 && endpoint[0:4] != "http" {
+		endpoint = "https://" + endpoint
+	}
+
+	config := &rest.Config{
+		Host: endpoint,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData: caData,
+		},
+	}
+
+	// The projected service account token is exchanged again shortly before the
+	// Entra ID token expires
+	ts := oauth2.ReuseTokenSourceWithExpiry(nil, &azureFederatedTokenSource{
+		identity: id,
+		scope:    aksServerScope,
+	}, azureTokenRefreshMargin)
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return &oauth2.Transport{
+			Source: ts,
+			Base:   rt,
+		}
+	}
+
+	// Set reasonable defaults
+	config.QPS = 50
+	config.Burst = 100
+
+	f.logger.Info().
+		Str("environment", env.Name).
+		Str("cluster", env.Cluster.Name).
+		Str("endpoint", endpoint).
+		Msg("successfully created AKS cluster config")
+
+	return config, nil
+}
+
+// fetchAKSClusterInfo returns the API server endpoint and CA of an AKS cluster from the
+// kubeconfig returned by listClusterUserCredential
+func fetchAKSClusterInfo(ctx context.Context, id *azureWorkloadIdentity, armToken string, cluster agentregistryv1alpha1.ClusterConfig) (string, []byte, error) {
+	credentialsURL := fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s/listClusterUserCredential?api-version=%s",
+		strings.TrimSuffix(id.ResourceManager, "/"),
+		url.PathEscape(cluster.SubscriptionID),
+		url.PathEscape(cluster.ResourceGroup),
+		url.PathEscape(cluster.Name),
+		aksAPIVersion,
+	)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, credentialsURL, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+armToken)
+
+	resp, err := id.HTTPClient.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get AKS cluster %s: %w", cluster.Name, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read AKS cluster %s: %w", cluster.Name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("failed to get AKS cluster %s: %s: %s", cluster.Name, resp.Status, azureErrorMessage(body))
+	}
+
+	var result struct {
+		Kubeconfigs []struct {
+			Name  string `json:"name"`
+			Value []byte `json:"value"`
+		} `json:"kubeconfigs"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", nil, fmt.Errorf("failed to decode AKS credentials: %w", err)
+	}
+	if len(result.Kubeconfigs) == 0 {
+		return "", nil, fmt.Errorf("AKS cluster %s returned no kubeconfig", cluster.Name)
+	}
+
+	kubeconfig, err := clientcmd.Load(result.Kubeconfigs[0].Value)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse AKS kubeconfig: %w", err)
+	}
+	// Only the server and CA are used; authentication uses the federated token
+	var server *clientcmdapi.Cluster
+	if kctx, ok := kubeconfig.Contexts[kubeconfig.CurrentContext]; ok {
+		server = kubeconfig.Clusters[kctx.Cluster]
+	}
+	if server == nil {
+		for _, c := range kubeconfig.Clusters {
+			server = c
+			break
+		}
+	}
+	if server == nil {
+		return "", nil, fmt.Errorf("AKS kubeconfig for %s has no cluster", cluster.Name)
+	}
+	return server.Server, server.CertificateAuthorityData, nil
+}
+
+// azureFederatedTokenSource exchanges the projected service account token for an Entra ID
+// access token with the OAuth 2.0 client credentials flow and a federated client assertion.
+type azureFederatedTokenSource struct {
+	identity *azureWorkloadIdentity
+	scope    string
+}
+
+// Token requests a new access token. The service account token file is read on every call
+// because the kubelet rotates it.
+func (s *azureFederatedTokenSource) Token() (*oauth2.Token, error) {
+	assertion, err := os.ReadFile(s.identity.TokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read federated token: %w", err)
+	}
+
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token",
+		strings.TrimSuffix(s.identity.AuthorityHost, "/"), url.PathEscape(s.identity.TenantID))
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {s.identity.ClientID},
+		"scope":                 {s.scope},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+	}
+
+	resp, err := s.identity.HTTPClient.PostForm(tokenURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request Entra ID token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Entra ID token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Entra ID token request failed: %s: %s", resp.Status, azureErrorMessage(body))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode Entra ID token: %w", err)
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("Entra ID returned an empty access token")
+	}
+	return &oauth2.Token{
+		AccessToken: result.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// azureErrorMessage extracts the message from an Entra ID or ARM error body
+func azureErrorMessage(body []byte) string {
+	var e struct {
+		Error            json.RawMessage `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	if json.Unmarshal(body, &e) == nil {
+		if e.ErrorDescription != "" {
+			return e.ErrorDescription
+		}
+		// ARM nests the message: {"error":{"code":"...","message":"..."}}
+		var armError struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(e.Error, &armError) == nil && armError.Message != "" {
+			return armError.Code + ": " + armError.Message
+		}
+	}
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,268 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+const testAKSKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod-dns-1a2b3c.hcp.westeurope.azmk8s.io:443
+    certificate-authority-data: dGVzdC1jYQ==
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: clusterUser_rg_prod
+current-context: prod
+users:
+- name: clusterUser_rg_prod
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: kubelogin
+`
+
+// fakeAzure serves the Entra ID token endpoint and the AKS listClusterUserCredential API.
+// Tokens are only issued for the current service account token, and the token's value
+// encodes the requested scope so callers can tell ARM and AKS tokens apart.
+type fakeAzure struct {
+	*httptest.Server
+	tokenFile        string
+	tokenRequests    atomic.Int32
+	credentialsCalls atomic.Int32
+	expiresIn        int
+}
+
+func newFakeAzure(t *testing.T) *fakeAzure {
+	f := &fakeAzure{tokenFile: filepath.Join(t.TempDir(), "azure-identity-token"), expiresIn: 3600}
+	require.NoError(t, os.WriteFile(f.tokenFile, []byte("sa-token-1\n"), 0o600))
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/tenant-id/oauth2/v2.0/token":
+			f.tokenRequests.Add(1)
+			require.NoError(t, r.ParseForm())
+			current, _ := os.ReadFile(f.tokenFile)
+			if r.PostForm.Get("client_id") != "client-id" ||
+				r.PostForm.Get("grant_type") != "client_credentials" ||
+				r.PostForm.Get("client_assertion_type") != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" ||
+				r.PostForm.Get("client_assertion") != strings.TrimSpace(string(current)) {
+				w.WriteHeader(http.StatusUnauthorized)
+				_ = json.NewEncoder(w).Encode(map[string]string{
+					"error":             "invalid_client",
+					"error_description": "AADSTS700024: Client assertion is not within its valid time range.",
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"token_type":   "Bearer",
+				"expires_in":   f.expiresIn,
+				"access_token": r.PostForm.Get("scope") + "|" + strings.TrimSpace(string(current)),
+			})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/listClusterUserCredential"):
+			f.credentialsCalls.Add(1)
+			if r.Header.Get("Authorization") != "Bearer "+azureResourceManagerScope+"|sa-token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Path != "/subscriptions/sub-id/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/prod/listClusterUserCredential" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":"ResourceNotFound","message":"The Resource 'Microsoft.ContainerService/managedClusters/missing' was not found."}}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"kubeconfigs": []map[string]string{{
+					"name":  "clusterUser",
+					"value": base64.StdEncoding.EncodeToString([]byte(testAKSKubeconfig)),
+				}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeAzure) identity() *azureWorkloadIdentity {
+	return &azureWorkloadIdentity{
+		TenantID:        "tenant-id",
+		ClientID:        "client-id",
+		TokenFile:       f.tokenFile,
+		AuthorityHost:   f.URL + "/",
+		ResourceManager: f.URL,
+		HTTPClient:      f.Client(),
+	}
+}
+
+func newTestAKSFactory(fake *fakeAzure) *Factory {
+	factory := NewFactory(nil, zerolog.Nop())
+	factory.loadAzureIdentity = func() (*azureWorkloadIdentity, error) { return fake.identity(), nil }
+	return factory
+}
+
+func aksEnv(cluster agentregistryv1alpha1.ClusterConfig) *agentregistryv1alpha1.Environment {
+	cluster.UseWorkloadIdentity = true
+	return &agentregistryv1alpha1.Environment{Name: "prod", Provider: "aks", Cluster: cluster}
+}
+
+func TestCreateAKSConfig_FromARM(t *testing.T) {
+	fake := newFakeAzure(t)
+	factory := newTestAKSFactory(fake)
+
+	config, err := factory.createAKSConfig(context.Background(), aksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:           "prod",
+		SubscriptionID: "sub-id",
+		ResourceGroup:  "rg",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod-dns-1a2b3c.hcp.westeurope.azmk8s.io:443", config.Host)
+	assert.Equal(t, []byte("test-ca"), config.TLSClientConfig.CAData)
+	assert.NotNil(t, config.WrapTransport)
+	assert.Equal(t, int32(1), fake.credentialsCalls.Load())
+}
+
+func TestCreateAKSConfig_Offline(t *testing.T) {
+	fake := newFakeAzure(t)
+	factory := newTestAKSFactory(fake)
+
+	config, err := factory.createAKSConfig(context.Background(), aksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:     "prod",
+		Endpoint: "prod-dns-1a2b3c.hcp.westeurope.azmk8s.io",
+		CAData:   testCAData,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod-dns-1a2b3c.hcp.westeurope.azmk8s.io", config.Host)
+	assert.Zero(t, fake.credentialsCalls.Load(), "ARM should not be called when endpoint and CA are set")
+	assert.Zero(t, fake.tokenRequests.Load(), "tokens should only be requested when the cluster is called")
+}
+
+func TestCreateAKSConfig_Errors(t *testing.T) {
+	fake := newFakeAzure(t)
+	factory := newTestAKSFactory(fake)
+	ctx := context.Background()
+
+	_, err := factory.createAKSConfig(ctx, aksEnv(agentregistryv1alpha1.ClusterConfig{Name: "prod"}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "subscriptionId and resourceGroup are required")
+
+	_, err = factory.createAKSConfig(ctx, aksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:           "missing",
+		SubscriptionID: "sub-id",
+		ResourceGroup:  "rg",
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ResourceNotFound: The Resource")
+
+	// A stale service account token is rejected by Entra ID
+	id := fake.identity()
+	id.TokenFile = filepath.Join(t.TempDir(), "stale")
+	require.NoError(t, os.WriteFile(id.TokenFile, []byte("stale"), 0o600))
+	factory.loadAzureIdentity = func() (*azureWorkloadIdentity, error) { return id, nil }
+	_, err = factory.createAKSConfig(ctx, aksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:           "prod",
+		SubscriptionID: "sub-id",
+		ResourceGroup:  "rg",
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AADSTS700024")
+}
+
+func TestAzureWorkloadIdentityFromEnv(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "")
+	t.Setenv("AZURE_CLIENT_ID", "")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+	t.Setenv("AZURE_AUTHORITY_HOST", "")
+	_, err := azureWorkloadIdentityFromEnv()
+	require.Error(t, err)
+
+	t.Setenv("AZURE_TENANT_ID", "tenant-id")
+	t.Setenv("AZURE_CLIENT_ID", "client-id")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "/var/run/secrets/azure/tokens/azure-identity-token")
+	id, err := azureWorkloadIdentityFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, defaultAzureAuthorityHost, id.AuthorityHost)
+	assert.Equal(t, defaultAzureResourceManager, id.ResourceManager)
+}
+
+func TestAzureFederatedTokenSource_RereadsRotatedToken(t *testing.T) {
+	fake := newFakeAzure(t)
+	// Tokens expire within the refresh margin, so each call requests a new one
+	fake.expiresIn = int(azureTokenRefreshMargin.Seconds()) / 2
+	ts := oauth2.ReuseTokenSourceWithExpiry(nil, &azureFederatedTokenSource{
+		identity: fake.identity(),
+		scope:    aksServerScope,
+	}, azureTokenRefreshMargin)
+
+	token, err := ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, aksServerScope+"|sa-token-1", token.AccessToken)
+	assert.WithinDuration(t, time.Now().Add(azureTokenRefreshMargin/2), token.Expiry, 5*time.Second)
+
+	// The kubelet rotates the projected service account token
+	require.NoError(t, os.WriteFile(fake.tokenFile, []byte("sa-token-2"), 0o600))
+	token, err = ts.Token()
+	require.NoError(t, err)
+	assert.Equal(t, aksServerScope+"|sa-token-2", token.AccessToken)
+	assert.Equal(t, int32(2), fake.tokenRequests.Load())
+}
+
+func TestAzureFederatedTokenSource_ReusesFreshToken(t *testing.T) {
+	fake := newFakeAzure(t)
+	ts := oauth2.ReuseTokenSourceWithExpiry(nil, &azureFederatedTokenSource{
+		identity: fake.identity(),
+		scope:    aksServerScope,
+	}, azureTokenRefreshMargin)
+
+	for i := 0; i < 3; i++ {
+		_, err := ts.Token()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), fake.tokenRequests.Load())
+}
+
+func TestCreateAKSConfig_AuthorizesRequests(t *testing.T) {
+	fake := newFakeAzure(t)
+	factory := newTestAKSFactory(fake)
+
+	var authorization string
+	apiserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer apiserver.Close()
+
+	config, err := factory.createAKSConfig(context.Background(), aksEnv(agentregistryv1alpha1.ClusterConfig{
+		Name:     "prod",
+		Endpoint: apiserver.URL,
+		CAData:   testCAData,
+	}))
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: config.WrapTransport(http.DefaultTransport)}
+	resp, err := client.Get(apiserver.URL + "/version")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "Bearer "+aksServerScope+"|sa-token-1", authorization)
+}
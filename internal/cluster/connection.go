@@ -0,0 +1,451 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+const (
+	// DefaultProbeInterval is how often a healthy cluster is probed
+	DefaultProbeInterval = 30 * time.Second
+	// DefaultProbeTimeout bounds a single probe
+	DefaultProbeTimeout = 10 * time.Second
+	// DefaultFailureThreshold is the number of consecutive failures that opens the circuit breaker
+	DefaultFailureThreshold = 3
+	// DefaultBaseBackoff is the delay after the first failure; it doubles with every further failure
+	DefaultBaseBackoff = 5 * time.Second
+	// DefaultMaxBackoff caps the delay between retries of a failing cluster
+	DefaultMaxBackoff = 5 * time.Minute
+)
+
+// ErrCircuitOpen is matched by errors returned while an environment's circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitOpenError is returned instead of a client while an environment's circuit breaker is open
+type CircuitOpenError struct {
+	// Environment is the environment whose cluster is unavailable
+	Environment string
+	// Failures is the number of consecutive failed connection attempts
+	Failures int
+	// RetryAt is when the cluster is tried again
+	RetryAt time.Time
+	// LastError is the error of the last failed attempt
+	LastError string
+}
+
+// Error implements error
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("cluster of environment %q is unavailable after %d consecutive failures, retrying at %s: %s",
+		e.Environment, e.Failures, e.RetryAt.UTC().Format(time.RFC3339), e.LastError)
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) match
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// Scope identifies what a cluster connection is made for: the DiscoveryConfig, its environment
+// and the environment's cluster. DiscoveryConfigs with environments of the same name get their
+// own client and circuit breaker.
+type Scope struct {
+	Config      string
+	Environment string
+	Cluster     string
+}
+
+// String returns the scope as "config/environment/cluster"
+func (s Scope) String() string {
+	return s.Config + "/" + s.Environment + "/" + s.Cluster
+}
+
+// ConnectionStatus is the health of the connection to one environment's cluster
+type ConnectionStatus struct {
+	// State is Healthy, Degraded or Open
+	State agentregistryv1alpha1.ConnectionState
+	// ConsecutiveFailures is the number of failed attempts since the cluster last answered
+	ConsecutiveFailures int
+	// LastProbe is when the cluster was last probed
+	LastProbe time.Time
+	// LastSuccess is when the cluster last answered
+	LastSuccess time.Time
+	// RetryAt is when an open circuit breaker lets the cluster be tried again
+	RetryAt time.Time
+	// Error is the error of the last failed attempt
+	Error string
+}
+
+// connection tracks the cluster of one scope
+type connection struct {
+	// configHash identifies the client configuration the status applies to
+	configHash string
+	// probe checks the cluster; nil until a client has been built
+	probe func(ctx context.Context) error
+	// nextProbe is when the cluster is probed next
+	nextProbe time.Time
+	// probing is set while a probe is in flight
+	probing bool
+
+	status        ConnectionStatus
+	probes        map[string]float64 // key: result
+	probeDuration time.Duration
+}
+
+// ConnectionManager probes remote clusters, backs off exponentially from failing ones and
+// opens a circuit breaker after repeated failures so callers fail fast instead of waiting on
+// timeouts. Probes go through the same transport as the cached client, which also refreshes
+// expiring credentials before the client needs them.
+type ConnectionManager struct {
+	// ProbeInterval is how often a healthy cluster is probed
+	ProbeInterval time.Duration
+	// ProbeTimeout bounds a single probe
+	ProbeTimeout time.Duration
+	// FailureThreshold is the number of consecutive failures that opens the circuit breaker
+	FailureThreshold int
+	// BaseBackoff is the delay after the first failure
+	BaseBackoff time.Duration
+	// MaxBackoff caps the delay between retries
+	MaxBackoff time.Duration
+
+	logger zerolog.Logger
+	// now is the clock used for probe scheduling (injectable for testing)
+	now func() time.Time
+	// newProbe builds the probe of a cluster (injectable for testing)
+	newProbe func(config *rest.Config) (func(ctx context.Context) error, error)
+	// onOpen is called when the circuit breaker of a scope opens
+	onOpen func(scope Scope)
+	// onUnauthorized is called when a cluster rejects the client's credentials
+	onUnauthorized func(scope Scope)
+
+	mu    sync.Mutex
+	conns map[Scope]*connection // key: scope
+
+	stateDesc    *prometheus.Desc
+	failuresDesc *prometheus.Desc
+	probesDesc   *prometheus.Desc
+	durationDesc *prometheus.Desc
+}
+
+// NewConnectionManager creates a ConnectionManager with default settings
+func NewConnectionManager(logger zerolog.Logger) *ConnectionManager {
+	variableLabels := []string{"config", "environment", "cluster"}
+	return &ConnectionManager{
+		ProbeInterval:    DefaultProbeInterval,
+		ProbeTimeout:     DefaultProbeTimeout,
+		FailureThreshold: DefaultFailureThreshold,
+		BaseBackoff:      DefaultBaseBackoff,
+		MaxBackoff:       DefaultMaxBackoff,
+		logger:           logger.With().Str("component", "cluster-connections").Logger(),
+		now:              time.Now,
+		newProbe:         versionProbe,
+		conns:            make(map[Scope]*connection),
+		stateDesc: prometheus.NewDesc(
+			"agentregistry_cluster_connection_state",
+			"Connection state of an environment's cluster; 1 for the current state, 0 otherwise",
+			append(variableLabels, "state"), nil,
+		),
+		failuresDesc: prometheus.NewDesc(
+			"agentregistry_cluster_connection_consecutive_failures",
+			"Number of failed connection attempts since the cluster last answered",
+			variableLabels, nil,
+		),
+		probesDesc: prometheus.NewDesc(
+			"agentregistry_cluster_probes_total",
+			"Number of cluster health probes by result",
+			append(variableLabels, "result"), nil,
+		),
+		durationDesc: prometheus.NewDesc(
+			"agentregistry_cluster_probe_duration_seconds",
+			"Duration of the last cluster health probe",
+			variableLabels, nil,
+		),
+	}
+}
+
+// versionProbe returns a probe that requests /version with the credentials of config
+func versionProbe(config *rest.Config) (func(ctx context.Context) error, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create probe client: %w", err)
+	}
+	return func(ctx context.Context) error {
+		_, err := dc.RESTClient().Get().AbsPath("/version").Do(ctx).Raw()
+		return err
+	}, nil
+}
+
+// connectionFor returns the connection of a scope, starting afresh when the client
+// configuration changed. Callers must hold mu.
+func (m *ConnectionManager) connectionFor(scope Scope, configHash string) *connection {
+	c, ok := m.conns[scope]
+	if !ok || c.configHash != configHash {
+		c = &connection{
+			configHash: configHash,
+			status:     ConnectionStatus{State: agentregistryv1alpha1.ConnectionStateHealthy},
+			probes:     make(map[string]float64),
+		}
+		m.conns[scope] = c
+	}
+	return c
+}
+
+// Allow returns a *CircuitOpenError if the circuit breaker of a scope is open.
+// A changed configHash resets the breaker so corrected settings are tried right away.
+func (m *ConnectionManager) Allow(scope Scope, configHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.conns[scope]
+	if !ok {
+		return nil
+	}
+	if c.configHash != configHash {
+		delete(m.conns, scope)
+		return nil
+	}
+	if c.status.State == agentregistryv1alpha1.ConnectionStateOpen && m.now().Before(c.status.RetryAt) {
+		return &CircuitOpenError{
+			Environment: scope.Environment,
+			Failures:    c.status.ConsecutiveFailures,
+			RetryAt:     c.status.RetryAt,
+			LastError:   c.status.Error,
+		}
+	}
+	return nil
+}
+
+// Track starts probing the cluster of a scope through the given client config.
+// Known state is kept, so a client rebuilt while the breaker is open stays open until it
+// answers a probe.
+func (m *ConnectionManager) Track(scope Scope, configHash string, config *rest.Config) error {
+	probe, err := m.newProbe(config)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.connectionFor(scope, configHash)
+	c.probe = probe
+	c.nextProbe = m.now()
+	return nil
+}
+
+// RecordFailure counts a failed attempt to connect to the cluster of a scope
+func (m *ConnectionManager) RecordFailure(scope Scope, configHash string, err error) {
+	m.mu.Lock()
+	opened := m.recordLocked(scope, m.connectionFor(scope, configHash), err)
+	m.mu.Unlock()
+
+	if opened && m.onOpen != nil {
+		m.onOpen(scope)
+	}
+}
+
+// recordLocked applies the outcome of an attempt and schedules the next probe. It reports
+// whether the circuit breaker opened. Callers must hold mu.
+func (m *ConnectionManager) recordLocked(scope Scope, c *connection, err error) bool {
+	now := m.now()
+	if err == nil {
+		if c.status.State != agentregistryv1alpha1.ConnectionStateHealthy {
+			m.logger.Info().
+				Str("scope", scope.String()).
+				Int("failures", c.status.ConsecutiveFailures).
+				Msg("cluster connection recovered")
+		}
+		c.status = ConnectionStatus{
+			State:       agentregistryv1alpha1.ConnectionStateHealthy,
+			LastProbe:   c.status.LastProbe,
+			LastSuccess: now,
+		}
+		c.nextProbe = now.Add(m.ProbeInterval)
+		return false
+	}
+
+	c.status.ConsecutiveFailures++
+	c.status.Error = err.Error()
+	delay := m.backoff(c.status.ConsecutiveFailures)
+	c.nextProbe = now.Add(delay)
+
+	if c.status.ConsecutiveFailures < m.FailureThreshold {
+		c.status.State = agentregistryv1alpha1.ConnectionStateDegraded
+		m.logger.Warn().Err(err).
+			Str("scope", scope.String()).
+			Int("failures", c.status.ConsecutiveFailures).
+			Msg("cluster connection degraded")
+		return false
+	}
+
+	c.status.State = agentregistryv1alpha1.ConnectionStateOpen
+	c.status.RetryAt = c.nextProbe
+	m.logger.Error().Err(err).
+		Str("scope", scope.String()).
+		Int("failures", c.status.ConsecutiveFailures).
+		Time("retryAt", c.status.RetryAt).
+		Msg("cluster circuit breaker open")
+	return true
+}
+
+// backoff returns the delay after the given number of consecutive failures
+func (m *ConnectionManager) backoff(failures int) time.Duration {
+	delay := m.BaseBackoff
+	for i := 1; i < failures && delay < m.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, m.MaxBackoff)
+}
+
+// Status returns the connection status of a scope. ok is false if its cluster has not been
+// connected to yet.
+func (m *ConnectionManager) Status(scope Scope) (status ConnectionStatus, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.conns[scope]
+	if !ok {
+		return ConnectionStatus{}, false
+	}
+	return c.status, true
+}
+
+// Forget stops tracking a scope
+func (m *ConnectionManager) Forget(scope Scope) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.conns, scope)
+}
+
+// probeDue probes every cluster whose next probe is due and waits for the results
+func (m *ConnectionManager) probeDue(ctx context.Context) {
+	type due struct {
+		scope Scope
+		conn  *connection
+		probe func(ctx context.Context) error
+	}
+
+	m.mu.Lock()
+	now := m.now()
+	var probes []due
+	for scope, c := range m.conns {
+		if c.probe == nil || c.probing || now.Before(c.nextProbe) {
+			continue
+		}
+		c.probing = true
+		probes = append(probes, due{scope: scope, conn: c, probe: c.probe})
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.probeOne(ctx, p.scope, p.conn, p.probe)
+		}()
+	}
+	wg.Wait()
+}
+
+// probeOne runs a single probe and records its outcome
+func (m *ConnectionManager) probeOne(ctx context.Context, scope Scope, c *connection, probe func(ctx context.Context) error) {
+	probeCtx, cancel := context.WithTimeout(ctx, m.ProbeTimeout)
+	defer cancel()
+
+	start := m.now()
+	err := probe(probeCtx)
+	if ctx.Err() != nil {
+		// Shutting down; the outcome says nothing about the cluster
+		m.mu.Lock()
+		c.probing = false
+		m.mu.Unlock()
+		return
+	}
+
+	m.mu.Lock()
+	c.probing = false
+	c.probeDuration = m.now().Sub(start)
+	c.status.LastProbe = start
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	c.probes[result]++
+	// The connection may have been replaced or forgotten while the probe was in flight
+	var opened bool
+	if m.conns[scope] == c {
+		opened = m.recordLocked(scope, c, err)
+	}
+	m.mu.Unlock()
+
+	if opened && m.onOpen != nil {
+		m.onOpen(scope)
+	} else if apierrors.IsUnauthorized(err) && m.onUnauthorized != nil {
+		m.onUnauthorized(scope)
+	}
+}
+
+// Start implements manager.Runnable. It probes tracked clusters until ctx is cancelled.
+func (m *ConnectionManager) Start(ctx context.Context) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			m.probeDue(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica keeps its own
+// client cache, so every replica probes.
+func (m *ConnectionManager) NeedLeaderElection() bool {
+	return false
+}
+
+// Describe implements prometheus.Collector
+func (m *ConnectionManager) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.stateDesc
+	ch <- m.failuresDesc
+	ch <- m.probesDesc
+	ch <- m.durationDesc
+}
+
+// Collect implements prometheus.Collector
+func (m *ConnectionManager) Collect(ch chan<- prometheus.Metric) {
+	states := []agentregistryv1alpha1.ConnectionState{
+		agentregistryv1alpha1.ConnectionStateHealthy,
+		agentregistryv1alpha1.ConnectionStateDegraded,
+		agentregistryv1alpha1.ConnectionStateOpen,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for scope, c := range m.conns {
+		labels := []string{scope.Config, scope.Environment, scope.Cluster}
+		for _, state := range states {
+			value := 0.0
+			if c.status.State == state {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(m.stateDesc, prometheus.GaugeValue, value, append(labels, string(state))...)
+		}
+		ch <- prometheus.MustNewConstMetric(m.failuresDesc, prometheus.GaugeValue, float64(c.status.ConsecutiveFailures), labels...)
+		for result, n := range c.probes {
+			ch <- prometheus.MustNewConstMetric(m.probesDesc, prometheus.CounterValue, n, append(labels, result)...)
+		}
+		if !c.status.LastProbe.IsZero() {
+			ch <- prometheus.MustNewConstMetric(m.durationDesc, prometheus.GaugeValue, c.probeDuration.Seconds(), labels...)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+// fakeProbe is a probe whose outcome is switched by the test
+type fakeProbe struct {
+	err   atomic.Pointer[error]
+	calls atomic.Int32
+}
+
+func (p *fakeProbe) fail(err error) { p.err.Store(&err) }
+
+func (p *fakeProbe) heal() { p.err.Store(nil) }
+
+func (p *fakeProbe) build(*rest.Config) (func(ctx context.Context) error, error) {
+	return func(context.Context) error {
+		p.calls.Add(1)
+		if err := p.err.Load(); err != nil {
+			return *err
+		}
+		return nil
+	}, nil
+}
+
+func newTestConnectionManager(probe *fakeProbe) (*ConnectionManager, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewConnectionManager(zerolog.Nop())
+	m.now = func() time.Time { return now }
+	m.newProbe = probe.build
+	return m, &now
+}
+
+// testScope is the scope of an environment of the "discovery" DiscoveryConfig
+func testScope(envName string) Scope {
+	return Scope{Config: "discovery", Environment: envName, Cluster: envName + "-cluster"}
+}
+
+func TestConnectionManager_CircuitBreaker(t *testing.T) {
+	probe := &fakeProbe{}
+	m, now := newTestConnectionManager(probe)
+	var opened []Scope
+	m.onOpen = func(scope Scope) { opened = append(opened, scope) }
+	prod := testScope("prod")
+	ctx := context.Background()
+
+	require.NoError(t, m.Track(prod, "hash", &rest.Config{}))
+	m.probeDue(ctx)
+	status, ok := m.Status(prod)
+	require.True(t, ok)
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateHealthy, status.State)
+	assert.Equal(t, *now, status.LastSuccess)
+
+	// Nothing is probed before the interval elapses
+	m.probeDue(ctx)
+	assert.Equal(t, int32(1), probe.calls.Load())
+
+	probe.fail(errors.New("connection refused"))
+	*now = now.Add(DefaultProbeInterval)
+	m.probeDue(ctx)
+	status, _ = m.Status(prod)
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateDegraded, status.State)
+	assert.Equal(t, 1, status.ConsecutiveFailures)
+	assert.NoError(t, m.Allow(prod, "hash"), "degraded clusters are still used")
+
+	// Failing clusters are retried with exponential backoff
+	*now = now.Add(DefaultBaseBackoff)
+	m.probeDue(ctx)
+	*now = now.Add(2 * DefaultBaseBackoff)
+	m.probeDue(ctx)
+	status, _ = m.Status(prod)
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateOpen, status.State)
+	assert.Equal(t, 3, status.ConsecutiveFailures)
+	assert.Equal(t, now.Add(4*DefaultBaseBackoff), status.RetryAt)
+	assert.Equal(t, []Scope{prod}, opened)
+
+	err := m.Allow(prod, "hash")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	var circuitOpen *CircuitOpenError
+	require.ErrorAs(t, err, &circuitOpen)
+	assert.Equal(t, "prod", circuitOpen.Environment)
+	assert.Contains(t, err.Error(), "after 3 consecutive failures")
+	assert.Contains(t, err.Error(), "connection refused")
+
+	// The open breaker is probed again once the retry time is reached
+	calls := probe.calls.Load()
+	m.probeDue(ctx)
+	assert.Equal(t, calls, probe.calls.Load())
+
+	probe.heal()
+	*now = status.RetryAt
+	assert.NoError(t, m.Allow(prod, "hash"), "the breaker lets a trial through at the retry time")
+	m.probeDue(ctx)
+	status, _ = m.Status(prod)
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateHealthy, status.State)
+	assert.Zero(t, status.ConsecutiveFailures)
+	assert.Empty(t, status.Error)
+}
+
+func TestConnectionManager_Backoff(t *testing.T) {
+	m := NewConnectionManager(zerolog.Nop())
+	assert.Equal(t, 5*time.Second, m.backoff(1))
+	assert.Equal(t, 10*time.Second, m.backoff(2))
+	assert.Equal(t, 20*time.Second, m.backoff(3))
+	assert.Equal(t, DefaultMaxBackoff, m.backoff(10))
+	assert.Equal(t, DefaultMaxBackoff, m.backoff(100))
+}
+
+func TestConnectionManager_ConfigChangeResetsBreaker(t *testing.T) {
+	m, _ := newTestConnectionManager(&fakeProbe{})
+	prod := testScope("prod")
+	for range DefaultFailureThreshold {
+		m.RecordFailure(prod, "old", errors.New("unauthorized"))
+	}
+	require.ErrorIs(t, m.Allow(prod, "old"), ErrCircuitOpen)
+
+	assert.NoError(t, m.Allow(prod, "new"))
+	_, ok := m.Status(prod)
+	assert.False(t, ok)
+}
+
+func TestConnectionManager_ScopesWithSameEnvironmentName(t *testing.T) {
+	m, _ := newTestConnectionManager(&fakeProbe{})
+	team := Scope{Config: "team-a", Environment: "prod", Cluster: "eu"}
+	other := Scope{Config: "team-b", Environment: "prod", Cluster: "us"}
+
+	for range DefaultFailureThreshold {
+		m.RecordFailure(team, "team-hash", errors.New("connection refused"))
+	}
+	require.ErrorIs(t, m.Allow(team, "team-hash"), ErrCircuitOpen)
+
+	// The other config's environment keeps its own breaker, and using it leaves the open one alone
+	require.NoError(t, m.Allow(other, "other-hash"))
+	require.NoError(t, m.Track(other, "other-hash", &rest.Config{}))
+	status, ok := m.Status(other)
+	require.True(t, ok)
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateHealthy, status.State)
+
+	status, _ = m.Status(team)
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateOpen, status.State)
+	assert.ErrorIs(t, m.Allow(team, "team-hash"), ErrCircuitOpen)
+}
+
+func TestConnectionManager_Metrics(t *testing.T) {
+	probe := &fakeProbe{}
+	m, _ := newTestConnectionManager(probe)
+	require.NoError(t, m.Track(testScope("prod"), "hash", &rest.Config{}))
+	m.probeDue(context.Background())
+	m.RecordFailure(testScope("staging"), "hash", errors.New("timeout"))
+
+	expected := `
+# HELP agentregistry_cluster_connection_state Connection state of an environment's cluster; 1 for the current state, 0 otherwise
+# TYPE agentregistry_cluster_connection_state gauge
+agentregistry_cluster_connection_state{cluster="prod-cluster",config="discovery",environment="prod",state="Degraded"} 0
+agentregistry_cluster_connection_state{cluster="prod-cluster",config="discovery",environment="prod",state="Healthy"} 1
+agentregistry_cluster_connection_state{cluster="prod-cluster",config="discovery",environment="prod",state="Open"} 0
+agentregistry_cluster_connection_state{cluster="staging-cluster",config="discovery",environment="staging",state="Degraded"} 1
+agentregistry_cluster_connection_state{cluster="staging-cluster",config="discovery",environment="staging",state="Healthy"} 0
+agentregistry_cluster_connection_state{cluster="staging-cluster",config="discovery",environment="staging",state="Open"} 0
+# HELP agentregistry_cluster_connection_consecutive_failures Number of failed connection attempts since the cluster last answered
+# TYPE agentregistry_cluster_connection_consecutive_failures gauge
+agentregistry_cluster_connection_consecutive_failures{cluster="prod-cluster",config="discovery",environment="prod"} 0
+agentregistry_cluster_connection_consecutive_failures{cluster="staging-cluster",config="discovery",environment="staging"} 1
+# HELP agentregistry_cluster_probes_total Number of cluster health probes by result
+# TYPE agentregistry_cluster_probes_total counter
+agentregistry_cluster_probes_total{cluster="prod-cluster",config="discovery",environment="prod",result="success"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(m, strings.NewReader(expected),
+		"agentregistry_cluster_connection_state",
+		"agentregistry_cluster_connection_consecutive_failures",
+		"agentregistry_cluster_probes_total",
+	))
+}
+
+func TestGetClient_CircuitBreaker(t *testing.T) {
+	var failing atomic.Bool
+	var versionCalls atomic.Int32
+	apiserver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		versionCalls.Add(1)
+		if failing.Load() {
+			http.Error(w, "etcd unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"major":"1","minor":"31","gitVersion":"v1.31.0"}`))
+	}))
+	defer apiserver.Close()
+
+	env := &agentregistryv1alpha1.Environment{
+		Name: "onprem",
+		Cluster: agentregistryv1alpha1.ClusterConfig{
+			Name:     "onprem",
+			Endpoint: apiserver.URL,
+			CAData: base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: apiserver.Certificate().Raw,
+			})),
+		},
+	}
+	scope := Scope{Config: "discovery", Environment: "onprem", Cluster: "onprem"}
+	factory := NewFactory(nil, zerolog.Nop())
+	connections := factory.Connections()
+	connections.FailureThreshold = 1
+	now := time.Now()
+	connections.now = func() time.Time { return now }
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+
+	_, err := factory.GetClient(ctx, scope, env, scheme)
+	require.NoError(t, err)
+	connections.probeDue(ctx)
+	assert.Equal(t, int32(1), versionCalls.Load())
+	status, ok := connections.Status(scope)
+	require.True(t, ok)
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateHealthy, status.State)
+
+	failing.Store(true)
+	now = now.Add(DefaultProbeInterval)
+	connections.probeDue(ctx)
+	status, _ = connections.Status(scope)
+	require.Equal(t, agentregistryv1alpha1.ConnectionStateOpen, status.State)
+	assert.Contains(t, status.Error, "unable to handle the request")
+
+	// The cached client is dropped and callers fail fast
+	factory.mu.RLock()
+	_, cached := factory.cache[scope]
+	factory.mu.RUnlock()
+	assert.False(t, cached)
+	_, err = factory.GetClient(ctx, scope, env, scheme)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// Once the retry time is reached a fresh client is built and probed
+	failing.Store(false)
+	now = status.RetryAt
+	_, err = factory.GetClient(ctx, scope, env, scheme)
+	require.NoError(t, err)
+	connections.probeDue(ctx)
+	status, _ = connections.Status(scope)
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateHealthy, status.State)
+}
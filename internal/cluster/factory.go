@@ -9,7 +9,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/rs/zerolog"
+	"golang.org/x/oauth2/google"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,12 +26,12 @@ const (
 
 // ClientFactory creates Kubernetes clients for remote clusters.
 type ClientFactory interface {
-	// GetClient returns a client for the specified environment.
+	// GetClient returns a client for the specified environment of a scope.
 	// Clients are cached and reused when possible.
-	GetClient(ctx context.Context, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error)
+	GetClient(ctx context.Context, scope Scope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error)
 
-	// InvalidateClient removes a cached client for the specified environment.
-	InvalidateClient(envName string)
+	// InvalidateClient removes a cached client for the specified scope.
+	InvalidateClient(scope Scope)
 }
 
 // cachedClient holds a client and its metadata for cache management.
@@ -37,6 +39,8 @@ type cachedClient struct {
 	client     client.WithWatch
 	configHash string
 	createdAt  time.Time
+	// env is the environment the client was built for, passed to Provider.Refresh
+	env *agentregistryv1alpha1.Environment
 }
 
 // Factory implements ClientFactory with caching and support for multiple auth methods.
@@ -46,22 +50,45 @@ type Factory struct {
 	cacheTTL    time.Duration
 
 	mu     sync.RWMutex
-	cache  map[string]*cachedClient
+	cache  map[Scope]*cachedClient // key: scope
 	scheme *runtime.Scheme
+
+	// loadAWSConfig loads AWS credentials for EKS clusters; nil uses the default chain
+	loadAWSConfig func(ctx context.Context, region string) (aws.Config, error)
+	// loadAzureIdentity loads Azure workload identity settings for AKS clusters; nil reads the environment
+	loadAzureIdentity func() (*azureWorkloadIdentity, error)
+	// findGoogleCredentials loads GCP credentials for GKE clusters; nil uses the default chain
+	findGoogleCredentials func(ctx context.Context) (*google.Credentials, error)
+	// gkeEndpoint overrides the GKE API endpoint; empty uses the default
+	gkeEndpoint string
+
+	// connections probes the clusters of cached clients and trips their circuit breakers
+	connections *ConnectionManager
 }
 
 // NewFactory creates a new Factory with the given local client.
 func NewFactory(localClient client.Client, logger zerolog.Logger) *Factory {
-	return &Factory{
+	f := &Factory{
 		localClient: localClient,
 		logger:      logger.With().Str("component", "cluster-factory").Logger(),
 		cacheTTL:    DefaultCacheTTL,
-		cache:       make(map[string]*cachedClient),
+		cache:       make(map[Scope]*cachedClient),
+		connections: NewConnectionManager(logger),
 	}
+	// A cluster that stopped answering gets a fresh client once it is retried
+	f.connections.onOpen = f.InvalidateClient
+	f.connections.onUnauthorized = f.refreshClient
+	return f
+}
+
+// Connections returns the manager tracking the health of remote clusters. It must be added to
+// the controller manager for clusters to be probed.
+func (f *Factory) Connections() *ConnectionManager {
+	return f.connections
 }
 
-// GetClient returns a client for the specified environment.
-func (f *Factory) GetClient(ctx context.Context, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+// GetClient returns a client for the specified environment of a scope.
+func (f *Factory) GetClient(ctx context.Context, scope Scope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
 	f.scheme = scheme
 
 	// Check if this is a local cluster request
@@ -75,9 +102,24 @@ func (f *Factory) GetClient(ctx context.Context, env *agentregistryv1alpha1.Envi
 	// Calculate config hash for cache invalidation
 	configHash := f.computeConfigHash(env)
 
+	// Clients built from a credentials Secret are rebuilt when the Secret changes
+	if _, ok := CredentialsSecretKey(env); ok {
+		secret, err := f.credentialsSecret(ctx, env)
+		if err != nil {
+			f.InvalidateClient(scope)
+			return nil, fmt.Errorf("failed to create client for environment %s: %w", env.Name, err)
+		}
+		configHash += "/" + secret.ResourceVersion
+	}
+
+	// Fail fast while the cluster's circuit breaker is open
+	if err := f.connections.Allow(scope, configHash); err != nil {
+		return nil, err
+	}
+
 	// Check cache
 	f.mu.RLock()
-	cached, exists := f.cache[env.Name]
+	cached, exists := f.cache[scope]
 	f.mu.RUnlock()
 
 	if exists {
@@ -89,61 +131,73 @@ func (f *Factory) GetClient(ctx context.Context, env *agentregistryv1alpha1.Envi
 			return cached.client, nil
 		}
 		// Cache is stale, invalidate it
-		f.InvalidateClient(env.Name)
+		f.InvalidateClient(scope)
 	}
 
 	// Create new client
-	remoteClient, err := f.createClient(ctx, env)
+	remoteClient, config, err := f.createClient(ctx, env)
 	if err != nil {
+		f.connections.RecordFailure(scope, configHash, err)
+		return nil, fmt.Errorf("failed to create client for environment %s: %w", env.Name, err)
+	}
+	if err := f.connections.Track(scope, configHash, config); err != nil {
 		return nil, fmt.Errorf("failed to create client for environment %s: %w", env.Name, err)
 	}
 
 	// Cache the client
 	f.mu.Lock()
-	f.cache[env.Name] = &cachedClient{
+	f.cache[scope] = &cachedClient{
 		client:     remoteClient,
 		configHash: configHash,
 		createdAt:  time.Now(),
+		env:        env.DeepCopy(),
 	}
 	f.mu.Unlock()
 
 	f.logger.Info().
-		Str("environment", env.Name).
-		Str("cluster", env.Cluster.Name).
+		Str("scope", scope.String()).
 		Msg("created and cached new client")
 
 	return remoteClient, nil
 }
 
-// InvalidateClient removes a cached client for the specified environment.
-func (f *Factory) InvalidateClient(envName string) {
+// InvalidateClient removes a cached client for the specified scope.
+func (f *Factory) InvalidateClient(scope Scope) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	if _, exists := f.cache[envName]; exists {
-		delete(f.cache, envName)
+	if _, exists := f.cache[scope]; exists {
+		delete(f.cache, scope)
 		f.logger.Debug().
-			Str("environment", envName).
+			Str("scope", scope.String()).
 			Msg("invalidated cached client")
 	}
 }
 
-// isLocalCluster determines if the environment refers to the local cluster.
-func (f *Factory) isLocalCluster(env *agentregistryv1alpha1.Environment) bool {
-	// Explicit local cluster
-	if env.Cluster.Name == "local" || env.Cluster.Name == "" {
-		return true
+// refreshClient lets the provider of a scope's environment drop its credentials after the
+// cluster rejected them, and drops the cached client so the next GetClient builds a new one.
+func (f *Factory) refreshClient(scope Scope) {
+	f.mu.RLock()
+	cached, exists := f.cache[scope]
+	f.mu.RUnlock()
+	if !exists {
+		return
 	}
 
-	// No endpoint and no workload identity configured means local
-	if env.Cluster.Endpoint == "" && !env.Cluster.UseWorkloadIdentity {
-		// Check if provider-specific config is present
-		if env.Provider == "" || (env.Provider == "gcp" && env.Cluster.ProjectID == "") {
-			return true
+	if provider, err := providerFor(cached.env); err == nil {
+		if err := provider.Refresh(context.Background(), f, cached.env); err != nil {
+			f.logger.Warn().Err(err).
+				Str("scope", scope.String()).
+				Msg("failed to refresh cluster credentials")
 		}
 	}
+	f.InvalidateClient(scope)
+}
 
-	return false
+// isLocalCluster determines if the environment refers to the local cluster.
+func (f *Factory) isLocalCluster(env *agentregistryv1alpha1.Environment) bool {
+	name, err := ProviderName(env)
+	return err == nil && name == ProviderInCluster
 }
 
 // wrapLocalClient wraps the local client to implement client.WithWatch.
@@ -157,27 +211,17 @@ func (f *Factory) wrapLocalClient() (client.WithWatch, error) {
 	return nil, fmt.Errorf("local client does not implement client.WithWatch")
 }
 
-// createClient creates a new client for the environment based on its configuration.
-func (f *Factory) createClient(ctx context.Context, env *agentregistryv1alpha1.Environment) (client.WithWatch, error) {
-	var config *rest.Config
-	var err error
-
-	// Determine how to get the cluster config
-	if env.Cluster.Endpoint != "" && env.Cluster.CAData != "" {
-		// Static credentials provided
-		config, err = f.createStaticConfig(env)
-	} else if env.Provider == "gcp" && env.Cluster.UseWorkloadIdentity {
-		// GCP workload identity
-		config, err = f.createGKEConfig(ctx, env)
-	} else if env.Cluster.UseWorkloadIdentity {
-		// Generic workload identity - try to auto-detect provider
-		config, err = f.createWorkloadIdentityConfig(ctx, env)
-	} else {
-		return nil, fmt.Errorf("no valid authentication method for environment %s: need endpoint+caData or workload identity", env.Name)
+// createClient creates a new client for the environment with its provider, along with the
+// rest.Config it was built from.
+func (f *Factory) createClient(ctx context.Context, env *agentregistryv1alpha1.Environment) (client.WithWatch, *rest.Config, error) {
+	provider, err := providerFor(env)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	config, err := provider.RESTConfig(ctx, f, env)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create config: %w", err)
+		return nil, nil, fmt.Errorf("failed to create config: %w", err)
 	}
 
 	// Create the client
@@ -185,34 +229,36 @@ func (f *Factory) createClient(ctx context.Context, env *agentregistryv1alpha1.E
 		Scheme: f.scheme,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create client from config: %w", err)
+		return nil, nil, fmt.Errorf("failed to create client from config: %w", err)
 	}
 
-	return remoteClient, nil
-}
-
-// createWorkloadIdentityConfig attempts to create a config using workload identity,
-// auto-detecting the provider if not specified.
-func (f *Factory) createWorkloadIdentityConfig(ctx context.Context, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
-	// If we have GCP project info, use GKE
-	if env.Cluster.ProjectID != "" {
-		return f.createGKEConfig(ctx, env)
-	}
-
-	// Add support for other providers (AWS, Azure) here in the future
-	return nil, fmt.Errorf("unable to determine provider for workload identity; set provider field or provide projectId for GCP")
+	return remoteClient, config, nil
 }
 
 // computeConfigHash computes a hash of the environment config for cache invalidation.
 func (f *Factory) computeConfigHash(env *agentregistryv1alpha1.Environment) string {
+	return ConfigHash(env)
+}
+
+// ConfigHash returns a hash of the environment fields that affect how a cluster client is built.
+// Two environments with the same hash can share a client.
+func ConfigHash(env *agentregistryv1alpha1.Environment) string {
 	// Hash relevant config fields that affect the client
-	data := fmt.Sprintf("%s:%s:%s:%s:%s:%s:%t",
+	var secretRef string
+	if key, ok := CredentialsSecretKey(env); ok {
+		secretRef = key.String()
+	}
+	data := fmt.Sprintf("%s:%s:%s:%s:%s:%s:%s:%s:%s:%s:%t",
+		env.Provider,
 		env.Cluster.Name,
 		env.Cluster.Endpoint,
 		env.Cluster.CAData,
 		env.Cluster.ProjectID,
+		env.Cluster.SubscriptionID,
+		env.Cluster.ResourceGroup,
 		env.Cluster.Zone,
 		env.Cluster.Region,
+		secretRef,
 		env.Cluster.UseWorkloadIdentity,
 	)
 
@@ -220,9 +266,9 @@ func (f *Factory) computeConfigHash(env *agentregistryv1alpha1.Environment) stri
 	return hex.EncodeToString(hash[:8]) // Use first 8 bytes for shorter hash
 }
 
-// CreateClientFunc returns a function suitable for use as controller.RemoteClientFactory.
-func (f *Factory) CreateClientFunc() func(*agentregistryv1alpha1.Environment, *runtime.Scheme) (client.WithWatch, error) {
-	return func(env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
-		return f.GetClient(context.Background(), env, scheme)
+// CreateClientFunc returns a function that builds clients in the background context.
+func (f *Factory) CreateClientFunc() func(Scope, *agentregistryv1alpha1.Environment, *runtime.Scheme) (client.WithWatch, error) {
+	return func(scope Scope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+		return f.GetClient(context.Background(), scope, env, scheme)
 	}
 }
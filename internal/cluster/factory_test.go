@@ -157,22 +157,23 @@ func TestInvalidateClient(t *testing.T) {
 	factory := NewFactory(nil, logger)
 
 	// Add a cached entry
-	factory.cache["test-env"] = &cachedClient{
+	scope := Scope{Config: "discovery", Environment: "test-env", Cluster: "test-cluster"}
+	factory.cache[scope] = &cachedClient{
 		client:     nil,
 		configHash: "abc123",
 	}
 
 	// Verify it exists
-	assert.Contains(t, factory.cache, "test-env")
+	assert.Contains(t, factory.cache, scope)
 
 	// Invalidate it
-	factory.InvalidateClient("test-env")
+	factory.InvalidateClient(scope)
 
 	// Verify it's removed
-	assert.NotContains(t, factory.cache, "test-env")
+	assert.NotContains(t, factory.cache, scope)
 
 	// Invalidating non-existent entry should not panic
-	factory.InvalidateClient("non-existent")
+	factory.InvalidateClient(Scope{Config: "discovery", Environment: "non-existent"})
 }
 
 func TestNewFactory(t *testing.T) {
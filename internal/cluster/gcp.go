@@ -16,17 +16,53 @@ import (
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
 )
 
+func init() {
+	RegisterProvider(ProviderGCP, gkeProvider{})
+}
+
+// gkeProvider reaches GKE clusters with GCP workload identity
+type gkeProvider struct{ noRefresh }
+
+// Validate implements Provider
+func (gkeProvider) Validate(env *agentregistryv1alpha1.Environment) error {
+	if env.Cluster.ProjectID == "" {
+		return fmt.Errorf("cluster projectId is required for GKE")
+	}
+	if env.Cluster.Zone == "" && env.Cluster.Region == "" {
+		return fmt.Errorf("cluster zone or region is required for GKE")
+	}
+	return nil
+}
+
+// RESTConfig implements Provider
+func (gkeProvider) RESTConfig(ctx context.Context, f *Factory, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	return f.createGKEConfig(ctx, env)
+}
+
+// findGoogleCredentials loads the default GCP credentials with the GKE scope
+func findGoogleCredentials(ctx context.Context) (*google.Credentials, error) {
+	return google.FindDefaultCredentials(ctx, container.CloudPlatformScope)
+}
+
 // createGKEConfig creates a rest.Config for a GKE cluster using workload identity.
 func (f *Factory) createGKEConfig(ctx context.Context, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
 	// Get default credentials with GKE scope
 	// In GKE with workload identity, this will use the pod's service account
-	creds, err := google.FindDefaultCredentials(ctx, container.CloudPlatformScope)
+	findCredentials := f.findGoogleCredentials
+	if findCredentials == nil {
+		findCredentials = findGoogleCredentials
+	}
+	creds, err := findCredentials(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get default credentials: %w", err)
 	}
 
 	// Create GKE service client
-	gkeService, err := container.NewService(ctx, option.WithCredentials(creds))
+	opts := []option.ClientOption{option.WithCredentials(creds)}
+	if f.gkeEndpoint != "" {
+		opts = append(opts, option.WithEndpoint(f.gkeEndpoint))
+	}
+	gkeService, err := container.NewService(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GKE service: %w", err)
 	}
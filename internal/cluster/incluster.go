@@ -0,0 +1,28 @@
+package cluster
+
+import (
+	"context"
+
+	"k8s.io/client-go/rest"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+func init() {
+	RegisterProvider(ProviderInCluster, inClusterProvider{})
+}
+
+// inClusterProvider is the cluster the controller runs in. GetClient hands out the manager's
+// client for it; RESTConfig returns the controller's own config.
+type inClusterProvider struct{ noRefresh }
+
+// Validate implements Provider
+func (inClusterProvider) Validate(*agentregistryv1alpha1.Environment) error {
+	return nil
+}
+
+// RESTConfig implements Provider
+func (inClusterProvider) RESTConfig(context.Context, *Factory, *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	return ctrlconfig.GetConfig()
+}
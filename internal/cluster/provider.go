@@ -0,0 +1,156 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"k8s.io/client-go/rest"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+// Names of the built-in providers, as used in Environment.Provider
+const (
+	// ProviderGCP reaches GKE clusters with GCP workload identity
+	ProviderGCP = "gcp"
+	// ProviderAWS reaches EKS clusters with IRSA or EKS Pod Identity
+	ProviderAWS = "aws"
+	// ProviderAzure reaches AKS clusters with Azure workload identity
+	ProviderAzure = "azure"
+	// ProviderAKS is an alias of ProviderAzure
+	ProviderAKS = "aks"
+	// ProviderStatic reaches a cluster at a fixed endpoint, trusting ClusterConfig.CAData
+	ProviderStatic = "static"
+	// ProviderKubeconfig reaches a cluster with a kubeconfig, token or client certificate from
+	// a credentials Secret
+	ProviderKubeconfig = "kubeconfig"
+	// ProviderInCluster is the cluster the controller runs in
+	ProviderInCluster = "in-cluster"
+	// ProviderSatellite is a cluster the registry never connects to. A satellite running in
+	// the cluster pulls its deployments and reports their status.
+	ProviderSatellite = "satellite"
+)
+
+// Provider builds rest.Configs for one way of reaching a cluster. Providers register under
+// the name used in Environment.Provider with RegisterProvider, usually from an init function.
+type Provider interface {
+	// Validate checks the environment's cluster settings without contacting any API. It runs
+	// when DiscoveryConfigs are reconciled and before every RESTConfig call.
+	Validate(env *agentregistryv1alpha1.Environment) error
+
+	// RESTConfig builds a rest.Config for the environment's cluster
+	RESTConfig(ctx context.Context, f *Factory, env *agentregistryv1alpha1.Environment) (*rest.Config, error)
+
+	// Refresh is called when the cluster rejects credentials built by the provider, before the
+	// client is rebuilt. Providers that cache credentials outside the rest.Config drop them here.
+	Refresh(ctx context.Context, f *Factory, env *agentregistryv1alpha1.Environment) error
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]Provider)
+)
+
+// RegisterProvider makes a provider available under name. It panics if name is empty or
+// already registered.
+func RegisterProvider(name string, provider Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if name == "" || provider == nil {
+		panic("cluster: RegisterProvider needs a name and a provider")
+	}
+	if _, exists := providers[name]; exists {
+		panic(fmt.Sprintf("cluster: provider %q registered twice", name))
+	}
+	providers[name] = provider
+}
+
+// ProviderNames returns the names of all registered providers, sorted
+func ProviderNames() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupProvider returns the provider registered under name
+func lookupProvider(name string) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+// ProviderName returns the name of the provider that builds clients for env. An explicit
+// Environment.Provider is used as is, except that the cloud providers (gcp, aws, azure, aks)
+// only apply with workload identity and otherwise fall back to inference from the cluster
+// settings, as they did before providers were pluggable.
+func ProviderName(env *agentregistryv1alpha1.Environment) (string, error) {
+	c := &env.Cluster
+	if c.Name == "local" || c.Name == "" {
+		return ProviderInCluster, nil
+	}
+	if c.CredentialsSecretRef != nil {
+		return ProviderKubeconfig, nil
+	}
+
+	switch env.Provider {
+	case "", ProviderGCP, ProviderAWS, ProviderAzure, ProviderAKS:
+	default:
+		return env.Provider, nil
+	}
+
+	switch {
+	case c.UseWorkloadIdentity && (env.Provider == ProviderAWS || env.Provider == ProviderAzure || env.Provider == ProviderAKS):
+		// Endpoint and CA may be set to skip the cloud API
+		return env.Provider, nil
+	case c.Endpoint != "" && c.CAData != "":
+		return ProviderStatic, nil
+	case c.UseWorkloadIdentity && (env.Provider == ProviderGCP || c.ProjectID != ""):
+		return ProviderGCP, nil
+	case c.UseWorkloadIdentity:
+		return "", fmt.Errorf("unable to determine provider for workload identity; set provider field or provide projectId for GCP")
+	case c.Endpoint == "" && (env.Provider == "" || (env.Provider == ProviderGCP && c.ProjectID == "")):
+		// No endpoint and no workload identity configured means local
+		return ProviderInCluster, nil
+	}
+	return "", fmt.Errorf("no valid authentication method for environment %s: need endpoint+caData or workload identity", env.Name)
+}
+
+// providerFor resolves and validates the provider of env
+func providerFor(env *agentregistryv1alpha1.Environment) (Provider, error) {
+	name, err := ProviderName(env)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := lookupProvider(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q for environment %s; registered providers are %v", name, env.Name, ProviderNames())
+	}
+	if err := p.Validate(env); err != nil {
+		return nil, fmt.Errorf("invalid %s cluster settings for environment %s: %w", name, env.Name, err)
+	}
+	return p, nil
+}
+
+// ValidateEnvironment checks that env selects a registered provider and has the settings it
+// needs, without contacting the cluster
+func ValidateEnvironment(env *agentregistryv1alpha1.Environment) error {
+	_, err := providerFor(env)
+	return err
+}
+
+// noRefresh implements Provider.Refresh for providers that keep no credentials outside the
+// rest.Config
+type noRefresh struct{}
+
+// Refresh implements Provider
+func (noRefresh) Refresh(context.Context, *Factory, *agentregistryv1alpha1.Environment) error {
+	return nil
+}
@@ -0,0 +1,379 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	sigyaml "sigs.k8s.io/yaml"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+// fakeAPIServer is a TLS Kubernetes API server that answers /version and records the
+// Authorization header of the last request
+type fakeAPIServer struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	authorization string
+}
+
+func newFakeAPIServer(t *testing.T) *fakeAPIServer {
+	s := &fakeAPIServer{}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		s.mu.Lock()
+		s.authorization = r.Header.Get("Authorization")
+		s.mu.Unlock()
+		_, _ = w.Write([]byte(`{"major":"1","minor":"31","gitVersion":"v1.31.0"}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// caData returns the server's certificate as base64 PEM, as in ClusterConfig.CAData
+func (s *fakeAPIServer) caData() string {
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: s.Certificate().Raw,
+	}))
+}
+
+// lastAuthorization returns the Authorization header of the last request
+func (s *fakeAPIServer) lastAuthorization() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authorization
+}
+
+// newFakeGKE serves the GKE clusters.get API for projects/proj/locations/us-central1/clusters/prod
+func newFakeGKE(t *testing.T, endpoint, caData string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/proj/locations/us-central1/clusters/prod" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer gcp-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"name":       "prod",
+			"endpoint":   strings.TrimPrefix(endpoint, "https://"),
+			"masterAuth": map[string]string{"clusterCaCertificate": caData},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProviderName(t *testing.T) {
+	secretRef := &agentregistryv1alpha1.ClusterCredentialsSecretRef{Name: "creds"}
+	tests := []struct {
+		name     string
+		provider string
+		cluster  agentregistryv1alpha1.ClusterConfig
+		want     string
+		wantErr  string
+	}{
+		{name: "unnamed cluster", want: ProviderInCluster},
+		{name: "local cluster", cluster: agentregistryv1alpha1.ClusterConfig{Name: "local", Endpoint: "https://10.0.0.1"}, want: ProviderInCluster},
+		{name: "no endpoint or identity", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev"}, want: ProviderInCluster},
+		{name: "credentials secret", provider: "gcp", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", CredentialsSecretRef: secretRef}, want: ProviderKubeconfig},
+		{name: "endpoint and CA", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", Endpoint: "https://10.0.0.1", CAData: testCAData}, want: ProviderStatic},
+		{name: "cloud provider without identity falls back to static", provider: "aws", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", Endpoint: "https://10.0.0.1", CAData: testCAData}, want: ProviderStatic},
+		{name: "EKS with endpoint and CA", provider: "aws", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", Endpoint: "https://10.0.0.1", CAData: testCAData, UseWorkloadIdentity: true}, want: ProviderAWS},
+		{name: "AKS", provider: "aks", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", UseWorkloadIdentity: true}, want: ProviderAKS},
+		{name: "GKE", provider: "gcp", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", UseWorkloadIdentity: true}, want: ProviderGCP},
+		{name: "GKE inferred from project", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", ProjectID: "proj", UseWorkloadIdentity: true}, want: ProviderGCP},
+		{name: "explicit provider", provider: "static", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", UseWorkloadIdentity: true}, want: ProviderStatic},
+		{name: "unknown identity provider", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", UseWorkloadIdentity: true}, wantErr: "unable to determine provider for workload identity"},
+		{name: "endpoint without CA", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", Endpoint: "https://10.0.0.1"}, wantErr: "no valid authentication method"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, err := ProviderName(&agentregistryv1alpha1.Environment{Name: "env", Provider: tt.provider, Cluster: tt.cluster})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, name)
+		})
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		cluster  agentregistryv1alpha1.ClusterConfig
+		wantErr  string
+	}{
+		{name: "in-cluster", cluster: agentregistryv1alpha1.ClusterConfig{Name: "local"}},
+		{name: "unregistered provider", provider: "openshift", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev"}, wantErr: `unknown provider "openshift"`},
+		{name: "GKE without project", provider: "gcp", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", Zone: "us-central1-a", UseWorkloadIdentity: true}, wantErr: "projectId is required"},
+		{name: "GKE without location", provider: "gcp", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", ProjectID: "proj", UseWorkloadIdentity: true}, wantErr: "zone or region is required"},
+		{name: "AKS without resource group", provider: "azure", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", SubscriptionID: "sub", UseWorkloadIdentity: true}, wantErr: "subscriptionId and resourceGroup are required"},
+		{name: "AKS with endpoint and CA", provider: "azure", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", Endpoint: "https://10.0.0.1", CAData: testCAData, UseWorkloadIdentity: true}},
+		{name: "EKS with invalid CA", provider: "aws", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", Endpoint: "https://10.0.0.1", CAData: "!!!", UseWorkloadIdentity: true}, wantErr: "failed to decode CA data"},
+		{name: "static without CA", provider: "static", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", Endpoint: "https://10.0.0.1"}, wantErr: "endpoint and caData are required"},
+		{name: "kubeconfig without secret", provider: "kubeconfig", cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev"}, wantErr: "credentialsSecretRef is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEnvironment(&agentregistryv1alpha1.Environment{Name: "env", Provider: tt.provider, Cluster: tt.cluster})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// testProvider builds configs for a fixed host and counts refreshes
+type testProvider struct {
+	host      string
+	refreshed int
+}
+
+func (p *testProvider) Validate(env *agentregistryv1alpha1.Environment) error {
+	if env.Cluster.Endpoint != "" {
+		return errors.New("endpoint is not supported")
+	}
+	return nil
+}
+
+func (p *testProvider) RESTConfig(context.Context, *Factory, *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	return &rest.Config{Host: p.host}, nil
+}
+
+func (p *testProvider) Refresh(context.Context, *Factory, *agentregistryv1alpha1.Environment) error {
+	p.refreshed++
+	return nil
+}
+
+func TestRegisterProvider(t *testing.T) {
+	provider := &testProvider{host: "https://cluster.example.com"}
+	RegisterProvider("example", provider)
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, "example")
+		providersMu.Unlock()
+	})
+
+	assert.Panics(t, func() { RegisterProvider("example", provider) })
+	assert.Panics(t, func() { RegisterProvider("", provider) })
+	assert.Contains(t, ProviderNames(), "example")
+
+	factory := NewFactory(nil, zerolog.Nop())
+	env := &agentregistryv1alpha1.Environment{Name: "custom", Provider: "example", Cluster: agentregistryv1alpha1.ClusterConfig{Name: "custom"}}
+	scope := Scope{Config: "discovery", Environment: "custom", Cluster: "custom"}
+	_, err := factory.GetClient(context.Background(), scope, env, runtime.NewScheme())
+	require.NoError(t, err)
+
+	// A rejected credential lets the provider refresh and drops the cached client
+	factory.refreshClient(scope)
+	assert.Equal(t, 1, provider.refreshed)
+	assert.NotContains(t, factory.cache, scope)
+
+	env.Cluster.Endpoint = "https://10.0.0.1"
+	_, err = factory.GetClient(context.Background(), scope, env, runtime.NewScheme())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid example cluster settings for environment custom: endpoint is not supported")
+}
+
+// TestProviderNames_MatchCRD keeps the admission-time enum of Environment.Provider in step
+// with the registered providers
+func TestProviderNames_MatchCRD(t *testing.T) {
+	for _, path := range []string{
+		"../../config/crd/agentregistry.dev_discoveryconfigs.yaml",
+		"../../charts/agentregistry/crds/agentregistry.dev_discoveryconfigs.yaml",
+	} {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		var crd struct {
+			Spec struct {
+				Versions []struct {
+					Schema struct {
+						OpenAPIV3Schema struct {
+							Properties struct {
+								Spec struct {
+									Properties struct {
+										Environments struct {
+											Items struct {
+												Properties struct {
+													Provider struct {
+														Enum []string `json:"enum"`
+													} `json:"provider"`
+												} `json:"properties"`
+											} `json:"items"`
+										} `json:"environments"`
+									} `json:"properties"`
+								} `json:"spec"`
+							} `json:"properties"`
+						} `json:"openAPIV3Schema"`
+					} `json:"schema"`
+				} `json:"versions"`
+			} `json:"spec"`
+		}
+		require.NoError(t, sigyaml.Unmarshal(data, &crd))
+		require.NotEmpty(t, crd.Spec.Versions)
+		enum := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties.Spec.Properties.Environments.Items.Properties.Provider.Enum
+		assert.Equal(t, ProviderNames(), enum, "%s: update the +kubebuilder:validation:Enum marker of Environment.Provider", filepath.Base(path))
+	}
+}
+
+// TestProviders_FakeAPIServer builds a client with every built-in provider against a fake
+// API server and checks the credentials it presents
+func TestProviders_FakeAPIServer(t *testing.T) {
+	apiserver := newFakeAPIServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		env       *agentregistryv1alpha1.Environment
+		setup     func(t *testing.T, f *Factory)
+		wantAuth  string
+		wantToken string
+	}{
+		{
+			name: "static",
+			env: &agentregistryv1alpha1.Environment{Name: "static", Cluster: agentregistryv1alpha1.ClusterConfig{
+				Name: "static", Endpoint: apiserver.URL, CAData: apiserver.caData(),
+			}},
+		},
+		{
+			name: "kubeconfig",
+			env: &agentregistryv1alpha1.Environment{Name: "kubeconfig", Cluster: agentregistryv1alpha1.ClusterConfig{
+				Name: "kubeconfig", Endpoint: apiserver.URL, CAData: apiserver.caData(),
+				CredentialsSecretRef: &agentregistryv1alpha1.ClusterCredentialsSecretRef{Name: "creds", Namespace: "agentregistry"},
+			}},
+			setup: func(t *testing.T, f *Factory) {
+				scheme := runtime.NewScheme()
+				require.NoError(t, corev1.AddToScheme(scheme))
+				f.localClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "agentregistry"},
+					Data:       map[string][]byte{"token": []byte("secret-token")},
+				}).Build()
+			},
+			wantAuth: "Bearer secret-token",
+		},
+		{
+			name: "gcp",
+			env: &agentregistryv1alpha1.Environment{Name: "gke", Provider: "gcp", Cluster: agentregistryv1alpha1.ClusterConfig{
+				Name: "prod", ProjectID: "proj", Region: "us-central1", UseWorkloadIdentity: true,
+			}},
+			setup: func(t *testing.T, f *Factory) {
+				f.gkeEndpoint = newFakeGKE(t, apiserver.URL, apiserver.caData()).URL + "/"
+				f.findGoogleCredentials = func(context.Context) (*google.Credentials, error) {
+					return &google.Credentials{TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "gcp-token"})}, nil
+				}
+			},
+			wantAuth: "Bearer gcp-token",
+		},
+		{
+			name: "aws",
+			env:  eksEnv(agentregistryv1alpha1.ClusterConfig{Name: "prod", Region: "us-west-2"}),
+			setup: func(t *testing.T, f *Factory) {
+				aws := newFakeAWS(t)
+				aws.endpoint = apiserver.URL
+				aws.caData = apiserver.caData()
+				f.loadAWSConfig = newTestEKSFactory(aws).loadAWSConfig
+			},
+			wantToken: eksTokenPrefix,
+		},
+		{
+			name: "aks",
+			env: aksEnv(agentregistryv1alpha1.ClusterConfig{
+				Name: "prod", Endpoint: apiserver.URL, CAData: apiserver.caData(),
+			}),
+			setup: func(t *testing.T, f *Factory) {
+				f.loadAzureIdentity = newTestAKSFactory(newFakeAzure(t)).loadAzureIdentity
+			},
+			wantAuth: "Bearer " + aksServerScope + "|sa-token-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := NewFactory(nil, zerolog.Nop())
+			if tt.setup != nil {
+				tt.setup(t, factory)
+			}
+			_, config, err := factory.createClient(ctx, tt.env)
+			require.NoError(t, err)
+
+			probe, err := versionProbe(config)
+			require.NoError(t, err)
+			require.NoError(t, probe(ctx))
+			if tt.wantToken != "" {
+				assert.True(t, strings.HasPrefix(apiserver.lastAuthorization(), "Bearer "+tt.wantToken), apiserver.lastAuthorization())
+			} else {
+				assert.Equal(t, tt.wantAuth, apiserver.lastAuthorization())
+			}
+		})
+	}
+
+	t.Run("in-cluster", func(t *testing.T) {
+		kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+		require.NoError(t, os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: local
+  cluster:
+    server: `+apiserver.URL+`
+    certificate-authority-data: `+apiserver.caData()+`
+contexts:
+- name: local
+  context:
+    cluster: local
+    user: controller
+current-context: local
+users:
+- name: controller
+  user:
+    token: controller-token
+`), 0o600))
+		t.Setenv("KUBECONFIG", kubeconfig)
+
+		provider, ok := lookupProvider(ProviderInCluster)
+		require.True(t, ok)
+		config, err := provider.RESTConfig(ctx, NewFactory(nil, zerolog.Nop()), &agentregistryv1alpha1.Environment{Name: "local"})
+		require.NoError(t, err)
+		probe, err := versionProbe(config)
+		require.NoError(t, err)
+		require.NoError(t, probe(ctx))
+		assert.Equal(t, "Bearer controller-token", apiserver.lastAuthorization())
+	})
+}
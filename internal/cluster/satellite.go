@@ -0,0 +1,29 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/rest"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+func init() {
+	RegisterProvider(ProviderSatellite, satelliteProvider{})
+}
+
+// satelliteProvider is a cluster the registry cannot reach. A satellite in the cluster pulls
+// its deployments from the registry, and inventory is pushed as reports, so there is no
+// rest.Config to build.
+type satelliteProvider struct{ noRefresh }
+
+// Validate implements Provider
+func (satelliteProvider) Validate(*agentregistryv1alpha1.Environment) error {
+	return nil
+}
+
+// RESTConfig implements Provider
+func (satelliteProvider) RESTConfig(_ context.Context, _ *Factory, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	return nil, fmt.Errorf("cluster %s of environment %s is served by a satellite and cannot be reached by the registry", env.Cluster.Name, env.Name)
+}
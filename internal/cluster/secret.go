@@ -0,0 +1,160 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// Keys read from a credentials Secret
+const (
+	// SecretKeyKubeconfig holds a complete kubeconfig; its current context is used
+	SecretKeyKubeconfig = "kubeconfig"
+	// SecretKeyToken holds a bearer token
+	SecretKeyToken = "token"
+	// SecretKeyCA holds a PEM CA bundle, overriding ClusterConfig.CAData
+	SecretKeyCA = "ca.crt"
+)
+
+func init() {
+	RegisterProvider(ProviderKubeconfig, kubeconfigProvider{})
+}
+
+// kubeconfigProvider reaches a cluster with the credentials in ClusterConfig.CredentialsSecretRef
+type kubeconfigProvider struct{ noRefresh }
+
+// Validate implements Provider
+func (kubeconfigProvider) Validate(env *agentregistryv1alpha1.Environment) error {
+	if env.Cluster.CredentialsSecretRef == nil || env.Cluster.CredentialsSecretRef.Name == "" {
+		return fmt.Errorf("cluster credentialsSecretRef is required")
+	}
+	return validateCAData(env)
+}
+
+// RESTConfig implements Provider
+func (kubeconfigProvider) RESTConfig(ctx context.Context, f *Factory, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	return f.createSecretConfig(ctx, env)
+}
+
+// CredentialsSecretKey returns the key of the Secret an environment takes its credentials
+// from. ok is false if it does not reference one.
+func CredentialsSecretKey(env *agentregistryv1alpha1.Environment) (key client.ObjectKey, ok bool) {
+	ref := env.Cluster.CredentialsSecretRef
+	if ref == nil {
+		return client.ObjectKey{}, false
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = config.GetNamespace()
+	}
+	return client.ObjectKey{Namespace: namespace, Name: ref.Name}, true
+}
+
+// credentialsSecret fetches the credentials Secret of an environment
+func (f *Factory) credentialsSecret(ctx context.Context, env *agentregistryv1alpha1.Environment) (*corev1.Secret, error) {
+	key, _ := CredentialsSecretKey(env)
+	if f.localClient == nil {
+		return nil, fmt.Errorf("cannot read credentials secret %s: no local client", key)
+	}
+	var secret corev1.Secret
+	if err := f.localClient.Get(ctx, key, &secret); err != nil {
+		return nil, fmt.Errorf("failed to read credentials secret %s: %w", key, err)
+	}
+	return &secret, nil
+}
+
+// createSecretConfig creates a rest.Config from the environment's credentials Secret
+func (f *Factory) createSecretConfig(ctx context.Context, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	secret, err := f.credentialsSecret(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	config, err := RESTConfigFromSecret(env, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	f.logger.Info().
+		Str("environment", env.Name).
+		Str("cluster", env.Cluster.Name).
+		Str("secret", secret.Namespace+"/"+secret.Name).
+		Str("endpoint", config.Host).
+		Msg("created cluster config from credentials secret")
+
+	return config, nil
+}
+
+// RESTConfigFromSecret builds a rest.Config from a credentials Secret. A kubeconfig is used
+// as is; a token or client certificate is combined with the environment's endpoint and CA.
+// When the reference names a key, the Secret must hold a kubeconfig under it.
+func RESTConfigFromSecret(env *agentregistryv1alpha1.Environment, secret *corev1.Secret) (*rest.Config, error) {
+	name := secret.Namespace + "/" + secret.Name
+
+	kubeconfigKey := SecretKeyKubeconfig
+	if ref := env.Cluster.CredentialsSecretRef; ref != nil && ref.Key != "" {
+		kubeconfigKey = ref.Key
+		if _, ok := secret.Data[kubeconfigKey]; !ok {
+			return nil, fmt.Errorf("secret %s has no key %q", name, kubeconfigKey)
+		}
+	}
+	if kubeconfig, ok := secret.Data[kubeconfigKey]; ok {
+		config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kubeconfig in secret %s: %w", name, err)
+		}
+		config.QPS = 50
+		config.Burst = 100
+		return config, nil
+	}
+
+	token := secret.Data[SecretKeyToken]
+	certData := secret.Data[corev1.TLSCertKey]
+	keyData := secret.Data[corev1.TLSPrivateKeyKey]
+	if len(token) == 0 && (len(certData) == 0 || len(keyData) == 0) {
+		return nil, fmt.Errorf("secret %s has none of %q, %q or %q and %q",
+			name, SecretKeyKubeconfig, SecretKeyToken, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	}
+
+	endpoint := env.Cluster.Endpoint
+	if endpoint == "" {
+		return nil, fmt.Errorf("cluster endpoint is required with a token or client certificate in secret %s", name)
+	}
+	// Ensure endpoint has https:// prefix
+	if len(endpoint) > 4 && endpoint[0:4] != "http" {
+		endpoint = "https://" + endpoint
+	}
+
+	caData := secret.Data[SecretKeyCA]
+	if len(caData) == 0 && env.Cluster.CAData != "" {
+		var err error
+		caData, err = base64.StdEncoding.DecodeString(env.Cluster.CAData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode CA data: %w", err)
+		}
+	}
+
+	config := &rest.Config{
+		Host:        endpoint,
+		BearerToken: strings.TrimSpace(string(token)),
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData:   caData,
+			CertData: certData,
+			KeyData:  keyData,
+		},
+	}
+
+	// Set reasonable defaults
+	config.QPS = 50
+	config.Burst = 100
+
+	return config, nil
+}
@@ -0,0 +1,189 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: kind
+  cluster:
+    server: https://127.0.0.1:6443
+    certificate-authority-data: dGVzdC1jYQ==
+contexts:
+- name: kind
+  context:
+    cluster: kind
+    user: admin
+current-context: kind
+users:
+- name: admin
+  user:
+    token: kubeconfig-token
+`
+
+func secretEnv(cluster agentregistryv1alpha1.ClusterConfig) *agentregistryv1alpha1.Environment {
+	cluster.CredentialsSecretRef = &agentregistryv1alpha1.ClusterCredentialsSecretRef{Name: "creds", Namespace: "agentregistry"}
+	return &agentregistryv1alpha1.Environment{Name: "onprem", Cluster: cluster}
+}
+
+func TestRESTConfigFromSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster agentregistryv1alpha1.ClusterConfig
+		key     string
+		data    map[string][]byte
+		wantErr string
+		check   func(t *testing.T, host string, token string, ca, cert, key []byte)
+	}{
+		{
+			name: "kubeconfig",
+			data: map[string][]byte{"kubeconfig": []byte(testKubeconfig)},
+			check: func(t *testing.T, host, token string, ca, _, _ []byte) {
+				assert.Equal(t, "https://127.0.0.1:6443", host)
+				assert.Equal(t, "kubeconfig-token", token)
+				assert.Equal(t, []byte("test-ca"), ca)
+			},
+		},
+		{
+			name: "kubeconfig under a custom key",
+			key:  "value",
+			data: map[string][]byte{"value": []byte(testKubeconfig), "token": []byte("ignored")},
+			check: func(t *testing.T, host, token string, _, _, _ []byte) {
+				assert.Equal(t, "https://127.0.0.1:6443", host)
+				assert.Equal(t, "kubeconfig-token", token)
+			},
+		},
+		{
+			name:    "missing custom key",
+			key:     "value",
+			data:    map[string][]byte{"kubeconfig": []byte(testKubeconfig)},
+			wantErr: `secret agentregistry/creds has no key "value"`,
+		},
+		{
+			name:    "token with CA from the secret",
+			cluster: agentregistryv1alpha1.ClusterConfig{Endpoint: "k3s.example.com:6443", CAData: testCAData},
+			data:    map[string][]byte{"token": []byte("sa-token\n"), "ca.crt": []byte("secret-ca")},
+			check: func(t *testing.T, host, token string, ca, _, _ []byte) {
+				assert.Equal(t, "https://k3s.example.com:6443", host)
+				assert.Equal(t, "sa-token", token)
+				assert.Equal(t, []byte("secret-ca"), ca)
+			},
+		},
+		{
+			name:    "client certificate with CA from the cluster config",
+			cluster: agentregistryv1alpha1.ClusterConfig{Endpoint: "https://10.0.0.1", CAData: testCAData},
+			data:    map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+			check: func(t *testing.T, host, token string, ca, cert, key []byte) {
+				assert.Equal(t, "https://10.0.0.1", host)
+				assert.Empty(t, token)
+				assert.Equal(t, []byte("test-ca"), ca)
+				assert.Equal(t, []byte("cert"), cert)
+				assert.Equal(t, []byte("key"), key)
+			},
+		},
+		{
+			name:    "invalid kubeconfig",
+			data:    map[string][]byte{"kubeconfig": []byte("not: [a kubeconfig")},
+			wantErr: "invalid kubeconfig in secret agentregistry/creds",
+		},
+		{
+			name:    "no credentials",
+			cluster: agentregistryv1alpha1.ClusterConfig{Endpoint: "https://10.0.0.1"},
+			data:    map[string][]byte{"tls.crt": []byte("cert")},
+			wantErr: "has none of",
+		},
+		{
+			name:    "token without endpoint",
+			data:    map[string][]byte{"token": []byte("sa-token")},
+			wantErr: "cluster endpoint is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "agentregistry"},
+				Data:       tt.data,
+			}
+			env := secretEnv(tt.cluster)
+			env.Cluster.CredentialsSecretRef.Key = tt.key
+			config, err := RESTConfigFromSecret(env, secret)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, config.Host, config.BearerToken, config.CAData, config.CertData, config.KeyData)
+		})
+	}
+}
+
+func TestCredentialsSecretKey(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "registry")
+
+	_, ok := CredentialsSecretKey(&agentregistryv1alpha1.Environment{})
+	assert.False(t, ok)
+
+	env := secretEnv(agentregistryv1alpha1.ClusterConfig{Name: "onprem"})
+	env.Cluster.CredentialsSecretRef.Namespace = ""
+	key, ok := CredentialsSecretKey(env)
+	assert.True(t, ok)
+	assert.Equal(t, client.ObjectKey{Namespace: "registry", Name: "creds"}, key)
+}
+
+func TestGetClient_CredentialsSecret(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "agentregistry"},
+		Data:       map[string][]byte{"token": []byte("token-1")},
+	}
+	local := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	factory := NewFactory(local, zerolog.Nop())
+	ctx := context.Background()
+
+	// Without endpoint or workload identity, the Secret alone makes the cluster remote
+	assert.False(t, factory.isLocalCluster(secretEnv(agentregistryv1alpha1.ClusterConfig{Name: "kind"})))
+
+	env := secretEnv(agentregistryv1alpha1.ClusterConfig{Name: "k3s", Endpoint: "https://10.0.0.1:6443"})
+	scope := Scope{Config: "discovery", Environment: env.Name, Cluster: env.Cluster.Name}
+
+	first, err := factory.GetClient(ctx, scope, env, scheme)
+	require.NoError(t, err)
+	again, err := factory.GetClient(ctx, scope, env, scheme)
+	require.NoError(t, err)
+	assert.Same(t, first, again, "unchanged secret should reuse the cached client")
+
+	// Rotating the credentials rebuilds the client
+	secret.Data["token"] = []byte("token-2")
+	require.NoError(t, local.Update(ctx, secret))
+	rotated, err := factory.GetClient(ctx, scope, env, scheme)
+	require.NoError(t, err)
+	assert.NotSame(t, first, rotated)
+
+	// A deleted secret drops the cached client and reports the error
+	require.NoError(t, local.Delete(ctx, secret))
+	_, err = factory.GetClient(ctx, scope, env, scheme)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read credentials secret agentregistry/creds")
+	factory.mu.RLock()
+	_, cached := factory.cache[scope]
+	factory.mu.RUnlock()
+	assert.False(t, cached)
+}
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 
@@ -9,6 +10,37 @@ import (
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
 )
 
+func init() {
+	RegisterProvider(ProviderStatic, staticProvider{})
+}
+
+// staticProvider reaches a cluster at ClusterConfig.Endpoint, trusting ClusterConfig.CAData
+type staticProvider struct{ noRefresh }
+
+// Validate implements Provider
+func (staticProvider) Validate(env *agentregistryv1alpha1.Environment) error {
+	if env.Cluster.Endpoint == "" || env.Cluster.CAData == "" {
+		return fmt.Errorf("cluster endpoint and caData are required")
+	}
+	return validateCAData(env)
+}
+
+// RESTConfig implements Provider
+func (staticProvider) RESTConfig(_ context.Context, f *Factory, env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
+	return f.createStaticConfig(env)
+}
+
+// validateCAData checks that ClusterConfig.CAData, if set, is base64
+func validateCAData(env *agentregistryv1alpha1.Environment) error {
+	if env.Cluster.CAData == "" {
+		return nil
+	}
+	if _, err := base64.StdEncoding.DecodeString(env.Cluster.CAData); err != nil {
+		return fmt.Errorf("failed to decode CA data: %w", err)
+	}
+	return nil
+}
+
 // createStaticConfig creates a rest.Config using static credentials (endpoint + CA data).
 func (f *Factory) createStaticConfig(env *agentregistryv1alpha1.Environment) (*rest.Config, error) {
 	endpoint := env.Cluster.Endpoint
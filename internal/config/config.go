@@ -18,6 +18,9 @@ const (
 
 	// DefaultHealthPort is the default port for health probes
 	DefaultHealthPort = ":8082"
+
+	// APITokensSecretName is the Secret in the controller namespace holding the API tokens
+	APITokensSecretName = "agentregistry-api-tokens"
 )
 
 // GetNamespace returns the namespace to use for Agent Registry resources.
@@ -2,14 +2,25 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/rs/zerolog"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
 )
@@ -21,6 +32,17 @@ type AgentCatalogReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Logger zerolog.Logger
+
+	// DiscoveredCache holds resources seen by DiscoveryConfig informers, to tell when the source
+	// of a discovered entry is gone
+	DiscoveredCache *DiscoveredResourceCache
+	// Shards is set when discovery is sharded across replicas. The reconciler then runs on every
+	// replica and only reconciles the entries it owns; see ownsCatalogEntry.
+	Shards *DiscoveryShards
+	// Recorder records lifecycle transitions of discovered entries
+	Recorder record.EventRecorder
+	// Lifecycle is the grace period policy for discovered entries whose source is gone
+	Lifecycle CatalogLifecycle
 }
 
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=agentcatalogs,verbs=get;list;watch;create;update;patch;delete
@@ -28,6 +50,7 @@ type AgentCatalogReconciler struct {
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=agentcatalogs/finalizers,verbs=update
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=mcpservercatalogs,verbs=get;list;watch
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=mcpservercatalogs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile handles AgentCatalog reconciliation
 func (r *AgentCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -39,6 +62,11 @@ func (r *AgentCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// Entries of other replicas are checked again in case they move to this one
+	if !ownsCatalogEntry(r.Shards, &agent) {
+		return ctrl.Result{RequeueAfter: discoveredSourceCheckInterval}, nil
+	}
+
 	logger.Trace().
 		Str("specName", agent.Spec.Name).
 		Str("version", agent.Spec.Version).
@@ -65,6 +93,28 @@ func (r *AgentCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
+	// Move discovered entries whose source is gone through the lifecycle
+	external := agent.Status.ManagementType == agentregistryv1alpha1.ManagementTypeExternal
+	lifecycleChanged := false
+	if external {
+		lifecycle, err := r.Lifecycle.advance(ctx, r.Client, r.DiscoveredCache, r.Recorder, lifecycleEntry{
+			obj:        &agent,
+			status:     &agent.Status.Status,
+			conditions: &agent.Status.Conditions,
+			deployedAs: agentregistryv1alpha1.ResourceTypeAgent,
+			specName:   agent.Spec.Name,
+			version:    agent.Spec.Version,
+		}, logger)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to advance catalog entry lifecycle")
+			return ctrl.Result{}, err
+		}
+		if lifecycle.removed {
+			return ctrl.Result{}, nil
+		}
+		lifecycleChanged = lifecycle.statusChanged
+	}
+
 	// Update isLatest status for all versions of this agent
 	if err := r.updateLatestVersion(ctx, &agent); err != nil {
 		logger.Error().Err(err).Msg("failed to update latest version")
@@ -82,8 +132,15 @@ func (r *AgentCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
+	// Flag referenced tools the MCP servers do not expose
+	toolsChanged, err := r.resolveTools(ctx, &agent)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to resolve agent tools")
+		return ctrl.Result{}, err
+	}
+
 	// Update observed generation
-	if agent.Status.ObservedGeneration != agent.Generation {
+	if toolsChanged || lifecycleChanged || agent.Status.ObservedGeneration != agent.Generation {
 		agent.Status.ObservedGeneration = agent.Generation
 		if err := r.Status().Update(ctx, &agent); err != nil {
 			if apierrors.IsConflict(err) {
@@ -95,6 +152,10 @@ func (r *AgentCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
+	// Requeue discovered entries to notice when their source goes away
+	if external && agent.Labels[discoveryLabel] == "true" {
+		return ctrl.Result{RequeueAfter: discoveredSourceCheckInterval}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -151,6 +212,10 @@ func (r *AgentCatalogReconciler) updateMCPServerUsedBy(
 
 		for i := range serverList.Items {
 			server := &serverList.Items[i]
+			// A discovered agent only uses the servers discovered alongside it
+			if !sameDiscoveryScope(agent.Labels, server.Labels) {
+				continue
+			}
 			if usageRefEqual(server.Status.UsedBy, ref) {
 				continue
 			}
@@ -193,6 +258,10 @@ func (r *AgentCatalogReconciler) cleanupStaleUsedByRefs(
 		if !containsUsageRef(server.Status.UsedBy, ref) {
 			continue
 		}
+		// Refs in other environments belong to a same-named agent there
+		if !sameDiscoveryScope(agent.Labels, server.Labels) {
+			continue
+		}
 		// If this server's spec.name is still referenced, keep the ref
 		if _, ok := currentRefs[server.Spec.Name]; ok {
 			continue
@@ -280,6 +349,128 @@ func removeUsageRef(refs []agentregistryv1alpha1.MCPServerUsageRef, ref agentreg
 	return result
 }
 
+// resolveTools compares the tool names the agent references with the tools its MCP servers
+// exposed when they were last introspected, and records the missing ones in
+// status.unresolvedTools and the ToolsResolved condition. It reports whether the status changed.
+func (r *AgentCatalogReconciler) resolveTools(ctx context.Context, agent *agentregistryv1alpha1.AgentCatalog) (bool, error) {
+	before := agent.Status.DeepCopy()
+
+	var unresolved []agentregistryv1alpha1.AgentToolRef
+	var messages []string
+	referenced, notIntrospected := 0, 0
+	for _, tool := range agent.Spec.Tools {
+		// Without tool names the agent uses whatever the server exposes
+		if tool.Type != "McpServer" || len(tool.ToolNames) == 0 {
+			continue
+		}
+		referenced++
+
+		var serverList agentregistryv1alpha1.MCPServerCatalogList
+		if err := r.List(ctx, &serverList, client.MatchingFields{
+			IndexMCPServerName: tool.Name,
+		}); err != nil {
+			return false, err
+		}
+		// A tool is resolved if any introspected instance of the server exposes it
+		exposed := make(map[string]struct{})
+		introspected := false
+		for i := range serverList.Items {
+			server := &serverList.Items[i]
+			if !sameDiscoveryScope(agent.Labels, server.Labels) {
+				continue
+			}
+			if names, ok := introspectedToolNames(server); ok {
+				introspected = true
+				for name := range names {
+					exposed[name] = struct{}{}
+				}
+			}
+		}
+		if !introspected {
+			notIntrospected++
+			continue
+		}
+
+		var missing []string
+		for _, name := range tool.ToolNames {
+			if _, ok := exposed[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			unresolved = append(unresolved, agentregistryv1alpha1.AgentToolRef{
+				Type:      tool.Type,
+				Name:      tool.Name,
+				ToolNames: missing,
+			})
+			messages = append(messages, fmt.Sprintf("%s does not expose %s", tool.Name, strings.Join(missing, ", ")))
+		}
+	}
+
+	agent.Status.UnresolvedTools = unresolved
+	switch {
+	case referenced == 0:
+		agent.Status.Conditions = removeCatalogCondition(agent.Status.Conditions, agentregistryv1alpha1.CatalogConditionToolsResolved)
+	case len(unresolved) > 0:
+		agent.Status.Conditions = setCatalogCondition(agent.Status.Conditions, agentregistryv1alpha1.CatalogConditionToolsResolved,
+			metav1.ConditionFalse, "ToolsNotFound", strings.Join(messages, "; "))
+	case notIntrospected > 0:
+		agent.Status.Conditions = setCatalogCondition(agent.Status.Conditions, agentregistryv1alpha1.CatalogConditionToolsResolved,
+			metav1.ConditionUnknown, "NotIntrospected", fmt.Sprintf("%d of %d referenced MCP servers have not been introspected", notIntrospected, referenced))
+	default:
+		agent.Status.Conditions = setCatalogCondition(agent.Status.Conditions, agentregistryv1alpha1.CatalogConditionToolsResolved,
+			metav1.ConditionTrue, "ToolsFound", "All referenced tools are exposed by their MCP servers")
+	}
+
+	return !equality.Semantic.DeepEqual(before, &agent.Status), nil
+}
+
+// agentsForServer maps an MCPServerCatalog to the agents that reference it, so their tools
+// are resolved again when the server's introspection changes
+func (r *AgentCatalogReconciler) agentsForServer(ctx context.Context, obj client.Object) []reconcile.Request {
+	server, ok := obj.(*agentregistryv1alpha1.MCPServerCatalog)
+	if !ok {
+		return nil
+	}
+	var agents agentregistryv1alpha1.AgentCatalogList
+	if err := r.List(ctx, &agents); err != nil {
+		r.Logger.Error().Err(err).Msg("failed to list agents for MCP server")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range agents.Items {
+		agent := &agents.Items[i]
+		if _, ok := extractReferencedMCPServers(agent)[server.Spec.Name]; !ok {
+			continue
+		}
+		if !sameDiscoveryScope(agent.Labels, server.Labels) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Name:      agent.Name,
+			Namespace: agent.Namespace,
+		}})
+	}
+	return requests
+}
+
+// introspectedToolsChanged passes server updates that change the set of tools it exposes
+var introspectedToolsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldServer, ok := e.ObjectOld.(*agentregistryv1alpha1.MCPServerCatalog)
+		if !ok {
+			return true
+		}
+		newServer, ok := e.ObjectNew.(*agentregistryv1alpha1.MCPServerCatalog)
+		if !ok {
+			return true
+		}
+		oldNames, oldOK := introspectedToolNames(oldServer)
+		newNames, newOK := introspectedToolNames(newServer)
+		return oldOK != newOK || !equality.Semantic.DeepEqual(oldNames, newNames)
+	},
+}
+
 // updateLatestVersion determines and updates the latest version flag for all versions of an agent
 func (r *AgentCatalogReconciler) updateLatestVersion(ctx context.Context, agent *agentregistryv1alpha1.AgentCatalog) error {
 	return updateLatestVersionForAgents(ctx, r.Client, agent.Spec.Name)
@@ -287,7 +478,12 @@ func (r *AgentCatalogReconciler) updateLatestVersion(ctx context.Context, agent
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *AgentCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	needLeaderElection := r.Shards == nil
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&agentregistryv1alpha1.AgentCatalog{}).
+		WithOptions(crcontroller.Options{NeedLeaderElection: &needLeaderElection}).
+		Watches(&agentregistryv1alpha1.MCPServerCatalog{},
+			handler.EnqueueRequestsFromMapFunc(r.agentsForServer),
+			builder.WithPredicates(introspectedToolsChanged)).
 		Complete(r)
 }
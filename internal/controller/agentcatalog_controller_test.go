@@ -390,3 +390,112 @@ func TestAgentCatalogReconciler_Reconcile_UpdateGenerationTracking(t *testing.T)
 
 	// Note: Proper generation tracking requires envtest with status subresource
 }
+
+func TestAgentCatalogReconciler_ResolveTools(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = agentregistryv1alpha1.AddToScheme(scheme)
+
+	probed := metav1.Now()
+	search := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "search-1-0-0", Namespace: "default"},
+		Spec:       agentregistryv1alpha1.MCPServerCatalogSpec{Name: "tools/search", Version: "1.0.0"},
+		Status: agentregistryv1alpha1.MCPServerCatalogStatus{
+			Introspection: &agentregistryv1alpha1.MCPServerIntrospection{
+				URL:         "http://search/mcp",
+				Tools:       []agentregistryv1alpha1.MCPTool{{Name: "search"}, {Name: "fetch"}},
+				LastSuccess: &probed,
+			},
+		},
+	}
+	pending := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "files-1-0-0", Namespace: "default"},
+		Spec:       agentregistryv1alpha1.MCPServerCatalogSpec{Name: "tools/files", Version: "1.0.0"},
+	}
+	agent := &agentregistryv1alpha1.AgentCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "research-agent-1-0-0", Namespace: "default"},
+		Spec: agentregistryv1alpha1.AgentCatalogSpec{
+			Name:    "research-agent",
+			Version: "1.0.0",
+			Image:   "agent:1.0.0",
+			Tools: []agentregistryv1alpha1.AgentToolRef{
+				{Type: "McpServer", Name: "tools/search", ToolNames: []string{"search", "delete"}},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&agentregistryv1alpha1.AgentCatalog{}, IndexAgentName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.AgentCatalog).Spec.Name}
+		}).
+		WithIndex(&agentregistryv1alpha1.MCPServerCatalog{}, IndexMCPServerName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.MCPServerCatalog).Spec.Name}
+		}).
+		WithObjects(agent, search, pending).
+		WithStatusSubresource(&agentregistryv1alpha1.AgentCatalog{}, &agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+	r := &AgentCatalogReconciler{Client: c, Scheme: scheme, Logger: zerolog.Nop()}
+	ctx := context.Background()
+	key := types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace}
+
+	reconcileAgent := func() *agentregistryv1alpha1.AgentCatalog {
+		// The first pass requeues after the latest-version update conflicts with the status update
+		for range 3 {
+			result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			require.NoError(t, err)
+			if result.IsZero() {
+				break
+			}
+		}
+		var updated agentregistryv1alpha1.AgentCatalog
+		require.NoError(t, c.Get(ctx, key, &updated))
+		return &updated
+	}
+	toolsCondition := func(agent *agentregistryv1alpha1.AgentCatalog) *agentregistryv1alpha1.CatalogCondition {
+		for i := range agent.Status.Conditions {
+			if agent.Status.Conditions[i].Type == agentregistryv1alpha1.CatalogConditionToolsResolved {
+				return &agent.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	// The server does not expose "delete"
+	updated := reconcileAgent()
+	assert.Equal(t, []agentregistryv1alpha1.AgentToolRef{
+		{Type: "McpServer", Name: "tools/search", ToolNames: []string{"delete"}},
+	}, updated.Status.UnresolvedTools)
+	cond := toolsCondition(updated)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, "ToolsNotFound", cond.Reason)
+	assert.Equal(t, "tools/search does not expose delete", cond.Message)
+
+	// Servers that were never introspected leave the result unknown
+	updated.Spec.Tools = []agentregistryv1alpha1.AgentToolRef{
+		{Type: "McpServer", Name: "tools/search", ToolNames: []string{"search"}},
+		{Type: "McpServer", Name: "tools/files", ToolNames: []string{"read"}},
+	}
+	require.NoError(t, c.Update(ctx, updated))
+	updated = reconcileAgent()
+	assert.Empty(t, updated.Status.UnresolvedTools)
+	assert.Equal(t, metav1.ConditionUnknown, toolsCondition(updated).Status)
+
+	// A server update that changes the exposed tools re-queues the agents using it
+	assert.Equal(t, []reconcile.Request{{NamespacedName: key}}, r.agentsForServer(ctx, pending))
+	assert.Empty(t, r.agentsForServer(ctx, &agentregistryv1alpha1.MCPServerCatalog{
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{Name: "tools/other"},
+	}))
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(pending), pending))
+	pending.Status.Introspection = &agentregistryv1alpha1.MCPServerIntrospection{
+		URL:         "http://files/mcp",
+		Tools:       []agentregistryv1alpha1.MCPTool{{Name: "read"}},
+		LastSuccess: &probed,
+	}
+	require.NoError(t, c.Status().Update(ctx, pending))
+	updated = reconcileAgent()
+	cond = toolsCondition(updated)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, "ToolsFound", cond.Reason)
+}
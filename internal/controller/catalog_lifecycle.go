@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+const (
+	// DefaultCatalogDeleteAfter is how long the source of a discovered entry is missing before
+	// the deprecated entry is marked deleted
+	DefaultCatalogDeleteAfter = 24 * time.Hour
+	// DefaultCatalogRemoveAfter is how long a deleted entry is kept before it is removed
+	DefaultCatalogRemoveAfter = 7 * 24 * time.Hour
+
+	// discoveredSourceCheckInterval is how often discovered entries are checked against their
+	// source, which also paces the lifecycle
+	discoveredSourceCheckInterval = 30 * time.Second
+)
+
+// Event reasons recorded as discovered catalog entries move through the lifecycle. The
+// SourceAvailable condition uses SourceMissing and RemovalBlocked as its reasons.
+const (
+	eventReasonDeprecated     = "Deprecated"
+	eventReasonDeleted        = "Deleted"
+	eventReasonRemoved        = "Removed"
+	eventReasonRemovalBlocked = "RemovalBlocked"
+	eventReasonRestored       = "SourceRestored"
+
+	conditionReasonSourceMissing = "SourceMissing"
+)
+
+// CatalogLifecycle is the grace period policy for discovered catalog entries whose source is
+// gone. An entry is deprecated as soon as its source is missing, marked deleted once the source
+// has been missing for DeleteAfter, and removed RemoveAfter later unless a RegistryDeployment or
+// an agent still references it. A zero duration disables that step.
+type CatalogLifecycle struct {
+	DeleteAfter time.Duration
+	RemoveAfter time.Duration
+}
+
+// lifecycleEntry is the part of a catalog entry the lifecycle reads and updates
+type lifecycleEntry struct {
+	obj        client.Object
+	status     *agentregistryv1alpha1.CatalogStatus
+	conditions *[]agentregistryv1alpha1.CatalogCondition
+
+	// deployedAs is the resource type RegistryDeployments reference the entry by, if any
+	deployedAs agentregistryv1alpha1.ResourceType
+	specName   string
+	version    string
+	// usedBy names the agents referencing the entry
+	usedBy []string
+}
+
+// lifecycleResult reports what advancing an entry did
+type lifecycleResult struct {
+	statusChanged bool
+	removed       bool
+}
+
+// advance moves a discovered entry one step through the lifecycle. Whether the source exists is
+// only decided once the informer of its group has listed it in full, so entries are left alone
+// while discovery starts up or when another replica discovers them. Status changes are made on
+// entry and left to the caller to write; removal is done here.
+func (p CatalogLifecycle) advance(
+	ctx context.Context,
+	c client.Client,
+	discovered *DiscoveredResourceCache,
+	recorder record.EventRecorder,
+	entry lifecycleEntry,
+	logger zerolog.Logger,
+) (lifecycleResult, error) {
+	labels := entry.obj.GetLabels()
+	if labels[discoveryLabel] != "true" {
+		return lifecycleResult{}, nil
+	}
+	scope := scopeFromLabels(labels)
+	kind, namespace, name := labels[sourceKindLabel], labels[sourceNSLabel], labels[sourceNameLabel]
+	if !discovered.Synced(scope, kind, namespace) {
+		return lifecycleResult{}, nil
+	}
+
+	previous := findCatalogCondition(*entry.conditions, agentregistryv1alpha1.CatalogConditionSourceAvailable)
+	if _, found := discovered.Get(scope, kind, namespace, name); found {
+		// Entries deprecated before the condition existed are restored as well
+		if previous == nil && *entry.status != agentregistryv1alpha1.CatalogStatusDeprecated {
+			return lifecycleResult{}, nil
+		}
+		*entry.status = agentregistryv1alpha1.CatalogStatusActive
+		*entry.conditions = removeCatalogCondition(*entry.conditions, agentregistryv1alpha1.CatalogConditionSourceAvailable)
+		recordCatalogEvent(recorder, entry.obj, corev1.EventTypeNormal, eventReasonRestored,
+			"Source %s %s/%s reappeared, entry is active again", kind, namespace, name)
+		logger.Info().Msg("source resource reappeared, restoring catalog entry to active")
+		return lifecycleResult{statusChanged: true}, nil
+	}
+
+	now := time.Now()
+	missingSince := now
+	if previous != nil && previous.Status == metav1.ConditionFalse {
+		missingSince = previous.LastTransitionTime.Time
+	}
+	reason := conditionReasonSourceMissing
+	message := fmt.Sprintf("Source %s %s/%s no longer exists in environment %s", kind, namespace, name, scope.Environment)
+
+	var result lifecycleResult
+	switch *entry.status {
+	case agentregistryv1alpha1.CatalogStatusDeleted:
+		if p.RemoveAfter == 0 {
+			break
+		}
+		if now.Before(missingSince.Add(p.DeleteAfter + p.RemoveAfter)) {
+			break
+		}
+		refs, err := catalogReferences(ctx, c, entry)
+		if err != nil {
+			return lifecycleResult{}, err
+		}
+		if len(refs) > 0 {
+			reason = eventReasonRemovalBlocked
+			message = "Removal blocked by " + strings.Join(refs, ", ")
+			if previous == nil || previous.Reason != reason {
+				recordCatalogEvent(recorder, entry.obj, corev1.EventTypeWarning, eventReasonRemovalBlocked, "%s", message)
+			}
+			break
+		}
+		if err := c.Delete(ctx, entry.obj); client.IgnoreNotFound(err) != nil {
+			return lifecycleResult{}, err
+		}
+		recordCatalogEvent(recorder, entry.obj, corev1.EventTypeNormal, eventReasonRemoved,
+			"Source %s %s/%s missing since %s, removed catalog entry", kind, namespace, name, missingSince.UTC().Format(time.RFC3339))
+		logger.Info().Time("missingSince", missingSince).Msg("source resource gone past the grace period, removed catalog entry")
+		return lifecycleResult{removed: true}, nil
+
+	case agentregistryv1alpha1.CatalogStatusDeprecated:
+		if p.DeleteAfter == 0 {
+			break
+		}
+		if now.Before(missingSince.Add(p.DeleteAfter)) {
+			break
+		}
+		*entry.status = agentregistryv1alpha1.CatalogStatusDeleted
+		result.statusChanged = true
+		recordCatalogEvent(recorder, entry.obj, corev1.EventTypeNormal, eventReasonDeleted,
+			"Source %s %s/%s missing for %s, marked catalog entry deleted", kind, namespace, name, p.DeleteAfter)
+		logger.Info().Time("missingSince", missingSince).Msg("source resource still missing, marking catalog entry as deleted")
+
+	default:
+		*entry.status = agentregistryv1alpha1.CatalogStatusDeprecated
+		result.statusChanged = true
+		recordCatalogEvent(recorder, entry.obj, corev1.EventTypeNormal, eventReasonDeprecated,
+			"Source %s %s/%s no longer exists, marked catalog entry deprecated", kind, namespace, name)
+		logger.Info().
+			Str("sourceKind", kind).
+			Str("sourceName", name).
+			Str("sourceNamespace", namespace).
+			Msg("source resource not found, marking catalog entry as deprecated")
+	}
+
+	if previous == nil || previous.Status != metav1.ConditionFalse || previous.Reason != reason || previous.Message != message {
+		*entry.conditions = setCatalogCondition(*entry.conditions, agentregistryv1alpha1.CatalogConditionSourceAvailable,
+			metav1.ConditionFalse, reason, message)
+		result.statusChanged = true
+	}
+	return result, nil
+}
+
+// catalogReferences lists what still references a catalog entry: the agents in its UsedBy and
+// the RegistryDeployments of its name and version
+func catalogReferences(ctx context.Context, c client.Client, entry lifecycleEntry) ([]string, error) {
+	var refs []string
+	for _, agent := range entry.usedBy {
+		refs = append(refs, "agent "+agent)
+	}
+	if entry.deployedAs != "" {
+		var deployments agentregistryv1alpha1.RegistryDeploymentList
+		if err := c.List(ctx, &deployments, client.MatchingFields{IndexDeploymentResourceName: entry.specName}); err != nil {
+			return nil, fmt.Errorf("list registry deployments: %w", err)
+		}
+		for _, d := range deployments.Items {
+			if d.Spec.ResourceType == entry.deployedAs && d.Spec.Version == entry.version {
+				refs = append(refs, "RegistryDeployment "+d.Namespace+"/"+d.Name)
+			}
+		}
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+// findCatalogCondition returns the condition of the given type, or nil
+func findCatalogCondition(conditions []agentregistryv1alpha1.CatalogCondition, condType agentregistryv1alpha1.CatalogConditionType) *agentregistryv1alpha1.CatalogCondition {
+	for i := range conditions {
+		if conditions[i].Type == condType {
+			c := conditions[i]
+			return &c
+		}
+	}
+	return nil
+}
+
+// recordCatalogEvent records an event on a catalog entry if a recorder is configured
+func recordCatalogEvent(recorder record.EventRecorder, obj client.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+	recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+func TestCatalogLifecycle_Advance(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev-cluster"}
+	policy := CatalogLifecycle{DeleteAfter: time.Hour, RemoveAfter: 2 * time.Hour}
+	ctx := context.Background()
+
+	newEntry := func(status agentregistryv1alpha1.CatalogStatus, missingFor time.Duration) *agentregistryv1alpha1.MCPServerCatalog {
+		server := &agentregistryv1alpha1.MCPServerCatalog{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dev-tools-weather",
+				Namespace: testNamespace,
+				Labels: map[string]string{
+					discoveryLabel:       "true",
+					discoveryConfigLabel: scope.Config,
+					envLabel:             scope.Environment,
+					clusterLabel:         scope.Cluster,
+					sourceKindLabel:      "MCPServer",
+					sourceNameLabel:      "weather",
+					sourceNSLabel:        "tools",
+				},
+			},
+			Spec: agentregistryv1alpha1.MCPServerCatalogSpec{Name: "tools/weather", Version: "latest"},
+		}
+		server.Status.Status = status
+		if missingFor > 0 {
+			server.Status.Conditions = []agentregistryv1alpha1.CatalogCondition{{
+				Type:               agentregistryv1alpha1.CatalogConditionSourceAvailable,
+				Status:             metav1.ConditionFalse,
+				Reason:             conditionReasonSourceMissing,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-missingFor)),
+			}}
+		}
+		return server
+	}
+	advance := func(c client.Client, discovered *DiscoveredResourceCache, recorder record.EventRecorder, server *agentregistryv1alpha1.MCPServerCatalog) lifecycleResult {
+		result, err := policy.advance(ctx, c, discovered, recorder, lifecycleEntry{
+			obj:        server,
+			status:     &server.Status.Status,
+			conditions: &server.Status.Conditions,
+			deployedAs: agentregistryv1alpha1.ResourceTypeMCP,
+			specName:   server.Spec.Name,
+			version:    server.Spec.Version,
+			usedBy:     mcpServerUsers(server.Status.UsedBy),
+		}, zerolog.Nop())
+		require.NoError(t, err)
+		return result
+	}
+	syncedCache := func() *DiscoveredResourceCache {
+		discovered := NewDiscoveredResourceCache()
+		discovered.MarkSynced(scope, "MCPServer", "tools")
+		return discovered
+	}
+	newClient := func(objs ...client.Object) client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(objs...).
+			WithIndex(&agentregistryv1alpha1.RegistryDeployment{}, IndexDeploymentResourceName, func(obj client.Object) []string {
+				return []string{obj.(*agentregistryv1alpha1.RegistryDeployment).Spec.ResourceName}
+			}).
+			Build()
+	}
+
+	t.Run("nothing happens before the source group has synced", func(t *testing.T) {
+		server := newEntry(agentregistryv1alpha1.CatalogStatusActive, 0)
+		recorder := record.NewFakeRecorder(10)
+		result := advance(newClient(), NewDiscoveredResourceCache(), recorder, server)
+		assert.False(t, result.statusChanged)
+		assert.Equal(t, agentregistryv1alpha1.CatalogStatusActive, server.Status.Status)
+		assert.Empty(t, recorder.Events)
+	})
+
+	t.Run("a missing source deprecates the entry", func(t *testing.T) {
+		server := newEntry(agentregistryv1alpha1.CatalogStatusActive, 0)
+		recorder := record.NewFakeRecorder(10)
+		result := advance(newClient(), syncedCache(), recorder, server)
+		assert.True(t, result.statusChanged)
+		assert.Equal(t, agentregistryv1alpha1.CatalogStatusDeprecated, server.Status.Status)
+		cond := findCatalogCondition(server.Status.Conditions, agentregistryv1alpha1.CatalogConditionSourceAvailable)
+		require.NotNil(t, cond)
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Contains(t, <-recorder.Events, "Normal Deprecated")
+
+		// Within the grace period nothing more happens
+		result = advance(newClient(), syncedCache(), recorder, server)
+		assert.False(t, result.statusChanged)
+		assert.Equal(t, agentregistryv1alpha1.CatalogStatusDeprecated, server.Status.Status)
+		assert.Empty(t, recorder.Events)
+	})
+
+	t.Run("deprecated entries are marked deleted after the first TTL", func(t *testing.T) {
+		server := newEntry(agentregistryv1alpha1.CatalogStatusDeprecated, policy.DeleteAfter+time.Minute)
+		recorder := record.NewFakeRecorder(10)
+		result := advance(newClient(), syncedCache(), recorder, server)
+		assert.True(t, result.statusChanged)
+		assert.Equal(t, agentregistryv1alpha1.CatalogStatusDeleted, server.Status.Status)
+		assert.Contains(t, <-recorder.Events, "Normal Deleted")
+
+		// The second TTL runs from when the entry was deleted
+		c := newClient(server)
+		result = advance(c, syncedCache(), recorder, server)
+		assert.False(t, result.removed)
+	})
+
+	t.Run("deleted entries are removed after the second TTL", func(t *testing.T) {
+		server := newEntry(agentregistryv1alpha1.CatalogStatusDeleted, policy.DeleteAfter+policy.RemoveAfter+time.Minute)
+		c := newClient(server.DeepCopy())
+		recorder := record.NewFakeRecorder(10)
+		result := advance(c, syncedCache(), recorder, server)
+		assert.True(t, result.removed)
+		assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(server), &agentregistryv1alpha1.MCPServerCatalog{})))
+		assert.Contains(t, <-recorder.Events, "Normal Removed")
+	})
+
+	t.Run("references block removal", func(t *testing.T) {
+		server := newEntry(agentregistryv1alpha1.CatalogStatusDeleted, policy.DeleteAfter+policy.RemoveAfter+time.Minute)
+		server.Status.UsedBy = []agentregistryv1alpha1.MCPServerUsageRef{{Namespace: testNamespace, Name: "assistant"}}
+		deployment := &agentregistryv1alpha1.RegistryDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: testNamespace},
+			Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+				ResourceName: "tools/weather",
+				Version:      "latest",
+				ResourceType: agentregistryv1alpha1.ResourceTypeMCP,
+			},
+		}
+		c := newClient(server.DeepCopy(), deployment)
+		recorder := record.NewFakeRecorder(10)
+		result := advance(c, syncedCache(), recorder, server)
+		assert.False(t, result.removed)
+		assert.True(t, result.statusChanged)
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(server), &agentregistryv1alpha1.MCPServerCatalog{}))
+		cond := findCatalogCondition(server.Status.Conditions, agentregistryv1alpha1.CatalogConditionSourceAvailable)
+		require.NotNil(t, cond)
+		assert.Equal(t, eventReasonRemovalBlocked, cond.Reason)
+		assert.Equal(t, "Removal blocked by RegistryDeployment "+testNamespace+"/weather, agent "+testNamespace+"/assistant", cond.Message)
+		assert.Contains(t, <-recorder.Events, "Warning RemovalBlocked")
+
+		// The blocked removal is only reported once
+		result = advance(c, syncedCache(), recorder, server)
+		assert.False(t, result.statusChanged)
+		assert.Empty(t, recorder.Events)
+	})
+
+	t.Run("a reappearing source restores the entry", func(t *testing.T) {
+		server := newEntry(agentregistryv1alpha1.CatalogStatusDeleted, policy.DeleteAfter+time.Minute)
+		discovered := syncedCache()
+		discovered.Set(scope, "MCPServer", &kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "tools"}})
+		recorder := record.NewFakeRecorder(10)
+		result := advance(newClient(), discovered, recorder, server)
+		assert.True(t, result.statusChanged)
+		assert.Equal(t, agentregistryv1alpha1.CatalogStatusActive, server.Status.Status)
+		assert.Nil(t, findCatalogCondition(server.Status.Conditions, agentregistryv1alpha1.CatalogConditionSourceAvailable))
+		assert.Contains(t, <-recorder.Events, "Normal SourceRestored")
+	})
+
+	t.Run("manually deprecated entries without discovery labels are left alone", func(t *testing.T) {
+		server := newEntry(agentregistryv1alpha1.CatalogStatusDeprecated, 0)
+		server.Labels = nil
+		result := advance(newClient(), syncedCache(), nil, server)
+		assert.False(t, result.statusChanged)
+		assert.Equal(t, agentregistryv1alpha1.CatalogStatusDeprecated, server.Status.Status)
+	})
+}
+
+func TestModelCatalogReconciler_Lifecycle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev-cluster"}
+
+	model := &agentregistryv1alpha1.ModelCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dev-models-gpt",
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				discoveryLabel:       "true",
+				discoveryConfigLabel: scope.Config,
+				envLabel:             scope.Environment,
+				clusterLabel:         scope.Cluster,
+				sourceKindLabel:      "ModelConfig",
+				sourceNameLabel:      "gpt",
+				sourceNSLabel:        "models",
+			},
+		},
+		Status: agentregistryv1alpha1.ModelCatalogStatus{ManagementType: agentregistryv1alpha1.ManagementTypeExternal},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(model).
+		WithStatusSubresource(&agentregistryv1alpha1.ModelCatalog{}).
+		Build()
+	discovered := NewDiscoveredResourceCache()
+	discovered.MarkSynced(scope, "ModelConfig", "models")
+	recorder := record.NewFakeRecorder(10)
+	r := &ModelCatalogReconciler{
+		Client:          c,
+		Scheme:          scheme,
+		Logger:          zerolog.Nop(),
+		DiscoveredCache: discovered,
+		Recorder:        recorder,
+		Lifecycle:       CatalogLifecycle{DeleteAfter: time.Hour, RemoveAfter: time.Hour},
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: model.Name, Namespace: testNamespace}}
+	result, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, discoveredSourceCheckInterval, result.RequeueAfter)
+
+	var updated agentregistryv1alpha1.ModelCatalog
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &updated))
+	assert.Equal(t, agentregistryv1alpha1.CatalogStatusDeprecated, updated.Status.Status)
+	assert.Contains(t, <-recorder.Events, "Normal Deprecated")
+}
+
+func TestModelCatalogReconciler_LifecycleSharded(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	replica := func(identity string) *DiscoveryShards {
+		shards := &DiscoveryShards{Logger: zerolog.Nop(), Namespace: testNamespace, Identity: identity}
+		shards.setMembers([]string{"controller-a", "controller-b"})
+		return shards
+	}
+	owner, other := replica("controller-a"), replica("controller-b")
+	var scope DiscoveryScope
+	for i := 0; ; i++ {
+		scope = DiscoveryScope{Config: "discovery", Environment: fmt.Sprintf("env-%d", i), Cluster: "cluster"}
+		if owner.Owns(scope) {
+			break
+		}
+	}
+
+	model := &agentregistryv1alpha1.ModelCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "models-gpt",
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				discoveryLabel:       "true",
+				discoveryConfigLabel: scope.Config,
+				envLabel:             scope.Environment,
+				clusterLabel:         scope.Cluster,
+				sourceKindLabel:      "ModelConfig",
+				sourceNameLabel:      "gpt",
+				sourceNSLabel:        "models",
+			},
+		},
+		Status: agentregistryv1alpha1.ModelCatalogStatus{
+			ManagementType: agentregistryv1alpha1.ManagementTypeExternal,
+			Status:         agentregistryv1alpha1.CatalogStatusActive,
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(model).
+		WithStatusSubresource(&agentregistryv1alpha1.ModelCatalog{}).
+		Build()
+
+	// The owner's cache holds the source. The other replica's cache is synced but empty, as it is
+	// after the environment moved away from it.
+	ownerCache := NewDiscoveredResourceCache()
+	ownerCache.Set(scope, "ModelConfig", &kagentv1alpha2.ModelConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpt", Namespace: "models"},
+	})
+	ownerCache.MarkSynced(scope, "ModelConfig", "models")
+	staleCache := NewDiscoveredResourceCache()
+	staleCache.MarkSynced(scope, "ModelConfig", "models")
+
+	reconciler := func(shards *DiscoveryShards, discovered *DiscoveredResourceCache, recorder record.EventRecorder) *ModelCatalogReconciler {
+		return &ModelCatalogReconciler{
+			Client:          c,
+			Scheme:          scheme,
+			Logger:          zerolog.Nop(),
+			DiscoveredCache: discovered,
+			Shards:          shards,
+			Recorder:        recorder,
+			Lifecycle:       CatalogLifecycle{DeleteAfter: time.Hour, RemoveAfter: time.Hour},
+		}
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: model.Name, Namespace: testNamespace}}
+	var updated agentregistryv1alpha1.ModelCatalog
+
+	otherRecorder := record.NewFakeRecorder(10)
+	result, err := reconciler(other, staleCache, otherRecorder).Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, discoveredSourceCheckInterval, result.RequeueAfter)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &updated))
+	assert.Equal(t, agentregistryv1alpha1.CatalogStatusActive, updated.Status.Status, "only the owner's cache decides")
+	assert.Empty(t, otherRecorder.Events)
+
+	ownerRecorder := record.NewFakeRecorder(10)
+	_, err = reconciler(owner, ownerCache, ownerRecorder).Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &updated))
+	assert.Equal(t, agentregistryv1alpha1.CatalogStatusActive, updated.Status.Status)
+
+	ownerCache.Delete(scope, "ModelConfig", "models", "gpt")
+	_, err = reconciler(owner, ownerCache, ownerRecorder).Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &updated))
+	assert.Equal(t, agentregistryv1alpha1.CatalogStatusDeprecated, updated.Status.Status)
+	assert.Contains(t, <-ownerRecorder.Events, "Normal Deprecated")
+}
@@ -0,0 +1,390 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/rs/zerolog"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// clusterAPIClusterGVK is the Cluster API Cluster resource. It is accessed unstructured so
+// Cluster API stays an optional dependency.
+var clusterAPIClusterGVK = schema.GroupVersionKind{Group: "cluster.x-k8s.io", Version: "v1beta1", Kind: "Cluster"}
+
+const (
+	// clusterAPINameLabel is the label Cluster API puts on objects that belong to a cluster
+	clusterAPINameLabel = "cluster.x-k8s.io/cluster-name"
+	// clusterAPINamespaceLabel records the namespace of the Cluster a child DiscoveryConfig enrolls
+	clusterAPINamespaceLabel = "agentregistry.dev/cluster-namespace"
+	// clusterAPIKubeconfigKey is the key of the kubeconfig in the Secret Cluster API generates
+	// for every cluster, named <cluster>-kubeconfig
+	clusterAPIKubeconfigKey = "value"
+
+	// conditionClusterAPIEnrolled reports the outcome of the last enrollment on the parent
+	conditionClusterAPIEnrolled = "ClusterAPIEnrolled"
+
+	defaultEnrollmentEnvironmentName = "{{ .Namespace }}-{{ .Name }}"
+)
+
+// ClusterEnrollmentReconciler generates a child DiscoveryConfig for every Cluster API cluster
+// selected by a DiscoveryConfig's spec.clusterAPI, so discovery follows the cluster lifecycle
+type ClusterEnrollmentReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger zerolog.Logger
+}
+
+// clusterTemplateData is what enrollment templates are evaluated against
+type clusterTemplateData struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// +kubebuilder:rbac:groups=agentregistry.dev,resources=discoveryconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=agentregistry.dev,resources=discoveryconfigs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+
+// Reconcile creates, updates and deletes the child DiscoveryConfigs of a DiscoveryConfig
+func (r *ClusterEnrollmentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.With().Str("discoveryconfig", req.Name).Logger()
+
+	var parent agentregistryv1alpha1.DiscoveryConfig
+	if err := r.Get(ctx, req.NamespacedName, &parent); err != nil {
+		// Children of a deleted config are garbage collected through their owner reference
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	var children agentregistryv1alpha1.DiscoveryConfigList
+	if err := r.List(ctx, &children,
+		client.InNamespace(parent.Namespace),
+		client.MatchingLabels{agentregistryv1alpha1.LabelEnrolledBy: parent.Name},
+	); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	desired := make(map[string]*agentregistryv1alpha1.DiscoveryConfig)
+	// Children of clusters that fail to render are kept until the enrollment is fixed
+	failed := make(map[string]bool)
+	var errs []error
+	if parent.Spec.ClusterAPI != nil && parent.DeletionTimestamp == nil {
+		clusters, err := r.selectClusters(ctx, parent.Spec.ClusterAPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		for i := range clusters {
+			child, err := enrolledDiscoveryConfig(&parent, &clusters[i])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cluster %s/%s: %w", clusters[i].GetNamespace(), clusters[i].GetName(), err))
+				failed[enrolledConfigName(parent.Name, clusters[i].GetNamespace(), clusters[i].GetName())] = true
+				continue
+			}
+			desired[child.Name] = child
+		}
+	}
+
+	for i := range children.Items {
+		child := &children.Items[i]
+		if _, ok := desired[child.Name]; ok || failed[child.Name] {
+			continue
+		}
+		logger.Info().
+			Str("child", child.Name).
+			Str("cluster", child.Labels[clusterAPINamespaceLabel]+"/"+child.Labels[clusterAPINameLabel]).
+			Msg("unenrolling Cluster API cluster")
+		if err := r.Delete(ctx, child); client.IgnoreNotFound(err) != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", child.Name, err))
+		}
+	}
+
+	for _, want := range desired {
+		child := &agentregistryv1alpha1.DiscoveryConfig{ObjectMeta: metav1.ObjectMeta{Name: want.Name, Namespace: want.Namespace}}
+		result, err := controllerutil.CreateOrUpdate(ctx, r.Client, child, func() error {
+			if child.Labels == nil {
+				child.Labels = make(map[string]string)
+			}
+			for k, v := range want.Labels {
+				child.Labels[k] = v
+			}
+			child.Spec = want.Spec
+			return controllerutil.SetControllerReference(&parent, child, r.Scheme)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to enroll into %s: %w", want.Name, err))
+			continue
+		}
+		if result != controllerutil.OperationResultNone {
+			logger.Info().
+				Str("child", child.Name).
+				Str("cluster", want.Labels[clusterAPINamespaceLabel]+"/"+want.Labels[clusterAPINameLabel]).
+				Str("operation", string(result)).
+				Msg("enrolled Cluster API cluster")
+		}
+	}
+
+	if err := r.setEnrolledCondition(ctx, &parent, len(desired), errs); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, errors.Join(errs...)
+}
+
+// selectClusters lists the ready, selected Clusters of an enrollment
+func (r *ClusterEnrollmentReconciler) selectClusters(
+	ctx context.Context,
+	enrollment *agentregistryv1alpha1.ClusterAPIEnrollment,
+) ([]unstructured.Unstructured, error) {
+	selector, err := metav1.LabelSelectorAsSelector(enrollment.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clusterAPI selector: %w", err)
+	}
+
+	namespaces := enrollment.ClusterNamespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	var clusters []unstructured.Unstructured
+	for _, ns := range namespaces {
+		list := newUnstructuredList(clusterAPIClusterGVK)
+		if err := r.List(ctx, list, client.InNamespace(ns), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, fmt.Errorf("failed to list Cluster API clusters: %w", err)
+		}
+		for _, c := range list.Items {
+			if isClusterAPIClusterReady(&c) {
+				clusters = append(clusters, c)
+			}
+		}
+	}
+	return clusters, nil
+}
+
+// isClusterAPIClusterReady reports whether a Cluster's control plane is up, at which point its
+// kubeconfig Secret exists. Clusters being deleted are not ready.
+func isClusterAPIClusterReady(c *unstructured.Unstructured) bool {
+	if c.GetDeletionTimestamp() != nil {
+		return false
+	}
+	if ready, _, _ := unstructured.NestedBool(c.Object, "status", "controlPlaneReady"); ready {
+		return true
+	}
+	phase, _, _ := unstructured.NestedString(c.Object, "status", "phase")
+	return phase == "Provisioned"
+}
+
+// enrolledDiscoveryConfig builds the child DiscoveryConfig of a Cluster
+func enrolledDiscoveryConfig(
+	parent *agentregistryv1alpha1.DiscoveryConfig,
+	c *unstructured.Unstructured,
+) (*agentregistryv1alpha1.DiscoveryConfig, error) {
+	enrollment := parent.Spec.ClusterAPI
+	data := clusterTemplateData{
+		Name:        c.GetName(),
+		Namespace:   c.GetNamespace(),
+		Labels:      c.GetLabels(),
+		Annotations: c.GetAnnotations(),
+	}
+
+	nameTemplate := enrollment.EnvironmentName
+	if nameTemplate == "" {
+		nameTemplate = defaultEnrollmentEnvironmentName
+	}
+	envName, err := renderEnrollmentTemplate("environmentName", nameTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+	if envName == "" {
+		return nil, fmt.Errorf("environmentName %q renders empty", nameTemplate)
+	}
+	namespaces, err := renderEnrollmentTemplates("namespaces", enrollment.Namespaces, data)
+	if err != nil {
+		return nil, err
+	}
+	excludeNamespaces, err := renderEnrollmentTemplates("excludeNamespaces", enrollment.ExcludeNamespaces, data)
+	if err != nil {
+		return nil, err
+	}
+	var labels map[string]string
+	if len(enrollment.Labels) > 0 {
+		labels = make(map[string]string, len(enrollment.Labels))
+		for k, v := range enrollment.Labels {
+			if labels[k], err = renderEnrollmentTemplate("labels."+k, v, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	env := agentregistryv1alpha1.Environment{
+		Name: envName,
+		Cluster: agentregistryv1alpha1.ClusterConfig{
+			// Namespaced so equally named Clusters never share catalog entries
+			Name: generateCatalogName(c.GetNamespace(), c.GetName()),
+			CredentialsSecretRef: &agentregistryv1alpha1.ClusterCredentialsSecretRef{
+				Name:      c.GetName() + "-kubeconfig",
+				Namespace: c.GetNamespace(),
+				Key:       clusterAPIKubeconfigKey,
+			},
+		},
+		Provider:          cluster.ProviderKubeconfig,
+		DiscoveryEnabled:  true,
+		DeployEnabled:     enrollment.DeployEnabled,
+		Namespaces:        namespaces,
+		AllNamespaces:     enrollment.AllNamespaces,
+		ExcludeNamespaces: excludeNamespaces,
+		ResourceTypes:     slices.Clone(enrollment.ResourceTypes),
+		Labels:            labels,
+	}
+
+	child := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      enrolledConfigName(parent.Name, c.GetNamespace(), c.GetName()),
+			Namespace: parent.Namespace,
+			Labels: map[string]string{
+				agentregistryv1alpha1.LabelEnrolledBy: parent.Name,
+				clusterAPINameLabel:                   c.GetName(),
+				clusterAPINamespaceLabel:              c.GetNamespace(),
+			},
+		},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{env},
+			Prune:        parent.Spec.Prune,
+		},
+	}
+	for i := range parent.Spec.CustomResources {
+		child.Spec.CustomResources = append(child.Spec.CustomResources, *parent.Spec.CustomResources[i].DeepCopy())
+	}
+	return child, nil
+}
+
+// renderEnrollmentTemplates renders a list of templates, dropping empty results
+func renderEnrollmentTemplates(field string, templates []string, data clusterTemplateData) ([]string, error) {
+	var out []string
+	for i, text := range templates {
+		value, err := renderEnrollmentTemplate(fmt.Sprintf("%s[%d]", field, i), text, data)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			out = append(out, value)
+		}
+	}
+	return out, nil
+}
+
+// renderEnrollmentTemplate evaluates a Go template against a Cluster. Missing labels and
+// annotations render empty.
+func renderEnrollmentTemplate(field, text string, data clusterTemplateData) (string, error) {
+	tmpl, err := template.New(field).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", field, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", field, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// enrolledConfigName names the child DiscoveryConfig of a Cluster. The hash suffix keeps names
+// of different Clusters apart when the readable prefix is truncated.
+func enrolledConfigName(parent, clusterNamespace, clusterName string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{parent, clusterNamespace, clusterName}, "/")))
+	suffix := hex.EncodeToString(sum[:4])
+
+	prefix := generateCatalogName(parent, clusterNamespace+"-"+clusterName)
+	if maxLen := 63 - len(suffix) - 1; len(prefix) > maxLen {
+		prefix = strings.TrimRight(prefix[:maxLen], "-")
+	}
+	return prefix + "-" + suffix
+}
+
+// setEnrolledCondition records the outcome of an enrollment on the parent DiscoveryConfig
+func (r *ClusterEnrollmentReconciler) setEnrolledCondition(
+	ctx context.Context,
+	parent *agentregistryv1alpha1.DiscoveryConfig,
+	enrolled int,
+	errs []error,
+) error {
+	orig := parent.DeepCopy()
+	if parent.Spec.ClusterAPI == nil {
+		if !meta.RemoveStatusCondition(&parent.Status.Conditions, conditionClusterAPIEnrolled) {
+			return nil
+		}
+	} else {
+		condition := metav1.Condition{
+			Type:               conditionClusterAPIEnrolled,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: parent.Generation,
+			Reason:             "ClustersEnrolled",
+			Message:            fmt.Sprintf("Enrolled %d clusters", enrolled),
+		}
+		if len(errs) > 0 {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "EnrollmentFailed"
+			condition.Message = errors.Join(errs...).Error()
+		}
+		if !meta.SetStatusCondition(&parent.Status.Conditions, condition) {
+			return nil
+		}
+	}
+	return client.IgnoreNotFound(r.Status().Patch(ctx, parent, client.MergeFrom(orig)))
+}
+
+// discoveryConfigsForCluster maps a Cluster event to the DiscoveryConfigs that may enroll it
+func (r *ClusterEnrollmentReconciler) discoveryConfigsForCluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list agentregistryv1alpha1.DiscoveryConfigList
+	if err := r.List(ctx, &list, client.InNamespace(config.GetNamespace())); err != nil {
+		r.Logger.Error().Err(err).Msg("failed to list DiscoveryConfigs for Cluster API cluster")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range list.Items {
+		enrollment := list.Items[i].Spec.ClusterAPI
+		if enrollment == nil {
+			continue
+		}
+		if len(enrollment.ClusterNamespaces) > 0 && !slices.Contains(enrollment.ClusterNamespaces, obj.GetNamespace()) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Name:      list.Items[i].Name,
+			Namespace: list.Items[i].Namespace,
+		}})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller. Cluster API must be installed in the cluster.
+func (r *ClusterEnrollmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	clusterObj := &unstructured.Unstructured{}
+	clusterObj.SetGroupVersionKind(clusterAPIClusterGVK)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("clusterenrollment").
+		For(&agentregistryv1alpha1.DiscoveryConfig{}).
+		Owns(&agentregistryv1alpha1.DiscoveryConfig{}).
+		Watches(clusterObj, handler.EnqueueRequestsFromMapFunc(r.discoveryConfigsForCluster)).
+		Complete(r)
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+)
+
+func capiCluster(namespace, name, team string, ready bool) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"controlPlaneReady": ready},
+	}}
+	if !ready {
+		obj.Object["status"] = map[string]interface{}{"phase": "Provisioning"}
+	}
+	obj.SetGroupVersionKind(clusterAPIClusterGVK)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetLabels(map[string]string{"env": "prod", "team": team})
+	return obj
+}
+
+func TestClusterEnrollmentReconciler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	parent := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "fleet", Namespace: testNamespace, UID: "fleet-uid"},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Prune: true,
+			ClusterAPI: &agentregistryv1alpha1.ClusterAPIEnrollment{
+				Selector:      &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+				Namespaces:    []string{`team-{{ index .Labels "team" }}`, `{{ index .Annotations "extra-namespace" }}`},
+				ResourceTypes: []string{"MCPServer", "Agent"},
+				Labels:        map[string]string{"team": `{{ index .Labels "team" }}`, "source": "capi"},
+			},
+		},
+	}
+	other := capiCluster("fleet-b", "eu-1", "search", true)
+	other.SetLabels(map[string]string{"env": "dev"})
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&agentregistryv1alpha1.DiscoveryConfig{}).
+		WithObjects(
+			parent,
+			capiCluster("fleet-a", "eu-1", "payments", true),
+			capiCluster("fleet-b", "us-1", "search", true),
+			capiCluster("fleet-b", "ap-1", "search", false),
+			other,
+		).
+		Build()
+	r := &ClusterEnrollmentReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop()}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "fleet", Namespace: testNamespace}}
+
+	children := func() map[string]agentregistryv1alpha1.DiscoveryConfig {
+		var list agentregistryv1alpha1.DiscoveryConfigList
+		require.NoError(t, local.List(ctx, &list, client.MatchingLabels{agentregistryv1alpha1.LabelEnrolledBy: "fleet"}))
+		out := make(map[string]agentregistryv1alpha1.DiscoveryConfig)
+		for _, dc := range list.Items {
+			out[dc.Labels[clusterAPINamespaceLabel]+"/"+dc.Labels[clusterAPINameLabel]] = dc
+		}
+		return out
+	}
+	condition := func() *metav1.Condition {
+		var dc agentregistryv1alpha1.DiscoveryConfig
+		require.NoError(t, local.Get(ctx, req.NamespacedName, &dc))
+		return meta.FindStatusCondition(dc.Status.Conditions, conditionClusterAPIEnrolled)
+	}
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	enrolled := children()
+	require.Len(t, enrolled, 2, "only ready clusters matching the selector are enrolled")
+	child, ok := enrolled["fleet-a/eu-1"]
+	require.True(t, ok)
+	assert.Equal(t, enrolledConfigName("fleet", "fleet-a", "eu-1"), child.Name)
+	assert.True(t, child.Spec.Prune)
+	require.Len(t, child.OwnerReferences, 1)
+	assert.Equal(t, "fleet", child.OwnerReferences[0].Name)
+	require.Len(t, child.Spec.Environments, 1)
+	env := child.Spec.Environments[0]
+	assert.Equal(t, "fleet-a-eu-1", env.Name)
+	assert.Equal(t, "fleet-a-eu-1", env.Cluster.Name)
+	assert.Equal(t, cluster.ProviderKubeconfig, env.Provider)
+	assert.Equal(t, &agentregistryv1alpha1.ClusterCredentialsSecretRef{
+		Name:      "eu-1-kubeconfig",
+		Namespace: "fleet-a",
+		Key:       "value",
+	}, env.Cluster.CredentialsSecretRef)
+	assert.True(t, env.DiscoveryEnabled)
+	assert.Equal(t, []string{"team-payments"}, env.Namespaces, "empty templates are dropped")
+	assert.Equal(t, []string{"MCPServer", "Agent"}, env.ResourceTypes)
+	assert.Equal(t, map[string]string{"team": "payments", "source": "capi"}, env.Labels)
+	assert.NoError(t, cluster.ValidateEnvironment(&env))
+	assert.Equal(t, "team-search", enrolled["fleet-b/us-1"].Spec.Environments[0].Namespaces[0])
+
+	cond := condition()
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Enrolled 2 clusters", cond.Message)
+
+	t.Run("clusters become ready", func(t *testing.T) {
+		ap := capiCluster("fleet-b", "ap-1", "search", true)
+		var current unstructured.Unstructured
+		current.SetGroupVersionKind(clusterAPIClusterGVK)
+		require.NoError(t, local.Get(ctx, client.ObjectKeyFromObject(ap), &current))
+		current.Object["status"] = ap.Object["status"]
+		require.NoError(t, local.Update(ctx, &current))
+
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Len(t, children(), 3)
+	})
+
+	t.Run("deleted clusters are unenrolled", func(t *testing.T) {
+		require.NoError(t, local.Delete(ctx, capiCluster("fleet-b", "us-1", "", true)))
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		enrolled := children()
+		assert.Len(t, enrolled, 2)
+		assert.NotContains(t, enrolled, "fleet-b/us-1")
+	})
+
+	t.Run("template errors are reported on the parent", func(t *testing.T) {
+		var dc agentregistryv1alpha1.DiscoveryConfig
+		require.NoError(t, local.Get(ctx, req.NamespacedName, &dc))
+		dc.Spec.ClusterAPI.EnvironmentName = "{{ .Missing"
+		require.NoError(t, local.Update(ctx, &dc))
+
+		_, err := r.Reconcile(ctx, req)
+		require.Error(t, err)
+		cond := condition()
+		require.NotNil(t, cond)
+		assert.Equal(t, metav1.ConditionFalse, cond.Status)
+		assert.Contains(t, cond.Message, "invalid environmentName template")
+		assert.Len(t, children(), 2, "enrolled clusters are kept while the templates are broken")
+	})
+
+	t.Run("cluster events map to enrolling configs", func(t *testing.T) {
+		requests := r.discoveryConfigsForCluster(ctx, capiCluster("fleet-c", "eu-2", "search", true))
+		assert.Equal(t, []reconcile.Request{req}, requests)
+
+		var dc agentregistryv1alpha1.DiscoveryConfig
+		require.NoError(t, local.Get(ctx, req.NamespacedName, &dc))
+		dc.Spec.ClusterAPI.ClusterNamespaces = []string{"fleet-a"}
+		require.NoError(t, local.Update(ctx, &dc))
+		assert.Empty(t, r.discoveryConfigsForCluster(ctx, capiCluster("fleet-c", "eu-2", "search", true)))
+	})
+
+	t.Run("removing clusterAPI unenrolls every cluster", func(t *testing.T) {
+		var dc agentregistryv1alpha1.DiscoveryConfig
+		require.NoError(t, local.Get(ctx, req.NamespacedName, &dc))
+		dc.Spec.ClusterAPI = nil
+		require.NoError(t, local.Update(ctx, &dc))
+
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Empty(t, children())
+		assert.Nil(t, condition())
+	})
+}
+
+func TestEnrolledConfigName(t *testing.T) {
+	a := enrolledConfigName("fleet", "team-a", "cluster")
+	b := enrolledConfigName("fleet", "team", "a-cluster")
+	assert.NotEqual(t, a, b, "the hash separates names with the same readable prefix")
+	assert.Regexp(t, `^fleet-team-a-cluster-[0-9a-f]{8}$`, a)
+
+	long := enrolledConfigName("fleet", "a-very-long-management-namespace-name", "an-even-longer-workload-cluster-name")
+	assert.LessOrEqual(t, len(long), 63)
+}
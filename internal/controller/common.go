@@ -1,8 +1,14 @@
 package controller
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
 )
 
 // Discovery label constants shared across discovery handlers
@@ -11,8 +17,18 @@ const (
 	sourceKindLabel = "agentregistry.dev/source-kind"
 	sourceNameLabel = "agentregistry.dev/source-name"
 	sourceNSLabel   = "agentregistry.dev/source-namespace"
+	envLabel        = agentregistryv1alpha1.LabelEnvironment
+	clusterLabel    = agentregistryv1alpha1.LabelCluster
+
+	discoveryConfigLabel = agentregistryv1alpha1.LabelDiscoveryConfig
 )
 
+// isManagedByRegistry reports whether a resource was created by Agent Registry itself.
+// Such resources are skipped by discovery to avoid duplicate catalog entries.
+func isManagedByRegistry(labels map[string]string) bool {
+	return labels[agentregistryv1alpha1.LabelManagedBy] == "agentregistry"
+}
+
 // getEnvironmentFromNamespace extracts environment from namespace
 // Returns the namespace as environment if not recognized
 func getEnvironmentFromNamespace(namespace string) string {
@@ -44,28 +60,29 @@ func generateCatalogName(namespace, name string) string {
 	return combined
 }
 
-// generateAgentCatalogName creates an agent catalog name from namespace and agent CR name
-func generateAgentCatalogName(namespace, agentCRName string) string {
-	combined := fmt.Sprintf("%s-%s", namespace, agentCRName)
-	combined = strings.ReplaceAll(combined, "/", "-")
-	combined = strings.ReplaceAll(combined, "_", "-")
-	combined = strings.ToLower(combined)
-	if len(combined) > 63 {
-		combined = combined[:63]
+// generateDiscoveredCatalogName creates a catalog name for a resource discovered in a
+// specific environment and cluster. The readable prefix is environment-namespace-name; the
+// hash suffix covers kind, environment, cluster, namespace and name so that the same
+// namespace/name in different clusters (or of different kinds) never share a catalog entry,
+// even when the prefix is truncated.
+func generateDiscoveredCatalogName(kind, environment, cluster, namespace, name string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{kind, environment, cluster, namespace, name}, "/")))
+	suffix := hex.EncodeToString(sum[:4])
+
+	prefix := generateCatalogName(environment, namespace+"-"+name)
+	if maxLen := 63 - len(suffix) - 1; len(prefix) > maxLen {
+		prefix = strings.TrimRight(prefix[:maxLen], "-")
 	}
-	return combined
+	return prefix + "-" + suffix
 }
 
-// generateModelCatalogName creates a model catalog name from namespace and model name
-func generateModelCatalogName(namespace, name string) string {
-	combined := fmt.Sprintf("%s-%s", namespace, name)
-	combined = strings.ReplaceAll(combined, "/", "-")
-	combined = strings.ReplaceAll(combined, "_", "-")
-	combined = strings.ToLower(combined)
-	if len(combined) > 63 {
-		combined = combined[:63]
+// sameDiscoveryScope reports whether two catalog entries belong to the same environment and
+// cluster. Entries without discovery labels (manual, imported) are in scope of everything.
+func sameDiscoveryScope(a, b map[string]string) bool {
+	if a[envLabel] == "" || b[envLabel] == "" {
+		return true
 	}
-	return combined
+	return a[envLabel] == b[envLabel] && a[clusterLabel] == b[clusterLabel]
 }
 
 // parseSkillRef parses a skill reference into name and version
@@ -114,3 +131,37 @@ func generateSkillCatalogName(name, version string) string {
 
 	return combined
 }
+
+// setCatalogCondition sets or updates a condition, moving its transition time only when the
+// status changes
+func setCatalogCondition(conditions []agentregistryv1alpha1.CatalogCondition, condType agentregistryv1alpha1.CatalogConditionType, status metav1.ConditionStatus, reason, message string) []agentregistryv1alpha1.CatalogCondition {
+	for i, c := range conditions {
+		if c.Type == condType {
+			if c.Status != status {
+				conditions[i].LastTransitionTime = metav1.Now()
+			}
+			conditions[i].Status = status
+			conditions[i].Reason = reason
+			conditions[i].Message = message
+			return conditions
+		}
+	}
+	return append(conditions, agentregistryv1alpha1.CatalogCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// removeCatalogCondition returns conditions without the given type
+func removeCatalogCondition(conditions []agentregistryv1alpha1.CatalogCondition, condType agentregistryv1alpha1.CatalogConditionType) []agentregistryv1alpha1.CatalogCondition {
+	var result []agentregistryv1alpha1.CatalogCondition
+	for _, c := range conditions {
+		if c.Type != condType {
+			result = append(result, c)
+		}
+	}
+	return result
+}
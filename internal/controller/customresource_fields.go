@@ -0,0 +1,198 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"k8s.io/client-go/util/jsonpath"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+// celPrefix marks a field expression as CEL rather than JSONPath
+const celPrefix = "cel:"
+
+const (
+	// celCostLimit bounds the work a single evaluation does, like the per-call limit of CEL
+	// validation rules in the API server
+	celCostLimit = 1000000
+	// celInterruptCheckFrequency is how many comprehension iterations run between checks of
+	// the evaluation timeout
+	celInterruptCheckFrequency = 100
+	// celEvaluationTimeout bounds a single evaluation, since expressions run in the informer
+	// event handlers
+	celEvaluationTimeout = 100 * time.Millisecond
+)
+
+// fieldExpression extracts a value from an unstructured resource
+type fieldExpression interface {
+	evaluate(obj map[string]interface{}) (interface{}, error)
+}
+
+// jsonPathExpression evaluates a JSONPath template; missing keys yield no value
+type jsonPathExpression struct {
+	path *jsonpath.JSONPath
+}
+
+func (e *jsonPathExpression) evaluate(obj map[string]interface{}) (interface{}, error) {
+	results, err := e.path.FindResults(obj)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 1 {
+		switch len(results[0]) {
+		case 0:
+			return nil, nil
+		case 1:
+			// Keep the native type so booleans stay booleans
+			return results[0][0].Interface(), nil
+		}
+	}
+	// Templates mixing text and several paths are rendered as a string
+	var buf bytes.Buffer
+	if err := e.path.Execute(&buf, obj); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+// celExpression evaluates a CEL program with the resource bound to `object`
+type celExpression struct {
+	program cel.Program
+}
+
+func (e *celExpression) evaluate(obj map[string]interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), celEvaluationTimeout)
+	defer cancel()
+	out, _, err := e.program.ContextEval(ctx, map[string]interface{}{"object": obj})
+	if err != nil {
+		return nil, err
+	}
+	return out.Value(), nil
+}
+
+// compileFieldExpression compiles a JSONPath or "cel:" expression. An empty expression
+// compiles to nil.
+func compileFieldExpression(expr string) (fieldExpression, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(expr, celPrefix) {
+		env, err := cel.NewEnv(
+			cel.Variable("object", cel.DynType),
+			// object.?status.?url.orValue('') reads fields that may be missing
+			cel.OptionalTypes(),
+			ext.Strings(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		ast, issues := env.Compile(strings.TrimPrefix(expr, celPrefix))
+		if issues != nil && issues.Err() != nil {
+			return nil, issues.Err()
+		}
+		program, err := env.Program(ast,
+			cel.CostLimit(celCostLimit),
+			cel.InterruptCheckFrequency(celInterruptCheckFrequency),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &celExpression{program: program}, nil
+	}
+
+	if !strings.Contains(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	path := jsonpath.New("field").AllowMissingKeys(true)
+	if err := path.Parse(expr); err != nil {
+		return nil, err
+	}
+	return &jsonPathExpression{path: path}, nil
+}
+
+// compiledMapping is a CustomResourceMapping with its field expressions compiled
+type compiledMapping struct {
+	mapping *agentregistryv1alpha1.CustomResourceMapping
+
+	name        fieldExpression
+	version     fieldExpression
+	description fieldExpression
+	url         fieldExpression
+	ready       fieldExpression
+	image       fieldExpression
+	provider    fieldExpression
+	model       fieldExpression
+	runtime     fieldExpression
+}
+
+// compileMapping compiles every field expression of a mapping
+func compileMapping(mapping *agentregistryv1alpha1.CustomResourceMapping) (*compiledMapping, error) {
+	c := &compiledMapping{mapping: mapping}
+	fields := []struct {
+		name string
+		expr string
+		dst  *fieldExpression
+	}{
+		{"name", mapping.Fields.Name, &c.name},
+		{"version", mapping.Fields.Version, &c.version},
+		{"description", mapping.Fields.Description, &c.description},
+		{"url", mapping.Fields.URL, &c.url},
+		{"ready", mapping.Fields.Ready, &c.ready},
+		{"image", mapping.Fields.Image, &c.image},
+		{"provider", mapping.Fields.Provider, &c.provider},
+		{"model", mapping.Fields.Model, &c.model},
+		{"runtime", mapping.Fields.Runtime, &c.runtime},
+	}
+	for _, f := range fields {
+		compiled, err := compileFieldExpression(f.expr)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %s: invalid %s expression %q: %w", mapping.Name, f.name, f.expr, err)
+		}
+		*f.dst = compiled
+	}
+	return c, nil
+}
+
+// evaluateString evaluates expr against obj and formats the result as a string.
+// A nil expression or missing value yields "".
+func evaluateString(expr fieldExpression, obj map[string]interface{}) (string, error) {
+	if expr == nil {
+		return "", nil
+	}
+	value, err := expr.evaluate(obj)
+	if err != nil || value == nil {
+		return "", err
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// evaluateBool evaluates expr against obj as a readiness flag. A nil expression is true;
+// a missing value is false.
+func evaluateBool(expr fieldExpression, obj map[string]interface{}) (bool, error) {
+	if expr == nil {
+		return true, nil
+	}
+	value, err := expr.evaluate(obj)
+	if err != nil {
+		return false, err
+	}
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strings.EqualFold(v, "true"), nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+func TestCompileFieldExpression(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "llama", "namespace": "models"},
+		"spec":     map[string]interface{}{"replicas": int64(2)},
+		"status": map[string]interface{}{
+			"url": "http://llama.models.svc",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Scheduled", "status": "True"},
+				map[string]interface{}{"type": "Ready", "status": "False"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{name: "bare jsonpath", expr: ".status.url", want: "http://llama.models.svc"},
+		{name: "braced jsonpath", expr: "{.metadata.namespace}/{.metadata.name}", want: "models/llama"},
+		{name: "jsonpath filter", expr: `{.status.conditions[?(@.type=="Ready")].status}`, want: "False"},
+		{name: "jsonpath keeps native type", expr: ".spec.replicas", want: int64(2)},
+		{name: "missing key", expr: ".status.address.url", want: nil},
+		{name: "cel string", expr: "cel:object.metadata.namespace + '/' + object.metadata.name", want: "models/llama"},
+		{name: "cel bool", expr: "cel:object.status.conditions.exists(c, c.type == 'Scheduled' && c.status == 'True')", want: true},
+		{name: "cel has", expr: "cel:has(object.status.address) ? object.status.address : ''", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := compileFieldExpression(tt.expr)
+			require.NoError(t, err)
+			got, err := expr.evaluate(obj)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	expr, err := compileFieldExpression("  ")
+	require.NoError(t, err)
+	assert.Nil(t, expr)
+
+	_, err = compileFieldExpression("{.status[")
+	assert.Error(t, err)
+	_, err = compileFieldExpression("cel:object.")
+	assert.Error(t, err)
+
+	t.Run("expensive cel expressions are stopped", func(t *testing.T) {
+		expr, err := compileFieldExpression("cel:[1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(a, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]" +
+			".map(b, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(c, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]" +
+			".map(d, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(e, [1, 2, 3, 4, 5, 6, 7, 8, 9, 10].map(f, a + b + c + d + e + f))))))")
+		require.NoError(t, err)
+		_, err = expr.evaluate(obj)
+		assert.ErrorContains(t, err, "cost limit exceeded")
+	})
+}
+
+func TestEvaluateBool(t *testing.T) {
+	obj := map[string]interface{}{"status": map[string]interface{}{"ready": "True", "live": false}}
+
+	ready, err := evaluateBool(nil, obj)
+	require.NoError(t, err)
+	assert.True(t, ready, "no expression means ready")
+
+	for expr, want := range map[string]bool{
+		".status.ready":                     true,
+		".status.live":                      false,
+		".status.missing":                   false,
+		"cel:object.status.ready == 'True'": true,
+	} {
+		compiled, err := compileFieldExpression(expr)
+		require.NoError(t, err)
+		ready, err := evaluateBool(compiled, obj)
+		require.NoError(t, err)
+		assert.Equal(t, want, ready, expr)
+	}
+}
+
+func TestCompileMapping(t *testing.T) {
+	_, err := compileMapping(&agentregistryv1alpha1.CustomResourceMapping{
+		Name:   "InferenceService",
+		Fields: agentregistryv1alpha1.CustomResourceFields{URL: ".status.url", Ready: "cel:true"},
+	})
+	require.NoError(t, err)
+
+	_, err = compileMapping(&agentregistryv1alpha1.CustomResourceMapping{
+		Name:   "InferenceService",
+		Fields: agentregistryv1alpha1.CustomResourceFields{Ready: "cel:object.status.ready &&"},
+	})
+	assert.ErrorContains(t, err, "invalid ready expression")
+}
@@ -0,0 +1,395 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+// DiscoveryScope identifies where a discovered resource was found.
+// Empty fields act as wildcards when looking resources up.
+type DiscoveryScope struct {
+	Config      string
+	Environment string
+	Cluster     string
+}
+
+// String returns the scope as "config/environment/cluster"
+func (s DiscoveryScope) String() string {
+	return s.Config + "/" + s.Environment + "/" + s.Cluster
+}
+
+// matches reports whether every non-empty field of s equals the field in other
+func (s DiscoveryScope) matches(other DiscoveryScope) bool {
+	return (s.Config == "" || s.Config == other.Config) &&
+		(s.Environment == "" || s.Environment == other.Environment) &&
+		(s.Cluster == "" || s.Cluster == other.Cluster)
+}
+
+// scopeFromLabels builds the discovery scope of a catalog entry from its discovery labels
+func scopeFromLabels(labels map[string]string) DiscoveryScope {
+	return DiscoveryScope{
+		Config:      labels[discoveryConfigLabel],
+		Environment: labels[envLabel],
+		Cluster:     labels[clusterLabel],
+	}
+}
+
+// discoveredEntry is a single cached resource with its origin
+type discoveredEntry struct {
+	scope    DiscoveryScope
+	kind     string
+	obj      client.Object
+	storedAt time.Time
+}
+
+// DiscoveredCacheStats summarizes the contents of a DiscoveredResourceCache
+type DiscoveredCacheStats struct {
+	// Entries is the number of cached resources per kind
+	Entries map[string]int
+	// OldestEntryAge is the time since the least recently stored resource was written
+	OldestEntryAge time.Duration
+}
+
+// DiscoveredResourceCache provides read access to resources from discovered namespaces.
+// Populated by DiscoveryConfig informers, used by catalog reconcilers for SourceRef lookups.
+// Entries are keyed by DiscoveryConfig, environment and cluster so the same namespace/name
+// in two clusters never collide.
+type DiscoveredResourceCache struct {
+	mu      sync.RWMutex
+	entries map[string]*discoveredEntry // key: config/env/cluster/kind/namespace/name
+
+	// Secondary indexes into entries
+	byName  map[string]map[string]struct{}         // key: kind/namespace/name
+	byScope map[DiscoveryScope]map[string]struct{} // key: scope
+
+	// synced holds the informer groups (scope/kind/namespace) that were listed in full, so a
+	// resource missing from them is known to be gone rather than not yet seen
+	synced map[string]bool
+
+	// now is the clock used for entry ages (injectable for testing)
+	now func() time.Time
+
+	entriesDesc *prometheus.Desc
+	ageDesc     *prometheus.Desc
+}
+
+// NewDiscoveredResourceCache creates an empty DiscoveredResourceCache
+func NewDiscoveredResourceCache() *DiscoveredResourceCache {
+	variableLabels := []string{"config", "environment", "cluster", "kind"}
+	return &DiscoveredResourceCache{
+		entries: make(map[string]*discoveredEntry),
+		byName:  make(map[string]map[string]struct{}),
+		byScope: make(map[DiscoveryScope]map[string]struct{}),
+		synced:  make(map[string]bool),
+		now:     time.Now,
+		entriesDesc: prometheus.NewDesc(
+			"agentregistry_discovered_cache_entries",
+			"Number of discovered resources held in the discovery cache",
+			variableLabels, nil,
+		),
+		ageDesc: prometheus.NewDesc(
+			"agentregistry_discovered_cache_oldest_entry_age_seconds",
+			"Seconds since the least recently updated discovered resource was stored",
+			variableLabels, nil,
+		),
+	}
+}
+
+func nameKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func entryKey(scope DiscoveryScope, kind, namespace, name string) string {
+	return scope.String() + "/" + nameKey(kind, namespace, name)
+}
+
+// Set stores a copy of obj under the given scope and kind
+func (c *DiscoveredResourceCache) Set(scope DiscoveryScope, kind string, obj client.Object) {
+	key := entryKey(scope, kind, obj.GetNamespace(), obj.GetName())
+	nk := nameKey(kind, obj.GetNamespace(), obj.GetName())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = &discoveredEntry{
+		scope:    scope,
+		kind:     kind,
+		obj:      obj.DeepCopyObject().(client.Object),
+		storedAt: c.now(),
+	}
+	if c.byName[nk] == nil {
+		c.byName[nk] = make(map[string]struct{})
+	}
+	c.byName[nk][key] = struct{}{}
+	if c.byScope[scope] == nil {
+		c.byScope[scope] = make(map[string]struct{})
+	}
+	c.byScope[scope][key] = struct{}{}
+}
+
+// Delete removes a resource from the given scope
+func (c *DiscoveredResourceCache) Delete(scope DiscoveryScope, kind, namespace, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteLocked(entryKey(scope, kind, namespace, name))
+}
+
+// DeleteScope removes every resource stored under scope and returns how many were removed
+func (c *DiscoveredResourceCache) DeleteScope(scope DiscoveryScope) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.byScope[scope]
+	n := len(keys)
+	for key := range keys {
+		c.deleteLocked(key)
+	}
+	for group := range c.synced {
+		if strings.HasPrefix(group, scope.String()+"/") {
+			delete(c.synced, group)
+		}
+	}
+	return n
+}
+
+// DeleteNamespace removes every resource of kind in namespace stored under scope and returns
+// how many were removed
+func (c *DiscoveredResourceCache) DeleteNamespace(scope DiscoveryScope, kind, namespace string) int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for key := range c.byScope[scope] {
+		entry := c.entries[key]
+		if entry.kind == kind && entry.obj.GetNamespace() == namespace {
+			c.deleteLocked(key)
+			n++
+		}
+	}
+	delete(c.synced, informerGroup(scope, kind, namespace))
+	return n
+}
+
+// MarkSynced records that every resource of kind in namespace under scope has been stored.
+// DeleteNamespace and DeleteScope clear the mark.
+func (c *DiscoveredResourceCache) MarkSynced(scope DiscoveryScope, kind, namespace string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.synced[informerGroup(scope, kind, namespace)] = true
+}
+
+// Synced reports whether the resources of kind in namespace under scope have been listed in
+// full, so one missing from the cache no longer exists
+func (c *DiscoveredResourceCache) Synced(scope DiscoveryScope, kind, namespace string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.synced[informerGroup(scope, kind, namespace)]
+}
+
+func (c *DiscoveredResourceCache) deleteLocked(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+
+	nk := nameKey(entry.kind, entry.obj.GetNamespace(), entry.obj.GetName())
+	delete(c.byName[nk], key)
+	if len(c.byName[nk]) == 0 {
+		delete(c.byName, nk)
+	}
+	delete(c.byScope[entry.scope], key)
+	if len(c.byScope[entry.scope]) == 0 {
+		delete(c.byScope, entry.scope)
+	}
+}
+
+// Get returns a copy of the resource matching scope, kind, namespace and name.
+// Empty scope fields match any value; if several entries match, the most recently
+// stored one wins.
+func (c *DiscoveredResourceCache) Get(scope DiscoveryScope, kind, namespace, name string) (client.Object, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var found *discoveredEntry
+	for key := range c.byName[nameKey(kind, namespace, name)] {
+		entry := c.entries[key]
+		if !scope.matches(entry.scope) {
+			continue
+		}
+		if found == nil || entry.storedAt.After(found.storedAt) {
+			found = entry
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	return found.obj.DeepCopyObject().(client.Object), true
+}
+
+// List returns copies of all resources of kind stored under scope.
+// Empty scope fields match any value; an empty kind matches every kind.
+func (c *DiscoveredResourceCache) List(scope DiscoveryScope, kind string) []client.Object {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var objs []client.Object
+	for s, keys := range c.byScope {
+		if !scope.matches(s) {
+			continue
+		}
+		for key := range keys {
+			entry := c.entries[key]
+			if kind != "" && entry.kind != kind {
+				continue
+			}
+			objs = append(objs, entry.obj.DeepCopyObject().(client.Object))
+		}
+	}
+	return objs
+}
+
+// Count returns the number of resources per kind stored under scope.
+// Empty scope fields match any value.
+func (c *DiscoveredResourceCache) Count(scope DiscoveryScope) map[string]int {
+	counts := make(map[string]int)
+	if c == nil {
+		return counts
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for s, keys := range c.byScope {
+		if !scope.matches(s) {
+			continue
+		}
+		for key := range keys {
+			counts[c.entries[key].kind]++
+		}
+	}
+	return counts
+}
+
+// Stats returns entry counts per kind and the age of the oldest entry
+func (c *DiscoveredResourceCache) Stats() DiscoveredCacheStats {
+	stats := DiscoveredCacheStats{Entries: make(map[string]int)}
+	if c == nil {
+		return stats
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.now()
+	for _, entry := range c.entries {
+		stats.Entries[entry.kind]++
+		if age := now.Sub(entry.storedAt); age > stats.OldestEntryAge {
+			stats.OldestEntryAge = age
+		}
+	}
+	return stats
+}
+
+// Describe implements prometheus.Collector
+func (c *DiscoveredResourceCache) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entriesDesc
+	ch <- c.ageDesc
+}
+
+// Collect implements prometheus.Collector. Sizes and ages are computed at scrape time.
+func (c *DiscoveredResourceCache) Collect(ch chan<- prometheus.Metric) {
+	type group struct {
+		scope DiscoveryScope
+		kind  string
+	}
+	counts := make(map[group]int)
+	oldest := make(map[group]time.Duration)
+
+	c.mu.RLock()
+	now := c.now()
+	for _, entry := range c.entries {
+		g := group{scope: entry.scope, kind: entry.kind}
+		counts[g]++
+		if age := now.Sub(entry.storedAt); age > oldest[g] {
+			oldest[g] = age
+		}
+	}
+	c.mu.RUnlock()
+
+	for g, n := range counts {
+		labels := []string{g.scope.Config, g.scope.Environment, g.scope.Cluster, g.kind}
+		ch <- prometheus.MustNewConstMetric(c.entriesDesc, prometheus.GaugeValue, float64(n), labels...)
+		ch <- prometheus.MustNewConstMetric(c.ageDesc, prometheus.GaugeValue, oldest[g].Seconds(), labels...)
+	}
+}
+
+// notFoundInCache returns a NotFound error for a resource missing from the discovery cache
+func notFoundInCache(group, resource string, scope DiscoveryScope, namespace, name string) error {
+	return apierrors.NewNotFound(
+		schema.GroupResource{Group: group, Resource: resource},
+		fmt.Sprintf("%s/%s (scope %s)", namespace, name, strings.Trim(scope.String(), "/")),
+	)
+}
+
+// GetMCPServer retrieves an MCPServer from the discovered cache.
+func (c *DiscoveredResourceCache) GetMCPServer(scope DiscoveryScope, namespace, name string) (*kmcpv1alpha1.MCPServer, error) {
+	if obj, ok := c.Get(scope, "MCPServer", namespace, name); ok {
+		return obj.(*kmcpv1alpha1.MCPServer), nil
+	}
+	return nil, notFoundInCache("kagent.dev", "mcpservers", scope, namespace, name)
+}
+
+// GetAgent retrieves an Agent from the discovered cache.
+func (c *DiscoveredResourceCache) GetAgent(scope DiscoveryScope, namespace, name string) (*kagentv1alpha2.Agent, error) {
+	if obj, ok := c.Get(scope, "Agent", namespace, name); ok {
+		return obj.(*kagentv1alpha2.Agent), nil
+	}
+	return nil, notFoundInCache("kagent.dev", "agents", scope, namespace, name)
+}
+
+// GetModelConfig retrieves a ModelConfig from the discovered cache.
+func (c *DiscoveredResourceCache) GetModelConfig(scope DiscoveryScope, namespace, name string) (*kagentv1alpha2.ModelConfig, error) {
+	if obj, ok := c.Get(scope, "ModelConfig", namespace, name); ok {
+		return obj.(*kagentv1alpha2.ModelConfig), nil
+	}
+	return nil, notFoundInCache("kagent.dev", "modelconfigs", scope, namespace, name)
+}
+
+// GetRemoteMCPServer retrieves a RemoteMCPServer from the discovered cache.
+func (c *DiscoveredResourceCache) GetRemoteMCPServer(scope DiscoveryScope, namespace, name string) (*kagentv1alpha2.RemoteMCPServer, error) {
+	if obj, ok := c.Get(scope, "RemoteMCPServer", namespace, name); ok {
+		return obj.(*kagentv1alpha2.RemoteMCPServer), nil
+	}
+	return nil, notFoundInCache("kagent.dev", "remotemcpservers", scope, namespace, name)
+}
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+func newCachedMCPServer(namespace, name, image string) *kmcpv1alpha1.MCPServer {
+	return &kmcpv1alpha1.MCPServer{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: kmcpv1alpha1.MCPServerSpec{
+			Deployment: kmcpv1alpha1.MCPServerDeployment{Image: image},
+		},
+	}
+}
+
+func TestDiscoveredResourceCache_ScopesDoNotCollide(t *testing.T) {
+	t.Parallel()
+	c := NewDiscoveredResourceCache()
+
+	dev := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev-cluster"}
+	prod := DiscoveryScope{Config: "discovery", Environment: "prod", Cluster: "prod-cluster"}
+
+	c.Set(dev, "MCPServer", newCachedMCPServer("default", "fetch", "fetch:dev"))
+	c.Set(prod, "MCPServer", newCachedMCPServer("default", "fetch", "fetch:prod"))
+
+	server, err := c.GetMCPServer(dev, "default", "fetch")
+	require.NoError(t, err)
+	assert.Equal(t, "fetch:dev", server.Spec.Deployment.Image)
+
+	server, err = c.GetMCPServer(prod, "default", "fetch")
+	require.NoError(t, err)
+	assert.Equal(t, "fetch:prod", server.Spec.Deployment.Image)
+
+	// Deleting in one scope leaves the other intact
+	c.Delete(dev, "MCPServer", "default", "fetch")
+	_, err = c.GetMCPServer(dev, "default", "fetch")
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = c.GetMCPServer(prod, "default", "fetch")
+	assert.NoError(t, err)
+}
+
+func TestDiscoveredResourceCache_PartialScopeLookup(t *testing.T) {
+	t.Parallel()
+	c := NewDiscoveredResourceCache()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.Set(DiscoveryScope{Config: "a", Environment: "dev", Cluster: "c1"}, "MCPServer", newCachedMCPServer("default", "fetch", "older"))
+	now = now.Add(time.Second)
+	c.Set(DiscoveryScope{Config: "b", Environment: "dev", Cluster: "c2"}, "MCPServer", newCachedMCPServer("default", "fetch", "newer"))
+
+	// Entries without a config label still resolve within their environment/cluster
+	server, err := c.GetMCPServer(DiscoveryScope{Environment: "dev", Cluster: "c1"}, "default", "fetch")
+	require.NoError(t, err)
+	assert.Equal(t, "older", server.Spec.Deployment.Image)
+
+	// An empty scope matches everything and prefers the most recent entry
+	server, err = c.GetMCPServer(DiscoveryScope{}, "default", "fetch")
+	require.NoError(t, err)
+	assert.Equal(t, "newer", server.Spec.Deployment.Image)
+
+	// Kinds are separate namespaces
+	_, err = c.GetAgent(DiscoveryScope{}, "default", "fetch")
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestDiscoveredResourceCache_ReturnsCopies(t *testing.T) {
+	t.Parallel()
+	c := NewDiscoveredResourceCache()
+	scope := DiscoveryScope{Environment: "dev"}
+
+	original := newCachedMCPServer("default", "fetch", "v1")
+	c.Set(scope, "MCPServer", original)
+	original.Spec.Deployment.Image = "mutated"
+
+	server, err := c.GetMCPServer(scope, "default", "fetch")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", server.Spec.Deployment.Image)
+
+	server.Spec.Deployment.Image = "mutated"
+	server, err = c.GetMCPServer(scope, "default", "fetch")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", server.Spec.Deployment.Image)
+}
+
+func TestDiscoveredResourceCache_DeleteScopeAndList(t *testing.T) {
+	t.Parallel()
+	c := NewDiscoveredResourceCache()
+
+	dev := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev"}
+	prod := DiscoveryScope{Config: "discovery", Environment: "prod", Cluster: "prod"}
+
+	c.Set(dev, "MCPServer", newCachedMCPServer("default", "a", ""))
+	c.Set(dev, "Agent", &kagentv1alpha2.Agent{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}})
+	c.Set(prod, "MCPServer", newCachedMCPServer("default", "a", ""))
+
+	assert.Len(t, c.List(dev, ""), 2)
+	assert.Len(t, c.List(DiscoveryScope{Config: "discovery"}, "MCPServer"), 2)
+
+	assert.Equal(t, 2, c.DeleteScope(dev))
+	assert.Empty(t, c.List(dev, ""))
+	assert.Len(t, c.List(DiscoveryScope{}, ""), 1)
+	assert.Equal(t, 0, c.DeleteScope(dev))
+}
+
+func TestDiscoveredResourceCache_Synced(t *testing.T) {
+	t.Parallel()
+	c := NewDiscoveredResourceCache()
+
+	dev := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev"}
+	prod := DiscoveryScope{Config: "discovery", Environment: "prod", Cluster: "prod"}
+
+	assert.False(t, c.Synced(dev, "MCPServer", "default"))
+	c.MarkSynced(dev, "MCPServer", "default")
+	c.MarkSynced(dev, "Agent", "default")
+	c.MarkSynced(prod, "MCPServer", "default")
+	assert.True(t, c.Synced(dev, "MCPServer", "default"))
+	assert.False(t, c.Synced(dev, "MCPServer", "other"))
+
+	// A stopped informer no longer vouches for its group
+	c.DeleteNamespace(dev, "MCPServer", "default")
+	assert.False(t, c.Synced(dev, "MCPServer", "default"))
+	assert.True(t, c.Synced(dev, "Agent", "default"))
+
+	c.DeleteScope(dev)
+	assert.False(t, c.Synced(dev, "Agent", "default"))
+	assert.True(t, c.Synced(prod, "MCPServer", "default"))
+}
+
+func TestDiscoveredResourceCache_StatsAndMetrics(t *testing.T) {
+	t.Parallel()
+	c := NewDiscoveredResourceCache()
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev"}
+	c.Set(scope, "MCPServer", newCachedMCPServer("default", "a", ""))
+	c.Set(scope, "MCPServer", newCachedMCPServer("default", "b", ""))
+	now = now.Add(90 * time.Second)
+
+	stats := c.Stats()
+	assert.Equal(t, 2, stats.Entries["MCPServer"])
+	assert.Equal(t, 90*time.Second, stats.OldestEntryAge)
+
+	expected := `
+# HELP agentregistry_discovered_cache_entries Number of discovered resources held in the discovery cache
+# TYPE agentregistry_discovered_cache_entries gauge
+agentregistry_discovered_cache_entries{cluster="dev",config="discovery",environment="dev",kind="MCPServer"} 2
+`
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected), "agentregistry_discovered_cache_entries"))
+}
+
+func TestDiscoveredResourceCache_NilIsEmpty(t *testing.T) {
+	t.Parallel()
+	var c *DiscoveredResourceCache
+
+	_, err := c.GetMCPServer(DiscoveryScope{}, "default", "fetch")
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.Empty(t, c.List(DiscoveryScope{}, ""))
+	assert.Empty(t, c.Stats().Entries)
+	assert.False(t, c.Synced(DiscoveryScope{}, "MCPServer", "default"))
+}
+
+func TestScopeFromLabels(t *testing.T) {
+	scope := scopeFromLabels(map[string]string{
+		discoveryConfigLabel: "discovery",
+		envLabel:             "dev",
+		clusterLabel:         "dev-cluster",
+	})
+	assert.Equal(t, DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev-cluster"}, scope)
+	assert.Equal(t, DiscoveryScope{}, scopeFromLabels(nil))
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,42 +29,45 @@ func TestGenerateCatalogName(t *testing.T) {
 	}
 }
 
-func TestGenerateAgentCatalogName(t *testing.T) {
-	tests := []struct {
-		namespace string
-		name      string
-		expected  string
-	}{
-		{"kagent", "helm-agent", "kagent-helm-agent"},
-		{"default", "my-agent", "default-my-agent"},
-	}
+func TestGenerateDiscoveredCatalogName(t *testing.T) {
+	prod := generateDiscoveredCatalogName("Agent", "prod", "gke-prod", "ops", "triage")
+	staging := generateDiscoveredCatalogName("Agent", "staging", "gke-staging", "ops", "triage")
 
-	for _, tt := range tests {
-		t.Run(tt.namespace+"/"+tt.name, func(t *testing.T) {
-			result := generateAgentCatalogName(tt.namespace, tt.name)
-			assert.Equal(t, tt.expected, result)
-			assert.LessOrEqual(t, len(result), 63)
-		})
-	}
+	assert.True(t, strings.HasPrefix(prod, "prod-ops-triage-"), prod)
+	assert.True(t, strings.HasPrefix(staging, "staging-ops-triage-"), staging)
+	assert.NotEqual(t, prod, staging, "same namespace/name in different environments must not collide")
+
+	// Stable across calls
+	assert.Equal(t, prod, generateDiscoveredCatalogName("Agent", "prod", "gke-prod", "ops", "triage"))
+
+	// Same environment name on a different cluster is a different entry
+	assert.NotEqual(t, prod, generateDiscoveredCatalogName("Agent", "prod", "gke-prod-eu", "ops", "triage"))
+
+	// MCPServer and RemoteMCPServer with the same name share the MCPServerCatalog kind
+	assert.NotEqual(t,
+		generateDiscoveredCatalogName("MCPServer", "prod", "gke-prod", "ops", "github"),
+		generateDiscoveredCatalogName("RemoteMCPServer", "prod", "gke-prod", "ops", "github"),
+	)
+
+	// Truncation keeps the hash suffix so long names stay unique
+	longA := generateDiscoveredCatalogName("MCPServer", "production", "c1", "team-platform-observability", "very-long-mcp-server-name-a")
+	longB := generateDiscoveredCatalogName("MCPServer", "production", "c1", "team-platform-observability", "very-long-mcp-server-name-b")
+	assert.LessOrEqual(t, len(longA), 63)
+	assert.LessOrEqual(t, len(longB), 63)
+	assert.NotEqual(t, longA, longB)
+	assert.False(t, strings.Contains(longA, "--"), longA)
 }
 
-func TestGenerateModelCatalogName(t *testing.T) {
-	tests := []struct {
-		namespace string
-		name      string
-		expected  string
-	}{
-		{"kagent", "default-model-config", "kagent-default-model-config"},
-		{"default", "openai-config", "default-openai-config"},
-	}
+func TestSameDiscoveryScope(t *testing.T) {
+	prod := map[string]string{envLabel: "prod", clusterLabel: "gke-prod"}
+	prodOther := map[string]string{envLabel: "prod", clusterLabel: "gke-prod-eu"}
+	staging := map[string]string{envLabel: "staging", clusterLabel: "gke-staging"}
 
-	for _, tt := range tests {
-		t.Run(tt.namespace+"/"+tt.name, func(t *testing.T) {
-			result := generateModelCatalogName(tt.namespace, tt.name)
-			assert.Equal(t, tt.expected, result)
-			assert.LessOrEqual(t, len(result), 63)
-		})
-	}
+	assert.True(t, sameDiscoveryScope(prod, prod))
+	assert.False(t, sameDiscoveryScope(prod, staging))
+	assert.False(t, sameDiscoveryScope(prod, prodOther))
+	assert.True(t, sameDiscoveryScope(prod, nil), "manual entries are in scope of every environment")
+	assert.True(t, sameDiscoveryScope(nil, staging))
 }
 
 func TestParseSkillRef(t *testing.T) {
@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// defaultPreviewConfigName names the DiscoveryConfig a preview runs as when no name is given
+const defaultPreviewConfigName = "preview"
+
+// PreviewEntry is a catalog entry a DiscoveryConfig would create, update or prune
+type PreviewEntry struct {
+	Kind            string `json:"kind" doc:"Catalog kind" example:"MCPServerCatalog"`
+	Name            string `json:"name" doc:"Catalog entry name"`
+	Environment     string `json:"environment" doc:"Environment the source was found in"`
+	Cluster         string `json:"cluster" doc:"Cluster the source was found in"`
+	SourceKind      string `json:"sourceKind" doc:"Resource type of the source" example:"MCPServer"`
+	SourceNamespace string `json:"sourceNamespace" doc:"Namespace of the source"`
+	SourceName      string `json:"sourceName,omitempty" doc:"Name of the source"`
+}
+
+// EnvironmentPreview reports whether an environment could be reached and listed
+type EnvironmentPreview struct {
+	Name      string   `json:"name" doc:"Environment name"`
+	Cluster   string   `json:"cluster" doc:"Cluster name"`
+	Connected bool     `json:"connected" doc:"Whether a client for the cluster could be created and namespaces listed"`
+	Errors    []string `json:"errors,omitempty" doc:"Connection, permission and listing errors"`
+}
+
+// DiscoveryPreview is the result of a discovery dry run
+type DiscoveryPreview struct {
+	Create       []PreviewEntry       `json:"create" doc:"Catalog entries that would be created"`
+	Update       []PreviewEntry       `json:"update" doc:"Existing catalog entries that would be updated"`
+	Prune        []PreviewEntry       `json:"prune" doc:"Catalog entries of the named DiscoveryConfig that would be removed"`
+	Environments []EnvironmentPreview `json:"environments" doc:"Per-environment connection results"`
+}
+
+// DiscoveryPreviewer runs a DiscoveryConfig spec once against its clusters without writing
+// anything, so a spec can be checked before it is applied
+type DiscoveryPreviewer struct {
+	reconciler *DiscoveryConfigReconciler
+}
+
+// NewDiscoveryPreviewer creates a DiscoveryPreviewer reading the catalog with c
+func NewDiscoveryPreviewer(c client.Client, scheme *runtime.Scheme, logger zerolog.Logger) *DiscoveryPreviewer {
+	return &DiscoveryPreviewer{reconciler: &DiscoveryConfigReconciler{
+		Client:          c,
+		Scheme:          scheme,
+		Logger:          logger,
+		DiscoveredCache: NewDiscoveredResourceCache(),
+	}}
+}
+
+// Preview lists the resources spec selects in each environment and returns the catalog entries
+// discovery would create or update. When name is an existing DiscoveryConfig, its entries the
+// spec would remove are returned as well: entries whose source is gone from a group that was
+// listed, and, with prune enabled, entries of groups the spec no longer selects. Environments
+// that cannot be reached or listed are reported and never cause entries to be pruned.
+func (p *DiscoveryPreviewer) Preview(ctx context.Context, name string, spec *agentregistryv1alpha1.DiscoveryConfigSpec) (*DiscoveryPreview, error) {
+	if len(spec.Environments) == 0 {
+		return nil, apierrors.NewBadRequest("at least one environment is required")
+	}
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: config.GetNamespace()},
+		Spec:       *spec,
+	}
+	if dc.Name == "" {
+		dc.Name = defaultPreviewConfigName
+	}
+
+	existing := make(map[string]discoveredEntryRef)
+	if name != "" {
+		var err error
+		if existing, err = p.reconciler.listDiscoveredEntries(ctx, name); err != nil {
+			return nil, fmt.Errorf("list catalog entries: %w", err)
+		}
+	}
+	catalog, err := p.catalogNames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list catalog entries: %w", err)
+	}
+
+	preview := &DiscoveryPreview{
+		Create:       []PreviewEntry{},
+		Update:       []PreviewEntry{},
+		Prune:        []PreviewEntry{},
+		Environments: make([]EnvironmentPreview, len(dc.Spec.Environments)),
+	}
+	remoteClients := make(map[string]client.Client)
+	observed := make(map[string][]string)
+	envPreviews := make(map[string]*EnvironmentPreview)
+	for i := range dc.Spec.Environments {
+		env := &dc.Spec.Environments[i]
+		ep := &preview.Environments[i]
+		ep.Name, ep.Cluster = env.Name, env.Cluster.Name
+		envPreviews[env.Name] = ep
+
+		if err := validateNamespaceScoping(env); err != nil {
+			ep.Errors = append(ep.Errors, err.Error())
+			continue
+		}
+		if err := cluster.ValidateEnvironment(env); err != nil {
+			ep.Errors = append(ep.Errors, err.Error())
+			continue
+		}
+		scope := DiscoveryScope{Config: dc.Name, Environment: env.Name, Cluster: env.Cluster.Name}
+		remoteClient, err := p.reconciler.getRemoteClient(scope, env)
+		if err != nil {
+			ep.Errors = append(ep.Errors, fmt.Sprintf("failed to create remote client: %v", err))
+			continue
+		}
+		if watchesNamespaces(env) {
+			var namespaces corev1.NamespaceList
+			if err := remoteClient.List(ctx, &namespaces); err != nil {
+				ep.Errors = append(ep.Errors, fmt.Sprintf("list namespaces: %v", err))
+				continue
+			}
+			for _, ns := range namespaces.Items {
+				observed[env.Name] = append(observed[env.Name], ns.Name)
+			}
+		}
+		ep.Connected = true
+		remoteClients[env.Name] = remoteClient
+	}
+
+	seen := make(map[string]bool)
+	listed := make(map[string]bool)
+	desiredGroups := make(map[string]bool)
+	for _, d := range desiredInformersFor(dc, func(envName string) []string { return observed[envName] }) {
+		if d.resourceType == namespaceResourceType {
+			continue
+		}
+		group := informerGroup(d.scope, d.resourceType, d.namespace)
+		desiredGroups[group] = true
+		remoteClient, ok := remoteClients[d.env.Name]
+		if !ok {
+			continue
+		}
+		resources, err := p.reconciler.listSources(ctx, remoteClient, d)
+		if err != nil {
+			ep := envPreviews[d.env.Name]
+			ep.Errors = append(ep.Errors, fmt.Sprintf("list %s in namespace %s: %v", d.resourceType, d.namespace, err))
+			continue
+		}
+		listed[group] = true
+
+		kind := previewCatalogKind(d)
+		for _, res := range resources {
+			if res.catalogName == "" || seen[res.catalogName] {
+				continue
+			}
+			seen[res.catalogName] = true
+			entry := PreviewEntry{
+				Kind:            kind,
+				Name:            res.catalogName,
+				Environment:     d.env.Name,
+				Cluster:         d.env.Cluster.Name,
+				SourceKind:      d.resourceType,
+				SourceNamespace: res.obj.GetNamespace(),
+				SourceName:      res.obj.GetName(),
+			}
+			if catalog[kind+"/"+res.catalogName] {
+				preview.Update = append(preview.Update, entry)
+			} else {
+				preview.Create = append(preview.Create, entry)
+			}
+		}
+	}
+
+	for entryName, entry := range existing {
+		if seen[entryName] {
+			continue
+		}
+		labels := entry.obj.GetLabels()
+		if ep, ok := envPreviews[labels[envLabel]]; ok && !ep.Connected {
+			continue
+		}
+		if !listed[entry.group] && (desiredGroups[entry.group] || !spec.Prune) {
+			continue
+		}
+		preview.Prune = append(preview.Prune, PreviewEntry{
+			Kind:            catalogKindOf(entry.obj),
+			Name:            entryName,
+			Environment:     labels[envLabel],
+			Cluster:         labels[clusterLabel],
+			SourceKind:      labels[sourceKindLabel],
+			SourceNamespace: labels[sourceNSLabel],
+			SourceName:      labels[sourceNameLabel],
+		})
+	}
+
+	for _, entries := range [][]PreviewEntry{preview.Create, preview.Update, preview.Prune} {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Kind != entries[j].Kind {
+				return entries[i].Kind < entries[j].Kind
+			}
+			return entries[i].Name < entries[j].Name
+		})
+	}
+	return preview, nil
+}
+
+// catalogNames returns the kind/name of every catalog entry in the registry namespace
+func (p *DiscoveryPreviewer) catalogNames(ctx context.Context) (map[string]bool, error) {
+	names := make(map[string]bool)
+	lists := map[string]client.ObjectList{
+		"MCPServerCatalog": &agentregistryv1alpha1.MCPServerCatalogList{},
+		"AgentCatalog":     &agentregistryv1alpha1.AgentCatalogList{},
+		"ModelCatalog":     &agentregistryv1alpha1.ModelCatalogList{},
+	}
+	for kind, list := range lists {
+		if err := p.reconciler.List(ctx, list, client.InNamespace(config.GetNamespace())); err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if obj, ok := item.(client.Object); ok {
+				names[kind+"/"+obj.GetName()] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+// previewCatalogKind returns the catalog kind the resources of an informer map into
+func previewCatalogKind(d desiredInformer) string {
+	resourceType := d.resourceType
+	if d.mapping != nil {
+		resourceType = mappedResourceType(d.mapping)
+	}
+	switch resourceType {
+	case "Agent":
+		return "AgentCatalog"
+	case "ModelConfig", vllmResourceType:
+		return "ModelCatalog"
+	default:
+		return "MCPServerCatalog"
+	}
+}
+
+// catalogKindOf returns the kind of a catalog entry, which typed lists leave out of TypeMeta
+func catalogKindOf(obj client.Object) string {
+	switch obj.(type) {
+	case *agentregistryv1alpha1.AgentCatalog:
+		return "AgentCatalog"
+	case *agentregistryv1alpha1.ModelCatalog:
+		return "ModelCatalog"
+	default:
+		return "MCPServerCatalog"
+	}
+}
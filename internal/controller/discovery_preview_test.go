@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+func TestDiscoveryPreviewer_Preview(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kagentv1alpha2.AddToScheme(scheme))
+
+	discoveredCatalog := func(env, cluster, sourceKind, sourceName string) client.Object {
+		meta := metav1.ObjectMeta{
+			Name:      generateDiscoveredCatalogName(sourceKind, env, cluster, "tools", sourceName),
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				discoveryLabel:       "true",
+				discoveryConfigLabel: "discovery",
+				envLabel:             env,
+				clusterLabel:         cluster,
+				sourceKindLabel:      sourceKind,
+				sourceNameLabel:      sourceName,
+				sourceNSLabel:        "tools",
+			},
+		}
+		if sourceKind == "Agent" {
+			return &agentregistryv1alpha1.AgentCatalog{ObjectMeta: meta}
+		}
+		return &agentregistryv1alpha1.MCPServerCatalog{ObjectMeta: meta}
+	}
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			discoveredCatalog("dev", "dev-cluster", "MCPServer", "existing"),
+			discoveredCatalog("dev", "dev-cluster", "MCPServer", "gone"),
+			discoveredCatalog("dev", "dev-cluster", "Agent", "assistant"),
+			discoveredCatalog("prod", "prod-cluster", "MCPServer", "existing"),
+		).
+		Build()
+
+	remote := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "tools"}},
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "tools"}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				if _, ok := list.(*kagentv1alpha2.AgentList); ok {
+					return apierrors.NewForbidden(schema.GroupResource{Group: "kagent.dev", Resource: "agents"}, "", errors.New("RBAC denied"))
+				}
+				return c.List(ctx, list, opts...)
+			},
+		}).
+		Build()
+
+	oldFactory := RemoteClientFactory
+	RemoteClientFactory = func(_ DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+		if env.Name == "prod" {
+			return nil, errors.New("connection refused")
+		}
+		return &testClientWithWatch{Client: remote}, nil
+	}
+	defer func() { RemoteClientFactory = oldFactory }()
+
+	spec := &agentregistryv1alpha1.DiscoveryConfigSpec{
+		Environments: []agentregistryv1alpha1.Environment{
+			{
+				Name:          "dev",
+				Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "dev-cluster"},
+				Namespaces:    []string{"tools"},
+				ResourceTypes: []string{"MCPServer", "Agent"},
+			},
+			{
+				Name:          "prod",
+				Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "prod-cluster"},
+				Namespaces:    []string{"tools"},
+				ResourceTypes: []string{"MCPServer"},
+			},
+		},
+	}
+	previewer := NewDiscoveryPreviewer(local, scheme, zerolog.Nop())
+	ctx := context.Background()
+	names := func(entries []PreviewEntry) []string {
+		out := make([]string, 0, len(entries))
+		for _, e := range entries {
+			out = append(out, e.Name)
+		}
+		return out
+	}
+
+	preview, err := previewer.Preview(ctx, "discovery", spec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{generateDiscoveredCatalogName("MCPServer", "dev", "dev-cluster", "tools", "new")}, names(preview.Create))
+	assert.Equal(t, []string{generateDiscoveredCatalogName("MCPServer", "dev", "dev-cluster", "tools", "existing")}, names(preview.Update))
+	require.Len(t, preview.Prune, 1, "entries of unreachable environments and unlisted groups are kept")
+	assert.Equal(t, PreviewEntry{
+		Kind:            "MCPServerCatalog",
+		Name:            generateDiscoveredCatalogName("MCPServer", "dev", "dev-cluster", "tools", "gone"),
+		Environment:     "dev",
+		Cluster:         "dev-cluster",
+		SourceKind:      "MCPServer",
+		SourceNamespace: "tools",
+		SourceName:      "gone",
+	}, preview.Prune[0])
+
+	require.Len(t, preview.Environments, 2)
+	assert.True(t, preview.Environments[0].Connected)
+	require.Len(t, preview.Environments[0].Errors, 1)
+	assert.Contains(t, preview.Environments[0].Errors[0], "forbidden")
+	assert.False(t, preview.Environments[1].Connected)
+	require.Len(t, preview.Environments[1].Errors, 1)
+	assert.Contains(t, preview.Environments[1].Errors[0], "connection refused")
+
+	var catalogs agentregistryv1alpha1.MCPServerCatalogList
+	require.NoError(t, local.List(ctx, &catalogs))
+	assert.Len(t, catalogs.Items, 3, "nothing is written")
+
+	t.Run("without a name nothing is pruned", func(t *testing.T) {
+		preview, err := previewer.Preview(ctx, "", spec)
+		require.NoError(t, err)
+		assert.Empty(t, preview.Prune)
+		assert.Len(t, preview.Create, 1)
+	})
+
+	t.Run("pruning groups the spec drops", func(t *testing.T) {
+		dropped := spec.DeepCopy()
+		dropped.Environments[0].ResourceTypes = []string{"MCPServer"}
+		preview, err := previewer.Preview(ctx, "discovery", dropped)
+		require.NoError(t, err)
+		assert.Len(t, preview.Prune, 1, "without prune, entries of dropped groups are kept")
+
+		dropped.Prune = true
+		preview, err = previewer.Preview(ctx, "discovery", dropped)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{
+			generateDiscoveredCatalogName("Agent", "dev", "dev-cluster", "tools", "assistant"),
+			generateDiscoveredCatalogName("MCPServer", "dev", "dev-cluster", "tools", "gone"),
+		}, names(preview.Prune))
+	})
+
+	t.Run("a spec without environments is rejected", func(t *testing.T) {
+		_, err := previewer.Preview(ctx, "", &agentregistryv1alpha1.DiscoveryConfigSpec{})
+		assert.True(t, apierrors.IsBadRequest(err))
+	})
+}
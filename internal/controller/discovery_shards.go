@@ -0,0 +1,293 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+const (
+	// discoveryShardLabel marks the Leases replicas renew to take part in discovery
+	discoveryShardLabel = "agentregistry.dev/discovery-shard"
+	// discoveryShardLeasePrefix prefixes the Lease name of each replica
+	discoveryShardLeasePrefix = "agentregistry-discovery-"
+
+	// DefaultShardLeaseDuration is how long a replica stays a member without renewing its Lease
+	DefaultShardLeaseDuration = 30 * time.Second
+	// DefaultShardRenewInterval is how often a replica renews its Lease and rereads the members
+	DefaultShardRenewInterval = 10 * time.Second
+)
+
+// DiscoveryShards partitions discovery across controller replicas. Every replica renews a
+// Lease in the controller namespace, and each environment is owned by one of the replicas with
+// a live Lease, chosen by rendezvous hashing of its scope. A replica joining or leaving only
+// moves the environments it gains or owned.
+//
+// Ownership can briefly overlap while replicas see a membership change at different times.
+// Catalog writes are optimistic-concurrency updates, and a replica handing off an environment
+// never prunes its entries, so the overlap only costs duplicate writes.
+type DiscoveryShards struct {
+	// Client writes the Lease of this replica and removes expired ones
+	Client client.Client
+	// Reader reads the Leases; use an uncached reader to avoid a Lease informer
+	Reader client.Reader
+	Logger zerolog.Logger
+
+	// Namespace holds the Leases
+	Namespace string
+	// Identity names this replica, usually the pod name
+	Identity string
+	// LeaseDuration and RenewInterval default to DefaultShardLeaseDuration and
+	// DefaultShardRenewInterval
+	LeaseDuration time.Duration
+	RenewInterval time.Duration
+
+	mu       sync.RWMutex
+	members  []string
+	onChange []func()
+
+	// lastRenew is when the Lease of this replica was last renewed; only the renew loop uses it
+	lastRenew time.Time
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// OnChange registers f to run after the members change. f runs on the renew loop and must
+// not block.
+func (s *DiscoveryShards) OnChange(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, f)
+}
+
+// Members returns the identities of the live replicas, sorted
+func (s *DiscoveryShards) Members() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.members)
+}
+
+// Owner returns the replica that discovers scope, or "" before the members are known
+func (s *DiscoveryShards) Owner(scope DiscoveryScope) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return rendezvousOwner(s.members, scope.String())
+}
+
+// Owns reports whether this replica discovers scope
+func (s *DiscoveryShards) Owns(scope DiscoveryScope) bool {
+	return s.Owner(scope) == s.Identity
+}
+
+// rendezvousOwner picks the member with the highest hash of member and key
+func rendezvousOwner(members []string, key string) string {
+	var owner string
+	var best uint64
+	for _, m := range members {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(m + "/" + key))
+		if sum := h.Sum64(); owner == "" || sum > best {
+			owner, best = m, sum
+		}
+	}
+	return owner
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica takes part.
+func (s *DiscoveryShards) NeedLeaderElection() bool {
+	return false
+}
+
+// Start renews the Lease of this replica until ctx is cancelled, then releases it so the
+// other replicas take over its environments without waiting for it to expire
+func (s *DiscoveryShards) Start(ctx context.Context) error {
+	interval := s.RenewInterval
+	if interval == 0 {
+		interval = DefaultShardRenewInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.sync(ctx); err != nil {
+			s.Logger.Error().Err(err).Msg("failed to renew discovery shard lease")
+		}
+		select {
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: s.leaseName(s.Identity), Namespace: s.Namespace}}
+			if err := s.Client.Delete(releaseCtx, lease); client.IgnoreNotFound(err) != nil {
+				s.Logger.Error().Err(err).Msg("failed to release discovery shard lease")
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+var _ manager.LeaderElectionRunnable = &DiscoveryShards{}
+
+func (s *DiscoveryShards) leaseName(identity string) string {
+	return discoveryShardLeasePrefix + identity
+}
+
+func (s *DiscoveryShards) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// sync renews the Lease of this replica, reads the live members and removes expired Leases.
+// A replica that cannot renew its Lease for a whole lease duration leaves the members it
+// computes, so it stops discovering rather than overlapping with the replicas that took over.
+func (s *DiscoveryShards) sync(ctx context.Context) error {
+	duration := s.LeaseDuration
+	if duration == 0 {
+		duration = DefaultShardLeaseDuration
+	}
+	now := s.clock()
+	renewErr := s.renew(ctx, now, duration)
+	if renewErr == nil {
+		s.lastRenew = now
+	}
+	live := !s.lastRenew.IsZero() && now.Sub(s.lastRenew) <= duration
+
+	var leases coordinationv1.LeaseList
+	if err := s.Reader.List(ctx, &leases, client.InNamespace(s.Namespace), client.MatchingLabels{discoveryShardLabel: "true"}); err != nil {
+		if !live {
+			s.setMembers(nil)
+		}
+		return fmt.Errorf("list discovery shard leases: %w", err)
+	}
+	var members []string
+	if live {
+		members = append(members, s.Identity)
+	}
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == s.Identity {
+			continue
+		}
+		identity := *lease.Spec.HolderIdentity
+		if leaseExpired(lease, now) {
+			// Best effort: any replica may clean up after one that died
+			if err := s.Client.Delete(ctx, lease); client.IgnoreNotFound(err) != nil {
+				s.Logger.Warn().Err(err).Str("replica", identity).Msg("failed to remove expired discovery shard lease")
+			}
+			continue
+		}
+		members = append(members, identity)
+	}
+	slices.Sort(members)
+	s.setMembers(members)
+	return renewErr
+}
+
+// renew creates or updates the Lease of this replica
+func (s *DiscoveryShards) renew(ctx context.Context, now time.Time, duration time.Duration) error {
+	renewTime := metav1.NewMicroTime(now)
+	seconds := int32(duration / time.Second)
+	var lease coordinationv1.Lease
+	err := s.Reader.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.leaseName(s.Identity)}, &lease)
+	if apierrors.IsNotFound(err) {
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.leaseName(s.Identity),
+				Namespace: s.Namespace,
+				Labels:    map[string]string{discoveryShardLabel: "true"},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &s.Identity,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &renewTime,
+				RenewTime:            &renewTime,
+			},
+		}
+		return s.Client.Create(ctx, &lease)
+	}
+	if err != nil {
+		return err
+	}
+	lease.Spec.HolderIdentity = &s.Identity
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.RenewTime = &renewTime
+	return s.Client.Update(ctx, &lease)
+}
+
+// leaseExpired reports whether a Lease was last renewed more than its duration before now
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.After(expiry)
+}
+
+// setMembers records members and notifies the OnChange callbacks if they changed
+func (s *DiscoveryShards) setMembers(members []string) {
+	s.mu.Lock()
+	if slices.Equal(s.members, members) {
+		s.mu.Unlock()
+		return
+	}
+	previous := s.members
+	s.members = members
+	callbacks := slices.Clone(s.onChange)
+	s.mu.Unlock()
+
+	s.Logger.Info().Strs("members", members).Strs("previous", previous).Msg("discovery shard members changed, rebalancing")
+	for _, f := range callbacks {
+		f()
+	}
+}
+
+// enqueueAllDiscoveryConfigs requests a reconcile of every DiscoveryConfig so each replica
+// starts and stops informers for the environments it gained or lost
+func (r *DiscoveryConfigReconciler) enqueueAllDiscoveryConfigs() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var list agentregistryv1alpha1.DiscoveryConfigList
+	if err := r.List(ctx, &list, client.InNamespace(config.GetNamespace())); err != nil {
+		r.Logger.Error().Err(err).Msg("failed to list discovery configs for rebalancing")
+		return
+	}
+	for i := range list.Items {
+		r.enqueueDiscoveryConfig(list.Items[i].Name)
+	}
+}
+
+// ownsCatalogEntry reports whether this replica reconciles a catalog entry. Sharded, catalog
+// reconcilers run on every replica: a discovered entry is reconciled by the replica that
+// discovers it, since only that replica's cache holds its source, and entries without a
+// discovery scope all go to one replica.
+func ownsCatalogEntry(shards *DiscoveryShards, obj client.Object) bool {
+	return shards == nil || shards.Owns(scopeFromLabels(obj.GetLabels()))
+}
+
+// everyReplica is a runnable that runs on every replica rather than only on the leader
+type everyReplica func(context.Context) error
+
+// Start implements manager.Runnable
+func (f everyReplica) Start(ctx context.Context) error {
+	return f(ctx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (everyReplica) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+func TestDiscoveryShards(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	ctx := context.Background()
+
+	now := time.Now()
+	replica := func(identity string) *DiscoveryShards {
+		return &DiscoveryShards{
+			Client:    c,
+			Reader:    c,
+			Logger:    zerolog.Nop(),
+			Namespace: testNamespace,
+			Identity:  identity,
+			now:       func() time.Time { return now },
+		}
+	}
+	a, b := replica("controller-a"), replica("controller-b")
+	changes := 0
+	a.OnChange(func() { changes++ })
+
+	scopes := make([]DiscoveryScope, 50)
+	for i := range scopes {
+		scopes[i] = DiscoveryScope{Config: "discovery", Environment: fmt.Sprintf("env-%d", i), Cluster: "cluster"}
+	}
+	owners := func() map[DiscoveryScope]string {
+		out := make(map[DiscoveryScope]string)
+		for _, scope := range scopes {
+			out[scope] = a.Owner(scope)
+			assert.Equal(t, out[scope], b.Owner(scope), "replicas agree on the owner of %s", scope)
+			assert.NotEqual(t, a.Owns(scope), b.Owns(scope), "exactly one replica owns %s", scope)
+		}
+		return out
+	}
+
+	assert.False(t, a.Owns(scopes[0]), "nothing is owned before the members are known")
+	require.NoError(t, a.sync(ctx))
+	require.NoError(t, b.sync(ctx))
+	require.NoError(t, a.sync(ctx))
+	assert.Equal(t, []string{"controller-a", "controller-b"}, a.Members())
+	assert.Equal(t, 2, changes)
+
+	before := owners()
+	counts := make(map[string]int)
+	for _, owner := range before {
+		counts[owner]++
+	}
+	assert.Positive(t, counts["controller-a"])
+	assert.Positive(t, counts["controller-b"])
+
+	t.Run("a joining replica only takes environments", func(t *testing.T) {
+		c3 := replica("controller-c")
+		require.NoError(t, c3.sync(ctx))
+		require.NoError(t, a.sync(ctx))
+		require.NoError(t, b.sync(ctx))
+		moved := 0
+		for _, scope := range scopes {
+			if owner := a.Owner(scope); owner != before[scope] {
+				assert.Equal(t, "controller-c", owner)
+				moved++
+			}
+		}
+		assert.Positive(t, moved)
+
+		// The replica shuts down and releases its Lease
+		require.NoError(t, c.Delete(ctx, &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{
+			Name: c3.leaseName("controller-c"), Namespace: testNamespace,
+		}}))
+		require.NoError(t, a.sync(ctx))
+		require.NoError(t, b.sync(ctx))
+		assert.Equal(t, before, owners(), "environments return to their previous owners")
+	})
+
+	t.Run("expired replicas are removed", func(t *testing.T) {
+		now = now.Add(DefaultShardLeaseDuration + time.Second)
+		require.NoError(t, a.sync(ctx))
+		assert.Equal(t, []string{"controller-a"}, a.Members())
+		for _, scope := range scopes {
+			assert.True(t, a.Owns(scope))
+		}
+
+		var leases coordinationv1.LeaseList
+		require.NoError(t, c.List(ctx, &leases, client.InNamespace(testNamespace)))
+		require.Len(t, leases.Items, 1, "the expired Lease is deleted")
+		assert.Equal(t, "controller-a", *leases.Items[0].Spec.HolderIdentity)
+	})
+}
+
+func TestEnvironmentStatuses_Sharded(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	shards := &DiscoveryShards{Client: c, Reader: c, Logger: zerolog.Nop(), Namespace: testNamespace, Identity: "controller-a"}
+	shards.setMembers([]string{"controller-a", "controller-b"})
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+	}
+	var remote []string
+	for i := 0; len(remote) < 2; i++ {
+		name := fmt.Sprintf("env-%d", i)
+		dc.Spec.Environments = append(dc.Spec.Environments, agentregistryv1alpha1.Environment{
+			Name:    name,
+			Cluster: agentregistryv1alpha1.ClusterConfig{Name: "local"},
+		})
+		if !shards.Owns(DiscoveryScope{Config: dc.Name, Environment: name, Cluster: "local"}) {
+			remote = append(remote, name)
+		}
+	}
+	written := agentregistryv1alpha1.EnvironmentStatus{Name: remote[0], Connected: true, Message: "4/4 informers synced"}
+	dc.Status.Environments = []agentregistryv1alpha1.EnvironmentStatus{written}
+
+	r := &DiscoveryConfigReconciler{Logger: zerolog.Nop(), Shards: shards, DiscoveredCache: NewDiscoveredResourceCache()}
+	statuses := make(map[string]agentregistryv1alpha1.EnvironmentStatus)
+	for _, es := range r.environmentStatuses(dc) {
+		statuses[es.Name] = es
+	}
+	require.Len(t, statuses, len(dc.Spec.Environments))
+	assert.Equal(t, written, statuses[remote[0]], "the owner's status is kept")
+	assert.Equal(t, "Discovered by replica controller-b", statuses[remote[1]].Message)
+}
+
+func TestDiscoveryConfigReconciler_InformersRunOnNonLeader(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+
+	// The API server is unreachable, so this replica never acquires the leader lease
+	mgr, err := manager.New(&rest.Config{Host: "https://127.0.0.1:1"}, manager.Options{
+		Scheme:                  scheme,
+		LeaderElection:          true,
+		LeaderElectionID:        "agentregistry-test",
+		LeaderElectionNamespace: testNamespace,
+		Metrics:                 metricsserver.Options{BindAddress: "0"},
+		HealthProbeBindAddress:  "0",
+	})
+	require.NoError(t, err)
+
+	remote := &testClientWithWatch{Client: fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "tools"}}).
+		Build()}
+	oldFactory := RemoteClientFactory
+	RemoteClientFactory = func(DiscoveryScope, *agentregistryv1alpha1.Environment, *runtime.Scheme) (client.WithWatch, error) {
+		return remote, nil
+	}
+	defer func() { RemoteClientFactory = oldFactory }()
+
+	shards := &DiscoveryShards{Logger: zerolog.Nop(), Namespace: testNamespace, Identity: "controller-a"}
+	shards.setMembers([]string{"controller-a"})
+	r := &DiscoveryConfigReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithStatusSubresource(&agentregistryv1alpha1.MCPServerCatalog{}).
+			Build(),
+		Scheme:          scheme,
+		Logger:          zerolog.Nop(),
+		Manager:         mgr,
+		Shards:          shards,
+		DiscoveredCache: NewDiscoveredResourceCache(),
+		informers:       make(map[string]cache.SharedIndexInformer),
+		stopChans:       make(map[string]chan struct{}),
+		informerState:   make(map[string]runningInformer),
+		errorTracker:    make(map[string]*informerError),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := &agentregistryv1alpha1.Environment{Name: "dev", Cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev-cluster"}}
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev-cluster"}
+	key := "discovery/dev/tools/MCPServer"
+	require.NoError(t, r.setupInformerForResource(ctx, env, runningInformer{
+		scope: scope, namespace: "tools", resourceType: "MCPServer",
+	}, key, zerolog.Nop()))
+
+	go func() { _ = mgr.Start(ctx) }()
+	require.Eventually(t, func() bool {
+		return r.DiscoveredCache.Synced(scope, "MCPServer", "tools")
+	}, 10*time.Second, 10*time.Millisecond, "the informer runs without the leader lease")
+	assert.Equal(t, 1, r.DiscoveredCache.Count(scope)["MCPServer"])
+
+	r.informersMu.Lock()
+	close(r.stopChans[key])
+	r.informersMu.Unlock()
+}
+
+func TestMCPServerCatalogReconciler_Sharded(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	replica := func(identity string) *DiscoveryShards {
+		shards := &DiscoveryShards{Logger: zerolog.Nop(), Namespace: testNamespace, Identity: identity}
+		shards.setMembers([]string{"controller-a", "controller-b"})
+		return shards
+	}
+	a, b := replica("controller-a"), replica("controller-b")
+	var scope DiscoveryScope
+	for i := 0; ; i++ {
+		scope = DiscoveryScope{Config: "discovery", Environment: fmt.Sprintf("env-%d", i), Cluster: "cluster"}
+		if b.Owns(scope) {
+			break
+		}
+	}
+
+	server := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "weather",
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				discoveryLabel:       "true",
+				discoveryConfigLabel: scope.Config,
+				envLabel:             scope.Environment,
+				clusterLabel:         scope.Cluster,
+			},
+		},
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+			Name:      "tools/weather",
+			Version:   "latest",
+			SourceRef: &agentregistryv1alpha1.SourceReference{Kind: "MCPServer", Name: "weather", Namespace: "tools"},
+		},
+		Status: agentregistryv1alpha1.MCPServerCatalogStatus{
+			ManagementType: agentregistryv1alpha1.ManagementTypeExternal,
+			IsLatest:       true,
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(server).
+		WithStatusSubresource(&agentregistryv1alpha1.MCPServerCatalog{}).
+		WithIndex(&agentregistryv1alpha1.MCPServerCatalog{}, IndexMCPServerName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.MCPServerCatalog).Spec.Name}
+		}).
+		Build()
+
+	// Only the owning replica has the source cached
+	ownerCache := NewDiscoveredResourceCache()
+	ownerCache.Set(scope, "MCPServer", &kmcpv1alpha1.MCPServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "weather", Namespace: "tools"},
+		Status: kmcpv1alpha1.MCPServerStatus{Conditions: []metav1.Condition{
+			{Type: "Ready", Status: metav1.ConditionTrue, Message: "serving"},
+		}},
+	})
+	reconciler := func(shards *DiscoveryShards, discovered *DiscoveredResourceCache) *MCPServerCatalogReconciler {
+		return &MCPServerCatalogReconciler{Client: c, Scheme: scheme, Logger: zerolog.Nop(), DiscoveredCache: discovered, Shards: shards}
+	}
+	ctx := context.Background()
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: server.Name, Namespace: testNamespace}}
+
+	result, err := reconciler(a, NewDiscoveredResourceCache()).Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, discoveredSourceCheckInterval, result.RequeueAfter, "checked again in case the entry moves")
+	var updated agentregistryv1alpha1.MCPServerCatalog
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &updated))
+	assert.Nil(t, updated.Status.Deployment, "another replica's entry is left alone")
+
+	_, err = reconciler(b, ownerCache).Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, &updated))
+	require.NotNil(t, updated.Status.Deployment)
+	assert.True(t, updated.Status.Deployment.Ready)
+	assert.Equal(t, "serving", updated.Status.Deployment.Message)
+}
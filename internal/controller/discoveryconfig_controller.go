@@ -2,22 +2,34 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
 	"github.com/agentregistry-dev/agentregistry/internal/config"
 	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
 	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
@@ -25,6 +37,7 @@ import (
 
 // informerError tracks errors from informer handlers for retry
 type informerError struct {
+	scope      DiscoveryScope
 	err        error
 	retryCount int
 	lastRetry  time.Time
@@ -38,144 +51,82 @@ type DiscoveryConfigReconciler struct {
 	Manager manager.Manager
 
 	// informers tracks active informers per environment/resourceType
-	informersMu sync.RWMutex
-	informers   map[string]cache.SharedIndexInformer
-	stopChans   map[string]chan struct{}
+	informersMu   sync.RWMutex
+	informers     map[string]cache.SharedIndexInformer
+	stopChans     map[string]chan struct{}
+	informerState map[string]runningInformer
 
 	// errorTracker tracks errors from informer handlers for retry
 	errorTrackerMu sync.RWMutex
 	errorTracker   map[string]*informerError
-}
 
-// RemoteClientFactory creates clients for remote clusters (injectable for testing)
-var RemoteClientFactory func(env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error)
-
-// DiscoveredResourceCache provides read access to resources from discovered namespaces.
-// Populated by DiscoveryConfig informers, used by catalog reconcilers for SourceRef lookups.
-type DiscoveredResourceCache struct {
-	mu               sync.RWMutex
-	mcpservers       map[string]*kmcpv1alpha1.MCPServer         // key: "namespace/name"
-	agents           map[string]*kagentv1alpha2.Agent           // key: "namespace/name"
-	modelconfigs     map[string]*kagentv1alpha2.ModelConfig     // key: "namespace/name"
-	remotemcpservers map[string]*kagentv1alpha2.RemoteMCPServer // key: "namespace/name"
-}
+	// health tracks sync state, last event and list/watch errors per informer for EnvironmentStatus
+	healthMu sync.Mutex
+	health   map[string]*informerHealth
 
-var discoveredCache = &DiscoveredResourceCache{
-	mcpservers:       make(map[string]*kmcpv1alpha1.MCPServer),
-	agents:           make(map[string]*kagentv1alpha2.Agent),
-	modelconfigs:     make(map[string]*kagentv1alpha2.ModelConfig),
-	remotemcpservers: make(map[string]*kagentv1alpha2.RemoteMCPServer),
-}
+	// requeue is the controller's workqueue, used to reconcile a DiscoveryConfig when namespaces
+	// matching its patterns change or replicas rebalance. Nil until the controller has started.
+	requeueMu sync.Mutex
+	requeue   workqueue.TypedRateLimitingInterface[reconcile.Request]
 
-// GetMCPServer retrieves an MCPServer from the discovered cache.
-func GetMCPServer(ctx context.Context, namespace, name string) (*kmcpv1alpha1.MCPServer, error) {
-	key := namespace + "/" + name
-	discoveredCache.mu.RLock()
-	defer discoveredCache.mu.RUnlock()
+	// credentialErrors holds the last credentials Secret error per config/environment
+	credentialsMu    sync.Mutex
+	credentialErrors map[string]error
 
-	if server, ok := discoveredCache.mcpservers[key]; ok {
-		return server.DeepCopy(), nil
-	}
-	return nil, fmt.Errorf("MCPServer %s/%s not found in discovery cache", namespace, name)
-}
+	// gitScans remembers the last scan per config/git source
+	gitScansMu sync.Mutex
+	gitScans   map[string]gitScan
 
-func setDiscoveredMCPServer(server *kmcpv1alpha1.MCPServer) {
-	key := server.Namespace + "/" + server.Name
-	discoveredCache.mu.Lock()
-	defer discoveredCache.mu.Unlock()
-	discoveredCache.mcpservers[key] = server.DeepCopy()
-}
-
-func deleteDiscoveredMCPServer(namespace, name string) {
-	key := namespace + "/" + name
-	discoveredCache.mu.Lock()
-	defer discoveredCache.mu.Unlock()
-	delete(discoveredCache.mcpservers, key)
-}
+	// DiscoveredCache receives every resource seen by the informers.
+	// Shared with the catalog reconcilers for SourceRef lookups.
+	DiscoveredCache *DiscoveredResourceCache
 
-// GetAgent retrieves an Agent from the discovered cache.
-func GetAgent(ctx context.Context, namespace, name string) (*kagentv1alpha2.Agent, error) {
-	key := namespace + "/" + name
-	discoveredCache.mu.RLock()
-	defer discoveredCache.mu.RUnlock()
+	// Connections reports the health of remote clusters for EnvironmentStatus (optional)
+	Connections *cluster.ConnectionManager
 
-	if agent, ok := discoveredCache.agents[key]; ok {
-		return agent.DeepCopy(), nil
-	}
-	return nil, fmt.Errorf("Agent %s/%s not found in discovery cache", namespace, name)
+	// Shards partitions environments across replicas (optional). When set, the reconciler runs
+	// on every replica and only starts informers for the environments this replica owns.
+	Shards *DiscoveryShards
 }
 
-func setDiscoveredAgent(agent *kagentv1alpha2.Agent) {
-	key := agent.Namespace + "/" + agent.Name
-	discoveredCache.mu.Lock()
-	defer discoveredCache.mu.Unlock()
-	discoveredCache.agents[key] = agent.DeepCopy()
+// runningInformer records what a running informer was started with, so spec changes can be
+// diffed against it
+type runningInformer struct {
+	scope        DiscoveryScope
+	namespace    string
+	resourceType string
+	configHash   string
+	prune        bool
+	// mapping is set for informers of custom resource mappings
+	mapping *agentregistryv1alpha1.CustomResourceMapping
 }
 
-func deleteDiscoveredAgent(namespace, name string) {
-	key := namespace + "/" + name
-	discoveredCache.mu.Lock()
-	defer discoveredCache.mu.Unlock()
-	delete(discoveredCache.agents, key)
+// desiredInformer is an informer the current DiscoveryConfig spec asks for
+type desiredInformer struct {
+	key          string
+	env          agentregistryv1alpha1.Environment
+	scope        DiscoveryScope
+	namespace    string
+	resourceType string
+	configHash   string
+	mapping      *agentregistryv1alpha1.CustomResourceMapping
 }
 
-// GetModelConfig retrieves a ModelConfig from the discovered cache.
-func GetModelConfig(ctx context.Context, namespace, name string) (*kagentv1alpha2.ModelConfig, error) {
-	key := namespace + "/" + name
-	discoveredCache.mu.RLock()
-	defer discoveredCache.mu.RUnlock()
-
-	if model, ok := discoveredCache.modelconfigs[key]; ok {
-		return model.DeepCopy(), nil
-	}
-	return nil, fmt.Errorf("ModelConfig %s/%s not found in discovery cache", namespace, name)
-}
-
-func setDiscoveredModelConfig(model *kagentv1alpha2.ModelConfig) {
-	key := model.Namespace + "/" + model.Name
-	discoveredCache.mu.Lock()
-	defer discoveredCache.mu.Unlock()
-	discoveredCache.modelconfigs[key] = model.DeepCopy()
-}
-
-func deleteDiscoveredModelConfig(namespace, name string) {
-	key := namespace + "/" + name
-	discoveredCache.mu.Lock()
-	defer discoveredCache.mu.Unlock()
-	delete(discoveredCache.modelconfigs, key)
-}
-
-// GetRemoteMCPServer retrieves a RemoteMCPServer from the discovered cache.
-func GetRemoteMCPServer(ctx context.Context, namespace, name string) (*kagentv1alpha2.RemoteMCPServer, error) {
-	key := namespace + "/" + name
-	discoveredCache.mu.RLock()
-	defer discoveredCache.mu.RUnlock()
-
-	if server, ok := discoveredCache.remotemcpservers[key]; ok {
-		return server.DeepCopy(), nil
-	}
-	return nil, fmt.Errorf("RemoteMCPServer %s/%s not found in discovery cache", namespace, name)
-}
-
-func setDiscoveredRemoteMCPServer(server *kagentv1alpha2.RemoteMCPServer) {
-	key := server.Namespace + "/" + server.Name
-	discoveredCache.mu.Lock()
-	defer discoveredCache.mu.Unlock()
-	discoveredCache.remotemcpservers[key] = server.DeepCopy()
-}
-
-func deleteDiscoveredRemoteMCPServer(namespace, name string) {
-	key := namespace + "/" + name
-	discoveredCache.mu.Lock()
-	defer discoveredCache.mu.Unlock()
-	delete(discoveredCache.remotemcpservers, key)
-}
+// RemoteClientFactory creates clients for remote clusters (injectable for testing)
+var RemoteClientFactory func(scope DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error)
 
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=discoveryconfigs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=discoveryconfigs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=mcpservercatalogs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=agentcatalogs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=modelcatalogs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=namespaces;services,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=serving.kserve.io,resources=inferenceservices;llminferenceservices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=agentgateway.dev,resources=agentgatewaybackends,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.kgateway.dev,resources=backends,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways;httproutes,verbs=get;list;watch
 
 // Reconcile sets up informers for each environment in the DiscoveryConfig
 func (r *DiscoveryConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -186,6 +137,7 @@ func (r *DiscoveryConfigReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		r.informersMu.Lock()
 		r.informers = make(map[string]cache.SharedIndexInformer)
 		r.stopChans = make(map[string]chan struct{})
+		r.informerState = make(map[string]runningInformer)
 		r.informersMu.Unlock()
 	}
 	if r.errorTracker == nil {
@@ -193,13 +145,27 @@ func (r *DiscoveryConfigReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		r.errorTracker = make(map[string]*informerError)
 		r.errorTrackerMu.Unlock()
 	}
+	if r.DiscoveredCache == nil {
+		r.DiscoveredCache = NewDiscoveredResourceCache()
+	}
+	if r.gitScans == nil {
+		r.gitScansMu.Lock()
+		r.gitScans = make(map[string]gitScan)
+		r.gitScansMu.Unlock()
+	}
 
 	// Fetch DiscoveryConfig
 	var config agentregistryv1alpha1.DiscoveryConfig
 	if err := r.Get(ctx, req.NamespacedName, &config); err != nil {
 		if apierrors.IsNotFound(err) {
-			logger.Info().Msg("DiscoveryConfig deleted, stopping all informers")
-			r.stopAllInformers()
+			logger.Info().Msg("DiscoveryConfig deleted, stopping its informers")
+			r.syncInformers(ctx, req.Name, nil, false, logger)
+			r.forgetCredentials(req.Name)
+			if r.forgetGitSources(req.Name, nil) {
+				if _, err := r.pruneGitSources(ctx, req.Name, nil); err != nil {
+					logger.Error().Err(err).Msg("failed to prune entries of git sources")
+				}
+			}
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
@@ -207,105 +173,313 @@ func (r *DiscoveryConfigReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	logger.Trace().Int("environments", len(config.Spec.Environments)).Msg("reconciling DiscoveryConfig")
 
-	// Set up informers for each environment/namespace/resourceType
+	// Diff the informers the spec asks for against the running ones
+	desired := r.desiredInformers(&config)
+	r.checkCredentials(ctx, &config, desired)
+	r.syncInformers(ctx, config.Name, desired, config.Spec.Prune, logger)
+
+	// A trigger annotation forces a full relist; clear it first so the next reconcile doesn't repeat it
+	var resyncReport *agentregistryv1alpha1.ResyncReport
+	triggered := config.Annotations[agentregistryv1alpha1.AnnotationTriggerDiscovery] == "true"
+	if triggered {
+		logger.Info().Msg("trigger annotation set, resyncing all environments")
+		resyncReport = r.resync(ctx, &config, logger)
+
+		patch := client.MergeFrom(config.DeepCopy())
+		delete(config.Annotations, agentregistryv1alpha1.AnnotationTriggerDiscovery)
+		if err := r.Patch(ctx, &config, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.Info().
+			Int("added", resyncReport.Added).
+			Int("updated", resyncReport.Updated).
+			Int("removed", resyncReport.Removed).
+			Int("errors", len(resyncReport.Errors)).
+			Msg("resync complete")
+	}
+
+	// Git sources are scanned on their interval, or right away when triggered
+	gitSources, nextGitScan := r.syncGitSources(ctx, &config, triggered, logger)
+
+	// Update status
+	now := metav1.Now()
+	config.Status.LastSyncTime = &now
+	if resyncReport != nil {
+		config.Status.LastResync = resyncReport
+	}
+	config.Status.GitSources = gitSources
+	r.setEnvironmentStatus(&config)
+
+	if err := r.Status().Update(ctx, &config); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: nextGitScan}, nil
+}
+
+// desiredInformers lists one informer per environment/namespace/resourceType in the spec.
+// Environments that select namespaces by pattern also get a namespace informer, and their
+// patterns are resolved against the namespaces it has seen.
+func (r *DiscoveryConfigReconciler) desiredInformers(config *agentregistryv1alpha1.DiscoveryConfig) []desiredInformer {
+	return desiredInformersFor(config, func(envName string) []string {
+		return r.observedNamespaces(config.Name, envName)
+	})
+}
+
+// desiredInformersFor computes the informers of config, resolving the namespaces of
+// environments that watch namespaces with observed
+func desiredInformersFor(config *agentregistryv1alpha1.DiscoveryConfig, observed func(envName string) []string) []desiredInformer {
+	var desired []desiredInformer
 	for _, env := range config.Spec.Environments {
+		scope := DiscoveryScope{Config: config.Name, Environment: env.Name, Cluster: env.Cluster.Name}
+		configHash := informerConfigHash(&env)
 		resourceTypes := env.ResourceTypes
 		if len(resourceTypes) == 0 {
 			// Default to all types
 			resourceTypes = []string{"MCPServer", "Agent", "ModelConfig", "RemoteMCPServer"}
 		}
 
-		for _, ns := range env.Namespaces {
+		var namespaces []string
+		if watchesNamespaces(&env) {
+			desired = append(desired, desiredInformer{
+				key:          namespaceInformerKey(config.Name, env.Name),
+				env:          env,
+				scope:        scope,
+				namespace:    "*",
+				resourceType: namespaceResourceType,
+				configHash:   configHash,
+			})
+			namespaces = observed(env.Name)
+		}
+
+		for _, ns := range resolveNamespaces(&env, namespaces) {
 			for _, resourceType := range resourceTypes {
-				envKey := fmt.Sprintf("%s/%s/%s/%s", config.Name, env.Name, ns, resourceType)
+				d := desiredInformer{
+					key:          fmt.Sprintf("%s/%s/%s/%s", config.Name, env.Name, ns, resourceType),
+					env:          env,
+					scope:        scope,
+					namespace:    ns,
+					resourceType: resourceType,
+					configHash:   configHash,
+				}
+				if mapping := customResourceMapping(config, resourceType); mapping != nil {
+					d.mapping = mapping
+					d.configHash += "/" + customResourceMappingHash(mapping)
+				}
+				desired = append(desired, d)
+			}
+		}
+	}
+	return desired
+}
 
-				r.informersMu.RLock()
-				_, exists := r.informers[envKey]
-				r.informersMu.RUnlock()
+// informerConfigHash hashes everything an informer bakes in at start: the cluster connection
+// (shared with the client factory), the resource label selector and the labels stamped onto
+// discovered entries
+func informerConfigHash(env *agentregistryv1alpha1.Environment) string {
+	keys := make([]string, 0, len(env.Labels))
+	for k := range env.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-				if exists {
-					logger.Debug().Str("key", envKey).Msg("informer already running")
-					continue
-				}
+	var b strings.Builder
+	b.WriteString(cluster.ConfigHash(env))
+	b.WriteString("," + metav1.FormatLabelSelector(env.LabelSelector))
+	for _, k := range keys {
+		b.WriteString("," + k + "=" + env.Labels[k])
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
 
-				if err := r.setupInformerForResource(ctx, &env, ns, resourceType, envKey, logger); err != nil {
-					logger.Error().Err(err).Str("key", envKey).Msg("failed to setup informer")
-					continue
-				}
-				logger.Info().Str("key", envKey).Msg("informer started")
+// syncInformers makes the running informers of a DiscoveryConfig match desired: obsolete
+// informers are stopped (and their catalog entries pruned if they were started with prune),
+// informers whose environment config changed are restarted, and missing ones are started.
+func (r *DiscoveryConfigReconciler) syncInformers(
+	ctx context.Context,
+	configName string,
+	desired []desiredInformer,
+	prune bool,
+	logger zerolog.Logger,
+) {
+	wanted := make(map[string]desiredInformer, len(desired))
+	for _, d := range desired {
+		wanted[d.key] = d
+	}
+
+	// Stop informers that are no longer wanted or whose config changed
+	r.informersMu.RLock()
+	var stale []string
+	for key, state := range r.informerState {
+		if state.scope.Config != configName {
+			continue
+		}
+		if d, ok := wanted[key]; !ok || d.configHash != state.configHash || !r.ownsScope(d.scope) {
+			stale = append(stale, key)
+		}
+	}
+	r.informersMu.RUnlock()
+	sort.Strings(stale)
+
+	for _, key := range stale {
+		state, ok := r.stopInformer(key)
+		if !ok {
+			continue
+		}
+		r.DiscoveredCache.DeleteNamespace(state.scope, state.resourceType, state.namespace)
+
+		if d, restarting := wanted[key]; restarting {
+			if !r.ownsScope(d.scope) {
+				// Never prune on hand-off: the new owner keeps the catalog entries
+				logger.Info().Str("key", key).Str("owner", r.Shards.Owner(d.scope)).Msg("environment moved to another replica, stopped informer")
+				continue
 			}
+			logger.Info().Str("key", key).Msg("environment config changed, restarting informer")
+			continue
+		}
+		logger.Info().Str("key", key).Msg("informer no longer configured, stopped")
+		if state.prune {
+			pruned, err := r.pruneCatalogEntries(ctx, state)
+			if err != nil {
+				logger.Error().Err(err).Str("key", key).Msg("failed to prune catalog entries")
+				continue
+			}
+			logger.Info().Str("key", key).Int("count", pruned).Msg("pruned catalog entries")
 		}
 	}
 
-	// Update status
-	now := metav1.Now()
-	config.Status.LastSyncTime = &now
-	config.Status.Conditions = []metav1.Condition{{
-		Type:               "Ready",
-		Status:             metav1.ConditionTrue,
-		ObservedGeneration: config.Generation,
-		LastTransitionTime: now,
-		Reason:             "InformersStarted",
-		Message:            fmt.Sprintf("Watching %d environments", len(config.Spec.Environments)),
-	}}
+	// Start informers that are wanted but not running
+	for _, d := range desired {
+		if !r.ownsScope(d.scope) {
+			continue
+		}
+		r.informersMu.RLock()
+		_, exists := r.informers[d.key]
+		r.informersMu.RUnlock()
 
-	if err := r.Status().Update(ctx, &config); err != nil {
-		if apierrors.IsConflict(err) {
-			return ctrl.Result{Requeue: true}, nil
+		if exists {
+			logger.Debug().Str("key", d.key).Msg("informer already running")
+			continue
 		}
-		return ctrl.Result{}, err
+
+		state := runningInformer{
+			scope:        d.scope,
+			namespace:    d.namespace,
+			resourceType: d.resourceType,
+			configHash:   d.configHash,
+			prune:        prune,
+			mapping:      d.mapping,
+		}
+		if err := r.setupInformerForResource(ctx, &d.env, state, d.key, logger); err != nil {
+			logger.Error().Err(err).Str("key", d.key).Msg("failed to setup informer")
+			r.recordInformerError(d.key, err, "")
+			continue
+		}
+		logger.Info().Str("key", d.key).Msg("informer started")
 	}
+}
 
-	return ctrl.Result{}, nil
+// ownsScope reports whether this replica discovers scope. Without sharding it discovers all.
+func (r *DiscoveryConfigReconciler) ownsScope(scope DiscoveryScope) bool {
+	return r.Shards == nil || r.Shards.Owns(scope)
 }
 
 // setupInformerForResource creates a SharedIndexInformer for a specific resource type
 func (r *DiscoveryConfigReconciler) setupInformerForResource(
 	ctx context.Context,
 	env *agentregistryv1alpha1.Environment,
-	namespace string,
-	resourceType string,
+	state runningInformer,
 	envKey string,
 	logger zerolog.Logger,
 ) error {
+	scope, namespace, resourceType := state.scope, state.namespace, state.resourceType
 	logger = logger.With().Str("namespace", namespace).Str("cluster", env.Cluster.Name).Str("resourceType", resourceType).Logger()
 
 	// Get client for remote cluster
-	remoteClient, err := r.getRemoteClient(env)
+	remoteClient, err := r.getRemoteClient(scope, env)
 	if err != nil {
 		return fmt.Errorf("failed to create remote client: %w", err)
 	}
 
+	listOpts, err := informerListOptions(namespace, env)
+	if err != nil {
+		return err
+	}
+
 	var informer cache.SharedIndexInformer
 
 	switch resourceType {
 	case "MCPServer":
-		informer = r.createMCPServerInformer(ctx, remoteClient, namespace, env, logger)
+		informer = r.createMCPServerInformer(ctx, remoteClient, listOpts, env, scope, logger)
 	case "Agent":
-		informer = r.createAgentInformer(ctx, remoteClient, namespace, env, logger)
+		informer = r.createAgentInformer(ctx, remoteClient, listOpts, env, scope, logger)
 	case "ModelConfig":
-		informer = r.createModelConfigInformer(ctx, remoteClient, namespace, env, logger)
+		informer = r.createModelConfigInformer(ctx, remoteClient, listOpts, env, scope, logger)
 	case "RemoteMCPServer":
-		informer = r.createRemoteMCPServerInformer(ctx, remoteClient, namespace, env, logger)
+		informer = r.createRemoteMCPServerInformer(ctx, remoteClient, listOpts, env, scope, logger)
+	case "Service":
+		informer = r.createServiceInformer(ctx, remoteClient, listOpts, env, scope, logger)
+	case "Deployment":
+		informer = r.createDeploymentInformer(ctx, remoteClient, listOpts, env, scope, logger)
+	case vllmResourceType:
+		informer = r.createVLLMInformer(ctx, remoteClient, listOpts, env, scope, logger)
+	case agentgatewayBackendResourceType, kgatewayBackendResourceType:
+		informer, err = r.createGatewayBackendInformer(ctx, remoteClient, listOpts, resourceType, env, scope, logger)
+		if err != nil {
+			return err
+		}
+	case namespaceResourceType:
+		informer = r.createNamespaceInformer(remoteClient, scope.Config, logger)
 	default:
-		return fmt.Errorf("unsupported resource type: %s", resourceType)
+		if state.mapping == nil {
+			return fmt.Errorf("unsupported resource type: %s", resourceType)
+		}
+		informer, err = r.createCustomResourceInformer(ctx, remoteClient, listOpts, state.mapping, env, scope, logger)
+		if err != nil {
+			return err
+		}
+	}
+	if err := r.trackInformerHealth(envKey, informer); err != nil {
+		return fmt.Errorf("failed to track informer health: %w", err)
 	}
 
-	// Store informer and stop channel
 	stopCh := make(chan struct{})
-	r.informersMu.Lock()
-	r.informers[envKey] = informer
-	r.stopChans[envKey] = stopCh
-	r.informersMu.Unlock()
 
-	// Run informer as manager runnable
-	r.Manager.Add(manager.RunnableFunc(func(ctx context.Context) error {
+	// Run informer as manager runnable. Sharded replicas discover their environments whether or
+	// not they hold the leader lease.
+	run := func(ctx context.Context) error {
 		go informer.Run(stopCh)
 		if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
 			return fmt.Errorf("failed to sync informer for %s", envKey)
 		}
+		r.recordSynced(envKey)
+		if resourceType != namespaceResourceType {
+			r.DiscoveredCache.MarkSynced(scope, resourceType, namespace)
+		}
+		if resourceType == namespaceResourceType {
+			// Resolve patterns against the full namespace list now that it's complete
+			r.enqueueDiscoveryConfig(scope.Config)
+		}
 		return nil
-	}))
+	}
+	var runnable manager.Runnable = manager.RunnableFunc(run)
+	if r.Shards != nil {
+		runnable = everyReplica(run)
+	}
+	if err := r.Manager.Add(runnable); err != nil {
+		return fmt.Errorf("failed to add informer runnable: %w", err)
+	}
+
+	// Store informer and stop channel
+	r.informersMu.Lock()
+	r.informers[envKey] = informer
+	r.stopChans[envKey] = stopCh
+	r.informerState[envKey] = state
+	r.informersMu.Unlock()
 
 	return nil
 }
@@ -314,19 +488,20 @@ func (r *DiscoveryConfigReconciler) setupInformerForResource(
 func (r *DiscoveryConfigReconciler) createMCPServerInformer(
 	ctx context.Context,
 	remoteClient client.WithWatch,
-	namespace string,
+	listOpts []client.ListOption,
 	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
 	logger zerolog.Logger,
 ) cache.SharedIndexInformer {
 	informer := cache.NewSharedIndexInformer(
 		&cache.ListWatch{
 			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 				list := &kmcpv1alpha1.MCPServerList{}
-				err := remoteClient.List(context.Background(), list, client.InNamespace(namespace))
+				err := remoteClient.List(context.Background(), list, listOpts...)
 				return list, err
 			},
 			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-				return remoteClient.Watch(context.Background(), &kmcpv1alpha1.MCPServerList{}, client.InNamespace(namespace))
+				return remoteClient.Watch(context.Background(), &kmcpv1alpha1.MCPServerList{}, listOpts...)
 			},
 		},
 		&kmcpv1alpha1.MCPServer{},
@@ -339,27 +514,27 @@ func (r *DiscoveryConfigReconciler) createMCPServerInformer(
 			mcpServer := obj.(*kmcpv1alpha1.MCPServer)
 			logger.Trace().Str("mcpserver", mcpServer.Name).Msg("MCPServer added")
 			// Add to discovery cache for SourceRef lookups
-			setDiscoveredMCPServer(mcpServer)
+			r.DiscoveredCache.Set(scope, "MCPServer", mcpServer)
 			resourceKey := fmt.Sprintf("mcpserver/%s/%s", mcpServer.Namespace, mcpServer.Name)
-			r.executeWithRetry(ctx, resourceKey, func() error {
-				return r.handleMCPServerAdd(ctx, mcpServer, env)
+			r.executeWithRetry(ctx, scope, resourceKey, func() error {
+				return r.handleMCPServerAdd(ctx, mcpServer, env, scope)
 			}, logger)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			mcpServer := newObj.(*kmcpv1alpha1.MCPServer)
 			logger.Trace().Str("mcpserver", mcpServer.Name).Msg("MCPServer updated")
 			// Update discovery cache
-			setDiscoveredMCPServer(mcpServer)
+			r.DiscoveredCache.Set(scope, "MCPServer", mcpServer)
 			resourceKey := fmt.Sprintf("mcpserver/%s/%s", mcpServer.Namespace, mcpServer.Name)
-			r.executeWithRetry(ctx, resourceKey, func() error {
-				return r.handleMCPServerAdd(ctx, mcpServer, env)
+			r.executeWithRetry(ctx, scope, resourceKey, func() error {
+				return r.handleMCPServerAdd(ctx, mcpServer, env, scope)
 			}, logger)
 		},
 		DeleteFunc: func(obj interface{}) {
 			mcpServer := obj.(*kmcpv1alpha1.MCPServer)
 			logger.Trace().Str("mcpserver", mcpServer.Name).Msg("MCPServer deleted")
 			// Remove from discovery cache
-			deleteDiscoveredMCPServer(mcpServer.Namespace, mcpServer.Name)
+			r.DiscoveredCache.Delete(scope, "MCPServer", mcpServer.Namespace, mcpServer.Name)
 			// TODO: Handle deletion - mark catalog entry as deleted or remove it
 		},
 	})
@@ -371,19 +546,20 @@ func (r *DiscoveryConfigReconciler) createMCPServerInformer(
 func (r *DiscoveryConfigReconciler) createAgentInformer(
 	ctx context.Context,
 	remoteClient client.WithWatch,
-	namespace string,
+	listOpts []client.ListOption,
 	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
 	logger zerolog.Logger,
 ) cache.SharedIndexInformer {
 	informer := cache.NewSharedIndexInformer(
 		&cache.ListWatch{
 			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 				list := &kagentv1alpha2.AgentList{}
-				err := remoteClient.List(context.Background(), list, client.InNamespace(namespace))
+				err := remoteClient.List(context.Background(), list, listOpts...)
 				return list, err
 			},
 			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-				return remoteClient.Watch(context.Background(), &kagentv1alpha2.AgentList{}, client.InNamespace(namespace))
+				return remoteClient.Watch(context.Background(), &kagentv1alpha2.AgentList{}, listOpts...)
 			},
 		},
 		&kagentv1alpha2.Agent{},
@@ -396,27 +572,27 @@ func (r *DiscoveryConfigReconciler) createAgentInformer(
 			agent := obj.(*kagentv1alpha2.Agent)
 			logger.Trace().Str("agent", agent.Name).Msg("Agent added")
 			// Add to discovery cache
-			setDiscoveredAgent(agent)
+			r.DiscoveredCache.Set(scope, "Agent", agent)
 			resourceKey := fmt.Sprintf("agent/%s/%s", agent.Namespace, agent.Name)
-			r.executeWithRetry(ctx, resourceKey, func() error {
-				return r.handleAgentAdd(ctx, agent, env)
+			r.executeWithRetry(ctx, scope, resourceKey, func() error {
+				return r.handleAgentAdd(ctx, agent, env, scope)
 			}, logger)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			agent := newObj.(*kagentv1alpha2.Agent)
 			logger.Trace().Str("agent", agent.Name).Msg("Agent updated")
 			// Update discovery cache
-			setDiscoveredAgent(agent)
+			r.DiscoveredCache.Set(scope, "Agent", agent)
 			resourceKey := fmt.Sprintf("agent/%s/%s", agent.Namespace, agent.Name)
-			r.executeWithRetry(ctx, resourceKey, func() error {
-				return r.handleAgentAdd(ctx, agent, env)
+			r.executeWithRetry(ctx, scope, resourceKey, func() error {
+				return r.handleAgentAdd(ctx, agent, env, scope)
 			}, logger)
 		},
 		DeleteFunc: func(obj interface{}) {
 			agent := obj.(*kagentv1alpha2.Agent)
 			logger.Trace().Str("agent", agent.Name).Msg("Agent deleted")
 			// Remove from discovery cache
-			deleteDiscoveredAgent(agent.Namespace, agent.Name)
+			r.DiscoveredCache.Delete(scope, "Agent", agent.Namespace, agent.Name)
 			// TODO: Handle deletion
 		},
 	})
@@ -428,19 +604,20 @@ func (r *DiscoveryConfigReconciler) createAgentInformer(
 func (r *DiscoveryConfigReconciler) createModelConfigInformer(
 	ctx context.Context,
 	remoteClient client.WithWatch,
-	namespace string,
+	listOpts []client.ListOption,
 	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
 	logger zerolog.Logger,
 ) cache.SharedIndexInformer {
 	informer := cache.NewSharedIndexInformer(
 		&cache.ListWatch{
 			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 				list := &kagentv1alpha2.ModelConfigList{}
-				err := remoteClient.List(context.Background(), list, client.InNamespace(namespace))
+				err := remoteClient.List(context.Background(), list, listOpts...)
 				return list, err
 			},
 			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-				return remoteClient.Watch(context.Background(), &kagentv1alpha2.ModelConfigList{}, client.InNamespace(namespace))
+				return remoteClient.Watch(context.Background(), &kagentv1alpha2.ModelConfigList{}, listOpts...)
 			},
 		},
 		&kagentv1alpha2.ModelConfig{},
@@ -453,27 +630,27 @@ func (r *DiscoveryConfigReconciler) createModelConfigInformer(
 			model := obj.(*kagentv1alpha2.ModelConfig)
 			logger.Trace().Str("modelconfig", model.Name).Msg("ModelConfig added")
 			// Add to discovery cache
-			setDiscoveredModelConfig(model)
+			r.DiscoveredCache.Set(scope, "ModelConfig", model)
 			resourceKey := fmt.Sprintf("model/%s/%s", model.Namespace, model.Name)
-			r.executeWithRetry(ctx, resourceKey, func() error {
-				return r.handleModelConfigAdd(ctx, model, env)
+			r.executeWithRetry(ctx, scope, resourceKey, func() error {
+				return r.handleModelConfigAdd(ctx, model, env, scope)
 			}, logger)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			model := newObj.(*kagentv1alpha2.ModelConfig)
 			logger.Trace().Str("modelconfig", model.Name).Msg("ModelConfig updated")
 			// Update discovery cache
-			setDiscoveredModelConfig(model)
+			r.DiscoveredCache.Set(scope, "ModelConfig", model)
 			resourceKey := fmt.Sprintf("model/%s/%s", model.Namespace, model.Name)
-			r.executeWithRetry(ctx, resourceKey, func() error {
-				return r.handleModelConfigAdd(ctx, model, env)
+			r.executeWithRetry(ctx, scope, resourceKey, func() error {
+				return r.handleModelConfigAdd(ctx, model, env, scope)
 			}, logger)
 		},
 		DeleteFunc: func(obj interface{}) {
 			model := obj.(*kagentv1alpha2.ModelConfig)
 			logger.Trace().Str("modelconfig", model.Name).Msg("ModelConfig deleted")
 			// Remove from discovery cache
-			deleteDiscoveredModelConfig(model.Namespace, model.Name)
+			r.DiscoveredCache.Delete(scope, "ModelConfig", model.Namespace, model.Name)
 			// TODO: Handle deletion
 		},
 	})
@@ -485,19 +662,20 @@ func (r *DiscoveryConfigReconciler) createModelConfigInformer(
 func (r *DiscoveryConfigReconciler) createRemoteMCPServerInformer(
 	ctx context.Context,
 	remoteClient client.WithWatch,
-	namespace string,
+	listOpts []client.ListOption,
 	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
 	logger zerolog.Logger,
 ) cache.SharedIndexInformer {
 	informer := cache.NewSharedIndexInformer(
 		&cache.ListWatch{
 			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 				list := &kagentv1alpha2.RemoteMCPServerList{}
-				err := remoteClient.List(context.Background(), list, client.InNamespace(namespace))
+				err := remoteClient.List(context.Background(), list, listOpts...)
 				return list, err
 			},
 			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
-				return remoteClient.Watch(context.Background(), &kagentv1alpha2.RemoteMCPServerList{}, client.InNamespace(namespace))
+				return remoteClient.Watch(context.Background(), &kagentv1alpha2.RemoteMCPServerList{}, listOpts...)
 			},
 		},
 		&kagentv1alpha2.RemoteMCPServer{},
@@ -509,25 +687,25 @@ func (r *DiscoveryConfigReconciler) createRemoteMCPServerInformer(
 		AddFunc: func(obj interface{}) {
 			server := obj.(*kagentv1alpha2.RemoteMCPServer)
 			logger.Trace().Str("remotemcpserver", server.Name).Msg("RemoteMCPServer added")
-			setDiscoveredRemoteMCPServer(server)
+			r.DiscoveredCache.Set(scope, "RemoteMCPServer", server)
 			resourceKey := fmt.Sprintf("remotemcpserver/%s/%s", server.Namespace, server.Name)
-			r.executeWithRetry(ctx, resourceKey, func() error {
-				return r.handleRemoteMCPServerAdd(ctx, server, env)
+			r.executeWithRetry(ctx, scope, resourceKey, func() error {
+				return r.handleRemoteMCPServerAdd(ctx, server, env, scope)
 			}, logger)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			server := newObj.(*kagentv1alpha2.RemoteMCPServer)
 			logger.Trace().Str("remotemcpserver", server.Name).Msg("RemoteMCPServer updated")
-			setDiscoveredRemoteMCPServer(server)
+			r.DiscoveredCache.Set(scope, "RemoteMCPServer", server)
 			resourceKey := fmt.Sprintf("remotemcpserver/%s/%s", server.Namespace, server.Name)
-			r.executeWithRetry(ctx, resourceKey, func() error {
-				return r.handleRemoteMCPServerAdd(ctx, server, env)
+			r.executeWithRetry(ctx, scope, resourceKey, func() error {
+				return r.handleRemoteMCPServerAdd(ctx, server, env, scope)
 			}, logger)
 		},
 		DeleteFunc: func(obj interface{}) {
 			server := obj.(*kagentv1alpha2.RemoteMCPServer)
 			logger.Trace().Str("remotemcpserver", server.Name).Msg("RemoteMCPServer deleted")
-			deleteDiscoveredRemoteMCPServer(server.Namespace, server.Name)
+			r.DiscoveredCache.Delete(scope, "RemoteMCPServer", server.Namespace, server.Name)
 		},
 	})
 
@@ -539,8 +717,9 @@ func (r *DiscoveryConfigReconciler) handleRemoteMCPServerAdd(
 	ctx context.Context,
 	server *kagentv1alpha2.RemoteMCPServer,
 	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
 ) error {
-	if managedBy, ok := server.Labels["agentregistry.dev/managed-by"]; ok && managedBy == "agentregistry" {
+	if isManagedByRegistry(server.Labels) {
 		r.Logger.Debug().
 			Str("remotemcpserver", server.Name).
 			Str("namespace", server.Namespace).
@@ -548,7 +727,7 @@ func (r *DiscoveryConfigReconciler) handleRemoteMCPServerAdd(
 		return nil
 	}
 
-	catalogName := generateCatalogName(server.Namespace, server.Name)
+	catalogName := generateDiscoveredCatalogName("RemoteMCPServer", env.Name, env.Cluster.Name, server.Namespace, server.Name)
 	namespace := config.GetNamespace()
 
 	version := "latest"
@@ -573,8 +752,9 @@ func (r *DiscoveryConfigReconciler) handleRemoteMCPServerAdd(
 	labels[sourceKindLabel] = "RemoteMCPServer"
 	labels[sourceNameLabel] = server.Name
 	labels[sourceNSLabel] = server.Namespace
-	labels["agentregistry.dev/environment"] = env.Name
-	labels["agentregistry.dev/cluster"] = env.Cluster.Name
+	labels[envLabel] = env.Name
+	labels[clusterLabel] = env.Cluster.Name
+	labels[discoveryConfigLabel] = scope.Config
 
 	catalog := agentregistryv1alpha1.MCPServerCatalog{
 		ObjectMeta: metav1.ObjectMeta{
@@ -666,9 +846,10 @@ func (r *DiscoveryConfigReconciler) handleMCPServerAdd(
 	ctx context.Context,
 	mcpServer *kmcpv1alpha1.MCPServer,
 	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
 ) error {
 	// Skip resources managed by Agent Registry to avoid duplicate catalog entries
-	if managedBy, ok := mcpServer.Labels["agentregistry.dev/managed-by"]; ok && managedBy == "agentregistry" {
+	if isManagedByRegistry(mcpServer.Labels) {
 		r.Logger.Debug().
 			Str("mcpserver", mcpServer.Name).
 			Str("namespace", mcpServer.Namespace).
@@ -676,8 +857,9 @@ func (r *DiscoveryConfigReconciler) handleMCPServerAdd(
 		return nil
 	}
 
-	// Catalog name: namespace-name (environment/cluster info in labels)
-	catalogName := generateCatalogName(mcpServer.Namespace, mcpServer.Name)
+	// Catalog name is unique per environment/cluster; Spec.Name stays namespace/name so
+	// instances of the same server in different environments group together
+	catalogName := generateDiscoveredCatalogName("MCPServer", env.Name, env.Cluster.Name, mcpServer.Namespace, mcpServer.Name)
 	namespace := config.GetNamespace()
 
 	// Extract version
@@ -714,8 +896,9 @@ func (r *DiscoveryConfigReconciler) handleMCPServerAdd(
 	labels[sourceKindLabel] = "MCPServer"
 	labels[sourceNameLabel] = mcpServer.Name
 	labels[sourceNSLabel] = mcpServer.Namespace
-	labels["agentregistry.dev/environment"] = env.Name
-	labels["agentregistry.dev/cluster"] = env.Cluster.Name
+	labels[envLabel] = env.Name
+	labels[clusterLabel] = env.Cluster.Name
+	labels[discoveryConfigLabel] = scope.Config
 
 	catalog := agentregistryv1alpha1.MCPServerCatalog{
 		ObjectMeta: metav1.ObjectMeta{
@@ -799,12 +982,31 @@ func syncDeploymentStatus(catalog *agentregistryv1alpha1.MCPServerCatalog, mcpSe
 	catalog.Status.Deployment = &agentregistryv1alpha1.DeploymentRef{
 		Namespace:   mcpServer.Namespace,
 		ServiceName: mcpServer.Name,
+		URL:         kmcpServerURL(mcpServer),
 		Ready:       ready,
 		Message:     message,
 		LastChecked: &now,
 	}
 }
 
+// kmcpServerURL returns the in-cluster endpoint of an MCPServer served over HTTP, or "" for
+// stdio servers. kmcp exposes the server through a Service of the same name on the
+// deployment port.
+func kmcpServerURL(mcpServer *kmcpv1alpha1.MCPServer) string {
+	if mcpServer.Spec.TransportType != kmcpv1alpha1.TransportTypeHTTP {
+		return ""
+	}
+	port := mcpServer.Spec.Deployment.Port
+	if port == 0 {
+		port = 3000
+	}
+	path := "/mcp"
+	if t := mcpServer.Spec.HTTPTransport; t != nil && t.TargetPath != "" {
+		path = "/" + strings.TrimPrefix(t.TargetPath, "/")
+	}
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d%s", mcpServer.Name, mcpServer.Namespace, port, path)
+}
+
 // syncAgentDeploymentStatus syncs deployment status from kagent Agent to catalog
 func syncAgentDeploymentStatus(catalog *agentregistryv1alpha1.AgentCatalog, agent *kagentv1alpha2.Agent) {
 	ready := false
@@ -833,9 +1035,10 @@ func (r *DiscoveryConfigReconciler) handleAgentAdd(
 	ctx context.Context,
 	agent *kagentv1alpha2.Agent,
 	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
 ) error {
 	// Skip resources managed by Agent Registry to avoid duplicate catalog entries
-	if managedBy, ok := agent.Labels["agentregistry.dev/managed-by"]; ok && managedBy == "agentregistry" {
+	if isManagedByRegistry(agent.Labels) {
 		r.Logger.Debug().
 			Str("agent", agent.Name).
 			Str("namespace", agent.Namespace).
@@ -843,8 +1046,9 @@ func (r *DiscoveryConfigReconciler) handleAgentAdd(
 		return nil
 	}
 
-	// Catalog name: namespace-name (environment/cluster info in labels)
-	catalogName := generateAgentCatalogName(agent.Namespace, agent.Name)
+	// Catalog name is unique per environment/cluster; Spec.Name stays namespace/name so
+	// instances of the same agent in different environments group together
+	catalogName := generateDiscoveredCatalogName("Agent", env.Name, env.Cluster.Name, agent.Namespace, agent.Name)
 	namespace := config.GetNamespace()
 
 	// Extract version
@@ -869,8 +1073,9 @@ func (r *DiscoveryConfigReconciler) handleAgentAdd(
 	labels[sourceKindLabel] = "Agent"
 	labels[sourceNameLabel] = agent.Name
 	labels[sourceNSLabel] = agent.Namespace
-	labels["agentregistry.dev/environment"] = env.Name
-	labels["agentregistry.dev/cluster"] = env.Cluster.Name
+	labels[envLabel] = env.Name
+	labels[clusterLabel] = env.Cluster.Name
+	labels[discoveryConfigLabel] = scope.Config
 
 	// Build annotations
 	annotations := make(map[string]string)
@@ -998,9 +1203,10 @@ func (r *DiscoveryConfigReconciler) handleModelConfigAdd(
 	ctx context.Context,
 	model *kagentv1alpha2.ModelConfig,
 	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
 ) error {
 	// Skip resources managed by Agent Registry to avoid duplicate catalog entries
-	if managedBy, ok := model.Labels["agentregistry.dev/managed-by"]; ok && managedBy == "agentregistry" {
+	if isManagedByRegistry(model.Labels) {
 		r.Logger.Debug().
 			Str("modelconfig", model.Name).
 			Str("namespace", model.Namespace).
@@ -1008,8 +1214,9 @@ func (r *DiscoveryConfigReconciler) handleModelConfigAdd(
 		return nil
 	}
 
-	// Catalog name: namespace-name (environment/cluster info in labels)
-	catalogName := generateModelCatalogName(model.Namespace, model.Name)
+	// Catalog name is unique per environment/cluster; Spec.Name stays namespace/name so
+	// instances of the same model config in different environments group together
+	catalogName := generateDiscoveredCatalogName("ModelConfig", env.Name, env.Cluster.Name, model.Namespace, model.Name)
 	namespace := config.GetNamespace()
 
 	// Build labels
@@ -1021,8 +1228,9 @@ func (r *DiscoveryConfigReconciler) handleModelConfigAdd(
 	labels[sourceKindLabel] = "ModelConfig"
 	labels[sourceNameLabel] = model.Name
 	labels[sourceNSLabel] = model.Namespace
-	labels["agentregistry.dev/environment"] = env.Name
-	labels["agentregistry.dev/cluster"] = env.Cluster.Name
+	labels[envLabel] = env.Name
+	labels[clusterLabel] = env.Cluster.Name
+	labels[discoveryConfigLabel] = scope.Config
 
 	catalog := agentregistryv1alpha1.ModelCatalog{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1034,6 +1242,7 @@ func (r *DiscoveryConfigReconciler) handleModelConfigAdd(
 			Name:     fmt.Sprintf("%s/%s", model.Namespace, model.Name),
 			Provider: string(model.Spec.Provider),
 			Model:    model.Spec.Model,
+			BaseURL:  modelConfigBaseURL(model),
 			SourceRef: &agentregistryv1alpha1.SourceReference{
 				Kind:      "ModelConfig",
 				Name:      model.Name,
@@ -1076,26 +1285,81 @@ func (r *DiscoveryConfigReconciler) handleModelConfigAdd(
 	return nil
 }
 
-// getRemoteClient gets or creates a client for remote cluster
-func (r *DiscoveryConfigReconciler) getRemoteClient(env *agentregistryv1alpha1.Environment) (client.WithWatch, error) {
+// getRemoteClient gets or creates a client for the remote cluster of a scope
+func (r *DiscoveryConfigReconciler) getRemoteClient(scope DiscoveryScope, env *agentregistryv1alpha1.Environment) (client.WithWatch, error) {
 	// Use factory if provided (for testing)
 	if RemoteClientFactory != nil {
-		return RemoteClientFactory(env, r.Scheme)
+		return RemoteClientFactory(scope, env, r.Scheme)
 	}
 	return nil, fmt.Errorf("remote client factory not configured")
 }
 
-// stopAllInformers stops all running informers
-func (r *DiscoveryConfigReconciler) stopAllInformers() {
+// stopInformer stops a running informer and returns what it was started with
+func (r *DiscoveryConfigReconciler) stopInformer(key string) (runningInformer, bool) {
 	r.informersMu.Lock()
 	defer r.informersMu.Unlock()
 
-	for key, stopCh := range r.stopChans {
+	state, ok := r.informerState[key]
+	if stopCh, running := r.stopChans[key]; running {
 		close(stopCh)
-		r.Logger.Info().Str("key", key).Msg("stopped informer")
 	}
-	r.informers = make(map[string]cache.SharedIndexInformer)
-	r.stopChans = make(map[string]chan struct{})
+	delete(r.informers, key)
+	delete(r.stopChans, key)
+	delete(r.informerState, key)
+	r.forgetHealth(key)
+	return state, ok
+}
+
+// pruneCatalogEntries deletes the catalog entries an informer produced. Only entries
+// carrying this informer's discovery labels are touched; manual and imported entries stay.
+func (r *DiscoveryConfigReconciler) pruneCatalogEntries(ctx context.Context, state runningInformer) (int, error) {
+	resourceType := state.resourceType
+	if state.mapping != nil {
+		resourceType = mappedResourceType(state.mapping)
+	}
+
+	var list client.ObjectList
+	switch resourceType {
+	case "MCPServer", "RemoteMCPServer", "Service", "Deployment", agentgatewayBackendResourceType, kgatewayBackendResourceType:
+		list = &agentregistryv1alpha1.MCPServerCatalogList{}
+	case "Agent":
+		list = &agentregistryv1alpha1.AgentCatalogList{}
+	case "ModelConfig", vllmResourceType:
+		list = &agentregistryv1alpha1.ModelCatalogList{}
+	default:
+		return 0, nil
+	}
+
+	if err := r.List(ctx, list,
+		client.InNamespace(config.GetNamespace()),
+		client.MatchingLabels{
+			discoveryLabel:       "true",
+			discoveryConfigLabel: state.scope.Config,
+			envLabel:             state.scope.Environment,
+			clusterLabel:         state.scope.Cluster,
+			sourceKindLabel:      state.resourceType,
+			sourceNSLabel:        state.namespace,
+		},
+	); err != nil {
+		return 0, err
+	}
+
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok {
+			continue
+		}
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
 }
 
 // shouldRetry determines if an error should be retried based on error type and retry count
@@ -1132,10 +1396,14 @@ const retryBackoff = 500 * time.Millisecond
 // executeWithRetry executes a handler function with retry logic
 func (r *DiscoveryConfigReconciler) executeWithRetry(
 	ctx context.Context,
+	scope DiscoveryScope,
 	resourceKey string,
 	handler func() error,
 	logger zerolog.Logger,
 ) {
+	// The same namespace/name can exist in several clusters, so track errors per scope
+	resourceKey = scope.String() + "/" + resourceKey
+
 	err := handler()
 	if err == nil {
 		// Clear error tracker on success
@@ -1145,6 +1413,18 @@ func (r *DiscoveryConfigReconciler) executeWithRetry(
 		return
 	}
 
+	// Check if we should retry
+	// Track the failure so it shows up in EnvironmentStatus until the resource syncs
+	r.errorTrackerMu.Lock()
+	tracker, exists := r.errorTracker[resourceKey]
+	if !exists {
+		tracker = &informerError{scope: scope}
+		r.errorTracker[resourceKey] = tracker
+	}
+	tracker.err = err
+	tracker.lastRetry = time.Now()
+	r.errorTrackerMu.Unlock()
+
 	// Check if we should retry
 	if !shouldRetry(err) {
 		logger.Error().Err(err).Str("key", resourceKey).Msg("informer handler failed, not retrying")
@@ -1153,12 +1433,6 @@ func (r *DiscoveryConfigReconciler) executeWithRetry(
 
 	// Check retry count
 	r.errorTrackerMu.Lock()
-	tracker, exists := r.errorTracker[resourceKey]
-	if !exists {
-		tracker = &informerError{}
-		r.errorTracker[resourceKey] = tracker
-	}
-	tracker.err = err
 	tracker.retryCount++
 	retryCount := tracker.retryCount
 	r.errorTrackerMu.Unlock()
@@ -1189,7 +1463,38 @@ func (r *DiscoveryConfigReconciler) executeWithRetry(
 // SetupWithManager sets up the controller
 func (r *DiscoveryConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Manager = mgr
+
+	// Sharded discovery runs on every replica; rebalance whenever the replicas change
+	needLeaderElection := r.Shards == nil
+	var statusLoop manager.Runnable = manager.RunnableFunc(r.runEnvironmentStatusLoop)
+	if r.Shards != nil {
+		statusLoop = everyReplica(r.runEnvironmentStatusLoop)
+		r.Shards.OnChange(func() { go r.enqueueAllDiscoveryConfigs() })
+		if err := mgr.Add(r.Shards); err != nil {
+			return err
+		}
+	}
+	if err := mgr.Add(statusLoop); err != nil {
+		return err
+	}
+	// Entries named by earlier versions are removed once, by the leader
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		if err := r.removeRenamedEntries(ctx); err != nil {
+			r.Logger.Error().Err(err).Msg("failed to remove renamed catalog entries")
+		}
+		return nil
+	})); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&agentregistryv1alpha1.DiscoveryConfig{}).
+		WithOptions(crcontroller.Options{NeedLeaderElection: &needLeaderElection}).
+		WatchesRawSource(source.Func(func(_ context.Context, queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+			r.requeueMu.Lock()
+			r.requeue = queue
+			r.requeueMu.Unlock()
+			return nil
+		})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.discoveryConfigsForSecret), builder.OnlyMetadata).
 		Complete(r)
 }
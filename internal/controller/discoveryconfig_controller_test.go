@@ -15,8 +15,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
 	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
@@ -183,7 +185,7 @@ func TestDiscoveryConfigReconciler_MultiNamespaceDiscovery(t *testing.T) {
 	// Inject remote client factory for testing
 	// Use envtest's client wrapped to implement WithWatch
 	oldFactory := RemoteClientFactory
-	RemoteClientFactory = func(env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+	RemoteClientFactory = func(_ DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
 		return &testClientWithWatch{Client: helper.Client}, nil
 	}
 	defer func() { RemoteClientFactory = oldFactory }()
@@ -404,7 +406,7 @@ func TestDiscoveryConfigReconciler_MultipleEnvironments(t *testing.T) {
 
 	// Inject remote client factory for testing
 	oldFactory := RemoteClientFactory
-	RemoteClientFactory = func(env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+	RemoteClientFactory = func(_ DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
 		return &testClientWithWatch{Client: helper.Client}, nil
 	}
 	defer func() { RemoteClientFactory = oldFactory }()
@@ -528,7 +530,7 @@ func TestDiscoveryConfigReconciler_InformerLifecycle(t *testing.T) {
 
 	// Inject remote client factory
 	oldFactory := RemoteClientFactory
-	RemoteClientFactory = func(env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+	RemoteClientFactory = func(_ DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
 		return &testClientWithWatch{Client: helper.Client}, nil
 	}
 	defer func() { RemoteClientFactory = oldFactory }()
@@ -566,3 +568,188 @@ func TestDiscoveryConfigReconciler_InformerLifecycle(t *testing.T) {
 	assert.Empty(t, reconciler.informers)
 	reconciler.informersMu.RUnlock()
 }
+
+func TestDesiredInformers(t *testing.T) {
+	config := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery"},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{
+				{
+					Name:          "dev",
+					Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "dev-cluster"},
+					Namespaces:    []string{"a", "b"},
+					ResourceTypes: []string{"MCPServer"},
+				},
+				{
+					Name:       "prod",
+					Cluster:    agentregistryv1alpha1.ClusterConfig{Name: "prod-cluster"},
+					Namespaces: []string{"a"},
+				},
+			},
+		},
+	}
+
+	desired := (&DiscoveryConfigReconciler{}).desiredInformers(config)
+	keys := make([]string, 0, len(desired))
+	for _, d := range desired {
+		keys = append(keys, d.key)
+	}
+	assert.ElementsMatch(t, []string{
+		"discovery/dev/a/MCPServer",
+		"discovery/dev/b/MCPServer",
+		"discovery/prod/a/MCPServer",
+		"discovery/prod/a/Agent",
+		"discovery/prod/a/ModelConfig",
+		"discovery/prod/a/RemoteMCPServer",
+	}, keys)
+	assert.Equal(t, DiscoveryScope{Config: "discovery", Environment: "prod", Cluster: "prod-cluster"}, desired[len(desired)-1].scope)
+}
+
+func TestInformerConfigHash(t *testing.T) {
+	env := &agentregistryv1alpha1.Environment{
+		Name:    "dev",
+		Cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev", Endpoint: "https://1.2.3.4"},
+		Labels:  map[string]string{"team": "a", "tier": "gold"},
+	}
+	base := informerConfigHash(env)
+	assert.Equal(t, base, informerConfigHash(env.DeepCopy()))
+
+	changed := env.DeepCopy()
+	changed.Cluster.Endpoint = "https://5.6.7.8"
+	assert.NotEqual(t, base, informerConfigHash(changed), "endpoint change should restart informers")
+
+	changed = env.DeepCopy()
+	changed.Labels["team"] = "b"
+	assert.NotEqual(t, base, informerConfigHash(changed), "label change should restart informers")
+
+	// Namespaces and resource types are part of the informer key, not its hash
+	changed = env.DeepCopy()
+	changed.Namespaces = []string{"other"}
+	assert.Equal(t, base, informerConfigHash(changed))
+}
+
+func TestDiscoveryConfigReconciler_SyncInformers_StopsAndPrunes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	dev := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev"}
+	catalogFor := func(name, sourceNS string, extra map[string]string) *agentregistryv1alpha1.MCPServerCatalog {
+		labels := map[string]string{
+			discoveryLabel:       "true",
+			discoveryConfigLabel: dev.Config,
+			envLabel:             dev.Environment,
+			clusterLabel:         dev.Cluster,
+			sourceKindLabel:      "MCPServer",
+			sourceNSLabel:        sourceNS,
+		}
+		for k, v := range extra {
+			labels[k] = v
+		}
+		return &agentregistryv1alpha1.MCPServerCatalog{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels},
+		}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		catalogFor("kept-ns-a", "a", nil),
+		catalogFor("pruned-ns-b", "b", nil),
+		catalogFor("other-config-ns-b", "b", map[string]string{discoveryConfigLabel: "other"}),
+	).Build()
+
+	r := &DiscoveryConfigReconciler{
+		Client:          c,
+		Scheme:          scheme,
+		Logger:          zerolog.Nop(),
+		informers:       make(map[string]cache.SharedIndexInformer),
+		stopChans:       make(map[string]chan struct{}),
+		informerState:   make(map[string]runningInformer),
+		DiscoveredCache: NewDiscoveredResourceCache(),
+	}
+
+	stopChans := map[string]chan struct{}{}
+	for _, ns := range []string{"a", "b"} {
+		key := "discovery/dev/" + ns + "/MCPServer"
+		stopChans[key] = make(chan struct{})
+		r.informers[key] = nil
+		r.stopChans[key] = stopChans[key]
+		r.informerState[key] = runningInformer{scope: dev, namespace: ns, resourceType: "MCPServer", configHash: "h1", prune: true}
+		r.DiscoveredCache.Set(dev, "MCPServer", &kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "s", Namespace: ns}})
+	}
+	// An informer of another DiscoveryConfig is never touched
+	r.informers["other/dev/b/MCPServer"] = nil
+	r.stopChans["other/dev/b/MCPServer"] = make(chan struct{})
+	r.informerState["other/dev/b/MCPServer"] = runningInformer{scope: DiscoveryScope{Config: "other"}, namespace: "b", resourceType: "MCPServer"}
+
+	// Namespace b was removed from the spec; namespace a is unchanged
+	r.syncInformers(context.Background(), "discovery", []desiredInformer{{
+		key:          "discovery/dev/a/MCPServer",
+		scope:        dev,
+		namespace:    "a",
+		resourceType: "MCPServer",
+		configHash:   "h1",
+	}}, true, zerolog.Nop())
+
+	assert.Contains(t, r.informers, "discovery/dev/a/MCPServer")
+	assert.NotContains(t, r.informers, "discovery/dev/b/MCPServer")
+	assert.Contains(t, r.informers, "other/dev/b/MCPServer")
+
+	select {
+	case <-stopChans["discovery/dev/b/MCPServer"]:
+	default:
+		t.Fatal("informer for removed namespace was not stopped")
+	}
+
+	_, err := r.DiscoveredCache.GetMCPServer(dev, "b", "s")
+	assert.Error(t, err, "cache entries of the stopped informer should be dropped")
+	_, err = r.DiscoveredCache.GetMCPServer(dev, "a", "s")
+	assert.NoError(t, err)
+
+	var catalogs agentregistryv1alpha1.MCPServerCatalogList
+	require.NoError(t, c.List(context.Background(), &catalogs))
+	names := make([]string, 0, len(catalogs.Items))
+	for _, item := range catalogs.Items {
+		names = append(names, item.Name)
+	}
+	assert.ElementsMatch(t, []string{"kept-ns-a", "other-config-ns-b"}, names)
+}
+
+func TestDiscoveryConfigReconciler_SyncInformers_RestartsOnConfigChange(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	dev := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev"}
+	key := "discovery/dev/a/MCPServer"
+	stopCh := make(chan struct{})
+
+	r := &DiscoveryConfigReconciler{
+		Client:          fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Scheme:          scheme,
+		Logger:          zerolog.Nop(),
+		informers:       map[string]cache.SharedIndexInformer{key: nil},
+		stopChans:       map[string]chan struct{}{key: stopCh},
+		informerState:   map[string]runningInformer{key: {scope: dev, namespace: "a", resourceType: "MCPServer", configHash: "old"}},
+		DiscoveredCache: NewDiscoveredResourceCache(),
+	}
+
+	// Fail the restart so the test doesn't need a manager; the old informer must still be stopped
+	oldFactory := RemoteClientFactory
+	RemoteClientFactory = func(_ DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+		return nil, assert.AnError
+	}
+	defer func() { RemoteClientFactory = oldFactory }()
+
+	r.syncInformers(context.Background(), "discovery", []desiredInformer{{
+		key:          key,
+		scope:        dev,
+		namespace:    "a",
+		resourceType: "MCPServer",
+		configHash:   "new",
+	}}, false, zerolog.Nop())
+
+	select {
+	case <-stopCh:
+	default:
+		t.Fatal("informer with changed config was not stopped")
+	}
+	assert.NotContains(t, r.informerState, key)
+}
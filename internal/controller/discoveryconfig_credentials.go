@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// checkCredentials validates the credentials Secret of every environment that references one
+// and records the outcome for EnvironmentStatus. The informers of an environment with valid
+// credentials get the Secret's resource version in their config hash, so they are restarted
+// with a fresh client when the Secret changes.
+func (r *DiscoveryConfigReconciler) checkCredentials(
+	ctx context.Context,
+	dc *agentregistryv1alpha1.DiscoveryConfig,
+	desired []desiredInformer,
+) {
+	errs := make(map[string]error)
+	for i := range dc.Spec.Environments {
+		env := &dc.Spec.Environments[i]
+		key, ok := cluster.CredentialsSecretKey(env)
+		if !ok {
+			continue
+		}
+
+		var secret corev1.Secret
+		err := r.Get(ctx, key, &secret)
+		if err == nil {
+			_, err = cluster.RESTConfigFromSecret(env, &secret)
+		}
+		if err != nil {
+			errs[env.Name] = err
+			continue
+		}
+		for j := range desired {
+			if desired[j].scope.Environment == env.Name {
+				desired[j].configHash += "/" + secret.ResourceVersion
+			}
+		}
+	}
+
+	r.credentialsMu.Lock()
+	defer r.credentialsMu.Unlock()
+	r.forgetCredentialsLocked(dc.Name)
+	for envName, err := range errs {
+		if r.credentialErrors == nil {
+			r.credentialErrors = make(map[string]error)
+		}
+		r.credentialErrors[dc.Name+"/"+envName] = err
+	}
+}
+
+// credentialsError returns the last credentials error of an environment, if any
+func (r *DiscoveryConfigReconciler) credentialsError(configName, envName string) error {
+	r.credentialsMu.Lock()
+	defer r.credentialsMu.Unlock()
+	return r.credentialErrors[configName+"/"+envName]
+}
+
+// forgetCredentials drops the credentials errors of a deleted DiscoveryConfig
+func (r *DiscoveryConfigReconciler) forgetCredentials(configName string) {
+	r.credentialsMu.Lock()
+	defer r.credentialsMu.Unlock()
+	r.forgetCredentialsLocked(configName)
+}
+
+// forgetCredentialsLocked drops the credentials errors of a DiscoveryConfig.
+// Callers must hold credentialsMu.
+func (r *DiscoveryConfigReconciler) forgetCredentialsLocked(configName string) {
+	for key := range r.credentialErrors {
+		if strings.HasPrefix(key, configName+"/") {
+			delete(r.credentialErrors, key)
+		}
+	}
+}
+
+// discoveryConfigsForSecret maps a Secret to the DiscoveryConfigs with an environment that
+// takes its credentials from it
+func (r *DiscoveryConfigReconciler) discoveryConfigsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	var list agentregistryv1alpha1.DiscoveryConfigList
+	if err := r.List(ctx, &list, client.InNamespace(config.GetNamespace())); err != nil {
+		r.Logger.Error().Err(err).Msg("failed to list DiscoveryConfigs for secret")
+		return nil
+	}
+
+	secretKey := client.ObjectKeyFromObject(obj)
+	var requests []reconcile.Request
+	for i := range list.Items {
+		dc := &list.Items[i]
+		for j := range dc.Spec.Environments {
+			if key, ok := cluster.CredentialsSecretKey(&dc.Spec.Environments[j]); ok && key == secretKey {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Name:      dc.Name,
+					Namespace: dc.Namespace,
+				}})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+func TestDiscoveryConfigReconciler_CheckCredentials(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	env := func(name, secret string) agentregistryv1alpha1.Environment {
+		e := agentregistryv1alpha1.Environment{
+			Name:          name,
+			Cluster:       agentregistryv1alpha1.ClusterConfig{Name: name, Endpoint: "https://10.0.0.1:6443"},
+			Namespaces:    []string{"tools"},
+			ResourceTypes: []string{"MCPServer"},
+		}
+		if secret != "" {
+			e.Cluster.CredentialsSecretRef = &agentregistryv1alpha1.ClusterCredentialsSecretRef{Name: secret}
+		}
+		return e
+	}
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{
+				env("k3s", "k3s-creds"),
+				env("kind", "missing"),
+				env("empty", "empty-creds"),
+				env("gke", ""),
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "k3s-creds", Namespace: testNamespace},
+		Data:       map[string][]byte{"token": []byte("token-1")},
+	}
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(dc, secret, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "empty-creds", Namespace: testNamespace}}).
+		Build()
+	r := &DiscoveryConfigReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop(), DiscoveredCache: NewDiscoveredResourceCache()}
+	ctx := context.Background()
+
+	hashes := func() map[string]string {
+		desired := r.desiredInformers(dc)
+		r.checkCredentials(ctx, dc, desired)
+		out := make(map[string]string)
+		for _, d := range desired {
+			out[d.scope.Environment] = d.configHash
+		}
+		return out
+	}
+
+	before := hashes()
+	require.NoError(t, r.credentialsError("discovery", "k3s"))
+	assert.NoError(t, r.credentialsError("discovery", "gke"))
+	require.Error(t, r.credentialsError("discovery", "kind"))
+	assert.Contains(t, r.credentialsError("discovery", "kind").Error(), "not found")
+	require.Error(t, r.credentialsError("discovery", "empty"))
+	assert.Contains(t, r.credentialsError("discovery", "empty").Error(), "has none of")
+
+	t.Run("errors are reported in environment status", func(t *testing.T) {
+		statuses := r.environmentStatuses(dc)
+		require.Len(t, statuses, 4)
+		assert.Contains(t, statuses[1].Error, "not found")
+		assert.False(t, statuses[1].Connected)
+		assert.Contains(t, statuses[2].Error, "has none of")
+	})
+
+	t.Run("rotating the secret restarts informers of its environment only", func(t *testing.T) {
+		secret.Data["token"] = []byte("token-2")
+		require.NoError(t, local.Update(ctx, secret))
+		after := hashes()
+		assert.NotEqual(t, before["k3s"], after["k3s"])
+		assert.Equal(t, before["gke"], after["gke"])
+	})
+
+	t.Run("fixed credentials clear the error", func(t *testing.T) {
+		require.NoError(t, local.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing", Namespace: testNamespace},
+			Data:       map[string][]byte{"token": []byte("token")},
+		}))
+		hashes()
+		assert.NoError(t, r.credentialsError("discovery", "kind"))
+	})
+
+	t.Run("secret events map to the configs that reference them", func(t *testing.T) {
+		requests := r.discoveryConfigsForSecret(ctx, secret)
+		assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "discovery", Namespace: testNamespace}}}, requests)
+
+		other := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "k3s-creds", Namespace: "elsewhere"}}
+		assert.Empty(t, r.discoveryConfigsForSecret(ctx, other))
+	})
+
+	t.Run("deleted configs forget their errors", func(t *testing.T) {
+		r.forgetCredentials("discovery")
+		assert.NoError(t, r.credentialsError("discovery", "empty"))
+	})
+}
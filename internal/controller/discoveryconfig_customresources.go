@@ -0,0 +1,494 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// builtinResourceTypes are the resource types discovery handles natively. Custom resource
+// mappings with the same name are ignored.
+var builtinResourceTypes = map[string]bool{
+	"MCPServer":                     true,
+	"Agent":                         true,
+	"ModelConfig":                   true,
+	"RemoteMCPServer":               true,
+	"Service":                       true,
+	"Deployment":                    true,
+	vllmResourceType:                true,
+	agentgatewayBackendResourceType: true,
+	kgatewayBackendResourceType:     true,
+	namespaceResourceType:           true,
+}
+
+// customResourceMapping returns the mapping named resourceType: a built-in served model
+// mapping, else one from dc. It returns nil if resourceType is built in or has no mapping.
+func customResourceMapping(dc *agentregistryv1alpha1.DiscoveryConfig, resourceType string) *agentregistryv1alpha1.CustomResourceMapping {
+	if builtinResourceTypes[resourceType] {
+		return nil
+	}
+	if mapping, ok := servedModelMappings[resourceType]; ok {
+		return &mapping
+	}
+	for i := range dc.Spec.CustomResources {
+		if dc.Spec.CustomResources[i].Name == resourceType {
+			return &dc.Spec.CustomResources[i]
+		}
+	}
+	return nil
+}
+
+// customResourceMappingHash hashes a mapping so informers restart when it changes
+func customResourceMappingHash(mapping *agentregistryv1alpha1.CustomResourceMapping) string {
+	data, _ := json.Marshal(mapping)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// mappedResourceType returns the built-in resource type producing the same catalog kind as
+// mapping, for pruning and resource counts
+func mappedResourceType(mapping *agentregistryv1alpha1.CustomResourceMapping) string {
+	switch mapping.CatalogKind {
+	case "Agent":
+		return "Agent"
+	case "Model":
+		return "ModelConfig"
+	default:
+		return "MCPServer"
+	}
+}
+
+// customResourceGVK resolves the kind of a mapping's resource on the remote cluster
+func customResourceGVK(remoteClient client.Client, mapping *agentregistryv1alpha1.CustomResourceMapping) (schema.GroupVersionKind, error) {
+	return resolveGVK(remoteClient, schema.GroupVersionResource{Group: mapping.Group, Version: mapping.Version, Resource: mapping.Resource})
+}
+
+// resolveGVK resolves the kind of a resource on the remote cluster
+func resolveGVK(remoteClient client.Client, gvr schema.GroupVersionResource) (schema.GroupVersionKind, error) {
+	gvk, err := remoteClient.RESTMapper().KindFor(gvr)
+	if err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("failed to resolve %s: %w", gvr.String(), err)
+	}
+	return gvk, nil
+}
+
+// newUnstructuredList returns an empty list of gvk for the remote client
+func newUnstructuredList(gvk schema.GroupVersionKind) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	return list
+}
+
+// createCustomResourceInformer creates a dynamic informer for the resource of a custom
+// resource mapping
+func (r *DiscoveryConfigReconciler) createCustomResourceInformer(
+	ctx context.Context,
+	remoteClient client.WithWatch,
+	listOpts []client.ListOption,
+	mapping *agentregistryv1alpha1.CustomResourceMapping,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+	logger zerolog.Logger,
+) (cache.SharedIndexInformer, error) {
+	compiled, err := compileMapping(mapping)
+	if err != nil {
+		return nil, err
+	}
+	gvk, err := customResourceGVK(remoteClient, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				list := newUnstructuredList(gvk)
+				err := remoteClient.List(context.Background(), list, listOpts...)
+				return list, err
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return remoteClient.Watch(context.Background(), newUnstructuredList(gvk), listOpts...)
+			},
+		},
+		&unstructured.Unstructured{},
+		0,
+		cache.Indexers{},
+	)
+
+	upsert := func(obj *unstructured.Unstructured) {
+		r.DiscoveredCache.Set(scope, mapping.Name, obj)
+		resourceKey := fmt.Sprintf("%s/%s/%s", mapping.Name, obj.GetNamespace(), obj.GetName())
+		r.executeWithRetry(ctx, scope, resourceKey, func() error {
+			return r.handleCustomResourceAdd(ctx, obj, compiled, env, scope)
+		}, logger)
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			u := obj.(*unstructured.Unstructured)
+			logger.Trace().Str("name", u.GetName()).Str("kind", gvk.Kind).Msg("custom resource added")
+			upsert(u)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			u := newObj.(*unstructured.Unstructured)
+			logger.Trace().Str("name", u.GetName()).Str("kind", gvk.Kind).Msg("custom resource updated")
+			upsert(u)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			u, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return
+			}
+			logger.Trace().Str("name", u.GetName()).Str("kind", gvk.Kind).Msg("custom resource deleted")
+			r.DiscoveredCache.Delete(scope, mapping.Name, u.GetNamespace(), u.GetName())
+		},
+	})
+
+	return informer, nil
+}
+
+// customResourceEntry holds the catalog fields extracted from a custom resource
+type customResourceEntry struct {
+	name        string
+	version     string
+	description string
+	url         string
+	ready       bool
+	message     string
+	image       string
+	provider    string
+	model       string
+	runtime     string
+}
+
+// extract evaluates the field expressions of a mapping against obj. A failing readiness
+// expression marks the entry not ready; any other failing expression is an error.
+func (c *compiledMapping) extract(obj *unstructured.Unstructured) (*customResourceEntry, error) {
+	entry := &customResourceEntry{}
+	fields := []struct {
+		name string
+		expr fieldExpression
+		dst  *string
+	}{
+		{"name", c.name, &entry.name},
+		{"version", c.version, &entry.version},
+		{"description", c.description, &entry.description},
+		{"url", c.url, &entry.url},
+		{"image", c.image, &entry.image},
+		{"provider", c.provider, &entry.provider},
+		{"model", c.model, &entry.model},
+		{"runtime", c.runtime, &entry.runtime},
+	}
+	for _, f := range fields {
+		value, err := evaluateString(f.expr, obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %s: evaluating %s: %w", c.mapping.Name, f.name, err)
+		}
+		*f.dst = value
+	}
+
+	ready, err := evaluateBool(c.ready, obj.Object)
+	if err != nil {
+		entry.message = fmt.Sprintf("evaluating ready: %v", err)
+	}
+	entry.ready = ready
+
+	if entry.name == "" {
+		entry.name = fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+	}
+	if entry.version == "" {
+		entry.version = "latest"
+		if v, ok := obj.GetLabels()["app.kubernetes.io/version"]; ok {
+			entry.version = v
+		}
+	}
+	return entry, nil
+}
+
+// handleCustomResourceAdd creates or updates the catalog entry of a resource matched by a
+// custom resource mapping
+func (r *DiscoveryConfigReconciler) handleCustomResourceAdd(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	compiled *compiledMapping,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+) error {
+	if isManagedByRegistry(obj.GetLabels()) {
+		return nil
+	}
+
+	mapping := compiled.mapping
+	entry, err := compiled.extract(obj)
+	if err != nil {
+		// Expressions won't evaluate differently on retry
+		r.Logger.Warn().Err(err).Str("name", obj.GetName()).Str("namespace", obj.GetNamespace()).Msg("Skipping custom resource")
+		return nil
+	}
+
+	return r.upsertMappedEntry(ctx, mapping.CatalogKind, mapping.Name, &agentregistryv1alpha1.SourceReference{
+		Kind:      obj.GetKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+	}, entry, env, scope)
+}
+
+// upsertMappedEntry creates or updates the catalog entry of kind catalogKind described by entry.
+// sourceKind names what produced the entry (a custom resource mapping or an inventory record)
+// and is part of the catalog name.
+func (r *DiscoveryConfigReconciler) upsertMappedEntry(
+	ctx context.Context,
+	catalogKind string,
+	sourceKind string,
+	sourceRef *agentregistryv1alpha1.SourceReference,
+	entry *customResourceEntry,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+) error {
+	labels := make(map[string]string)
+	for k, v := range env.Labels {
+		labels[k] = v
+	}
+	labels[discoveryLabel] = "true"
+	labels[sourceKindLabel] = sourceKind
+	labels[sourceNameLabel] = sourceRef.Name
+	labels[sourceNSLabel] = sourceRef.Namespace
+	labels[envLabel] = env.Name
+	labels[clusterLabel] = env.Cluster.Name
+	labels[discoveryConfigLabel] = scope.Config
+
+	objectMeta := metav1.ObjectMeta{
+		Name:      generateDiscoveredCatalogName(sourceKind, env.Name, env.Cluster.Name, sourceRef.Namespace, sourceRef.Name),
+		Namespace: config.GetNamespace(),
+		Labels:    labels,
+	}
+	now := metav1.Now()
+	deployment := &agentregistryv1alpha1.DeploymentRef{
+		Namespace:   sourceRef.Namespace,
+		URL:         entry.url,
+		Ready:       entry.ready,
+		Message:     entry.message,
+		LastChecked: &now,
+	}
+
+	switch catalogKind {
+	case "Agent":
+		return r.upsertCustomAgent(ctx, &agentregistryv1alpha1.AgentCatalog{
+			ObjectMeta: objectMeta,
+			Spec: agentregistryv1alpha1.AgentCatalogSpec{
+				Name:        entry.name,
+				Version:     entry.version,
+				Title:       sourceRef.Name,
+				Description: entry.description,
+				Image:       entry.image,
+			},
+		}, deployment)
+	case "Model":
+		return r.upsertDiscoveredModel(ctx, &agentregistryv1alpha1.ModelCatalog{
+			ObjectMeta: objectMeta,
+			Spec: agentregistryv1alpha1.ModelCatalogSpec{
+				Name:        entry.name,
+				Provider:    entry.provider,
+				Model:       entry.model,
+				BaseURL:     entry.url,
+				Runtime:     entry.runtime,
+				Description: entry.description,
+				SourceRef:   sourceRef,
+			},
+		}, entry.ready, entry.message)
+	default:
+		var remotes []agentregistryv1alpha1.Transport
+		if entry.url != "" {
+			remotes = []agentregistryv1alpha1.Transport{{Type: "streamable-http", URL: entry.url}}
+		}
+		return r.upsertCustomMCPServer(ctx, &agentregistryv1alpha1.MCPServerCatalog{
+			ObjectMeta: objectMeta,
+			Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:        entry.name,
+				Version:     entry.version,
+				Title:       sourceRef.Name,
+				Description: entry.description,
+				SourceRef:   sourceRef,
+				Remotes:     remotes,
+			},
+		}, deployment, nil)
+	}
+}
+
+// upsertCustomMCPServer creates or updates an MCPServerCatalog produced by a custom resource
+// mapping or a gateway backend
+func (r *DiscoveryConfigReconciler) upsertCustomMCPServer(
+	ctx context.Context,
+	catalog *agentregistryv1alpha1.MCPServerCatalog,
+	deployment *agentregistryv1alpha1.DeploymentRef,
+	gateway *agentregistryv1alpha1.GatewayExposure,
+) error {
+	existing := &agentregistryv1alpha1.MCPServerCatalog{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(catalog), existing)
+
+	if apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, catalog); err != nil {
+			return err
+		}
+		catalog.Status.ManagementType = agentregistryv1alpha1.ManagementTypeExternal
+		catalog.Status.Published = true
+		catalog.Status.Status = agentregistryv1alpha1.CatalogStatusActive
+		catalog.Status.Deployment = deployment
+		catalog.Status.Gateway = gateway
+		return r.Status().Update(ctx, catalog)
+	} else if err != nil {
+		return err
+	}
+
+	existing.Spec = catalog.Spec
+	existing.Labels = catalog.Labels
+	if err := r.Update(ctx, existing); err != nil {
+		return err
+	}
+	if existing.Status.ManagementType == "" {
+		existing.Status.ManagementType = agentregistryv1alpha1.ManagementTypeExternal
+		existing.Status.Published = true
+		existing.Status.Status = agentregistryv1alpha1.CatalogStatusActive
+	}
+	if existing.Status.ManagementType != agentregistryv1alpha1.ManagementTypeExternal {
+		return nil
+	}
+	existing.Status.Deployment = deployment
+	existing.Status.Gateway = gateway
+	return r.Status().Update(ctx, existing)
+}
+
+// upsertCustomAgent creates or updates an AgentCatalog produced by a custom resource mapping.
+// AgentCatalogSpec has no SourceRef, so the source is only recorded in the discovery labels.
+func (r *DiscoveryConfigReconciler) upsertCustomAgent(
+	ctx context.Context,
+	catalog *agentregistryv1alpha1.AgentCatalog,
+	deployment *agentregistryv1alpha1.DeploymentRef,
+) error {
+	existing := &agentregistryv1alpha1.AgentCatalog{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(catalog), existing)
+
+	if apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, catalog); err != nil {
+			return err
+		}
+		catalog.Status.ManagementType = agentregistryv1alpha1.ManagementTypeExternal
+		catalog.Status.Published = true
+		catalog.Status.Status = agentregistryv1alpha1.CatalogStatusActive
+		catalog.Status.Deployment = deployment
+		return r.Status().Update(ctx, catalog)
+	} else if err != nil {
+		return err
+	}
+
+	existing.Spec = catalog.Spec
+	existing.Labels = catalog.Labels
+	if err := r.Update(ctx, existing); err != nil {
+		return err
+	}
+	if existing.Status.ManagementType == "" {
+		existing.Status.ManagementType = agentregistryv1alpha1.ManagementTypeExternal
+		existing.Status.Published = true
+		existing.Status.Status = agentregistryv1alpha1.CatalogStatusActive
+	}
+	if existing.Status.ManagementType != agentregistryv1alpha1.ManagementTypeExternal {
+		return nil
+	}
+	existing.Status.Deployment = deployment
+	return r.Status().Update(ctx, existing)
+}
+
+// upsertDiscoveredModel creates or updates a ModelCatalog produced by a custom resource mapping
+// or a model server
+func (r *DiscoveryConfigReconciler) upsertDiscoveredModel(
+	ctx context.Context,
+	catalog *agentregistryv1alpha1.ModelCatalog,
+	ready bool,
+	message string,
+) error {
+	existing := &agentregistryv1alpha1.ModelCatalog{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(catalog), existing)
+
+	if apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, catalog); err != nil {
+			return err
+		}
+		catalog.Status.ManagementType = agentregistryv1alpha1.ManagementTypeExternal
+		catalog.Status.Published = true
+		catalog.Status.Status = agentregistryv1alpha1.CatalogStatusActive
+		catalog.Status.Ready = ready
+		catalog.Status.Message = message
+		return r.Status().Update(ctx, catalog)
+	} else if err != nil {
+		return err
+	}
+
+	existing.Spec = catalog.Spec
+	existing.Labels = catalog.Labels
+	if err := r.Update(ctx, existing); err != nil {
+		return err
+	}
+	if existing.Status.ManagementType == "" {
+		existing.Status.ManagementType = agentregistryv1alpha1.ManagementTypeExternal
+		existing.Status.Published = true
+		existing.Status.Status = agentregistryv1alpha1.CatalogStatusActive
+	}
+	if existing.Status.ManagementType != agentregistryv1alpha1.ManagementTypeExternal {
+		return nil
+	}
+	existing.Status.Ready = ready
+	existing.Status.Message = message
+	return r.Status().Update(ctx, existing)
+}
+
+// relistCustomResources lists the resources of a custom resource mapping for a forced resync
+func (r *DiscoveryConfigReconciler) relistCustomResources(
+	ctx context.Context,
+	remoteClient client.Client,
+	listOpts []client.ListOption,
+	d desiredInformer,
+	catalogName func(client.Object) string,
+) ([]relistedResource, error) {
+	compiled, err := compileMapping(d.mapping)
+	if err != nil {
+		return nil, err
+	}
+	gvk, err := customResourceGVK(remoteClient, d.mapping)
+	if err != nil {
+		return nil, err
+	}
+	list := newUnstructuredList(gvk)
+	if err := remoteClient.List(ctx, list, listOpts...); err != nil {
+		return nil, err
+	}
+
+	env := &d.env
+	var resources []relistedResource
+	for i := range list.Items {
+		item := &list.Items[i]
+		resources = append(resources, relistedResource{
+			obj:         item,
+			catalogName: catalogName(item),
+			apply:       func() error { return r.handleCustomResourceAdd(ctx, item, compiled, env, d.scope) },
+		})
+	}
+	return resources, nil
+}
@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+var servedModelGVK = schema.GroupVersionKind{Group: "serving.example.dev", Version: "v1", Kind: "ServedModel"}
+
+func servedModel(name string, ready bool) *unstructured.Unstructured {
+	status := "False"
+	if ready {
+		status = "True"
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"model": map[string]interface{}{"format": "huggingface", "name": "meta-llama/Llama-3.1-8B"},
+		},
+		"status": map[string]interface{}{
+			"url":        "http://" + name + ".models.svc",
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": status}},
+		},
+	}}
+	obj.SetGroupVersionKind(servedModelGVK)
+	obj.SetName(name)
+	obj.SetNamespace("models")
+	return obj
+}
+
+func servedModelMapping(catalogKind string) agentregistryv1alpha1.CustomResourceMapping {
+	return agentregistryv1alpha1.CustomResourceMapping{
+		Name:        "ServedModel",
+		Group:       servedModelGVK.Group,
+		Version:     servedModelGVK.Version,
+		Resource:    "servedmodels",
+		CatalogKind: catalogKind,
+		Fields: agentregistryv1alpha1.CustomResourceFields{
+			Name:     "cel:'models/' + object.metadata.name",
+			URL:      ".status.url",
+			Ready:    `{.status.conditions[?(@.type=="Ready")].status}`,
+			Provider: "cel:'OpenAI'",
+			Model:    ".spec.model.name",
+		},
+	}
+}
+
+func TestDiscoveryConfigReconciler_HandleCustomResourceAdd(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&agentregistryv1alpha1.MCPServerCatalog{}, &agentregistryv1alpha1.ModelCatalog{}).
+		Build()
+	r := &DiscoveryConfigReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop()}
+
+	env := &agentregistryv1alpha1.Environment{Name: "dev", Cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev"}}
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev"}
+	ctx := context.Background()
+	catalogKey := client.ObjectKey{
+		Name:      generateDiscoveredCatalogName("ServedModel", "dev", "dev", "models", "llama"),
+		Namespace: testNamespace,
+	}
+
+	t.Run("model", func(t *testing.T) {
+		mapping := servedModelMapping("Model")
+		compiled, err := compileMapping(&mapping)
+		require.NoError(t, err)
+		require.NoError(t, r.handleCustomResourceAdd(ctx, servedModel("llama", true), compiled, env, scope))
+
+		var catalog agentregistryv1alpha1.ModelCatalog
+		require.NoError(t, local.Get(ctx, catalogKey, &catalog))
+		assert.Equal(t, "models/llama", catalog.Spec.Name)
+		assert.Equal(t, "OpenAI", catalog.Spec.Provider)
+		assert.Equal(t, "meta-llama/Llama-3.1-8B", catalog.Spec.Model)
+		assert.Equal(t, "http://llama.models.svc", catalog.Spec.BaseURL)
+		assert.Equal(t, "ServedModel", catalog.Spec.SourceRef.Kind)
+		assert.Equal(t, "ServedModel", catalog.Labels[sourceKindLabel])
+		assert.Equal(t, "discovery", catalog.Labels[discoveryConfigLabel])
+		assert.Equal(t, agentregistryv1alpha1.ManagementTypeExternal, catalog.Status.ManagementType)
+		assert.True(t, catalog.Status.Ready)
+
+		require.NoError(t, r.handleCustomResourceAdd(ctx, servedModel("llama", false), compiled, env, scope))
+		require.NoError(t, local.Get(ctx, catalogKey, &catalog))
+		assert.False(t, catalog.Status.Ready)
+	})
+
+	t.Run("mcp server", func(t *testing.T) {
+		mapping := servedModelMapping("MCPServer")
+		mapping.Fields.Name = ""
+		compiled, err := compileMapping(&mapping)
+		require.NoError(t, err)
+		require.NoError(t, r.handleCustomResourceAdd(ctx, servedModel("llama", true), compiled, env, scope))
+
+		var catalog agentregistryv1alpha1.MCPServerCatalog
+		require.NoError(t, local.Get(ctx, catalogKey, &catalog))
+		assert.Equal(t, "models/llama", catalog.Spec.Name)
+		assert.Equal(t, "latest", catalog.Spec.Version)
+		assert.Equal(t, []agentregistryv1alpha1.Transport{{Type: "streamable-http", URL: "http://llama.models.svc"}}, catalog.Spec.Remotes)
+		require.NotNil(t, catalog.Status.Deployment)
+		assert.True(t, catalog.Status.Deployment.Ready)
+	})
+
+	t.Run("failing expression skips the resource", func(t *testing.T) {
+		mapping := servedModelMapping("MCPServer")
+		mapping.Name = "Broken"
+		mapping.Fields.Description = "cel:object.spec.missing"
+		compiled, err := compileMapping(&mapping)
+		require.NoError(t, err)
+		require.NoError(t, r.handleCustomResourceAdd(ctx, servedModel("llama", true), compiled, env, scope))
+
+		var catalogs agentregistryv1alpha1.MCPServerCatalogList
+		require.NoError(t, local.List(ctx, &catalogs, client.MatchingLabels{sourceKindLabel: "Broken"}))
+		assert.Empty(t, catalogs.Items)
+	})
+}
+
+func TestDiscoveryConfigReconciler_CustomResourceInformers(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(servedModelGVK, meta.RESTScopeNamespace)
+	remote := &testClientWithWatch{Client: fake.NewClientBuilder().
+		WithRESTMapper(mapper).
+		WithObjects(servedModel("llama", true), servedModel("mistral", false)).
+		Build()}
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&agentregistryv1alpha1.ModelCatalog{}).
+		Build()
+
+	origFactory := RemoteClientFactory
+	RemoteClientFactory = func(DiscoveryScope, *agentregistryv1alpha1.Environment, *runtime.Scheme) (client.WithWatch, error) {
+		return remote, nil
+	}
+	defer func() { RemoteClientFactory = origFactory }()
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{{
+				Name:          "dev",
+				Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "dev"},
+				Namespaces:    []string{"models"},
+				ResourceTypes: []string{"MCPServer", "ServedModel", "Unknown"},
+			}},
+			CustomResources: []agentregistryv1alpha1.CustomResourceMapping{servedModelMapping("Model")},
+		},
+	}
+
+	r := &DiscoveryConfigReconciler{
+		Client:          local,
+		Scheme:          scheme,
+		Logger:          zerolog.Nop(),
+		DiscoveredCache: NewDiscoveredResourceCache(),
+	}
+
+	desired := r.desiredInformers(dc)
+	require.Len(t, desired, 3)
+	assert.Nil(t, desired[0].mapping)
+	require.NotNil(t, desired[1].mapping)
+	assert.Equal(t, "servedmodels", desired[1].mapping.Resource)
+	assert.Nil(t, desired[2].mapping)
+
+	// Changing the mapping restarts its informer
+	changed := dc.DeepCopy()
+	changed.Spec.CustomResources[0].Fields.URL = ".status.address.url"
+	assert.NotEqual(t, desired[1].configHash, r.desiredInformers(changed)[1].configHash)
+	assert.Equal(t, desired[0].configHash, r.desiredInformers(changed)[0].configHash)
+
+	// A relist resolves the kind through the RESTMapper and lists unstructured resources
+	resources, err := r.relist(context.Background(), desired[1])
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+	for _, res := range resources {
+		require.NoError(t, res.apply())
+	}
+
+	var catalogs agentregistryv1alpha1.ModelCatalogList
+	require.NoError(t, local.List(context.Background(), &catalogs))
+	assert.Len(t, catalogs.Items, 2)
+	assert.Equal(t, 2, r.discoveredCounts(desired[1].scope, dc).Models)
+
+	// Unmapped resource types have no informer
+	_, err = r.relist(context.Background(), desired[2])
+	assert.ErrorContains(t, err, "unsupported resource type")
+}
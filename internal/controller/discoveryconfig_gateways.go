@@ -0,0 +1,505 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+const (
+	// agentgatewayBackendResourceType is the resource type of agentgateway backends
+	agentgatewayBackendResourceType = "AgentgatewayBackend"
+	// kgatewayBackendResourceType is the resource type of kgateway backends
+	kgatewayBackendResourceType = "KgatewayBackend"
+
+	// gatewayBackendResyncPeriod is how often gateway backends are re-resolved, since changes to
+	// the routes and Gateways exposing them produce no backend events
+	gatewayBackendResyncPeriod = 5 * time.Minute
+)
+
+// gatewayBackendResources are the gateway backend resources discovered as MCP servers
+var gatewayBackendResources = map[string]schema.GroupVersionResource{
+	agentgatewayBackendResourceType: {Group: "agentgateway.dev", Version: "v1alpha1", Resource: "agentgatewaybackends"},
+	kgatewayBackendResourceType:     {Group: "gateway.kgateway.dev", Version: "v1alpha1", Resource: "backends"},
+}
+
+var (
+	httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+	gatewayGVK   = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "Gateway"}
+)
+
+// gatewayMCPTargets returns the MCP targets of an agentgateway or kgateway backend. ok is false
+// if the backend is not an MCP backend.
+func gatewayMCPTargets(backend *unstructured.Unstructured) (targets []agentregistryv1alpha1.GatewayMCPTarget, ok bool) {
+	items, found, err := unstructured.NestedSlice(backend.Object, "spec", "mcp", "targets")
+	if err != nil || !found {
+		return nil, false
+	}
+	for _, item := range items {
+		target, isMap := item.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(target, "name")
+		if static, found, _ := unstructured.NestedMap(target, "static"); found {
+			if name == "" {
+				// kgateway names static targets inside the static block
+				name, _, _ = unstructured.NestedString(static, "name")
+			}
+			targets = append(targets, agentregistryv1alpha1.GatewayMCPTarget{Name: name, URL: staticMCPTargetURL(static)})
+			continue
+		}
+		if selector, found, _ := unstructured.NestedFieldNoCopy(target, "selector"); found {
+			data, _ := json.Marshal(selector)
+			targets = append(targets, agentregistryv1alpha1.GatewayMCPTarget{Name: name, Selector: string(data)})
+		}
+	}
+	return targets, true
+}
+
+// staticMCPTargetURL builds the URL of a static MCP target. The path defaults to the one the
+// gateway uses for the target's protocol.
+func staticMCPTargetURL(static map[string]interface{}) string {
+	host, _, _ := unstructured.NestedString(static, "host")
+	protocol, _, _ := unstructured.NestedString(static, "protocol")
+	path, _, _ := unstructured.NestedString(static, "path")
+	if path == "" {
+		path = "/mcp"
+		if strings.EqualFold(protocol, "SSE") {
+			path = "/sse"
+		}
+	}
+	if port := unstructuredInt(static, "port"); port > 0 {
+		host = net.JoinHostPort(host, strconv.FormatInt(port, 10))
+	}
+	return "http://" + host + path
+}
+
+// unstructuredInt returns an integer field of obj, which decodes as int64 or float64
+func unstructuredInt(obj map[string]interface{}, fields ...string) int64 {
+	value, found, _ := unstructured.NestedFieldNoCopy(obj, fields...)
+	if !found {
+		return 0
+	}
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
+
+// gatewayRoute is how a gateway backend is reached from outside the cluster
+type gatewayRoute struct {
+	exposure agentregistryv1alpha1.GatewayExposure
+	ready    bool
+	message  string
+}
+
+// resolveGatewayRoute finds the HTTPRoute in the backend's namespace that routes to it and the
+// Gateway listener the route is attached to, and builds the URL clients reach the backend at
+func resolveGatewayRoute(ctx context.Context, remoteClient client.Client, backend *unstructured.Unstructured) (gatewayRoute, error) {
+	routes := newUnstructuredList(httpRouteGVK)
+	if err := remoteClient.List(ctx, routes, client.InNamespace(backend.GetNamespace())); err != nil {
+		if meta.IsNoMatchError(err) {
+			return gatewayRoute{message: "Gateway API is not installed"}, nil
+		}
+		return gatewayRoute{}, fmt.Errorf("list HTTPRoutes: %w", err)
+	}
+	sort.Slice(routes.Items, func(i, j int) bool { return routes.Items[i].GetName() < routes.Items[j].GetName() })
+
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		path, ok := routePathTo(route, backend)
+		if !ok {
+			continue
+		}
+		parents, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+		for _, p := range parents {
+			parent, isMap := p.(map[string]interface{})
+			if !isMap || !isGatewayParent(parent) {
+				continue
+			}
+			result, found, err := exposeThroughGateway(ctx, remoteClient, route, parent, path)
+			if err != nil {
+				return gatewayRoute{}, err
+			}
+			if found {
+				return result, nil
+			}
+		}
+	}
+	return gatewayRoute{message: "No HTTPRoute attached to a Gateway routes to the backend"}, nil
+}
+
+// routePathTo returns the path of the first rule of route with a backendRef to backend
+func routePathTo(route, backend *unstructured.Unstructured) (string, bool) {
+	gvk := backend.GroupVersionKind()
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	for _, r := range rules {
+		rule, isMap := r.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+		refs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		for _, b := range refs {
+			ref, isMap := b.(map[string]interface{})
+			if !isMap {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(ref, "name")
+			group, _, _ := unstructured.NestedString(ref, "group")
+			kind, _, _ := unstructured.NestedString(ref, "kind")
+			namespace, _, _ := unstructured.NestedString(ref, "namespace")
+			if name != backend.GetName() || group != gvk.Group || kind != gvk.Kind ||
+				(namespace != "" && namespace != backend.GetNamespace()) {
+				continue
+			}
+			matches, _, _ := unstructured.NestedSlice(rule, "matches")
+			for _, m := range matches {
+				if match, isMap := m.(map[string]interface{}); isMap {
+					if value, _, _ := unstructured.NestedString(match, "path", "value"); value != "" {
+						return value, true
+					}
+				}
+			}
+			return "/", true
+		}
+	}
+	return "", false
+}
+
+// isGatewayParent reports whether an HTTPRoute parentRef refers to a Gateway
+func isGatewayParent(parent map[string]interface{}) bool {
+	group, _, _ := unstructured.NestedString(parent, "group")
+	kind, _, _ := unstructured.NestedString(parent, "kind")
+	return (group == "" || group == gatewayGVK.Group) && (kind == "" || kind == gatewayGVK.Kind)
+}
+
+// exposeThroughGateway resolves the listener of the Gateway a parentRef points to. found is
+// false if the Gateway or a matching listener does not exist.
+func exposeThroughGateway(
+	ctx context.Context,
+	remoteClient client.Client,
+	route *unstructured.Unstructured,
+	parent map[string]interface{},
+	path string,
+) (gatewayRoute, bool, error) {
+	name, _, _ := unstructured.NestedString(parent, "name")
+	namespace, _, _ := unstructured.NestedString(parent, "namespace")
+	if namespace == "" {
+		namespace = route.GetNamespace()
+	}
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(gatewayGVK)
+	if err := remoteClient.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, gateway); err != nil {
+		if apierrors.IsNotFound(err) {
+			return gatewayRoute{}, false, nil
+		}
+		return gatewayRoute{}, false, fmt.Errorf("get Gateway %s/%s: %w", namespace, name, err)
+	}
+
+	sectionName, _, _ := unstructured.NestedString(parent, "sectionName")
+	listener := gatewayListener(gateway, sectionName, unstructuredInt(parent, "port"))
+	if listener == nil {
+		return gatewayRoute{}, false, nil
+	}
+	listenerName, _, _ := unstructured.NestedString(listener, "name")
+	protocol, _, _ := unstructured.NestedString(listener, "protocol")
+	port := unstructuredInt(listener, "port")
+
+	result := gatewayRoute{exposure: agentregistryv1alpha1.GatewayExposure{
+		Gateway:  namespace + "/" + name,
+		Listener: listenerName,
+		Route:    route.GetNamespace() + "/" + route.GetName(),
+	}}
+	host := gatewayHost(gateway, listener, route)
+	if host == "" {
+		result.message = fmt.Sprintf("Gateway %s/%s has no hostname or address", namespace, name)
+		return result, true, nil
+	}
+
+	scheme, defaultPort := "http", int64(80)
+	if protocol == "HTTPS" {
+		scheme, defaultPort = "https", 443
+	}
+	if port > 0 && port != defaultPort {
+		host = net.JoinHostPort(host, strconv.FormatInt(port, 10))
+	}
+	if path == "/" {
+		// Gateways serve MCP backends on any path of the route; /mcp is the conventional one
+		path = "/mcp"
+	}
+	result.exposure.URL = scheme + "://" + host + path
+
+	result.ready = true
+	result.message = fmt.Sprintf("Exposed on listener %s of Gateway %s/%s by HTTPRoute %s", listenerName, namespace, name, result.exposure.Route)
+	conditions, _, _ := unstructured.NestedSlice(gateway.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, isMap := c.(map[string]interface{})
+		if !isMap || condition["type"] != "Programmed" {
+			continue
+		}
+		if condition["status"] != string(metav1.ConditionTrue) {
+			result.ready = false
+			reason, _, _ := unstructured.NestedString(condition, "message")
+			if reason == "" {
+				reason, _, _ = unstructured.NestedString(condition, "reason")
+			}
+			result.message = fmt.Sprintf("Gateway %s/%s is not programmed: %s", namespace, name, reason)
+		}
+	}
+	return result, true, nil
+}
+
+// gatewayListener picks the listener a route attaches to: the named section, else the listener
+// on port, else the first HTTP or HTTPS listener
+func gatewayListener(gateway *unstructured.Unstructured, sectionName string, port int64) map[string]interface{} {
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	var fallback map[string]interface{}
+	for _, l := range listeners {
+		listener, isMap := l.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(listener, "name")
+		protocol, _, _ := unstructured.NestedString(listener, "protocol")
+		switch {
+		case sectionName != "":
+			if name == sectionName {
+				return listener
+			}
+		case port > 0:
+			if unstructuredInt(listener, "port") == port {
+				return listener
+			}
+		case fallback == nil && (protocol == "HTTP" || protocol == "HTTPS"):
+			fallback = listener
+		}
+	}
+	return fallback
+}
+
+// gatewayHost returns the host clients reach a listener at: its hostname, else the first
+// hostname of the route, else the first address of the Gateway. Wildcard hostnames are skipped.
+func gatewayHost(gateway *unstructured.Unstructured, listener map[string]interface{}, route *unstructured.Unstructured) string {
+	if hostname, _, _ := unstructured.NestedString(listener, "hostname"); hostname != "" && !strings.HasPrefix(hostname, "*") {
+		return hostname
+	}
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	for _, hostname := range hostnames {
+		if !strings.HasPrefix(hostname, "*") {
+			return hostname
+		}
+	}
+	addresses, _, _ := unstructured.NestedSlice(gateway.Object, "status", "addresses")
+	for _, a := range addresses {
+		if address, isMap := a.(map[string]interface{}); isMap {
+			if value, _, _ := unstructured.NestedString(address, "value"); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// createGatewayBackendInformer creates an informer for the agentgateway or kgateway backends
+// of resourceType. Backends without MCP targets are ignored.
+func (r *DiscoveryConfigReconciler) createGatewayBackendInformer(
+	ctx context.Context,
+	remoteClient client.WithWatch,
+	listOpts []client.ListOption,
+	resourceType string,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+	logger zerolog.Logger,
+) (cache.SharedIndexInformer, error) {
+	gvk, err := resolveGVK(remoteClient, gatewayBackendResources[resourceType])
+	if err != nil {
+		return nil, err
+	}
+
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				list := newUnstructuredList(gvk)
+				err := remoteClient.List(context.Background(), list, listOpts...)
+				return list, err
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return remoteClient.Watch(context.Background(), newUnstructuredList(gvk), listOpts...)
+			},
+		},
+		&unstructured.Unstructured{},
+		gatewayBackendResyncPeriod,
+		cache.Indexers{},
+	)
+
+	upsert := func(backend *unstructured.Unstructured) {
+		if _, ok := gatewayMCPTargets(backend); !ok {
+			r.DiscoveredCache.Delete(scope, resourceType, backend.GetNamespace(), backend.GetName())
+			return
+		}
+		r.DiscoveredCache.Set(scope, resourceType, backend)
+		resourceKey := fmt.Sprintf("%s/%s/%s", resourceType, backend.GetNamespace(), backend.GetName())
+		r.executeWithRetry(ctx, scope, resourceKey, func() error {
+			return r.handleGatewayBackendAdd(ctx, backend, resourceType, remoteClient, env, scope)
+		}, logger)
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			backend := obj.(*unstructured.Unstructured)
+			logger.Trace().Str("backend", backend.GetName()).Msg("gateway backend added")
+			upsert(backend)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			backend := newObj.(*unstructured.Unstructured)
+			logger.Trace().Str("backend", backend.GetName()).Msg("gateway backend updated")
+			upsert(backend)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			backend, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				return
+			}
+			logger.Trace().Str("backend", backend.GetName()).Msg("gateway backend deleted")
+			r.DiscoveredCache.Delete(scope, resourceType, backend.GetNamespace(), backend.GetName())
+		},
+	})
+
+	return informer, nil
+}
+
+// handleGatewayBackendAdd creates or updates the MCPServerCatalog entry of a gateway MCP
+// backend. Its remote and deployment URL are the gateway-fronted endpoint, not the targets.
+func (r *DiscoveryConfigReconciler) handleGatewayBackendAdd(
+	ctx context.Context,
+	backend *unstructured.Unstructured,
+	resourceType string,
+	remoteClient client.Client,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+) error {
+	targets, ok := gatewayMCPTargets(backend)
+	if !ok || isManagedByRegistry(backend.GetLabels()) {
+		return nil
+	}
+	route, err := resolveGatewayRoute(ctx, remoteClient, backend)
+	if err != nil {
+		return err
+	}
+	route.exposure.Targets = targets
+
+	name := fmt.Sprintf("%s/%s", backend.GetNamespace(), backend.GetName())
+	if n := backend.GetAnnotations()[agentregistryv1alpha1.AnnotationMCPName]; n != "" {
+		name = n
+	}
+	version := "latest"
+	if v, ok := backend.GetLabels()["app.kubernetes.io/version"]; ok {
+		version = v
+	}
+	var remotes []agentregistryv1alpha1.Transport
+	if route.exposure.URL != "" {
+		remotes = []agentregistryv1alpha1.Transport{{Type: "streamable-http", URL: route.exposure.URL}}
+	}
+
+	labels := make(map[string]string)
+	for k, v := range env.Labels {
+		labels[k] = v
+	}
+	labels[discoveryLabel] = "true"
+	labels[sourceKindLabel] = resourceType
+	labels[sourceNameLabel] = backend.GetName()
+	labels[sourceNSLabel] = backend.GetNamespace()
+	labels[envLabel] = env.Name
+	labels[clusterLabel] = env.Cluster.Name
+	labels[discoveryConfigLabel] = scope.Config
+
+	now := metav1.Now()
+	return r.upsertCustomMCPServer(ctx, &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateDiscoveredCatalogName(resourceType, env.Name, env.Cluster.Name, backend.GetNamespace(), backend.GetName()),
+			Namespace: config.GetNamespace(),
+			Labels:    labels,
+		},
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+			Name:    name,
+			Version: version,
+			Title:   backend.GetName(),
+			SourceRef: &agentregistryv1alpha1.SourceReference{
+				Kind:      backend.GetKind(),
+				Name:      backend.GetName(),
+				Namespace: backend.GetNamespace(),
+			},
+			Remotes: remotes,
+		},
+	}, &agentregistryv1alpha1.DeploymentRef{
+		Namespace:   backend.GetNamespace(),
+		URL:         route.exposure.URL,
+		Ready:       route.ready,
+		Message:     route.message,
+		LastChecked: &now,
+	}, &route.exposure)
+}
+
+// relistGatewayBackends lists the MCP backends of a gateway backend resource type for a forced resync
+func (r *DiscoveryConfigReconciler) relistGatewayBackends(
+	ctx context.Context,
+	remoteClient client.Client,
+	listOpts []client.ListOption,
+	d desiredInformer,
+	catalogName func(client.Object) string,
+) ([]relistedResource, error) {
+	gvk, err := resolveGVK(remoteClient, gatewayBackendResources[d.resourceType])
+	if err != nil {
+		return nil, err
+	}
+	list := newUnstructuredList(gvk)
+	if err := remoteClient.List(ctx, list, listOpts...); err != nil {
+		return nil, err
+	}
+
+	env := &d.env
+	var resources []relistedResource
+	for i := range list.Items {
+		item := &list.Items[i]
+		if _, ok := gatewayMCPTargets(item); !ok {
+			continue
+		}
+		resources = append(resources, relistedResource{
+			obj:         item,
+			catalogName: catalogName(item),
+			apply: func() error {
+				return r.handleGatewayBackendAdd(ctx, item, d.resourceType, remoteClient, env, d.scope)
+			},
+		})
+	}
+	return resources, nil
+}
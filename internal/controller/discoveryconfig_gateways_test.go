@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+var (
+	agentgatewayBackendGVK = schema.GroupVersionKind{Group: "agentgateway.dev", Version: "v1alpha1", Kind: "AgentgatewayBackend"}
+	kgatewayBackendGVK     = schema.GroupVersionKind{Group: "gateway.kgateway.dev", Version: "v1alpha1", Kind: "Backend"}
+)
+
+func gatewayObject(gvk schema.GroupVersionKind, namespace, name string, content map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func gatewayTestObjects() []client.Object {
+	return []client.Object{
+		gatewayObject(agentgatewayBackendGVK, "tools", "github", map[string]interface{}{
+			"spec": map[string]interface{}{"mcp": map[string]interface{}{"targets": []interface{}{
+				map[string]interface{}{
+					"name":   "github",
+					"static": map[string]interface{}{"host": "github-mcp.tools.svc.cluster.local", "port": int64(8080), "protocol": "StreamableHTTP"},
+				},
+				map[string]interface{}{
+					"name":     "search",
+					"selector": map[string]interface{}{"services": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "search"}}},
+				},
+			}}},
+		}),
+		gatewayObject(agentgatewayBackendGVK, "tools", "openai", map[string]interface{}{
+			"spec": map[string]interface{}{"ai": map[string]interface{}{"provider": map[string]interface{}{}}},
+		}),
+		gatewayObject(kgatewayBackendGVK, "tools", "jira", map[string]interface{}{
+			"spec": map[string]interface{}{"type": "MCP", "mcp": map[string]interface{}{"targets": []interface{}{
+				map[string]interface{}{
+					"static": map[string]interface{}{"name": "jira", "host": "jira.example.com", "port": int64(443), "protocol": "SSE"},
+				},
+			}}},
+		}),
+		gatewayObject(httpRouteGVK, "tools", "mcp", map[string]interface{}{
+			"spec": map[string]interface{}{
+				"parentRefs": []interface{}{
+					map[string]interface{}{"name": "agentgateway", "namespace": "gateways", "sectionName": "https"},
+				},
+				"rules": []interface{}{map[string]interface{}{
+					"matches": []interface{}{map[string]interface{}{
+						"path": map[string]interface{}{"type": "PathPrefix", "value": "/github"},
+					}},
+					"backendRefs": []interface{}{map[string]interface{}{
+						"group": "agentgateway.dev",
+						"kind":  "AgentgatewayBackend",
+						"name":  "github",
+					}},
+				}},
+			},
+		}),
+		gatewayObject(gatewayGVK, "gateways", "agentgateway", map[string]interface{}{
+			"spec": map[string]interface{}{"listeners": []interface{}{
+				map[string]interface{}{"name": "http", "port": int64(80), "protocol": "HTTP"},
+				map[string]interface{}{"name": "https", "port": int64(8443), "protocol": "HTTPS", "hostname": "mcp.example.com"},
+			}},
+			"status": map[string]interface{}{
+				"addresses":  []interface{}{map[string]interface{}{"value": "203.0.113.10"}},
+				"conditions": []interface{}{map[string]interface{}{"type": "Programmed", "status": "True"}},
+			},
+		}),
+	}
+}
+
+func TestGatewayMCPTargets(t *testing.T) {
+	objs := gatewayTestObjects()
+
+	targets, ok := gatewayMCPTargets(objs[0].(*unstructured.Unstructured))
+	require.True(t, ok)
+	assert.Equal(t, []agentregistryv1alpha1.GatewayMCPTarget{
+		{Name: "github", URL: "http://github-mcp.tools.svc.cluster.local:8080/mcp"},
+		{Name: "search", Selector: `{"services":{"matchLabels":{"app":"search"}}}`},
+	}, targets)
+
+	_, ok = gatewayMCPTargets(objs[1].(*unstructured.Unstructured))
+	assert.False(t, ok, "backends without spec.mcp are not MCP servers")
+
+	targets, ok = gatewayMCPTargets(objs[2].(*unstructured.Unstructured))
+	require.True(t, ok)
+	assert.Equal(t, []agentregistryv1alpha1.GatewayMCPTarget{{Name: "jira", URL: "http://jira.example.com:443/sse"}}, targets)
+}
+
+func TestDiscoveryConfigReconciler_GatewayBackends(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{agentgatewayBackendGVK, kgatewayBackendGVK, httpRouteGVK, gatewayGVK} {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	remote := &testClientWithWatch{Client: fake.NewClientBuilder().
+		WithRESTMapper(mapper).
+		WithObjects(gatewayTestObjects()...).
+		Build()}
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+
+	origFactory := RemoteClientFactory
+	RemoteClientFactory = func(DiscoveryScope, *agentregistryv1alpha1.Environment, *runtime.Scheme) (client.WithWatch, error) {
+		return remote, nil
+	}
+	defer func() { RemoteClientFactory = origFactory }()
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{{
+				Name:          "dev",
+				Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "dev"},
+				Namespaces:    []string{"tools"},
+				ResourceTypes: []string{agentgatewayBackendResourceType, kgatewayBackendResourceType},
+			}},
+		},
+	}
+	r := &DiscoveryConfigReconciler{
+		Client:          local,
+		Scheme:          scheme,
+		Logger:          zerolog.Nop(),
+		DiscoveredCache: NewDiscoveredResourceCache(),
+	}
+	ctx := context.Background()
+
+	desired := r.desiredInformers(dc)
+	require.Len(t, desired, 2)
+	for _, d := range desired {
+		assert.Nil(t, d.mapping)
+		resources, err := r.relist(ctx, d)
+		require.NoError(t, err)
+		require.Len(t, resources, 1, "only MCP backends are listed")
+		for _, res := range resources {
+			require.NoError(t, res.apply())
+		}
+	}
+
+	t.Run("routed backend gets the gateway URL", func(t *testing.T) {
+		var catalog agentregistryv1alpha1.MCPServerCatalog
+		require.NoError(t, local.Get(ctx, client.ObjectKey{
+			Name:      generateDiscoveredCatalogName(agentgatewayBackendResourceType, "dev", "dev", "tools", "github"),
+			Namespace: testNamespace,
+		}, &catalog))
+		assert.Equal(t, "tools/github", catalog.Spec.Name)
+		assert.Equal(t, "AgentgatewayBackend", catalog.Spec.SourceRef.Kind)
+		assert.Equal(t, []agentregistryv1alpha1.Transport{{Type: "streamable-http", URL: "https://mcp.example.com:8443/github"}}, catalog.Spec.Remotes)
+		assert.Equal(t, agentgatewayBackendResourceType, catalog.Labels[sourceKindLabel])
+
+		require.NotNil(t, catalog.Status.Deployment)
+		assert.Equal(t, "https://mcp.example.com:8443/github", catalog.Status.Deployment.URL)
+		assert.True(t, catalog.Status.Deployment.Ready)
+
+		require.NotNil(t, catalog.Status.Gateway)
+		assert.Equal(t, "gateways/agentgateway", catalog.Status.Gateway.Gateway)
+		assert.Equal(t, "https", catalog.Status.Gateway.Listener)
+		assert.Equal(t, "tools/mcp", catalog.Status.Gateway.Route)
+		assert.Equal(t, "https://mcp.example.com:8443/github", catalog.Status.Gateway.URL)
+		assert.Len(t, catalog.Status.Gateway.Targets, 2)
+	})
+
+	t.Run("unrouted backend is listed without a remote", func(t *testing.T) {
+		var catalog agentregistryv1alpha1.MCPServerCatalog
+		require.NoError(t, local.Get(ctx, client.ObjectKey{
+			Name:      generateDiscoveredCatalogName(kgatewayBackendResourceType, "dev", "dev", "tools", "jira"),
+			Namespace: testNamespace,
+		}, &catalog))
+		assert.Empty(t, catalog.Spec.Remotes)
+		require.NotNil(t, catalog.Status.Deployment)
+		assert.False(t, catalog.Status.Deployment.Ready)
+		assert.Contains(t, catalog.Status.Deployment.Message, "No HTTPRoute")
+		require.NotNil(t, catalog.Status.Gateway)
+		assert.Equal(t, []agentregistryv1alpha1.GatewayMCPTarget{{Name: "jira", URL: "http://jira.example.com:443/sse"}}, catalog.Status.Gateway.Targets)
+	})
+
+	assert.Equal(t, 2, r.discoveredCounts(desired[0].scope, dc).MCPServers)
+}
+
+func TestGatewayListener(t *testing.T) {
+	gateway := gatewayTestObjects()[4].(*unstructured.Unstructured)
+
+	name := func(listener map[string]interface{}) string {
+		require.NotNil(t, listener)
+		return listener["name"].(string)
+	}
+	assert.Equal(t, "https", name(gatewayListener(gateway, "https", 0)))
+	assert.Equal(t, "https", name(gatewayListener(gateway, "", 8443)))
+	assert.Equal(t, "http", name(gatewayListener(gateway, "", 0)))
+	assert.Nil(t, gatewayListener(gateway, "grpc", 0))
+}
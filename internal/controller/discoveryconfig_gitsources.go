@@ -0,0 +1,390 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+	"github.com/agentregistry-dev/agentregistry/internal/gitrepo"
+	"github.com/agentregistry-dev/agentregistry/internal/manifest"
+)
+
+const (
+	// gitSourceLabel is the git source an imported catalog entry was found by
+	gitSourceLabel = "agentregistry.dev/git-source"
+
+	// defaultGitScanInterval is how often a git source is scanned when it sets no interval
+	defaultGitScanInterval = 5 * time.Minute
+)
+
+// gitScan remembers the last scan of a git source
+type gitScan struct {
+	lastScan time.Time
+	// specHash is the hash of the source spec the scan ran with; a change rescans immediately
+	specHash string
+	prune    bool
+	status   agentregistryv1alpha1.GitSourceStatus
+}
+
+// gitServer is a server referenced in a repository file
+type gitServer struct {
+	spec agentregistryv1alpha1.MCPServerCatalogSpec
+	ref  agentregistryv1alpha1.GitReference
+}
+
+// gitSourceScope is the scope sharding assigns a git source by, so each source is scanned by
+// one replica
+func gitSourceScope(configName, sourceName string) DiscoveryScope {
+	return DiscoveryScope{Config: configName, Environment: "git:" + sourceName}
+}
+
+// syncGitSources scans the git sources of a DiscoveryConfig that are due, prunes the entries of
+// removed sources when the config prunes, and returns the status of every source with the time
+// until the next scan is due. Sources owned by other replicas keep their last reported status.
+func (r *DiscoveryConfigReconciler) syncGitSources(
+	ctx context.Context,
+	dc *agentregistryv1alpha1.DiscoveryConfig,
+	force bool,
+	logger zerolog.Logger,
+) ([]agentregistryv1alpha1.GitSourceStatus, time.Duration) {
+	previous := make(map[string]agentregistryv1alpha1.GitSourceStatus, len(dc.Status.GitSources))
+	for _, s := range dc.Status.GitSources {
+		previous[s.Name] = s
+	}
+
+	var statuses []agentregistryv1alpha1.GitSourceStatus
+	var requeueAfter time.Duration
+	configured := make(map[string]bool, len(dc.Spec.GitSources))
+	for i := range dc.Spec.GitSources {
+		src := &dc.Spec.GitSources[i]
+		configured[src.Name] = true
+		if !r.ownsScope(gitSourceScope(dc.Name, src.Name)) {
+			if s, ok := previous[src.Name]; ok {
+				statuses = append(statuses, s)
+			}
+			continue
+		}
+
+		key := dc.Name + "/" + src.Name
+		interval := defaultGitScanInterval
+		if src.Interval != nil && src.Interval.Duration > 0 {
+			interval = src.Interval.Duration
+		}
+		specHash := gitSourceHash(src)
+
+		r.gitScansMu.Lock()
+		scan, scanned := r.gitScans[key]
+		r.gitScansMu.Unlock()
+		if force || !scanned || scan.specHash != specHash || !time.Now().Before(scan.lastScan.Add(interval)) {
+			scan = gitScan{
+				lastScan: time.Now(),
+				specHash: specHash,
+				status:   r.scanGitSource(ctx, dc, src, logger.With().Str("gitSource", src.Name).Logger()),
+			}
+		}
+		scan.prune = dc.Spec.Prune
+		r.gitScansMu.Lock()
+		r.gitScans[key] = scan
+		r.gitScansMu.Unlock()
+
+		statuses = append(statuses, scan.status)
+		if next := time.Until(scan.lastScan.Add(interval)); requeueAfter == 0 || next < requeueAfter {
+			requeueAfter = max(next, time.Second)
+		}
+	}
+
+	r.forgetGitSources(dc.Name, configured)
+	if dc.Spec.Prune {
+		if pruned, err := r.pruneGitSources(ctx, dc.Name, configured); err != nil {
+			logger.Error().Err(err).Msg("failed to prune entries of removed git sources")
+		} else if pruned > 0 {
+			logger.Info().Int("count", pruned).Msg("pruned entries of removed git sources")
+		}
+	}
+	return statuses, requeueAfter
+}
+
+// forgetGitSources drops the scan state of the sources of a config that are not in keep and
+// reports whether any of them pruned
+func (r *DiscoveryConfigReconciler) forgetGitSources(configName string, keep map[string]bool) bool {
+	r.gitScansMu.Lock()
+	defer r.gitScansMu.Unlock()
+	prune := false
+	for key, scan := range r.gitScans {
+		sourceConfig, sourceName, _ := strings.Cut(key, "/")
+		if sourceConfig != configName || keep[sourceName] {
+			continue
+		}
+		prune = prune || scan.prune
+		delete(r.gitScans, key)
+	}
+	return prune
+}
+
+// pruneGitSources deletes the imported entries of a config's git sources that are not in keep
+// and are scanned by this replica
+func (r *DiscoveryConfigReconciler) pruneGitSources(ctx context.Context, configName string, keep map[string]bool) (int, error) {
+	hasSource, err := labels.NewRequirement(gitSourceLabel, selection.Exists, nil)
+	if err != nil {
+		return 0, err
+	}
+	selector := labels.SelectorFromSet(labels.Set{discoveryConfigLabel: configName}).Add(*hasSource)
+
+	var list agentregistryv1alpha1.MCPServerCatalogList
+	if err := r.List(ctx, &list, client.InNamespace(config.GetNamespace()), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for i := range list.Items {
+		source := list.Items[i].Labels[gitSourceLabel]
+		if keep[source] || !r.ownsScope(gitSourceScope(configName, source)) {
+			continue
+		}
+		if err := r.Delete(ctx, &list.Items[i]); client.IgnoreNotFound(err) != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// scanGitSource reads every repository of a source and imports the servers they reference.
+// Entries of the source whose server is no longer referenced are deleted, but only after a scan
+// without errors so an unreadable repository or broken file never empties the catalog.
+func (r *DiscoveryConfigReconciler) scanGitSource(
+	ctx context.Context,
+	dc *agentregistryv1alpha1.DiscoveryConfig,
+	src *agentregistryv1alpha1.GitSource,
+	logger zerolog.Logger,
+) agentregistryv1alpha1.GitSourceStatus {
+	now := metav1.Now()
+	status := agentregistryv1alpha1.GitSourceStatus{Name: src.Name, LastScanTime: &now}
+
+	// Servers are deduplicated by name and version across the repositories of the source
+	entries := make(map[string]*agentregistryv1alpha1.MCPServerCatalog)
+	var order []string
+	for _, location := range src.Repositories {
+		servers, errs := scanGitRepository(location, src.Ref)
+		status.Errors = append(status.Errors, errs...)
+		for _, s := range servers {
+			name := gitCatalogName(dc.Name, src.Name, s.spec.Name, s.spec.Version)
+			entry, ok := entries[name]
+			if !ok {
+				entry = newImportedMCPServer(dc.Name, src, name, s.spec)
+				entries[name] = entry
+				order = append(order, name)
+			}
+			entry.Status.GitReferences = append(entry.Status.GitReferences, s.ref)
+		}
+	}
+
+	for _, name := range order {
+		if err := r.upsertImportedMCPServer(ctx, entries[name]); err != nil {
+			status.Errors = append(status.Errors, fmt.Sprintf("catalog entry %s: %v", name, err))
+		}
+	}
+	status.Servers = len(entries)
+
+	if len(status.Errors) > 0 {
+		logger.Warn().Strs("errors", status.Errors).Int("servers", status.Servers).Msg("git source scanned with errors, not removing entries")
+		return status
+	}
+
+	var list agentregistryv1alpha1.MCPServerCatalogList
+	if err := r.List(ctx, &list,
+		client.InNamespace(config.GetNamespace()),
+		client.MatchingLabels{discoveryConfigLabel: dc.Name, gitSourceLabel: src.Name},
+	); err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("list catalog entries: %v", err))
+		return status
+	}
+	removed := 0
+	for i := range list.Items {
+		if _, found := entries[list.Items[i].Name]; found {
+			continue
+		}
+		if err := r.Delete(ctx, &list.Items[i]); client.IgnoreNotFound(err) != nil {
+			status.Errors = append(status.Errors, fmt.Sprintf("remove catalog entry %s: %v", list.Items[i].Name, err))
+			continue
+		}
+		removed++
+	}
+	logger.Debug().Int("servers", status.Servers).Int("removed", removed).Msg("git source scanned")
+	return status
+}
+
+// scanGitRepository returns the servers referenced at ref of a repository, and the files that
+// could not be parsed
+func scanGitRepository(location, ref string) ([]gitServer, []string) {
+	repo, err := gitrepo.Open(location)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("%s: %v", location, err)}
+	}
+	commit, err := repo.Resolve(ref)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("%s: %v", location, err)}
+	}
+	files, err := repo.Files(commit, isGitSourceFile)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("%s: %v", location, err)}
+	}
+
+	var servers []gitServer
+	var errs []string
+	for _, f := range files {
+		reference := agentregistryv1alpha1.GitReference{Repository: location, Path: f.Path, Commit: commit}
+		if path.Base(f.Path) == manifest.FileName {
+			m, err := manifest.Parse(f.Data)
+			if err == nil {
+				err = manifest.Validate(m)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s: %v", location, f.Path, err))
+				continue
+			}
+			if m.Kind != "mcp-server" {
+				continue
+			}
+			reference.Server = m.Name
+			servers = append(servers, gitServer{spec: m.MCPServerCatalogSpec(), ref: reference})
+			continue
+		}
+
+		configured, err := manifest.ParseMCPClientConfig(f.Data)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s: %v", location, f.Path, err))
+			continue
+		}
+		for _, s := range configured {
+			reference.Server = s.Name
+			servers = append(servers, gitServer{spec: s.MCPServerCatalogSpec(), ref: reference})
+		}
+	}
+	return servers, errs
+}
+
+// isGitSourceFile reports whether a repository file may reference MCP servers. Vendored
+// dependencies are skipped.
+func isGitSourceFile(p string) bool {
+	if strings.HasPrefix(p, "node_modules/") || strings.Contains(p, "/node_modules/") {
+		return false
+	}
+	return path.Base(p) == manifest.FileName || manifest.IsMCPClientConfig(p)
+}
+
+// newImportedMCPServer builds the catalog entry of a server found by a git source
+func newImportedMCPServer(
+	configName string,
+	src *agentregistryv1alpha1.GitSource,
+	name string,
+	spec agentregistryv1alpha1.MCPServerCatalogSpec,
+) *agentregistryv1alpha1.MCPServerCatalog {
+	entryLabels := make(map[string]string, len(src.Labels)+3)
+	for k, v := range src.Labels {
+		entryLabels[k] = v
+	}
+	entryLabels[agentregistryv1alpha1.LabelResourceSource] = agentregistryv1alpha1.ResourceSourceImport
+	entryLabels[discoveryConfigLabel] = configName
+	entryLabels[gitSourceLabel] = src.Name
+
+	return &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: config.GetNamespace(),
+			Labels:    entryLabels,
+		},
+		Spec: spec,
+	}
+}
+
+// upsertImportedMCPServer creates or updates an imported catalog entry and its git references.
+// Imported servers are deployable, so entries are managed rather than external.
+func (r *DiscoveryConfigReconciler) upsertImportedMCPServer(ctx context.Context, catalog *agentregistryv1alpha1.MCPServerCatalog) error {
+	refs := catalog.Status.GitReferences
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Repository != refs[j].Repository {
+			return refs[i].Repository < refs[j].Repository
+		}
+		if refs[i].Path != refs[j].Path {
+			return refs[i].Path < refs[j].Path
+		}
+		return refs[i].Server < refs[j].Server
+	})
+
+	existing := &agentregistryv1alpha1.MCPServerCatalog{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(catalog), existing)
+	if apierrors.IsNotFound(err) {
+		catalog.Status = agentregistryv1alpha1.MCPServerCatalogStatus{}
+		if err := r.Create(ctx, catalog); err != nil {
+			return err
+		}
+		catalog.Status.ManagementType = agentregistryv1alpha1.ManagementTypeManaged
+		catalog.Status.Published = true
+		catalog.Status.Status = agentregistryv1alpha1.CatalogStatusActive
+		catalog.Status.GitReferences = refs
+		return r.Status().Update(ctx, catalog)
+	} else if err != nil {
+		return err
+	}
+
+	if !equality.Semantic.DeepEqual(existing.Spec, catalog.Spec) || !equality.Semantic.DeepEqual(existing.Labels, catalog.Labels) {
+		existing.Spec = catalog.Spec
+		existing.Labels = catalog.Labels
+		if err := r.Update(ctx, existing); err != nil {
+			return err
+		}
+	}
+	if equality.Semantic.DeepEqual(existing.Status.GitReferences, refs) {
+		return nil
+	}
+	existing.Status.GitReferences = refs
+	return r.Status().Update(ctx, existing)
+}
+
+// gitCatalogName names the entry of a server imported by a git source. The hash covers the
+// config and source so sources never share an entry.
+func gitCatalogName(configName, sourceName, serverName, version string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{configName, sourceName, serverName, version}, "/")))
+	suffix := hex.EncodeToString(sum[:4])
+
+	var b strings.Builder
+	for _, c := range strings.ToLower("git-" + serverName + "-" + version) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+		} else if !strings.HasSuffix(b.String(), "-") {
+			b.WriteByte('-')
+		}
+	}
+	prefix := b.String()
+	if maxLen := 63 - len(suffix) - 1; len(prefix) > maxLen {
+		prefix = prefix[:maxLen]
+	}
+	return strings.TrimRight(prefix, "-") + "-" + suffix
+}
+
+// gitSourceHash hashes the fields of a git source that change what a scan finds
+func gitSourceHash(src *agentregistryv1alpha1.GitSource) string {
+	data, _ := json.Marshal(struct {
+		Repositories []string
+		Ref          string
+		Labels       map[string]string
+	}{src.Repositories, src.Ref, src.Labels})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
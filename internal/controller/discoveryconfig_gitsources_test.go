@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+// commitFiles writes files into a git repository, creating it if needed, and commits them
+func commitFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		git("init", "-q", "-b", "main")
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if content == "" {
+			require.NoError(t, os.Remove(p))
+			continue
+		}
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "update")
+}
+
+func TestDiscoveryConfigReconciler_GitSources(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	app, tools := t.TempDir(), t.TempDir()
+	commitFiles(t, app, map[string]string{
+		".vscode/mcp.json": `{
+  "servers": {
+    // GitHub's hosted server
+    "github": {"type": "http", "url": "https://api.githubcopilot.com/mcp/", "headers": {"Authorization": "Bearer ${input:token}"}},
+    "fs": {"command": "npx", "args": ["-y", "@modelcontextprotocol/server-filesystem@2025.8.21", "."]},
+  },
+}`,
+		"README.md": "# app\n",
+	})
+	commitFiles(t, tools, map[string]string{
+		".cursor/mcp.json": `{"mcpServers": {"filesystem": {"command": "npx", "args": ["@modelcontextprotocol/server-filesystem@2025.8.21"], "env": {"ROOT": "/srv"}}}}`,
+		".agentregistry.yaml": "kind: mcp-server\nname: acme/tools\nversion: 0.3.0\n" +
+			"packages:\n  - type: oci\n    image: ghcr.io/acme/tools:0.3.0\n    transport: stdio\n",
+	})
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Prune: true,
+			GitSources: []agentregistryv1alpha1.GitSource{{
+				Name:         "repos",
+				Repositories: []string{app, "file://" + tools},
+				Interval:     &metav1.Duration{Duration: time.Hour},
+				Labels:       map[string]string{"team": "platform"},
+			}},
+		},
+	}
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(dc).
+		WithStatusSubresource(&agentregistryv1alpha1.DiscoveryConfig{}, &agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+	r := &DiscoveryConfigReconciler{
+		Client:          local,
+		Scheme:          scheme,
+		Logger:          zerolog.Nop(),
+		DiscoveredCache: NewDiscoveredResourceCache(),
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "discovery", Namespace: testNamespace}}
+
+	entries := func() map[string]agentregistryv1alpha1.MCPServerCatalog {
+		var list agentregistryv1alpha1.MCPServerCatalogList
+		require.NoError(t, local.List(ctx, &list, client.MatchingLabels{gitSourceLabel: "repos"}))
+		byName := make(map[string]agentregistryv1alpha1.MCPServerCatalog)
+		for _, item := range list.Items {
+			byName[item.Spec.Name+"@"+item.Spec.Version] = item
+		}
+		return byName
+	}
+	trigger := func() {
+		var current agentregistryv1alpha1.DiscoveryConfig
+		require.NoError(t, local.Get(ctx, req.NamespacedName, &current))
+		current.Annotations = map[string]string{agentregistryv1alpha1.AnnotationTriggerDiscovery: "true"}
+		require.NoError(t, local.Update(ctx, &current))
+		_, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+
+	result, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, result.RequeueAfter, float64(time.Minute), "requeued for the next scan")
+
+	found := entries()
+	require.Len(t, found, 3)
+
+	fs := found["@modelcontextprotocol/server-filesystem@2025.8.21"]
+	assert.Equal(t, agentregistryv1alpha1.ResourceSourceImport, fs.Labels[agentregistryv1alpha1.LabelResourceSource])
+	assert.Equal(t, "discovery", fs.Labels[discoveryConfigLabel])
+	assert.Equal(t, "platform", fs.Labels["team"])
+	assert.Empty(t, fs.Labels[discoveryLabel], "imported entries are not subject to the discovery lifecycle")
+	require.Len(t, fs.Spec.Packages, 1)
+	assert.Equal(t, "npm", fs.Spec.Packages[0].RegistryType)
+	assert.Equal(t, agentregistryv1alpha1.ManagementTypeManaged, fs.Status.ManagementType)
+	assert.True(t, fs.Status.Published)
+	require.Len(t, fs.Status.GitReferences, 2, "both repositories reference the server")
+	assert.Equal(t, app, fs.Status.GitReferences[0].Repository)
+	assert.Equal(t, ".vscode/mcp.json", fs.Status.GitReferences[0].Path)
+	assert.Equal(t, "fs", fs.Status.GitReferences[0].Server)
+	assert.Len(t, fs.Status.GitReferences[0].Commit, 40)
+	assert.Equal(t, "file://"+tools, fs.Status.GitReferences[1].Repository)
+	assert.Equal(t, ".cursor/mcp.json", fs.Status.GitReferences[1].Path)
+
+	github := found["github@latest"]
+	require.Len(t, github.Spec.Remotes, 1)
+	assert.Equal(t, []agentregistryv1alpha1.KeyValueInput{{Name: "Authorization"}}, github.Spec.Remotes[0].Headers)
+
+	assert.Equal(t, ".agentregistry.yaml", found["acme/tools@0.3.0"].Status.GitReferences[0].Path)
+
+	var status agentregistryv1alpha1.DiscoveryConfig
+	require.NoError(t, local.Get(ctx, req.NamespacedName, &status))
+	require.Len(t, status.Status.GitSources, 1)
+	assert.Equal(t, 3, status.Status.GitSources[0].Servers)
+	assert.Empty(t, status.Status.GitSources[0].Errors)
+	require.NotNil(t, status.Status.GitSources[0].LastScanTime)
+
+	t.Run("broken file keeps entries", func(t *testing.T) {
+		commitFiles(t, app, map[string]string{".vscode/mcp.json": `{"servers": `})
+		trigger()
+
+		assert.Len(t, entries(), 3)
+		require.NoError(t, local.Get(ctx, req.NamespacedName, &status))
+		require.Len(t, status.Status.GitSources[0].Errors, 1)
+		assert.Contains(t, status.Status.GitSources[0].Errors[0], ".vscode/mcp.json")
+		assert.Equal(t, 2, status.Status.GitSources[0].Servers)
+	})
+
+	t.Run("unreferenced servers are removed", func(t *testing.T) {
+		commitFiles(t, app, map[string]string{".vscode/mcp.json": ""})
+		trigger()
+
+		found := entries()
+		assert.Len(t, found, 2)
+		assert.NotContains(t, found, "github@latest")
+		require.Len(t, found["@modelcontextprotocol/server-filesystem@2025.8.21"].Status.GitReferences, 1)
+	})
+
+	t.Run("removed source is pruned", func(t *testing.T) {
+		var current agentregistryv1alpha1.DiscoveryConfig
+		require.NoError(t, local.Get(ctx, req.NamespacedName, &current))
+		current.Spec.GitSources = nil
+		require.NoError(t, local.Update(ctx, &current))
+		result, err := r.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+
+		assert.Empty(t, entries())
+		require.NoError(t, local.Get(ctx, req.NamespacedName, &status))
+		assert.Empty(t, status.Status.GitSources)
+	})
+}
+
+func TestGitCatalogName(t *testing.T) {
+	name := gitCatalogName("discovery", "repos", "@modelcontextprotocol/server-filesystem", "2025.8.21")
+	assert.True(t, strings.HasPrefix(name, "git-modelcontextprotocol-server-filesystem-2025-8-21-"), name)
+	assert.LessOrEqual(t, len(name), 63)
+	assert.NotEqual(t, name, gitCatalogName("discovery", "other", "@modelcontextprotocol/server-filesystem", "2025.8.21"))
+
+	long := gitCatalogName("discovery", "repos", strings.Repeat("very-long-server-name-", 5), "latest")
+	assert.Len(t, long, 63)
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// removeRenamedEntries deletes discovered catalog entries whose name is not the one
+// generateDiscoveredCatalogName gives their source. Entries created before names covered
+// the environment and cluster would otherwise linger next to their renamed copies, since
+// discovery only updates and prunes entries under the current names. Discovery recreates
+// the entries of sources that still exist.
+func (r *DiscoveryConfigReconciler) removeRenamedEntries(ctx context.Context) error {
+	lists := []client.ObjectList{
+		&agentregistryv1alpha1.MCPServerCatalogList{},
+		&agentregistryv1alpha1.AgentCatalogList{},
+		&agentregistryv1alpha1.ModelCatalogList{},
+	}
+	removed := 0
+	for _, list := range lists {
+		if err := r.List(ctx, list,
+			client.InNamespace(config.GetNamespace()),
+			client.MatchingLabels{discoveryLabel: "true"},
+		); err != nil {
+			return fmt.Errorf("list discovered catalog entries: %w", err)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok {
+				continue
+			}
+			labels := obj.GetLabels()
+			want := generateDiscoveredCatalogName(labels[sourceKindLabel], labels[envLabel], labels[clusterLabel],
+				labels[sourceNSLabel], labels[sourceNameLabel])
+			if obj.GetName() == want {
+				continue
+			}
+			if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("delete renamed catalog entry %s: %w", obj.GetName(), err)
+			}
+			r.Logger.Info().
+				Str("catalog", obj.GetName()).
+				Str("name", want).
+				Msg("removed catalog entry named by an earlier version, discovery recreates it")
+			removed++
+		}
+	}
+	if removed > 0 {
+		r.Logger.Info().Int("removed", removed).Msg("removed discovered catalog entries with outdated names")
+	}
+	return nil
+}
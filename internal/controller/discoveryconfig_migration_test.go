@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+func TestDiscoveryConfigReconciler_RemoveRenamedEntries(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	discovered := func(kind, name string) map[string]string {
+		return map[string]string{
+			discoveryLabel:  "true",
+			sourceKindLabel: kind,
+			sourceNameLabel: name,
+			sourceNSLabel:   "tools",
+			envLabel:        "dev",
+			clusterLabel:    "dev-cluster",
+		}
+	}
+	meta := func(name string, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels}
+	}
+	current := generateDiscoveredCatalogName("MCPServer", "dev", "dev-cluster", "tools", "fs")
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			// Named namespace-name by earlier versions
+			&agentregistryv1alpha1.MCPServerCatalog{ObjectMeta: meta("tools-fs", discovered("MCPServer", "fs"))},
+			&agentregistryv1alpha1.AgentCatalog{ObjectMeta: meta("tools-helper", discovered("Agent", "helper"))},
+			&agentregistryv1alpha1.MCPServerCatalog{ObjectMeta: meta(current, discovered("MCPServer", "fs"))},
+			// Entries that are not discovered keep their names
+			&agentregistryv1alpha1.MCPServerCatalog{ObjectMeta: meta("manual", nil)},
+			&agentregistryv1alpha1.MCPServerCatalog{ObjectMeta: meta("imported", map[string]string{
+				agentregistryv1alpha1.LabelResourceSource: agentregistryv1alpha1.ResourceSourceImport,
+				gitSourceLabel: "catalog",
+			})},
+		).
+		Build()
+	r := &DiscoveryConfigReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop()}
+	ctx := context.Background()
+
+	require.NoError(t, r.removeRenamedEntries(ctx))
+
+	var servers agentregistryv1alpha1.MCPServerCatalogList
+	require.NoError(t, local.List(ctx, &servers))
+	var names []string
+	for _, s := range servers.Items {
+		names = append(names, s.Name)
+	}
+	assert.ElementsMatch(t, []string{current, "manual", "imported"}, names)
+
+	var agents agentregistryv1alpha1.AgentCatalogList
+	require.NoError(t, local.List(ctx, &agents, client.InNamespace(testNamespace)))
+	assert.Empty(t, agents.Items)
+}
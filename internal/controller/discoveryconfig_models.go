@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+)
+
+// vllmResourceType is the resource type of Deployments running a vLLM server
+const vllmResourceType = "VLLMDeployment"
+
+// vllmDefaultPort is the port vLLM's OpenAI-compatible server listens on unless --port is set
+const vllmDefaultPort = 8000
+
+// readyConditionExpr is true when a resource's Ready condition is True
+const readyConditionExpr = `{.status.conditions[?(@.type=="Ready")].status}`
+
+// servedModelMappings are the built-in mappings of model serving resources into ModelCatalog.
+// Self-hosted runtimes expose OpenAI-compatible APIs, so their provider is OpenAI.
+var servedModelMappings = map[string]agentregistryv1alpha1.CustomResourceMapping{
+	// KServe serves a model under the InferenceService name unless --model_name is passed
+	"InferenceService": {
+		Name:        "InferenceService",
+		Group:       "serving.kserve.io",
+		Version:     "v1beta1",
+		Resource:    "inferenceservices",
+		CatalogKind: "Model",
+		Fields: agentregistryv1alpha1.CustomResourceFields{
+			URL:      "cel:object.?status.?url.orValue('')",
+			Ready:    readyConditionExpr,
+			Provider: "cel:'OpenAI'",
+			Model: "cel:(object.?spec.?predictor.?model.?args.orValue([])" +
+				".filter(a, a.startsWith('--model_name='))" +
+				".map(a, a.substring(13)) + [object.metadata.name])[0]",
+			Description: "cel:object.?spec.?predictor.?model.?storageUri.orValue('')",
+			Runtime:     "cel:'kserve'",
+		},
+	},
+	// llm-d deploys models through KServe's LLMInferenceService
+	"LLMInferenceService": {
+		Name:        "LLMInferenceService",
+		Group:       "serving.kserve.io",
+		Version:     "v1alpha1",
+		Resource:    "llminferenceservices",
+		CatalogKind: "Model",
+		Fields: agentregistryv1alpha1.CustomResourceFields{
+			URL:         "cel:object.?status.?url.orValue('')",
+			Ready:       readyConditionExpr,
+			Provider:    "cel:'OpenAI'",
+			Model:       "cel:object.?spec.?model.?name.orValue(object.metadata.name)",
+			Description: "cel:object.?spec.?model.?uri.orValue('')",
+			Runtime:     "cel:'llm-d'",
+		},
+	},
+}
+
+// vllmArgs returns the command line, split into words, of the first container of a pod
+// template that runs vLLM. ok is false if no container does.
+func vllmArgs(spec *corev1.PodSpec) (args []string, ok bool) {
+	for _, c := range spec.Containers {
+		var words []string
+		for _, arg := range append(append([]string{}, c.Command...), c.Args...) {
+			// Shell wrappers pass the whole command line as one argument
+			words = append(words, strings.Fields(arg)...)
+		}
+		if strings.Contains(c.Image, "vllm") {
+			return words, true
+		}
+		for i, w := range words {
+			if strings.Contains(w, "vllm.entrypoints.openai.api_server") ||
+				(path.Base(w) == "vllm" && i+1 < len(words) && words[i+1] == "serve") {
+				return words, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// vllmFlag returns the value of --name or --name=value in args
+func vllmFlag(args []string, name string) string {
+	for i, arg := range args {
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			return v
+		}
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// vllmModelID returns the model name a vLLM server answers to: --served-model-name, then
+// --model, then the positional model of `vllm serve`
+func vllmModelID(args []string) string {
+	if name := vllmFlag(args, "--served-model-name"); name != "" {
+		return name
+	}
+	if model := vllmFlag(args, "--model"); model != "" {
+		return model
+	}
+	for i, arg := range args {
+		if arg == "serve" && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// vllmServicePort picks the Service port forwarding to the vLLM server port, falling back to
+// a port with the same number and then to the first port
+func vllmServicePort(svc *corev1.Service, serverPort int) (int32, error) {
+	for _, p := range svc.Spec.Ports {
+		if p.TargetPort.IntValue() == serverPort {
+			return p.Port, nil
+		}
+	}
+	if port, err := selectServicePort(svc, strconv.Itoa(serverPort)); err == nil {
+		return port, nil
+	}
+	return selectServicePort(svc, "")
+}
+
+// createVLLMInformer creates an informer for Deployments running a vLLM server
+func (r *DiscoveryConfigReconciler) createVLLMInformer(
+	ctx context.Context,
+	remoteClient client.WithWatch,
+	listOpts []client.ListOption,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+	logger zerolog.Logger,
+) cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				list := &appsv1.DeploymentList{}
+				err := remoteClient.List(context.Background(), list, listOpts...)
+				return list, err
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return remoteClient.Watch(context.Background(), &appsv1.DeploymentList{}, listOpts...)
+			},
+		},
+		&appsv1.Deployment{},
+		0,
+		cache.Indexers{},
+	)
+
+	upsert := func(deploy *appsv1.Deployment) {
+		if _, ok := vllmArgs(&deploy.Spec.Template.Spec); !ok {
+			r.DiscoveredCache.Delete(scope, vllmResourceType, deploy.Namespace, deploy.Name)
+			return
+		}
+		r.DiscoveredCache.Set(scope, vllmResourceType, deploy)
+		resourceKey := fmt.Sprintf("vllm/%s/%s", deploy.Namespace, deploy.Name)
+		r.executeWithRetry(ctx, scope, resourceKey, func() error {
+			return r.handleVLLMDeploymentAdd(ctx, deploy, remoteClient, env, scope)
+		}, logger)
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			deploy := obj.(*appsv1.Deployment)
+			logger.Trace().Str("deployment", deploy.Name).Msg("Deployment added")
+			upsert(deploy)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			deploy := newObj.(*appsv1.Deployment)
+			logger.Trace().Str("deployment", deploy.Name).Msg("Deployment updated")
+			upsert(deploy)
+		},
+		DeleteFunc: func(obj interface{}) {
+			deploy, ok := obj.(*appsv1.Deployment)
+			if !ok {
+				return
+			}
+			logger.Trace().Str("deployment", deploy.Name).Msg("Deployment deleted")
+			r.DiscoveredCache.Delete(scope, vllmResourceType, deploy.Namespace, deploy.Name)
+		},
+	})
+
+	return informer
+}
+
+// handleVLLMDeploymentAdd creates or updates the ModelCatalog entry of a Deployment running
+// vLLM, served through the Service that selects its pods
+func (r *DiscoveryConfigReconciler) handleVLLMDeploymentAdd(
+	ctx context.Context,
+	deploy *appsv1.Deployment,
+	remoteClient client.Client,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+) error {
+	args, ok := vllmArgs(&deploy.Spec.Template.Spec)
+	if !ok || isManagedByRegistry(deploy.Labels) {
+		return nil
+	}
+
+	svc, err := serviceForDeployment(ctx, remoteClient, deploy)
+	if err != nil {
+		return err
+	}
+	if svc == nil {
+		r.Logger.Warn().Str("deployment", deploy.Name).Str("namespace", deploy.Namespace).Msg("No Service selects vLLM Deployment, skipping")
+		return nil
+	}
+	serverPort := vllmDefaultPort
+	if p, err := strconv.Atoi(vllmFlag(args, "--port")); err == nil {
+		serverPort = p
+	}
+	port, err := vllmServicePort(svc, serverPort)
+	if err != nil {
+		r.Logger.Warn().Err(err).Str("deployment", deploy.Name).Str("namespace", deploy.Namespace).Msg("Skipping vLLM Deployment")
+		return nil
+	}
+
+	model := vllmModelID(args)
+	if model == "" {
+		model = deploy.Name
+	}
+	desired := int32(1)
+	if deploy.Spec.Replicas != nil {
+		desired = *deploy.Spec.Replicas
+	}
+
+	labels := make(map[string]string)
+	for k, v := range env.Labels {
+		labels[k] = v
+	}
+	labels[discoveryLabel] = "true"
+	labels[sourceKindLabel] = vllmResourceType
+	labels[sourceNameLabel] = deploy.Name
+	labels[sourceNSLabel] = deploy.Namespace
+	labels[envLabel] = env.Name
+	labels[clusterLabel] = env.Cluster.Name
+	labels[discoveryConfigLabel] = scope.Config
+
+	catalog := &agentregistryv1alpha1.ModelCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateDiscoveredCatalogName(vllmResourceType, env.Name, env.Cluster.Name, deploy.Namespace, deploy.Name),
+			Namespace: config.GetNamespace(),
+			Labels:    labels,
+		},
+		Spec: agentregistryv1alpha1.ModelCatalogSpec{
+			Name:     fmt.Sprintf("%s/%s", deploy.Namespace, deploy.Name),
+			Provider: string(agentregistryv1alpha1.ModelProviderOpenAI),
+			Model:    model,
+			BaseURL:  fmt.Sprintf("http://%s.%s.svc.cluster.local:%d/v1", svc.Name, svc.Namespace, port),
+			Runtime:  "vllm",
+			SourceRef: &agentregistryv1alpha1.SourceReference{
+				Kind:      "Deployment",
+				Name:      deploy.Name,
+				Namespace: deploy.Namespace,
+			},
+		},
+	}
+	message := fmt.Sprintf("%d/%d replicas available", deploy.Status.AvailableReplicas, desired)
+	return r.upsertDiscoveredModel(ctx, catalog, deploy.Status.AvailableReplicas > 0, message)
+}
+
+// modelConfigBaseURL returns the endpoint a ModelConfig overrides for self-hosted or proxied
+// models, so its catalog entry can be linked to the model server behind it
+func modelConfigBaseURL(model *kagentv1alpha2.ModelConfig) string {
+	switch {
+	case model.Spec.OpenAI != nil:
+		return model.Spec.OpenAI.BaseURL
+	case model.Spec.Ollama != nil:
+		return model.Spec.Ollama.Host
+	case model.Spec.Anthropic != nil:
+		return model.Spec.Anthropic.BaseURL
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+func TestVLLMArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		container corev1.Container
+		wantOK    bool
+		wantModel string
+		wantPort  string
+	}{
+		{
+			name: "official image",
+			container: corev1.Container{
+				Image: "vllm/vllm-openai:v0.10.0",
+				Args:  []string{"--model", "meta-llama/Llama-3.1-8B-Instruct", "--port=8080"},
+			},
+			wantOK:    true,
+			wantModel: "meta-llama/Llama-3.1-8B-Instruct",
+			wantPort:  "8080",
+		},
+		{
+			name: "vllm serve in a shell wrapper",
+			container: corev1.Container{
+				Image:   "registry.example.com/llm:latest",
+				Command: []string{"/bin/sh", "-c"},
+				Args:    []string{"vllm serve Qwen/Qwen2.5-7B --served-model-name qwen --max-model-len 8192"},
+			},
+			wantOK:    true,
+			wantModel: "qwen",
+		},
+		{
+			name: "api server module",
+			container: corev1.Container{
+				Image:   "python:3.12",
+				Command: []string{"python", "-m", "vllm.entrypoints.openai.api_server", "--model=mistralai/Mistral-7B"},
+			},
+			wantOK:    true,
+			wantModel: "mistralai/Mistral-7B",
+		},
+		{
+			name:      "not vllm",
+			container: corev1.Container{Image: "nginx", Args: []string{"serve"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, ok := vllmArgs(&corev1.PodSpec{Containers: []corev1.Container{tt.container}})
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantModel, vllmModelID(args))
+			assert.Equal(t, tt.wantPort, vllmFlag(args, "--port"))
+		})
+	}
+}
+
+func TestDiscoveryConfigReconciler_HandleVLLMDeploymentAdd(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&agentregistryv1alpha1.ModelCatalog{}).
+		Build()
+	remote := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "models"},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "llama"},
+				Ports: []corev1.ServicePort{
+					{Name: "metrics", Port: 9090},
+					{Name: "http", Port: 80, TargetPort: intstr.FromInt32(8000)},
+				},
+			},
+		}).
+		Build()
+	r := &DiscoveryConfigReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop()}
+
+	env := &agentregistryv1alpha1.Environment{Name: "dev", Cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev"}}
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev"}
+	ctx := context.Background()
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "models"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "llama"}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Image: "vllm/vllm-openai:latest",
+					Args:  []string{"--model", "meta-llama/Llama-3.1-8B-Instruct"},
+				}}},
+			},
+		},
+		Status: appsv1.DeploymentStatus{AvailableReplicas: 1},
+	}
+	require.NoError(t, r.handleVLLMDeploymentAdd(ctx, deploy, remote, env, scope))
+
+	var catalog agentregistryv1alpha1.ModelCatalog
+	require.NoError(t, local.Get(ctx, client.ObjectKey{
+		Name:      generateDiscoveredCatalogName(vllmResourceType, "dev", "dev", "models", "llama"),
+		Namespace: testNamespace,
+	}, &catalog))
+	assert.Equal(t, "models/llama", catalog.Spec.Name)
+	assert.Equal(t, "OpenAI", catalog.Spec.Provider)
+	assert.Equal(t, "meta-llama/Llama-3.1-8B-Instruct", catalog.Spec.Model)
+	assert.Equal(t, "http://llama.models.svc.cluster.local:80/v1", catalog.Spec.BaseURL)
+	assert.Equal(t, "vllm", catalog.Spec.Runtime)
+	assert.Equal(t, "Deployment", catalog.Spec.SourceRef.Kind)
+	assert.Equal(t, vllmResourceType, catalog.Labels[sourceKindLabel])
+	assert.True(t, catalog.Status.Ready)
+	assert.Equal(t, "1/1 replicas available", catalog.Status.Message)
+}
+
+func TestServedModelMappings(t *testing.T) {
+	isvc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "llama", "namespace": "models"},
+		"spec": map[string]interface{}{
+			"predictor": map[string]interface{}{
+				"model": map[string]interface{}{
+					"modelFormat": map[string]interface{}{"name": "huggingface"},
+					"args":        []interface{}{"--model_name=llama3", "--max_model_len=8192"},
+					"storageUri":  "hf://meta-llama/Llama-3.1-8B-Instruct",
+				},
+			},
+		},
+		"status": map[string]interface{}{
+			"url":        "http://llama.models.svc.cluster.local",
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+		},
+	}}
+	llmisvc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "qwen", "namespace": "models"},
+		"spec": map[string]interface{}{
+			"model": map[string]interface{}{"uri": "hf://Qwen/Qwen2.5-7B-Instruct", "name": "Qwen/Qwen2.5-7B-Instruct"},
+		},
+		"status": map[string]interface{}{},
+	}}
+
+	tests := []struct {
+		mapping string
+		obj     *unstructured.Unstructured
+		want    customResourceEntry
+	}{
+		{
+			mapping: "InferenceService",
+			obj:     isvc,
+			want: customResourceEntry{
+				name:        "models/llama",
+				version:     "latest",
+				description: "hf://meta-llama/Llama-3.1-8B-Instruct",
+				url:         "http://llama.models.svc.cluster.local",
+				ready:       true,
+				provider:    "OpenAI",
+				model:       "llama3",
+				runtime:     "kserve",
+			},
+		},
+		{
+			// Not yet reconciled: no URL and no conditions
+			mapping: "LLMInferenceService",
+			obj:     llmisvc,
+			want: customResourceEntry{
+				name:        "models/qwen",
+				version:     "latest",
+				description: "hf://Qwen/Qwen2.5-7B-Instruct",
+				provider:    "OpenAI",
+				model:       "Qwen/Qwen2.5-7B-Instruct",
+				runtime:     "llm-d",
+			},
+		},
+	}
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{}
+	for _, tt := range tests {
+		t.Run(tt.mapping, func(t *testing.T) {
+			mapping := customResourceMapping(dc, tt.mapping)
+			require.NotNil(t, mapping)
+			compiled, err := compileMapping(mapping)
+			require.NoError(t, err)
+			entry, err := compiled.extract(tt.obj)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *entry)
+		})
+	}
+
+	// The served model name defaults to the InferenceService name
+	delete(isvc.Object["spec"].(map[string]interface{}), "predictor")
+	compiled, err := compileMapping(customResourceMapping(dc, "InferenceService"))
+	require.NoError(t, err)
+	entry, err := compiled.extract(isvc)
+	require.NoError(t, err)
+	assert.Equal(t, "llama", entry.model)
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// namespaceResourceType is the resource type of the per-environment informer that follows
+// namespaces for glob, regex and all-namespaces scoping
+const namespaceResourceType = "Namespace"
+
+// isNamespacePattern reports whether a Namespaces entry is a glob pattern rather than a name
+func isNamespacePattern(ns string) bool {
+	return strings.ContainsAny(ns, "*?[")
+}
+
+// watchesNamespaces reports whether env selects namespaces by pattern, so the namespaces that
+// exist in the cluster have to be followed
+func watchesNamespaces(env *agentregistryv1alpha1.Environment) bool {
+	if env.AllNamespaces || len(env.NamespaceRegex) > 0 {
+		return true
+	}
+	for _, ns := range env.Namespaces {
+		if isNamespacePattern(ns) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateNamespaceScoping checks the namespace patterns and label selector of env
+func validateNamespaceScoping(env *agentregistryv1alpha1.Environment) error {
+	for _, pattern := range append(append([]string{}, env.Namespaces...), env.ExcludeNamespaces...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q: %w", pattern, err)
+		}
+	}
+	for _, expr := range env.NamespaceRegex {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid namespace regex %q: %w", expr, err)
+		}
+	}
+	if _, err := metav1.LabelSelectorAsSelector(env.LabelSelector); err != nil {
+		return fmt.Errorf("invalid label selector: %w", err)
+	}
+	return nil
+}
+
+// resolveNamespaces returns the namespaces env discovers in, sorted. Exact names are always
+// included; patterns and AllNamespaces are matched against observed, the namespaces currently
+// present in the cluster. Excluded namespaces are dropped last. Invalid patterns never match.
+func resolveNamespaces(env *agentregistryv1alpha1.Environment, observed []string) []string {
+	var regexes []*regexp.Regexp
+	for _, expr := range env.NamespaceRegex {
+		if re, err := regexp.Compile(expr); err == nil {
+			regexes = append(regexes, re)
+		}
+	}
+
+	selected := make(map[string]bool)
+	for _, ns := range env.Namespaces {
+		if !isNamespacePattern(ns) {
+			selected[ns] = true
+		}
+	}
+	for _, ns := range observed {
+		if env.AllNamespaces {
+			selected[ns] = true
+			continue
+		}
+		for _, pattern := range env.Namespaces {
+			if ok, _ := path.Match(pattern, ns); ok && isNamespacePattern(pattern) {
+				selected[ns] = true
+			}
+		}
+		for _, re := range regexes {
+			if re.MatchString(ns) {
+				selected[ns] = true
+			}
+		}
+	}
+
+	namespaces := make([]string, 0, len(selected))
+	for ns := range selected {
+		excluded := false
+		for _, pattern := range env.ExcludeNamespaces {
+			if ok, _ := path.Match(pattern, ns); ok {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// namespaceInformerKey returns the informer key of the namespace informer of an environment
+func namespaceInformerKey(configName, envName string) string {
+	return fmt.Sprintf("%s/%s/*/%s", configName, envName, namespaceResourceType)
+}
+
+// observedNamespaces returns the namespaces held by an environment's namespace informer, or
+// nil if it isn't running or hasn't synced yet
+func (r *DiscoveryConfigReconciler) observedNamespaces(configName, envName string) []string {
+	r.informersMu.RLock()
+	informer := r.informers[namespaceInformerKey(configName, envName)]
+	r.informersMu.RUnlock()
+
+	if informer == nil || !informer.HasSynced() {
+		return nil
+	}
+	return informer.GetStore().ListKeys()
+}
+
+// informerListOptions returns the list options an informer for namespace in env uses
+func informerListOptions(namespace string, env *agentregistryv1alpha1.Environment) ([]client.ListOption, error) {
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if env.LabelSelector == nil {
+		return opts, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(env.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+	return append(opts, client.MatchingLabelsSelector{Selector: selector}), nil
+}
+
+// createNamespaceInformer creates an informer for the namespaces of a remote cluster. Namespaces
+// appearing or disappearing requeue the DiscoveryConfig so its informers are re-resolved.
+func (r *DiscoveryConfigReconciler) createNamespaceInformer(
+	remoteClient client.WithWatch,
+	configName string,
+	logger zerolog.Logger,
+) cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				list := &corev1.NamespaceList{}
+				err := remoteClient.List(context.Background(), list)
+				return list, err
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return remoteClient.Watch(context.Background(), &corev1.NamespaceList{})
+			},
+		},
+		&corev1.Namespace{},
+		0,
+		cache.Indexers{},
+	)
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			ns := obj.(*corev1.Namespace)
+			logger.Trace().Str("ns", ns.Name).Msg("Namespace added")
+			r.enqueueDiscoveryConfig(configName)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if ns, ok := obj.(*corev1.Namespace); ok {
+				logger.Trace().Str("ns", ns.Name).Msg("Namespace deleted")
+			}
+			r.enqueueDiscoveryConfig(configName)
+		},
+	})
+
+	return informer
+}
+
+// enqueueDiscoveryConfig requests a reconcile of a DiscoveryConfig outside of watch events on it.
+// The workqueue coalesces requests for a config that is already queued, so a burst of namespace
+// events causes a single reconcile, which sees the latest namespaces.
+func (r *DiscoveryConfigReconciler) enqueueDiscoveryConfig(name string) {
+	r.requeueMu.Lock()
+	queue := r.requeue
+	r.requeueMu.Unlock()
+	if queue == nil {
+		// Not started yet; every DiscoveryConfig is reconciled on start
+		return
+	}
+	queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: config.GetNamespace(), Name: name}})
+}
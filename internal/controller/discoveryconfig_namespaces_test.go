@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+func TestResolveNamespaces(t *testing.T) {
+	observed := []string{"default", "kube-system", "team-a", "team-b", "team-ops", "tools"}
+
+	tests := []struct {
+		name     string
+		env      agentregistryv1alpha1.Environment
+		observed []string
+		want     []string
+	}{
+		{
+			name: "exact names are kept even if not observed",
+			env:  agentregistryv1alpha1.Environment{Namespaces: []string{"tools", "missing"}},
+			want: []string{"missing", "tools"},
+		},
+		{
+			name:     "glob matches observed namespaces",
+			env:      agentregistryv1alpha1.Environment{Namespaces: []string{"team-*", "default"}},
+			observed: observed,
+			want:     []string{"default", "team-a", "team-b", "team-ops"},
+		},
+		{
+			name:     "glob before namespaces are observed",
+			env:      agentregistryv1alpha1.Environment{Namespaces: []string{"team-*"}},
+			observed: nil,
+			want:     []string{},
+		},
+		{
+			name:     "regex",
+			env:      agentregistryv1alpha1.Environment{NamespaceRegex: []string{`^team-[a-z]$`}},
+			observed: observed,
+			want:     []string{"team-a", "team-b"},
+		},
+		{
+			name: "all namespaces with excludes",
+			env: agentregistryv1alpha1.Environment{
+				AllNamespaces:     true,
+				ExcludeNamespaces: []string{"kube-*", "team-ops"},
+			},
+			observed: observed,
+			want:     []string{"default", "team-a", "team-b", "tools"},
+		},
+		{
+			name: "excludes apply to exact names",
+			env: agentregistryv1alpha1.Environment{
+				Namespaces:        []string{"tools", "team-*"},
+				ExcludeNamespaces: []string{"tools", "team-b"},
+			},
+			observed: observed,
+			want:     []string{"team-a", "team-ops"},
+		},
+		{
+			name:     "invalid regex never matches",
+			env:      agentregistryv1alpha1.Environment{NamespaceRegex: []string{"("}},
+			observed: observed,
+			want:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resolveNamespaces(&tt.env, tt.observed))
+		})
+	}
+}
+
+func TestValidateNamespaceScoping(t *testing.T) {
+	assert.NoError(t, validateNamespaceScoping(&agentregistryv1alpha1.Environment{
+		Namespaces:     []string{"team-*", "tools"},
+		NamespaceRegex: []string{`^ml-.+$`},
+		LabelSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"discover": "true"}},
+	}))
+	assert.ErrorContains(t, validateNamespaceScoping(&agentregistryv1alpha1.Environment{
+		ExcludeNamespaces: []string{"team-["},
+	}), "invalid namespace pattern")
+	assert.ErrorContains(t, validateNamespaceScoping(&agentregistryv1alpha1.Environment{
+		NamespaceRegex: []string{"("},
+	}), "invalid namespace regex")
+	assert.ErrorContains(t, validateNamespaceScoping(&agentregistryv1alpha1.Environment{
+		LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "a", Operator: "Bogus"}}},
+	}), "invalid label selector")
+}
+
+func TestDiscoveryConfigReconciler_DesiredInformersFollowNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	remote := &testClientWithWatch{Client: fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		).
+		Build()}
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{{
+				Name:              "dev",
+				Cluster:           agentregistryv1alpha1.ClusterConfig{Name: "dev"},
+				Namespaces:        []string{"team-*"},
+				ExcludeNamespaces: []string{"team-b"},
+				ResourceTypes:     []string{"MCPServer"},
+			}},
+		},
+	}
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+	r := &DiscoveryConfigReconciler{
+		Logger:    zerolog.Nop(),
+		informers: make(map[string]cache.SharedIndexInformer),
+		requeue:   queue,
+	}
+
+	// Until the namespace informer has synced only the namespace informer itself is wanted
+	keys := func() []string {
+		var keys []string
+		for _, d := range r.desiredInformers(dc) {
+			keys = append(keys, d.key)
+		}
+		return keys
+	}
+	assert.Equal(t, []string{"discovery/dev/*/Namespace"}, keys())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	informer := r.createNamespaceInformer(remote, "discovery", zerolog.Nop())
+	go informer.Run(ctx.Done())
+	require.True(t, cache.WaitForCacheSync(ctx.Done(), informer.HasSynced))
+	r.informersMu.Lock()
+	r.informers[namespaceInformerKey("discovery", "dev")] = informer
+	r.informersMu.Unlock()
+
+	assert.Equal(t, []string{"discovery/dev/*/Namespace", "discovery/dev/team-a/MCPServer"}, keys())
+
+	// Namespace events requeue the DiscoveryConfig
+	require.Eventually(t, func() bool { return queue.Len() > 0 }, 5*time.Second, 10*time.Millisecond,
+		"expected a requeue for the DiscoveryConfig")
+	req, _ := queue.Get()
+	assert.Equal(t, "discovery", req.Name)
+	assert.Equal(t, testNamespace, req.Namespace)
+	queue.Done(req)
+}
+
+func TestDiscoveryConfigReconciler_EnqueueDiscoveryConfig(t *testing.T) {
+	// Before the controller starts there is nothing to enqueue to
+	r := &DiscoveryConfigReconciler{}
+	r.enqueueDiscoveryConfig("discovery")
+
+	queue := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer queue.ShutDown()
+	r.requeue = queue
+
+	// Bursts of namespace events are coalesced per config, and no config's events are dropped
+	for range 100 {
+		r.enqueueDiscoveryConfig("discovery")
+	}
+	for i := range 20 {
+		r.enqueueDiscoveryConfig(fmt.Sprintf("config-%d", i))
+	}
+	assert.Equal(t, 21, queue.Len())
+}
+
+func TestInformerListOptions_LabelSelector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+
+	remote := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "tools", Labels: map[string]string{"discover": "true"}}},
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "private", Namespace: "tools"}},
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", Namespace: "other", Labels: map[string]string{"discover": "true"}}},
+		).
+		Build()
+
+	env := &agentregistryv1alpha1.Environment{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"discover": "true"}},
+	}
+	opts, err := informerListOptions("tools", env)
+	require.NoError(t, err)
+
+	var list kmcpv1alpha1.MCPServerList
+	require.NoError(t, remote.List(context.Background(), &list, opts...))
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "public", list.Items[0].Name)
+
+	// Changing the selector restarts informers
+	changed := env.DeepCopy()
+	changed.LabelSelector.MatchLabels["discover"] = "false"
+	assert.NotEqual(t, informerConfigHash(env), informerConfigHash(changed))
+}
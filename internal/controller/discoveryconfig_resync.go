@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+// relistedResource is a resource returned by a forced relist, with the handler that maps it
+// into the catalog
+type relistedResource struct {
+	obj         client.Object
+	catalogName string
+	apply       func() error
+}
+
+// discoveredEntryRef identifies a catalog entry produced by discovery and the informer group
+// (scope/resourceType/namespace) it came from
+type discoveredEntryRef struct {
+	obj   client.Object
+	group string
+}
+
+// informerGroup returns the key grouping catalog entries by the informer that produced them
+func informerGroup(scope DiscoveryScope, resourceType, namespace string) string {
+	return scope.String() + "/" + resourceType + "/" + namespace
+}
+
+// resync relists every informed resource type in every environment of config, recomputes each
+// catalog entry, and deletes entries whose source no longer exists. Groups that fail to list
+// are reported and left untouched so a transient error never wipes the catalog.
+func (r *DiscoveryConfigReconciler) resync(
+	ctx context.Context,
+	dc *agentregistryv1alpha1.DiscoveryConfig,
+	logger zerolog.Logger,
+) *agentregistryv1alpha1.ResyncReport {
+	report := &agentregistryv1alpha1.ResyncReport{}
+
+	existing, err := r.listDiscoveredEntries(ctx, dc.Name)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("list catalog entries: %v", err))
+		report.CompletedAt = metav1.Now()
+		return report
+	}
+
+	seen := make(map[string]bool)
+	relisted := make(map[string]bool)
+	for _, d := range r.desiredInformers(dc) {
+		resources, err := r.relist(ctx, d)
+		if err != nil {
+			logger.Warn().Err(err).Str("key", d.key).Msg("resync relist failed")
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", d.key, err))
+			continue
+		}
+		if d.resourceType == namespaceResourceType {
+			continue
+		}
+		relisted[informerGroup(d.scope, d.resourceType, d.namespace)] = true
+
+		for _, res := range resources {
+			// An entry whose source still exists is kept even if it failed to update
+			if res.catalogName != "" {
+				seen[res.catalogName] = true
+			}
+			if err := res.apply(); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s/%s: %v", d.key, res.catalogName, err))
+				continue
+			}
+			if res.catalogName == "" {
+				continue
+			}
+			if _, ok := existing[res.catalogName]; ok {
+				report.Updated++
+			} else {
+				report.Added++
+			}
+		}
+	}
+
+	// Garbage-collect entries whose source is gone from a successfully relisted group
+	for name, entry := range existing {
+		if seen[name] || !relisted[entry.group] {
+			continue
+		}
+		if err := r.Delete(ctx, entry.obj); client.IgnoreNotFound(err) != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("delete %s: %v", name, err))
+			continue
+		}
+		logger.Info().Str("catalog", name).Msg("source no longer exists, removed catalog entry")
+		report.Removed++
+	}
+
+	report.CompletedAt = metav1.Now()
+	return report
+}
+
+// listDiscoveredEntries returns the catalog entries discovered by a DiscoveryConfig, keyed by name
+func (r *DiscoveryConfigReconciler) listDiscoveredEntries(ctx context.Context, configName string) (map[string]discoveredEntryRef, error) {
+	entries := make(map[string]discoveredEntryRef)
+	lists := []client.ObjectList{
+		&agentregistryv1alpha1.MCPServerCatalogList{},
+		&agentregistryv1alpha1.AgentCatalogList{},
+		&agentregistryv1alpha1.ModelCatalogList{},
+	}
+	for _, list := range lists {
+		if err := r.List(ctx, list,
+			client.InNamespace(config.GetNamespace()),
+			client.MatchingLabels{discoveryLabel: "true", discoveryConfigLabel: configName},
+		); err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok {
+				continue
+			}
+			labels := obj.GetLabels()
+			entries[obj.GetName()] = discoveredEntryRef{
+				obj:   obj,
+				group: informerGroup(scopeFromLabels(labels), labels[sourceKindLabel], labels[sourceNSLabel]),
+			}
+		}
+	}
+	return entries, nil
+}
+
+// relist lists the resources an informer watches straight from the remote cluster and
+// refreshes the discovery cache for that group
+func (r *DiscoveryConfigReconciler) relist(ctx context.Context, d desiredInformer) ([]relistedResource, error) {
+	if d.resourceType == namespaceResourceType {
+		// Namespace informers produce no catalog entries
+		return nil, nil
+	}
+
+	remoteClient, err := r.getRemoteClient(d.scope, &d.env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remote client: %w", err)
+	}
+	resources, err := r.listSources(ctx, remoteClient, d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the cached group with the fresh listing. With sharding, the informers of the
+	// owning replica keep the cache of environments other replicas discover.
+	if !r.ownsScope(d.scope) {
+		return resources, nil
+	}
+	r.DiscoveredCache.DeleteNamespace(d.scope, d.resourceType, d.namespace)
+	for _, res := range resources {
+		r.DiscoveredCache.Set(d.scope, d.resourceType, res.obj)
+	}
+	r.DiscoveredCache.MarkSynced(d.scope, d.resourceType, d.namespace)
+	return resources, nil
+}
+
+// listSources lists the resources an informer watches with remoteClient, without touching the
+// discovery cache or the catalog
+func (r *DiscoveryConfigReconciler) listSources(ctx context.Context, remoteClient client.Client, d desiredInformer) ([]relistedResource, error) {
+	listOpts, err := informerListOptions(d.namespace, &d.env)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &d.env
+	catalogName := func(obj client.Object) string {
+		if isManagedByRegistry(obj.GetLabels()) {
+			return ""
+		}
+		return generateDiscoveredCatalogName(d.resourceType, env.Name, env.Cluster.Name, obj.GetNamespace(), obj.GetName())
+	}
+
+	var resources []relistedResource
+	switch d.resourceType {
+	case "MCPServer":
+		var list kmcpv1alpha1.MCPServerList
+		if err := remoteClient.List(ctx, &list, listOpts...); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			resources = append(resources, relistedResource{
+				obj:         item,
+				catalogName: catalogName(item),
+				apply:       func() error { return r.handleMCPServerAdd(ctx, item, env, d.scope) },
+			})
+		}
+	case "Agent":
+		var list kagentv1alpha2.AgentList
+		if err := remoteClient.List(ctx, &list, listOpts...); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			resources = append(resources, relistedResource{
+				obj:         item,
+				catalogName: catalogName(item),
+				apply:       func() error { return r.handleAgentAdd(ctx, item, env, d.scope) },
+			})
+		}
+	case "ModelConfig":
+		var list kagentv1alpha2.ModelConfigList
+		if err := remoteClient.List(ctx, &list, listOpts...); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			resources = append(resources, relistedResource{
+				obj:         item,
+				catalogName: catalogName(item),
+				apply:       func() error { return r.handleModelConfigAdd(ctx, item, env, d.scope) },
+			})
+		}
+	case "RemoteMCPServer":
+		var list kagentv1alpha2.RemoteMCPServerList
+		if err := remoteClient.List(ctx, &list, listOpts...); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			resources = append(resources, relistedResource{
+				obj:         item,
+				catalogName: catalogName(item),
+				apply:       func() error { return r.handleRemoteMCPServerAdd(ctx, item, env, d.scope) },
+			})
+		}
+	case "Service":
+		var list corev1.ServiceList
+		if err := remoteClient.List(ctx, &list, listOpts...); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			if !hasMCPAnnotations(item.Annotations) {
+				continue
+			}
+			resources = append(resources, relistedResource{
+				obj:         item,
+				catalogName: catalogName(item),
+				apply:       func() error { return r.handleServiceAdd(ctx, item, env, d.scope) },
+			})
+		}
+	case "Deployment":
+		var list appsv1.DeploymentList
+		if err := remoteClient.List(ctx, &list, listOpts...); err != nil {
+			return nil, err
+		}
+		services, err := discoveredServices(ctx, remoteClient, d.namespace, env)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			if !hasMCPAnnotations(item.Annotations) || behindAnnotatedService(services, item) {
+				continue
+			}
+			resources = append(resources, relistedResource{
+				obj:         item,
+				catalogName: catalogName(item),
+				apply:       func() error { return r.handleDeploymentAdd(ctx, item, remoteClient, env, d.scope) },
+			})
+		}
+	case vllmResourceType:
+		var list appsv1.DeploymentList
+		if err := remoteClient.List(ctx, &list, listOpts...); err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			if _, ok := vllmArgs(&item.Spec.Template.Spec); !ok {
+				continue
+			}
+			resources = append(resources, relistedResource{
+				obj:         item,
+				catalogName: catalogName(item),
+				apply:       func() error { return r.handleVLLMDeploymentAdd(ctx, item, remoteClient, env, d.scope) },
+			})
+		}
+	case agentgatewayBackendResourceType, kgatewayBackendResourceType:
+		resources, err = r.relistGatewayBackends(ctx, remoteClient, listOpts, d, catalogName)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		if d.mapping == nil {
+			return nil, fmt.Errorf("unsupported resource type: %s", d.resourceType)
+		}
+		resources, err = r.relistCustomResources(ctx, remoteClient, listOpts, d, catalogName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+func TestDiscoveryConfigReconciler_TriggerAnnotationResyncs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+
+	env := agentregistryv1alpha1.Environment{
+		Name:          "dev",
+		Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "dev-cluster"},
+		Namespaces:    []string{"tools"},
+		ResourceTypes: []string{"MCPServer"},
+	}
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "discovery",
+			Namespace:   testNamespace,
+			Annotations: map[string]string{agentregistryv1alpha1.AnnotationTriggerDiscovery: "true"},
+		},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{env},
+		},
+	}
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev-cluster"}
+
+	discoveredCatalog := func(sourceName string) *agentregistryv1alpha1.MCPServerCatalog {
+		return &agentregistryv1alpha1.MCPServerCatalog{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      generateDiscoveredCatalogName("MCPServer", "dev", "dev-cluster", "tools", sourceName),
+				Namespace: testNamespace,
+				Labels: map[string]string{
+					discoveryLabel:       "true",
+					discoveryConfigLabel: "discovery",
+					envLabel:             "dev",
+					clusterLabel:         "dev-cluster",
+					sourceKindLabel:      "MCPServer",
+					sourceNameLabel:      sourceName,
+					sourceNSLabel:        "tools",
+				},
+			},
+			Spec: agentregistryv1alpha1.MCPServerCatalogSpec{Name: "tools/" + sourceName, Version: "latest"},
+		}
+	}
+	manual := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "manual-entry", Namespace: testNamespace},
+		Spec:       agentregistryv1alpha1.MCPServerCatalogSpec{Name: "manual", Version: "1.0.0"},
+	}
+
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(dc, discoveredCatalog("existing"), discoveredCatalog("gone"), manual).
+		WithStatusSubresource(&agentregistryv1alpha1.DiscoveryConfig{}, &agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+
+	remote := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "tools"}},
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "tools"}},
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{
+				Name:      "deployed-by-registry",
+				Namespace: "tools",
+				Labels:    map[string]string{agentregistryv1alpha1.LabelManagedBy: "agentregistry"},
+			}},
+		).
+		Build()
+
+	oldFactory := RemoteClientFactory
+	RemoteClientFactory = func(_ DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+		return &testClientWithWatch{Client: remote}, nil
+	}
+	defer func() { RemoteClientFactory = oldFactory }()
+
+	// Pretend the informer is already running so Reconcile doesn't need a manager
+	key := "discovery/dev/tools/MCPServer"
+	r := &DiscoveryConfigReconciler{
+		Client:        local,
+		Scheme:        scheme,
+		Logger:        zerolog.Nop(),
+		informers:     map[string]cache.SharedIndexInformer{key: nil},
+		stopChans:     map[string]chan struct{}{key: make(chan struct{})},
+		informerState: map[string]runningInformer{key: {scope: scope, namespace: "tools", resourceType: "MCPServer", configHash: informerConfigHash(&env)}},
+	}
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "discovery", Namespace: testNamespace}}
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	var updated agentregistryv1alpha1.DiscoveryConfig
+	require.NoError(t, local.Get(ctx, req.NamespacedName, &updated))
+	assert.NotContains(t, updated.Annotations, agentregistryv1alpha1.AnnotationTriggerDiscovery)
+	require.NotNil(t, updated.Status.LastResync)
+	assert.Equal(t, 1, updated.Status.LastResync.Added)
+	assert.Equal(t, 1, updated.Status.LastResync.Updated)
+	assert.Equal(t, 1, updated.Status.LastResync.Removed)
+	assert.Empty(t, updated.Status.LastResync.Errors)
+
+	var catalogs agentregistryv1alpha1.MCPServerCatalogList
+	require.NoError(t, local.List(ctx, &catalogs))
+	names := make([]string, 0, len(catalogs.Items))
+	for _, item := range catalogs.Items {
+		names = append(names, item.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		discoveredCatalog("existing").Name,
+		discoveredCatalog("new").Name,
+		"manual-entry",
+	}, names)
+
+	// The relist refreshed the discovery cache
+	_, err = r.DiscoveredCache.GetMCPServer(scope, "tools", "new")
+	assert.NoError(t, err)
+
+	// Without the annotation, a reconcile doesn't resync again
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, local.Get(ctx, req.NamespacedName, &updated))
+	assert.Equal(t, 1, updated.Status.LastResync.Added)
+}
+
+func TestDiscoveryConfigReconciler_ResyncKeepsEntriesWhenListFails(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{{
+				Name:          "dev",
+				Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "dev"},
+				Namespaces:    []string{"tools"},
+				ResourceTypes: []string{"MCPServer"},
+			}},
+		},
+	}
+	entry := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dev-tools-a",
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				discoveryLabel:       "true",
+				discoveryConfigLabel: "discovery",
+				envLabel:             "dev",
+				clusterLabel:         "dev",
+				sourceKindLabel:      "MCPServer",
+				sourceNSLabel:        "tools",
+			},
+		},
+	}
+	local := fake.NewClientBuilder().WithScheme(scheme).WithObjects(entry).Build()
+
+	oldFactory := RemoteClientFactory
+	RemoteClientFactory = func(_ DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+		return nil, assert.AnError
+	}
+	defer func() { RemoteClientFactory = oldFactory }()
+
+	r := &DiscoveryConfigReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop()}
+	report := r.resync(context.Background(), dc, zerolog.Nop())
+
+	assert.Len(t, report.Errors, 1)
+	assert.Zero(t, report.Removed)
+
+	var catalogs agentregistryv1alpha1.MCPServerCatalogList
+	require.NoError(t, local.List(context.Background(), &catalogs))
+	assert.Len(t, catalogs.Items, 1)
+}
+
+func TestDiscoveryConfigReconciler_ResyncKeepsEntriesWhenApplyFails(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{{
+				Name:          "dev",
+				Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "dev"},
+				Namespaces:    []string{"tools"},
+				ResourceTypes: []string{"MCPServer"},
+			}},
+		},
+	}
+	entry := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      generateDiscoveredCatalogName("MCPServer", "dev", "dev", "tools", "existing"),
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				discoveryLabel:       "true",
+				discoveryConfigLabel: "discovery",
+				envLabel:             "dev",
+				clusterLabel:         "dev",
+				sourceKindLabel:      "MCPServer",
+				sourceNameLabel:      "existing",
+				sourceNSLabel:        "tools",
+			},
+		},
+	}
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(entry).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(context.Context, client.WithWatch, client.Object, ...client.CreateOption) error {
+				return assert.AnError
+			},
+			Update: func(context.Context, client.WithWatch, client.Object, ...client.UpdateOption) error {
+				return assert.AnError
+			},
+		}).
+		Build()
+	remote := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "tools"}},
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "tools"}},
+		).
+		Build()
+
+	oldFactory := RemoteClientFactory
+	RemoteClientFactory = func(_ DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+		return &testClientWithWatch{Client: remote}, nil
+	}
+	defer func() { RemoteClientFactory = oldFactory }()
+
+	r := &DiscoveryConfigReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop(), DiscoveredCache: NewDiscoveredResourceCache()}
+	report := r.resync(context.Background(), dc, zerolog.Nop())
+
+	assert.Len(t, report.Errors, 2)
+	assert.Zero(t, report.Added, "failed applies are only reported as errors")
+	assert.Zero(t, report.Updated, "failed applies are only reported as errors")
+	assert.Zero(t, report.Removed, "entries whose source exists are kept")
+
+	var catalogs agentregistryv1alpha1.MCPServerCatalogList
+	require.NoError(t, local.List(context.Background(), &catalogs))
+	assert.Len(t, catalogs.Items, 1)
+}
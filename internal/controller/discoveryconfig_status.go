@@ -0,0 +1,380 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// environmentStatusInterval is how often environment status is recomputed between reconciles
+const environmentStatusInterval = 30 * time.Second
+
+// informerHealth tracks the activity of a single informer for EnvironmentStatus
+type informerHealth struct {
+	// syncedAt is when the informer finished its initial list
+	syncedAt time.Time
+	// lastEvent is when the informer last delivered an add, update or delete
+	lastEvent time.Time
+	// err is the last setup or list/watch error, cleared once the informer makes progress
+	err error
+	// errResourceVersion is the informer's resource version when err was recorded
+	errResourceVersion string
+}
+
+// healthFor returns the health record for an informer key, creating it if needed.
+// Callers must hold healthMu.
+func (r *DiscoveryConfigReconciler) healthFor(key string) *informerHealth {
+	if r.health == nil {
+		r.health = make(map[string]*informerHealth)
+	}
+	h, ok := r.health[key]
+	if !ok {
+		h = &informerHealth{}
+		r.health[key] = h
+	}
+	return h
+}
+
+// recordSynced marks an informer as having completed its initial list
+func (r *DiscoveryConfigReconciler) recordSynced(key string) {
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+	r.healthFor(key).syncedAt = time.Now()
+}
+
+// recordEvent notes that an informer delivered an event, which also clears any previous error
+func (r *DiscoveryConfigReconciler) recordEvent(key string) {
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+	h := r.healthFor(key)
+	h.lastEvent = time.Now()
+	h.err = nil
+}
+
+// recordInformerError records a setup or list/watch error for an informer
+func (r *DiscoveryConfigReconciler) recordInformerError(key string, err error, resourceVersion string) {
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+	h := r.healthFor(key)
+	h.err = err
+	h.errResourceVersion = resourceVersion
+}
+
+// forgetHealth drops the health record of an informer that is no longer running
+func (r *DiscoveryConfigReconciler) forgetHealth(key string) {
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+	delete(r.health, key)
+}
+
+// trackInformerHealth hooks an informer's events and list/watch errors into the health records.
+// Must be called before the informer is started.
+func (r *DiscoveryConfigReconciler) trackInformerHealth(key string, informer cache.SharedIndexInformer) error {
+	record := func(interface{}) { r.recordEvent(key) }
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    record,
+		UpdateFunc: func(_, newObj interface{}) { record(newObj) },
+		DeleteFunc: record,
+	}); err != nil {
+		return err
+	}
+	return informer.SetWatchErrorHandlerWithContext(func(ctx context.Context, reflector *cache.Reflector, err error) {
+		cache.DefaultWatchErrorHandler(ctx, reflector, err)
+		r.recordInformerError(key, err, reflector.LastSyncResourceVersion())
+	})
+}
+
+// informerSnapshot is the health of one informer at the time status is computed
+type informerSnapshot struct {
+	synced   bool
+	lastSeen time.Time
+	err      error
+}
+
+// snapshotInformer returns the current health of the informer with the given key
+func (r *DiscoveryConfigReconciler) snapshotInformer(key string) informerSnapshot {
+	r.informersMu.RLock()
+	informer, running := r.informers[key]
+	r.informersMu.RUnlock()
+
+	synced := running && informer != nil && informer.HasSynced()
+	snap := informerSnapshot{synced: synced}
+
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+	h, ok := r.health[key]
+	if !ok {
+		return snap
+	}
+	// A resource version that moved past the one seen at error time means the reflector
+	// has listed or watched successfully since
+	if h.err != nil && synced && informer.LastSyncResourceVersion() != h.errResourceVersion {
+		h.err = nil
+	}
+	snap.err = h.err
+	snap.lastSeen = h.syncedAt
+	if h.lastEvent.After(snap.lastSeen) {
+		snap.lastSeen = h.lastEvent
+	}
+	return snap
+}
+
+// handlerErrors returns the number of resources in scope whose handler is failing, and the
+// most recent error
+func (r *DiscoveryConfigReconciler) handlerErrors(scope DiscoveryScope) (int, error) {
+	r.errorTrackerMu.RLock()
+	defer r.errorTrackerMu.RUnlock()
+
+	count := 0
+	var latest *informerError
+	for _, tracker := range r.errorTracker {
+		if tracker.scope != scope {
+			continue
+		}
+		count++
+		if latest == nil || tracker.lastRetry.After(latest.lastRetry) {
+			latest = tracker
+		}
+	}
+	if latest == nil {
+		return 0, nil
+	}
+	return count, latest.err
+}
+
+// environmentStatuses computes the status of every environment in dc from its informers,
+// the handler error tracker and the discovery cache
+func (r *DiscoveryConfigReconciler) environmentStatuses(dc *agentregistryv1alpha1.DiscoveryConfig) []agentregistryv1alpha1.EnvironmentStatus {
+	byEnv := make(map[string][]desiredInformer)
+	for _, d := range r.desiredInformers(dc) {
+		byEnv[d.scope.Environment] = append(byEnv[d.scope.Environment], d)
+	}
+
+	statuses := make([]agentregistryv1alpha1.EnvironmentStatus, 0, len(dc.Spec.Environments))
+	for _, env := range dc.Spec.Environments {
+		scope := DiscoveryScope{Config: dc.Name, Environment: env.Name, Cluster: env.Cluster.Name}
+		if !r.ownsScope(scope) {
+			statuses = append(statuses, shardedEnvironmentStatus(dc, env.Name, r.Shards.Owner(scope)))
+			continue
+		}
+		status := agentregistryv1alpha1.EnvironmentStatus{
+			Name:                env.Name,
+			DiscoveredResources: r.discoveredCounts(scope, dc),
+		}
+
+		desired := byEnv[env.Name]
+		if err := validateNamespaceScoping(&env); err != nil {
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+		if err := cluster.ValidateEnvironment(&env); err != nil {
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+		if err := r.credentialsError(dc.Name, env.Name); err != nil {
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+		if conn, ok := r.connectionStatus(scope); ok {
+			status.ConnectionState = conn.State
+			status.ConsecutiveFailures = conn.ConsecutiveFailures
+			if conn.State == agentregistryv1alpha1.ConnectionStateOpen {
+				retryAt := metav1.NewTime(conn.RetryAt)
+				status.NextRetryTime = &retryAt
+				status.Error = fmt.Sprintf("circuit breaker open after %d consecutive failures: %s",
+					conn.ConsecutiveFailures, conn.Error)
+				status.Message = "Cluster unreachable, retrying at " + conn.RetryAt.UTC().Format(time.RFC3339)
+				statuses = append(statuses, status)
+				continue
+			}
+		}
+		if len(desired) == 0 {
+			status.Message = "No namespaces configured"
+			statuses = append(statuses, status)
+			continue
+		}
+
+		synced := 0
+		var lastSeen time.Time
+		var errs []string
+		for _, d := range desired {
+			snap := r.snapshotInformer(d.key)
+			if snap.synced {
+				synced++
+			}
+			if snap.lastSeen.After(lastSeen) {
+				lastSeen = snap.lastSeen
+			}
+			if snap.err != nil {
+				errs = append(errs, fmt.Sprintf("%s/%s: %v", d.namespace, d.resourceType, snap.err))
+			}
+		}
+		sort.Strings(errs)
+
+		status.Connected = synced == len(desired) && len(errs) == 0
+		status.Error = strings.Join(errs, "; ")
+		if !lastSeen.IsZero() {
+			t := metav1.NewTime(lastSeen)
+			status.LastSyncTime = &t
+		}
+		status.Message = fmt.Sprintf("%d/%d informers synced", synced, len(desired))
+		if failing, err := r.handlerErrors(scope); failing > 0 {
+			status.Message += fmt.Sprintf(", %d resources failing to sync: %v", failing, err)
+		}
+		if status.ConnectionState == agentregistryv1alpha1.ConnectionStateDegraded {
+			status.Message += fmt.Sprintf(", connection degraded after %d failed probes", status.ConsecutiveFailures)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// shardedEnvironmentStatus keeps the status the owning replica wrote for an environment
+// another replica discovers
+func shardedEnvironmentStatus(dc *agentregistryv1alpha1.DiscoveryConfig, envName, owner string) agentregistryv1alpha1.EnvironmentStatus {
+	for _, es := range dc.Status.Environments {
+		if es.Name == envName {
+			return es
+		}
+	}
+	if owner == "" {
+		return agentregistryv1alpha1.EnvironmentStatus{Name: envName, Message: "Waiting for discovery replicas"}
+	}
+	return agentregistryv1alpha1.EnvironmentStatus{Name: envName, Message: "Discovered by replica " + owner}
+}
+
+// connectionStatus returns the probed health of the cluster of a scope. ok is false for local
+// clusters and clusters that have not been connected to.
+func (r *DiscoveryConfigReconciler) connectionStatus(scope DiscoveryScope) (cluster.ConnectionStatus, bool) {
+	if r.Connections == nil {
+		return cluster.ConnectionStatus{}, false
+	}
+	return r.Connections.Status(cluster.Scope(scope))
+}
+
+// discoveredCounts counts the resources currently held in the discovery cache for scope.
+// Resources of mapped resource types count towards the catalog kind they produce, and
+// Deployments behind an annotated Service are only counted as the Service.
+func (r *DiscoveryConfigReconciler) discoveredCounts(
+	scope DiscoveryScope,
+	dc *agentregistryv1alpha1.DiscoveryConfig,
+) agentregistryv1alpha1.DiscoveredResourceCounts {
+	counts := r.DiscoveredCache.Count(scope)
+	mapped := make(map[string]int)
+	for kind, n := range counts {
+		if mapping := customResourceMapping(dc, kind); mapping != nil {
+			mapped[mappedResourceType(mapping)] += n
+		}
+	}
+	deployments := counts["Deployment"]
+	if deployments > 0 && counts["Service"] > 0 {
+		var services []corev1.Service
+		for _, obj := range r.DiscoveredCache.List(scope, "Service") {
+			if svc, ok := obj.(*corev1.Service); ok {
+				services = append(services, *svc)
+			}
+		}
+		for _, obj := range r.DiscoveredCache.List(scope, "Deployment") {
+			if deploy, ok := obj.(*appsv1.Deployment); ok && behindAnnotatedService(services, deploy) {
+				deployments--
+			}
+		}
+	}
+	return agentregistryv1alpha1.DiscoveredResourceCounts{
+		MCPServers: counts["MCPServer"] + counts["RemoteMCPServer"] + counts["Service"] + deployments +
+			counts[agentgatewayBackendResourceType] + counts[kgatewayBackendResourceType] + mapped["MCPServer"],
+		Agents: counts["Agent"] + mapped["Agent"],
+		Skills: counts["Skill"],
+		Models: counts["ModelConfig"] + counts[vllmResourceType] + mapped["ModelConfig"],
+	}
+}
+
+// setEnvironmentStatus writes environment statuses and the Ready condition into dc
+func (r *DiscoveryConfigReconciler) setEnvironmentStatus(dc *agentregistryv1alpha1.DiscoveryConfig) {
+	dc.Status.Environments = r.environmentStatuses(dc)
+
+	connected := 0
+	for _, es := range dc.Status.Environments {
+		if es.Connected {
+			connected++
+		}
+	}
+	condition := metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: dc.Generation,
+		Reason:             "InformersSynced",
+		Message:            fmt.Sprintf("Watching %d environments", len(dc.Spec.Environments)),
+	}
+	if connected < len(dc.Status.Environments) {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "EnvironmentsDisconnected"
+		condition.Message = fmt.Sprintf("%d of %d environments connected", connected, len(dc.Status.Environments))
+	}
+	meta.SetStatusCondition(&dc.Status.Conditions, condition)
+}
+
+// refreshEnvironmentStatus recomputes the status of every DiscoveryConfig and patches the ones
+// that changed. Runs on a schedule so connectivity changes surface without a spec change.
+func (r *DiscoveryConfigReconciler) refreshEnvironmentStatus(ctx context.Context) error {
+	var list agentregistryv1alpha1.DiscoveryConfigList
+	if err := r.List(ctx, &list, client.InNamespace(config.GetNamespace())); err != nil {
+		return err
+	}
+
+	for i := range list.Items {
+		dc := &list.Items[i]
+		orig := dc.DeepCopy()
+		r.setEnvironmentStatus(dc)
+		if equality.Semantic.DeepEqual(orig.Status, dc.Status) {
+			continue
+		}
+		patch := client.MergeFrom(orig)
+		if r.Shards != nil {
+			// Replicas write the environments they own; never overwrite a newer status wholesale
+			patch = client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})
+		}
+		if err := r.Status().Patch(ctx, dc, patch); client.IgnoreNotFound(err) != nil {
+			if apierrors.IsConflict(err) {
+				continue
+			}
+			return fmt.Errorf("failed to update status of %s: %w", dc.Name, err)
+		}
+	}
+	return nil
+}
+
+// runEnvironmentStatusLoop refreshes environment status every environmentStatusInterval until
+// ctx is cancelled
+func (r *DiscoveryConfigReconciler) runEnvironmentStatusLoop(ctx context.Context) error {
+	ticker := time.NewTicker(environmentStatusInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.refreshEnvironmentStatus(ctx); err != nil {
+				r.Logger.Error().Err(err).Msg("failed to refresh environment status")
+			}
+		}
+	}
+}
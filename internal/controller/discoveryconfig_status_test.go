@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+func TestDiscoveryConfigReconciler_EnvironmentStatus(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+
+	dev := agentregistryv1alpha1.Environment{
+		Name:          "dev",
+		Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "dev-cluster"},
+		Namespaces:    []string{"tools"},
+		ResourceTypes: []string{"MCPServer"},
+	}
+	prod := agentregistryv1alpha1.Environment{
+		Name:          "prod",
+		Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "prod-cluster"},
+		Namespaces:    []string{"tools"},
+		ResourceTypes: []string{"MCPServer"},
+	}
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{dev, prod, {Name: "empty"}},
+		},
+	}
+
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(dc).
+		WithStatusSubresource(&agentregistryv1alpha1.DiscoveryConfig{}, &agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+	remote := &testClientWithWatch{Client: fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "tools"}},
+			&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "tools"}},
+		).
+		Build()}
+
+	r := &DiscoveryConfigReconciler{
+		Client:          local,
+		Scheme:          scheme,
+		Logger:          zerolog.Nop(),
+		informers:       make(map[string]cache.SharedIndexInformer),
+		errorTracker:    make(map[string]*informerError),
+		DiscoveredCache: NewDiscoveredResourceCache(),
+	}
+
+	// dev has a synced informer over the fake remote cluster
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devScope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev-cluster"}
+	devKey := "discovery/dev/tools/MCPServer"
+	informer := r.createMCPServerInformer(ctx, remote, []client.ListOption{client.InNamespace("tools")}, &dev, devScope, zerolog.Nop())
+	require.NoError(t, r.trackInformerHealth(devKey, informer))
+	go informer.Run(ctx.Done())
+	require.True(t, cache.WaitForCacheSync(ctx.Done(), informer.HasSynced))
+	r.informersMu.Lock()
+	r.informers[devKey] = informer
+	r.informersMu.Unlock()
+	r.recordSynced(devKey)
+	require.Eventually(t, func() bool {
+		return r.DiscoveredCache.Count(devScope)["MCPServer"] == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// prod never connected
+	r.recordInformerError("discovery/prod/tools/MCPServer", assert.AnError, "")
+
+	// One dev resource keeps failing in its handler
+	r.errorTrackerMu.Lock()
+	r.errorTracker[devScope.String()+"/mcpserver/tools/c"] = &informerError{scope: devScope, err: assert.AnError, lastRetry: time.Now()}
+	r.errorTrackerMu.Unlock()
+
+	require.NoError(t, r.refreshEnvironmentStatus(ctx))
+
+	var updated agentregistryv1alpha1.DiscoveryConfig
+	require.NoError(t, local.Get(ctx, types.NamespacedName{Name: "discovery", Namespace: testNamespace}, &updated))
+	require.Len(t, updated.Status.Environments, 3)
+
+	devStatus := updated.Status.Environments[0]
+	assert.Equal(t, "dev", devStatus.Name)
+	assert.True(t, devStatus.Connected)
+	assert.Empty(t, devStatus.Error)
+	assert.NotNil(t, devStatus.LastSyncTime)
+	assert.Equal(t, 2, devStatus.DiscoveredResources.MCPServers)
+	assert.Contains(t, devStatus.Message, "1/1 informers synced")
+	assert.Contains(t, devStatus.Message, "1 resources failing to sync")
+
+	prodStatus := updated.Status.Environments[1]
+	assert.Equal(t, "prod", prodStatus.Name)
+	assert.False(t, prodStatus.Connected)
+	assert.Contains(t, prodStatus.Error, assert.AnError.Error())
+	assert.Nil(t, prodStatus.LastSyncTime)
+
+	emptyStatus := updated.Status.Environments[2]
+	assert.False(t, emptyStatus.Connected)
+	assert.Equal(t, "No namespaces configured", emptyStatus.Message)
+
+	require.Len(t, updated.Status.Conditions, 1)
+	assert.Equal(t, metav1.ConditionFalse, updated.Status.Conditions[0].Status)
+	assert.Equal(t, "EnvironmentsDisconnected", updated.Status.Conditions[0].Reason)
+	assert.Equal(t, "1 of 3 environments connected", updated.Status.Conditions[0].Message)
+}
+
+func TestDiscoveryConfigReconciler_InformerErrorClearsOnEvent(t *testing.T) {
+	r := &DiscoveryConfigReconciler{}
+	key := "discovery/dev/tools/MCPServer"
+
+	r.recordInformerError(key, assert.AnError, "1")
+	assert.Equal(t, assert.AnError, r.snapshotInformer(key).err)
+
+	r.recordEvent(key)
+	snap := r.snapshotInformer(key)
+	assert.NoError(t, snap.err)
+	assert.False(t, snap.lastSeen.IsZero())
+
+	r.forgetHealth(key)
+	assert.True(t, r.snapshotInformer(key).lastSeen.IsZero())
+}
+
+func TestDiscoveryConfigReconciler_ConnectionStatus(t *testing.T) {
+	env := func(name string) agentregistryv1alpha1.Environment {
+		return agentregistryv1alpha1.Environment{
+			Name: name,
+			Cluster: agentregistryv1alpha1.ClusterConfig{
+				Name:     name,
+				Endpoint: "https://10.0.0.1:6443",
+				CAData:   "dGVzdC1jYQ==",
+			},
+		}
+	}
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{env("healthy"), env("flaky"), env("down"), env("untracked")},
+		},
+	}
+
+	scope := func(config, name string) cluster.Scope {
+		return cluster.Scope{Config: config, Environment: name, Cluster: name}
+	}
+	connections := cluster.NewConnectionManager(zerolog.Nop())
+	connections.RecordFailure(scope("discovery", "flaky"), "hash", errors.New("i/o timeout"))
+	for range cluster.DefaultFailureThreshold {
+		connections.RecordFailure(scope("discovery", "down"), "hash", errors.New("connection refused"))
+		// Another DiscoveryConfig's environment of the same name has its own breaker
+		connections.RecordFailure(scope("other", "flaky"), "hash", errors.New("connection refused"))
+	}
+	connections.RecordFailure(scope("discovery", "healthy"), "hash", errors.New("i/o timeout"))
+	require.NoError(t, connections.Track(scope("discovery", "healthy"), "other", &rest.Config{Host: "https://10.0.0.1:6443"}))
+
+	r := &DiscoveryConfigReconciler{
+		Logger:          zerolog.Nop(),
+		DiscoveredCache: NewDiscoveredResourceCache(),
+		Connections:     connections,
+	}
+	statuses := r.environmentStatuses(dc)
+	require.Len(t, statuses, 4)
+
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateHealthy, statuses[0].ConnectionState)
+	assert.Zero(t, statuses[0].ConsecutiveFailures, "a new client config starts afresh")
+
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateDegraded, statuses[1].ConnectionState)
+	assert.Equal(t, 1, statuses[1].ConsecutiveFailures)
+	assert.Nil(t, statuses[1].NextRetryTime)
+
+	assert.Equal(t, agentregistryv1alpha1.ConnectionStateOpen, statuses[2].ConnectionState)
+	assert.Equal(t, cluster.DefaultFailureThreshold, statuses[2].ConsecutiveFailures)
+	assert.False(t, statuses[2].Connected)
+	require.NotNil(t, statuses[2].NextRetryTime)
+	assert.Contains(t, statuses[2].Error, "circuit breaker open after 3 consecutive failures: connection refused")
+	assert.Contains(t, statuses[2].Message, "retrying at")
+
+	assert.Empty(t, statuses[3].ConnectionState)
+}
@@ -0,0 +1,422 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// hasMCPAnnotations reports whether a Service or Deployment opted into MCP discovery
+func hasMCPAnnotations(annotations map[string]string) bool {
+	_, ok := annotations[agentregistryv1alpha1.AnnotationMCPPath]
+	return ok
+}
+
+// mcpTransportType maps the mcp-transport annotation to a catalog transport type
+func mcpTransportType(annotations map[string]string) string {
+	if annotations[agentregistryv1alpha1.AnnotationMCPTransport] == "sse" {
+		return "sse"
+	}
+	return "streamable-http"
+}
+
+// selectServicePort picks the port named or numbered by want, or the first port if want is empty
+func selectServicePort(svc *corev1.Service, want string) (int32, error) {
+	if len(svc.Spec.Ports) == 0 {
+		return 0, fmt.Errorf("service %s/%s has no ports", svc.Namespace, svc.Name)
+	}
+	if want == "" {
+		return svc.Spec.Ports[0].Port, nil
+	}
+	number, numErr := strconv.Atoi(want)
+	for _, p := range svc.Spec.Ports {
+		if p.Name == want || (numErr == nil && int(p.Port) == number) {
+			return p.Port, nil
+		}
+	}
+	return 0, fmt.Errorf("service %s/%s has no port %q", svc.Namespace, svc.Name, want)
+}
+
+// serviceMCPURL builds the in-cluster MCP URL of a Service from the MCP annotations
+func serviceMCPURL(svc *corev1.Service, annotations map[string]string) (string, error) {
+	port, err := selectServicePort(svc, annotations[agentregistryv1alpha1.AnnotationMCPPort])
+	if err != nil {
+		return "", err
+	}
+	path := annotations[agentregistryv1alpha1.AnnotationMCPPath]
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d%s", svc.Name, svc.Namespace, port, path), nil
+}
+
+// serviceForDeployment returns the first Service in the Deployment's namespace whose selector
+// matches its pod template
+func serviceForDeployment(ctx context.Context, remoteClient client.Client, deploy *appsv1.Deployment) (*corev1.Service, error) {
+	var services corev1.ServiceList
+	if err := remoteClient.List(ctx, &services, client.InNamespace(deploy.Namespace)); err != nil {
+		return nil, err
+	}
+	podLabels := labels.Set(deploy.Spec.Template.Labels)
+	for i := range services.Items {
+		svc := &services.Items[i]
+		if len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(svc.Spec.Selector).Matches(podLabels) {
+			return svc, nil
+		}
+	}
+	return nil, nil
+}
+
+// selectsAnnotatedService reports whether svc opted into MCP discovery and selects the pods
+// of deploy. Such a Deployment is discovered through the Service, which points at the same
+// server, so it gets no catalog entry or count of its own.
+func selectsAnnotatedService(svc *corev1.Service, deploy *appsv1.Deployment) bool {
+	return svc.Namespace == deploy.Namespace && hasMCPAnnotations(svc.Annotations) &&
+		!isManagedByRegistry(svc.Labels) && len(svc.Spec.Selector) > 0 &&
+		labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(deploy.Spec.Template.Labels))
+}
+
+// discoveredServices lists the Services of namespace that env discovers, or nothing if it
+// does not discover Services
+func discoveredServices(ctx context.Context, remoteClient client.Client, namespace string, env *agentregistryv1alpha1.Environment) ([]corev1.Service, error) {
+	if !slices.Contains(env.ResourceTypes, "Service") {
+		return nil, nil
+	}
+	listOpts, err := informerListOptions(namespace, env)
+	if err != nil {
+		return nil, err
+	}
+	var services corev1.ServiceList
+	if err := remoteClient.List(ctx, &services, listOpts...); err != nil {
+		return nil, err
+	}
+	return services.Items, nil
+}
+
+// behindAnnotatedService reports whether one of services selects deploy; see
+// selectsAnnotatedService
+func behindAnnotatedService(services []corev1.Service, deploy *appsv1.Deployment) bool {
+	for i := range services {
+		if selectsAnnotatedService(&services[i], deploy) {
+			return true
+		}
+	}
+	return false
+}
+
+// createServiceInformer creates an informer for Services annotated as MCP servers
+func (r *DiscoveryConfigReconciler) createServiceInformer(
+	ctx context.Context,
+	remoteClient client.WithWatch,
+	listOpts []client.ListOption,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+	logger zerolog.Logger,
+) cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				list := &corev1.ServiceList{}
+				err := remoteClient.List(context.Background(), list, listOpts...)
+				return list, err
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return remoteClient.Watch(context.Background(), &corev1.ServiceList{}, listOpts...)
+			},
+		},
+		&corev1.Service{},
+		0,
+		cache.Indexers{},
+	)
+
+	upsert := func(svc *corev1.Service) {
+		if !hasMCPAnnotations(svc.Annotations) {
+			// Annotation may have been removed
+			r.DiscoveredCache.Delete(scope, "Service", svc.Namespace, svc.Name)
+			return
+		}
+		r.DiscoveredCache.Set(scope, "Service", svc)
+		resourceKey := fmt.Sprintf("service/%s/%s", svc.Namespace, svc.Name)
+		r.executeWithRetry(ctx, scope, resourceKey, func() error {
+			return r.handleServiceAdd(ctx, svc, env, scope)
+		}, logger)
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			svc := obj.(*corev1.Service)
+			logger.Trace().Str("service", svc.Name).Msg("Service added")
+			upsert(svc)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			svc := newObj.(*corev1.Service)
+			logger.Trace().Str("service", svc.Name).Msg("Service updated")
+			upsert(svc)
+		},
+		DeleteFunc: func(obj interface{}) {
+			svc, ok := obj.(*corev1.Service)
+			if !ok {
+				return
+			}
+			logger.Trace().Str("service", svc.Name).Msg("Service deleted")
+			r.DiscoveredCache.Delete(scope, "Service", svc.Namespace, svc.Name)
+		},
+	})
+
+	return informer
+}
+
+// createDeploymentInformer creates an informer for Deployments annotated as MCP servers
+func (r *DiscoveryConfigReconciler) createDeploymentInformer(
+	ctx context.Context,
+	remoteClient client.WithWatch,
+	listOpts []client.ListOption,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+	logger zerolog.Logger,
+) cache.SharedIndexInformer {
+	informer := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				list := &appsv1.DeploymentList{}
+				err := remoteClient.List(context.Background(), list, listOpts...)
+				return list, err
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return remoteClient.Watch(context.Background(), &appsv1.DeploymentList{}, listOpts...)
+			},
+		},
+		&appsv1.Deployment{},
+		0,
+		cache.Indexers{},
+	)
+
+	upsert := func(deploy *appsv1.Deployment) {
+		if !hasMCPAnnotations(deploy.Annotations) {
+			r.DiscoveredCache.Delete(scope, "Deployment", deploy.Namespace, deploy.Name)
+			return
+		}
+		r.DiscoveredCache.Set(scope, "Deployment", deploy)
+		resourceKey := fmt.Sprintf("deployment/%s/%s", deploy.Namespace, deploy.Name)
+		r.executeWithRetry(ctx, scope, resourceKey, func() error {
+			return r.handleDeploymentAdd(ctx, deploy, remoteClient, env, scope)
+		}, logger)
+	}
+
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			deploy := obj.(*appsv1.Deployment)
+			logger.Trace().Str("deployment", deploy.Name).Msg("Deployment added")
+			upsert(deploy)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			deploy := newObj.(*appsv1.Deployment)
+			logger.Trace().Str("deployment", deploy.Name).Msg("Deployment updated")
+			upsert(deploy)
+		},
+		DeleteFunc: func(obj interface{}) {
+			deploy, ok := obj.(*appsv1.Deployment)
+			if !ok {
+				return
+			}
+			logger.Trace().Str("deployment", deploy.Name).Msg("Deployment deleted")
+			r.DiscoveredCache.Delete(scope, "Deployment", deploy.Namespace, deploy.Name)
+		},
+	})
+
+	return informer
+}
+
+// handleServiceAdd creates or updates the catalog entry of an annotated Service
+func (r *DiscoveryConfigReconciler) handleServiceAdd(
+	ctx context.Context,
+	svc *corev1.Service,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+) error {
+	if !hasMCPAnnotations(svc.Annotations) || isManagedByRegistry(svc.Labels) {
+		return nil
+	}
+
+	url, err := serviceMCPURL(svc, svc.Annotations)
+	if err != nil {
+		r.Logger.Warn().Err(err).Str("service", svc.Name).Str("namespace", svc.Namespace).Msg("Skipping annotated Service")
+		return nil
+	}
+
+	now := metav1.Now()
+	deployment := &agentregistryv1alpha1.DeploymentRef{
+		Namespace:   svc.Namespace,
+		ServiceName: svc.Name,
+		URL:         url,
+		Ready:       true,
+		LastChecked: &now,
+	}
+	return r.upsertAnnotatedMCPServer(ctx, "Service", svc, url, deployment, env, scope)
+}
+
+// handleDeploymentAdd creates or updates the catalog entry of an annotated Deployment, using the
+// Service that selects its pods for the URL. A Deployment behind an annotated Service that env
+// discovers is left to the Service, and an entry created for it before is removed.
+func (r *DiscoveryConfigReconciler) handleDeploymentAdd(
+	ctx context.Context,
+	deploy *appsv1.Deployment,
+	remoteClient client.Client,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+) error {
+	if !hasMCPAnnotations(deploy.Annotations) || isManagedByRegistry(deploy.Labels) {
+		return nil
+	}
+
+	services, err := discoveredServices(ctx, remoteClient, deploy.Namespace, env)
+	if err != nil {
+		return err
+	}
+	if behindAnnotatedService(services, deploy) {
+		stale := &agentregistryv1alpha1.MCPServerCatalog{ObjectMeta: metav1.ObjectMeta{
+			Name:      generateDiscoveredCatalogName("Deployment", env.Name, env.Cluster.Name, deploy.Namespace, deploy.Name),
+			Namespace: config.GetNamespace(),
+		}}
+		return client.IgnoreNotFound(r.Delete(ctx, stale))
+	}
+
+	svc, err := serviceForDeployment(ctx, remoteClient, deploy)
+	if err != nil {
+		return err
+	}
+	if svc == nil {
+		r.Logger.Warn().Str("deployment", deploy.Name).Str("namespace", deploy.Namespace).Msg("No Service selects annotated Deployment, skipping")
+		return nil
+	}
+	url, err := serviceMCPURL(svc, deploy.Annotations)
+	if err != nil {
+		r.Logger.Warn().Err(err).Str("deployment", deploy.Name).Str("namespace", deploy.Namespace).Msg("Skipping annotated Deployment")
+		return nil
+	}
+
+	desired := int32(1)
+	if deploy.Spec.Replicas != nil {
+		desired = *deploy.Spec.Replicas
+	}
+	now := metav1.Now()
+	deployment := &agentregistryv1alpha1.DeploymentRef{
+		Namespace:   deploy.Namespace,
+		ServiceName: svc.Name,
+		URL:         url,
+		Ready:       deploy.Status.AvailableReplicas > 0,
+		Message:     fmt.Sprintf("%d/%d replicas available", deploy.Status.AvailableReplicas, desired),
+		LastChecked: &now,
+	}
+	return r.upsertAnnotatedMCPServer(ctx, "Deployment", deploy, url, deployment, env, scope)
+}
+
+// upsertAnnotatedMCPServer creates or updates the MCPServerCatalog entry of an annotated
+// Service or Deployment, with a single remote pointing at its in-cluster URL
+func (r *DiscoveryConfigReconciler) upsertAnnotatedMCPServer(
+	ctx context.Context,
+	kind string,
+	obj client.Object,
+	url string,
+	deployment *agentregistryv1alpha1.DeploymentRef,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+) error {
+	catalogName := generateDiscoveredCatalogName(kind, env.Name, env.Cluster.Name, obj.GetNamespace(), obj.GetName())
+	namespace := config.GetNamespace()
+	annotations := obj.GetAnnotations()
+
+	version := "latest"
+	if v, ok := obj.GetLabels()["app.kubernetes.io/version"]; ok {
+		version = v
+	}
+	name := fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+	if n := annotations[agentregistryv1alpha1.AnnotationMCPName]; n != "" {
+		name = n
+	}
+
+	labels := make(map[string]string)
+	for k, v := range env.Labels {
+		labels[k] = v
+	}
+	labels[discoveryLabel] = "true"
+	labels[sourceKindLabel] = kind
+	labels[sourceNameLabel] = obj.GetName()
+	labels[sourceNSLabel] = obj.GetNamespace()
+	labels[envLabel] = env.Name
+	labels[clusterLabel] = env.Cluster.Name
+	labels[discoveryConfigLabel] = scope.Config
+
+	catalog := agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      catalogName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+			Name:    name,
+			Version: version,
+			Title:   obj.GetName(),
+			SourceRef: &agentregistryv1alpha1.SourceReference{
+				Kind:      kind,
+				Name:      obj.GetName(),
+				Namespace: obj.GetNamespace(),
+			},
+			Remotes: []agentregistryv1alpha1.Transport{{
+				Type: mcpTransportType(annotations),
+				URL:  url,
+			}},
+		},
+	}
+
+	existing := &agentregistryv1alpha1.MCPServerCatalog{}
+	err := r.Get(ctx, client.ObjectKey{Name: catalogName, Namespace: namespace}, existing)
+
+	if apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, &catalog); err != nil {
+			return err
+		}
+		catalog.Status.ManagementType = agentregistryv1alpha1.ManagementTypeExternal
+		catalog.Status.Published = true
+		catalog.Status.Status = agentregistryv1alpha1.CatalogStatusActive
+		catalog.Status.Deployment = deployment
+		return r.Status().Update(ctx, &catalog)
+	} else if err != nil {
+		return err
+	}
+
+	existing.Spec = catalog.Spec
+	existing.Labels = labels
+	if err := r.Update(ctx, existing); err != nil {
+		return err
+	}
+	if existing.Status.ManagementType == "" {
+		existing.Status.ManagementType = agentregistryv1alpha1.ManagementTypeExternal
+		existing.Status.Published = true
+		existing.Status.Status = agentregistryv1alpha1.CatalogStatusActive
+	}
+	if existing.Status.ManagementType != agentregistryv1alpha1.ManagementTypeExternal {
+		return nil
+	}
+	existing.Status.Deployment = deployment
+	return r.Status().Update(ctx, existing)
+}
@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+func annotatedService(name string, annotations map[string]string, ports ...corev1.ServicePort) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "tools", Annotations: annotations},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": name},
+			Ports:    ports,
+		},
+	}
+}
+
+func TestServiceMCPURL(t *testing.T) {
+	ports := []corev1.ServicePort{{Name: "metrics", Port: 9090}, {Name: "mcp", Port: 8080}}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		ports       []corev1.ServicePort
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "first port by default",
+			annotations: map[string]string{agentregistryv1alpha1.AnnotationMCPPath: "/mcp"},
+			ports:       ports,
+			want:        "http://fetch.tools.svc.cluster.local:9090/mcp",
+		},
+		{
+			name: "port by name",
+			annotations: map[string]string{
+				agentregistryv1alpha1.AnnotationMCPPath: "mcp",
+				agentregistryv1alpha1.AnnotationMCPPort: "mcp",
+			},
+			ports: ports,
+			want:  "http://fetch.tools.svc.cluster.local:8080/mcp",
+		},
+		{
+			name: "port by number",
+			annotations: map[string]string{
+				agentregistryv1alpha1.AnnotationMCPPath: "/sse",
+				agentregistryv1alpha1.AnnotationMCPPort: "8080",
+			},
+			ports: ports,
+			want:  "http://fetch.tools.svc.cluster.local:8080/sse",
+		},
+		{
+			name: "unknown port",
+			annotations: map[string]string{
+				agentregistryv1alpha1.AnnotationMCPPath: "/mcp",
+				agentregistryv1alpha1.AnnotationMCPPort: "grpc",
+			},
+			ports:   ports,
+			wantErr: true,
+		},
+		{
+			name:        "no ports",
+			annotations: map[string]string{agentregistryv1alpha1.AnnotationMCPPath: "/mcp"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := serviceMCPURL(annotatedService("fetch", tt.annotations, tt.ports...), tt.annotations)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, url)
+		})
+	}
+}
+
+func TestDiscoveryConfigReconciler_HandleServiceAdd(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+	r := &DiscoveryConfigReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop()}
+
+	env := &agentregistryv1alpha1.Environment{
+		Name:    "dev",
+		Cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev-cluster"},
+		Labels:  map[string]string{"team": "platform"},
+	}
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev-cluster"}
+	ctx := context.Background()
+
+	svc := annotatedService("fetch", map[string]string{
+		agentregistryv1alpha1.AnnotationMCPPath:      "/sse",
+		agentregistryv1alpha1.AnnotationMCPTransport: "sse",
+		agentregistryv1alpha1.AnnotationMCPName:      "acme/fetch",
+	}, corev1.ServicePort{Port: 8080})
+	require.NoError(t, r.handleServiceAdd(ctx, svc, env, scope))
+
+	var catalog agentregistryv1alpha1.MCPServerCatalog
+	require.NoError(t, local.Get(ctx, client.ObjectKey{
+		Name:      generateDiscoveredCatalogName("Service", "dev", "dev-cluster", "tools", "fetch"),
+		Namespace: testNamespace,
+	}, &catalog))
+	assert.Equal(t, "acme/fetch", catalog.Spec.Name)
+	assert.Equal(t, []agentregistryv1alpha1.Transport{{Type: "sse", URL: "http://fetch.tools.svc.cluster.local:8080/sse"}}, catalog.Spec.Remotes)
+	assert.Equal(t, "Service", catalog.Spec.SourceRef.Kind)
+	assert.Equal(t, "true", catalog.Labels[discoveryLabel])
+	assert.Equal(t, "Service", catalog.Labels[sourceKindLabel])
+	assert.Equal(t, "discovery", catalog.Labels[discoveryConfigLabel])
+	assert.Equal(t, "platform", catalog.Labels["team"])
+	assert.Equal(t, agentregistryv1alpha1.ManagementTypeExternal, catalog.Status.ManagementType)
+	require.NotNil(t, catalog.Status.Deployment)
+	assert.Equal(t, "fetch", catalog.Status.Deployment.ServiceName)
+
+	// Services without the path annotation are ignored
+	require.NoError(t, r.handleServiceAdd(ctx, annotatedService("plain", nil, corev1.ServicePort{Port: 80}), env, scope))
+	var catalogs agentregistryv1alpha1.MCPServerCatalogList
+	require.NoError(t, local.List(ctx, &catalogs))
+	assert.Len(t, catalogs.Items, 1)
+}
+
+func TestDiscoveryConfigReconciler_HandleDeploymentAdd(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, appsv1.AddToScheme(scheme))
+
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+	remote := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			annotatedService("other", nil, corev1.ServicePort{Port: 80}),
+			annotatedService("search", nil, corev1.ServicePort{Name: "http", Port: 3000}),
+		).
+		Build()
+	r := &DiscoveryConfigReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop()}
+
+	env := &agentregistryv1alpha1.Environment{Name: "dev", Cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev"}}
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev"}
+	ctx := context.Background()
+
+	replicas := int32(2)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "search",
+			Namespace:   "tools",
+			Labels:      map[string]string{"app.kubernetes.io/version": "1.4.0"},
+			Annotations: map[string]string{agentregistryv1alpha1.AnnotationMCPPath: "/mcp"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "search", "tier": "tools"}},
+			},
+		},
+		Status: appsv1.DeploymentStatus{AvailableReplicas: 1},
+	}
+	require.NoError(t, r.handleDeploymentAdd(ctx, deploy, remote, env, scope))
+
+	var catalog agentregistryv1alpha1.MCPServerCatalog
+	require.NoError(t, local.Get(ctx, client.ObjectKey{
+		Name:      generateDiscoveredCatalogName("Deployment", "dev", "dev", "tools", "search"),
+		Namespace: testNamespace,
+	}, &catalog))
+	assert.Equal(t, "tools/search", catalog.Spec.Name)
+	assert.Equal(t, "1.4.0", catalog.Spec.Version)
+	assert.Equal(t, []agentregistryv1alpha1.Transport{{Type: "streamable-http", URL: "http://search.tools.svc.cluster.local:3000/mcp"}}, catalog.Spec.Remotes)
+	require.NotNil(t, catalog.Status.Deployment)
+	assert.True(t, catalog.Status.Deployment.Ready)
+	assert.Equal(t, "1/2 replicas available", catalog.Status.Deployment.Message)
+
+	// Without a Service selecting its pods the Deployment is skipped
+	deploy.Name = "orphan"
+	deploy.Spec.Template.Labels = map[string]string{"app": "orphan"}
+	require.NoError(t, r.handleDeploymentAdd(ctx, deploy, remote, env, scope))
+	var catalogs agentregistryv1alpha1.MCPServerCatalogList
+	require.NoError(t, local.List(ctx, &catalogs))
+	assert.Len(t, catalogs.Items, 1)
+
+	// Once an annotated Service of a discovered type selects it, the Deployment is left to the
+	// Service and its entry is removed
+	deploy.Name = "search"
+	deploy.Spec.Template.Labels = map[string]string{"app": "search", "tier": "tools"}
+	require.NoError(t, remote.Update(ctx, annotatedService("search", map[string]string{
+		agentregistryv1alpha1.AnnotationMCPPath: "/mcp",
+	}, corev1.ServicePort{Name: "http", Port: 3000})))
+	require.NoError(t, r.handleDeploymentAdd(ctx, deploy, remote, env, scope))
+	require.NoError(t, local.List(ctx, &catalogs))
+	assert.Len(t, catalogs.Items, 1, "Services are not discovered in the environment")
+
+	env.ResourceTypes = []string{"Service", "Deployment"}
+	require.NoError(t, r.handleDeploymentAdd(ctx, deploy, remote, env, scope))
+	require.NoError(t, local.List(ctx, &catalogs))
+	assert.Empty(t, catalogs.Items)
+}
+
+func TestDiscoveryConfigReconciler_DiscoveredCountsDeploymentBehindService(t *testing.T) {
+	r := &DiscoveryConfigReconciler{DiscoveredCache: NewDiscoveredResourceCache()}
+	scope := DiscoveryScope{Config: "discovery", Environment: "dev", Cluster: "dev"}
+	deployment := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "tools"},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+			}},
+		}
+	}
+	r.DiscoveredCache.Set(scope, "Service", annotatedService("search", map[string]string{
+		agentregistryv1alpha1.AnnotationMCPPath: "/mcp",
+	}, corev1.ServicePort{Port: 3000}))
+	r.DiscoveredCache.Set(scope, "Deployment", deployment("search"))
+	r.DiscoveredCache.Set(scope, "Deployment", deployment("fetch"))
+
+	counts := r.discoveredCounts(scope, &agentregistryv1alpha1.DiscoveryConfig{})
+	assert.Equal(t, 2, counts.MCPServers, "the Deployment behind the Service is counted once")
+}
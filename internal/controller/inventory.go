@@ -0,0 +1,352 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+// InventoryReportConfig is the discovery config label value of catalog entries ingested from
+// inventory reports. DiscoveryConfig names cannot contain underscores, so it never collides.
+const InventoryReportConfig = "inventory_report"
+
+// inventoryRecordKind is the source kind of catalog entries ingested from pre-shaped records
+const inventoryRecordKind = "InventoryRecord"
+
+// InventoryReport is a full snapshot of the resources running in one environment/cluster,
+// pushed by a cluster the registry cannot reach
+type InventoryReport struct {
+	// Environment is the environment the resources run in
+	Environment string `json:"environment" doc:"Environment the resources run in"`
+	// Cluster is the cluster the resources run in
+	Cluster string `json:"cluster" doc:"Cluster the resources run in"`
+	// Labels are added to every catalog entry of the report
+	Labels map[string]string `json:"labels,omitempty" doc:"Labels added to every catalog entry"`
+	// A2AEndpoint is the base URL agents of the report are reachable at
+	A2AEndpoint string `json:"a2aEndpoint,omitempty" doc:"Base URL agents are reachable at"`
+	// Objects are kagent/kmcp resources (MCPServer, Agent, ModelConfig, RemoteMCPServer) and
+	// MCP-annotated Services, as returned by the Kubernetes API
+	Objects []map[string]any `json:"objects,omitempty" doc:"kagent/kmcp resources and MCP-annotated Services"`
+	// Records are catalog entries already shaped by the reporter
+	Records []InventoryRecord `json:"records,omitempty" doc:"Pre-shaped catalog records"`
+}
+
+// InventoryRecord is a catalog entry shaped by the reporter rather than mapped from a
+// kagent/kmcp resource
+type InventoryRecord struct {
+	// Kind is the catalog kind: MCPServer, Agent or Model
+	Kind string `json:"kind" enum:"MCPServer,Agent,Model" doc:"Catalog kind"`
+	// Namespace and Name identify the workload in the reporting cluster
+	Namespace string `json:"namespace" doc:"Namespace of the workload"`
+	Name      string `json:"name" doc:"Name of the workload"`
+	// CatalogName is the catalog spec name. Defaults to namespace/name.
+	CatalogName string `json:"catalogName,omitempty" doc:"Catalog name, defaults to namespace/name"`
+	// Version defaults to latest
+	Version     string `json:"version,omitempty" doc:"Version, defaults to latest"`
+	Description string `json:"description,omitempty"`
+	// URL is the MCP endpoint, agent endpoint or model base URL
+	URL string `json:"url,omitempty" doc:"MCP endpoint, agent endpoint or model base URL"`
+	// Image is the agent image
+	Image string `json:"image,omitempty"`
+	// Provider, Model and Runtime describe a model
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Runtime  string `json:"runtime,omitempty"`
+	// Ready defaults to true
+	Ready   *bool  `json:"ready,omitempty" doc:"Whether the workload is ready, defaults to true"`
+	Message string `json:"message,omitempty"`
+}
+
+// InventoryResult summarizes the catalog changes made by an inventory report
+type InventoryResult struct {
+	Added   int      `json:"added"`
+	Updated int      `json:"updated"`
+	Removed int      `json:"removed"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// InventoryIngester upserts catalog entries from inventory reports, using the same mapping as
+// informer-based discovery, and prunes entries missing from the latest report
+type InventoryIngester struct {
+	reconciler *DiscoveryConfigReconciler
+
+	// mu serializes reports so concurrent snapshots of one scope don't prune each other
+	mu sync.Mutex
+}
+
+// NewInventoryIngester creates an InventoryIngester. Ingested resources are added to
+// discoveredCache so catalog reconcilers can resolve their SourceRefs.
+func NewInventoryIngester(
+	c client.Client,
+	scheme *runtime.Scheme,
+	discoveredCache *DiscoveredResourceCache,
+	logger zerolog.Logger,
+) *InventoryIngester {
+	return &InventoryIngester{
+		reconciler: &DiscoveryConfigReconciler{
+			Client:          c,
+			Scheme:          scheme,
+			Logger:          logger,
+			DiscoveredCache: discoveredCache,
+		},
+	}
+}
+
+// inventoryObject is a decoded report object with its discovery resource type
+type inventoryObject struct {
+	resourceType string
+	obj          client.Object
+}
+
+// Ingest applies report as the complete inventory of its environment/cluster. Invalid reports
+// return a BadRequest error, and reports for an environment/cluster already discovered by a
+// DiscoveryConfig return a Conflict error; neither changes the catalog. Failures to apply
+// single entries are collected in the result.
+func (i *InventoryIngester) Ingest(ctx context.Context, report *InventoryReport) (*InventoryResult, error) {
+	r := i.reconciler
+	if err := validateInventoryReport(report); err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	objects, err := r.decodeInventoryObjects(report.Objects)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(err.Error())
+	}
+	if err := r.checkInventoryConflict(ctx, report); err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	scope := DiscoveryScope{Config: InventoryReportConfig, Environment: report.Environment, Cluster: report.Cluster}
+	env := &agentregistryv1alpha1.Environment{
+		Name:        report.Environment,
+		Cluster:     agentregistryv1alpha1.ClusterConfig{Name: report.Cluster},
+		Labels:      report.Labels,
+		A2AEndpoint: report.A2AEndpoint,
+	}
+	logger := r.Logger.With().Str("environment", env.Name).Str("cluster", env.Cluster.Name).Logger()
+
+	existing, err := r.listDiscoveredEntries(ctx, InventoryReportConfig)
+	if err != nil {
+		return nil, fmt.Errorf("list catalog entries: %w", err)
+	}
+
+	// Replace the cached scope with the reported objects
+	r.DiscoveredCache.DeleteScope(scope)
+	for _, o := range objects {
+		r.DiscoveredCache.Set(scope, o.resourceType, o.obj)
+	}
+
+	result := &InventoryResult{}
+	seen := make(map[string]bool)
+	track := func(catalogName, what string, err error) {
+		// A failed entry is still reported, so it is kept rather than pruned
+		seen[catalogName] = true
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", what, err))
+			return
+		}
+		if _, ok := existing[catalogName]; ok {
+			result.Updated++
+		} else {
+			result.Added++
+		}
+	}
+
+	for _, o := range objects {
+		if isManagedByRegistry(o.obj.GetLabels()) {
+			continue
+		}
+		catalogName := generateDiscoveredCatalogName(o.resourceType, env.Name, env.Cluster.Name, o.obj.GetNamespace(), o.obj.GetName())
+		what := fmt.Sprintf("%s %s/%s", o.resourceType, o.obj.GetNamespace(), o.obj.GetName())
+		track(catalogName, what, r.applyInventoryObject(ctx, o, env, scope))
+	}
+	for idx := range report.Records {
+		rec := &report.Records[idx]
+		sourceKind := inventoryRecordKind + rec.Kind
+		catalogName := generateDiscoveredCatalogName(sourceKind, env.Name, env.Cluster.Name, rec.Namespace, rec.Name)
+		what := fmt.Sprintf("%s record %s/%s", rec.Kind, rec.Namespace, rec.Name)
+		track(catalogName, what, r.upsertMappedEntry(ctx, rec.Kind, sourceKind, &agentregistryv1alpha1.SourceReference{
+			Kind:      inventoryRecordKind,
+			Name:      rec.Name,
+			Namespace: rec.Namespace,
+		}, rec.entry(), env, scope))
+	}
+
+	// Prune entries of this environment/cluster missing from the report
+	for name, entry := range existing {
+		if seen[name] || scopeFromLabels(entry.obj.GetLabels()) != scope {
+			continue
+		}
+		if err := r.Delete(ctx, entry.obj); client.IgnoreNotFound(err) != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("delete %s: %v", name, err))
+			continue
+		}
+		logger.Info().Str("catalog", name).Msg("missing from inventory report, removed catalog entry")
+		result.Removed++
+	}
+
+	logger.Info().
+		Int("added", result.Added).
+		Int("updated", result.Updated).
+		Int("removed", result.Removed).
+		Int("errors", len(result.Errors)).
+		Msg("ingested inventory report")
+	return result, nil
+}
+
+// validateInventoryReport checks the scope and records of a report
+func validateInventoryReport(report *InventoryReport) error {
+	for _, f := range []struct{ field, value string }{
+		{"environment", report.Environment},
+		{"cluster", report.Cluster},
+	} {
+		field, value := f.field, f.value
+		if value == "" {
+			return fmt.Errorf("%s is required", field)
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("invalid %s %q: %s", field, value, strings.Join(errs, "; "))
+		}
+	}
+	for idx, rec := range report.Records {
+		switch rec.Kind {
+		case "MCPServer", "Agent", "Model":
+		default:
+			return fmt.Errorf("records[%d]: unsupported kind %q", idx, rec.Kind)
+		}
+		if rec.Namespace == "" || rec.Name == "" {
+			return fmt.Errorf("records[%d]: namespace and name are required", idx)
+		}
+	}
+	return nil
+}
+
+// decodeInventoryObjects converts report objects into typed resources, rejecting kinds
+// discovery doesn't map
+func (r *DiscoveryConfigReconciler) decodeInventoryObjects(raw []map[string]any) ([]inventoryObject, error) {
+	objects := make([]inventoryObject, 0, len(raw))
+	for idx, content := range raw {
+		u := &unstructured.Unstructured{Object: content}
+		gvk := u.GroupVersionKind()
+		if gvk.Empty() || u.GetName() == "" || u.GetNamespace() == "" {
+			return nil, fmt.Errorf("objects[%d]: apiVersion, kind, metadata.name and metadata.namespace are required", idx)
+		}
+
+		var resourceType string
+		switch gvk {
+		case kmcpv1alpha1.GroupVersion.WithKind("MCPServer"),
+			kagentv1alpha2.GroupVersion.WithKind("Agent"),
+			kagentv1alpha2.GroupVersion.WithKind("ModelConfig"),
+			kagentv1alpha2.GroupVersion.WithKind("RemoteMCPServer"),
+			corev1.SchemeGroupVersion.WithKind("Service"):
+			resourceType = gvk.Kind
+		default:
+			return nil, fmt.Errorf("objects[%d]: unsupported kind %s", idx, gvk)
+		}
+
+		typed, err := r.Scheme.New(gvk)
+		if err != nil {
+			return nil, fmt.Errorf("objects[%d]: %w", idx, err)
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, typed); err != nil {
+			return nil, fmt.Errorf("objects[%d] %s/%s: %w", idx, u.GetNamespace(), u.GetName(), err)
+		}
+		obj, ok := typed.(client.Object)
+		if !ok {
+			return nil, fmt.Errorf("objects[%d]: %s is not an object", idx, gvk)
+		}
+		if svc, ok := obj.(*corev1.Service); ok && !hasMCPAnnotations(svc.Annotations) {
+			// Unannotated Services are not MCP servers; ignore them like the Service informer does
+			continue
+		}
+		objects = append(objects, inventoryObject{resourceType: resourceType, obj: obj})
+	}
+	return objects, nil
+}
+
+// applyInventoryObject maps a decoded report object into the catalog with the handler its
+// informer uses
+func (r *DiscoveryConfigReconciler) applyInventoryObject(
+	ctx context.Context,
+	o inventoryObject,
+	env *agentregistryv1alpha1.Environment,
+	scope DiscoveryScope,
+) error {
+	switch obj := o.obj.(type) {
+	case *kmcpv1alpha1.MCPServer:
+		return r.handleMCPServerAdd(ctx, obj, env, scope)
+	case *kagentv1alpha2.Agent:
+		return r.handleAgentAdd(ctx, obj, env, scope)
+	case *kagentv1alpha2.ModelConfig:
+		return r.handleModelConfigAdd(ctx, obj, env, scope)
+	case *kagentv1alpha2.RemoteMCPServer:
+		return r.handleRemoteMCPServerAdd(ctx, obj, env, scope)
+	case *corev1.Service:
+		return r.handleServiceAdd(ctx, obj, env, scope)
+	default:
+		return fmt.Errorf("unsupported object %T", obj)
+	}
+}
+
+// checkInventoryConflict rejects reports for an environment/cluster a DiscoveryConfig already
+// discovers, so pushed and informed entries never prune each other
+func (r *DiscoveryConfigReconciler) checkInventoryConflict(ctx context.Context, report *InventoryReport) error {
+	var configs agentregistryv1alpha1.DiscoveryConfigList
+	if err := r.List(ctx, &configs, client.InNamespace(config.GetNamespace())); err != nil {
+		return fmt.Errorf("list discovery configs: %w", err)
+	}
+	for _, dc := range configs.Items {
+		for i := range dc.Spec.Environments {
+			env := &dc.Spec.Environments[i]
+			// Satellite clusters are never discovered by the registry, so they report inventory
+			if env.Name == report.Environment && env.Cluster.Name == report.Cluster && !isSatelliteEnvironment(env) {
+				return apierrors.NewConflict(
+					schema.GroupResource{Group: agentregistryv1alpha1.GroupVersion.Group, Resource: "discoveryconfigs"},
+					dc.Name,
+					fmt.Errorf("environment %q in cluster %q is already discovered", report.Environment, report.Cluster),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// entry converts a record into the shape custom resource mappings produce
+func (rec *InventoryRecord) entry() *customResourceEntry {
+	entry := &customResourceEntry{
+		name:        rec.CatalogName,
+		version:     rec.Version,
+		description: rec.Description,
+		url:         rec.URL,
+		ready:       rec.Ready == nil || *rec.Ready,
+		message:     rec.Message,
+		image:       rec.Image,
+		provider:    rec.Provider,
+		model:       rec.Model,
+		runtime:     rec.Runtime,
+	}
+	if entry.name == "" {
+		entry.name = fmt.Sprintf("%s/%s", rec.Namespace, rec.Name)
+	}
+	if entry.version == "" {
+		entry.version = "latest"
+	}
+	return entry
+}
@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+func inventoryObjectContent(t *testing.T, obj client.Object, gvk schema.GroupVersionKind) map[string]any {
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	return content
+}
+
+func TestInventoryIngester_Ingest(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kagentv1alpha2.AddToScheme(scheme))
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{{
+				Name:    "dev",
+				Cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev-cluster"},
+			}},
+		},
+	}
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(dc).
+		WithStatusSubresource(
+			&agentregistryv1alpha1.MCPServerCatalog{},
+			&agentregistryv1alpha1.AgentCatalog{},
+			&agentregistryv1alpha1.ModelCatalog{},
+		).
+		Build()
+	discovered := NewDiscoveredResourceCache()
+	ingester := NewInventoryIngester(local, scheme, discovered, zerolog.Nop())
+	ctx := context.Background()
+
+	mcpServer := inventoryObjectContent(t,
+		&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "fs", Namespace: "tools"}},
+		kmcpv1alpha1.GroupVersion.WithKind("MCPServer"))
+	agent := inventoryObjectContent(t,
+		&kagentv1alpha2.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "helper", Namespace: "agents"},
+			Spec:       kagentv1alpha2.AgentSpec{Description: "Helps"},
+		},
+		kagentv1alpha2.GroupVersion.WithKind("Agent"))
+	annotated := inventoryObjectContent(t,
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "search",
+				Namespace:   "tools",
+				Annotations: map[string]string{agentregistryv1alpha1.AnnotationMCPPath: "/mcp"},
+			},
+			Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+		},
+		corev1.SchemeGroupVersion.WithKind("Service"))
+	plain := inventoryObjectContent(t,
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "tools"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+		},
+		corev1.SchemeGroupVersion.WithKind("Service"))
+
+	report := &InventoryReport{
+		Environment: "edge",
+		Cluster:     "edge-1",
+		Labels:      map[string]string{"site": "store-12"},
+		Objects:     []map[string]any{mcpServer, agent, annotated, plain},
+		Records: []InventoryRecord{{
+			Kind:      "Model",
+			Namespace: "ml",
+			Name:      "llama",
+			Provider:  "openai",
+			Model:     "llama-3-8b",
+			URL:       "http://llama.ml:8000/v1",
+		}},
+	}
+	result, err := ingester.Ingest(ctx, report)
+	require.NoError(t, err)
+	assert.Equal(t, &InventoryResult{Added: 4}, result, "the unannotated Service is ignored")
+
+	var server agentregistryv1alpha1.MCPServerCatalog
+	require.NoError(t, local.Get(ctx, client.ObjectKey{
+		Namespace: testNamespace,
+		Name:      generateDiscoveredCatalogName("MCPServer", "edge", "edge-1", "tools", "fs"),
+	}, &server))
+	assert.Equal(t, "tools/fs", server.Spec.Name)
+	assert.Equal(t, InventoryReportConfig, server.Labels[discoveryConfigLabel])
+	assert.Equal(t, "store-12", server.Labels["site"])
+
+	var model agentregistryv1alpha1.ModelCatalog
+	require.NoError(t, local.Get(ctx, client.ObjectKey{
+		Namespace: testNamespace,
+		Name:      generateDiscoveredCatalogName("InventoryRecordModel", "edge", "edge-1", "ml", "llama"),
+	}, &model))
+	assert.Equal(t, "ml/llama", model.Spec.Name)
+	assert.Equal(t, "llama-3-8b", model.Spec.Model)
+	assert.Equal(t, "http://llama.ml:8000/v1", model.Spec.BaseURL)
+
+	scope := DiscoveryScope{Config: InventoryReportConfig, Environment: "edge", Cluster: "edge-1"}
+	_, err = discovered.GetMCPServer(scope, "tools", "fs")
+	assert.NoError(t, err, "reported objects resolve through the discovered cache")
+
+	t.Run("other clusters are untouched", func(t *testing.T) {
+		result, err := ingester.Ingest(ctx, &InventoryReport{
+			Environment: "edge",
+			Cluster:     "edge-2",
+			Objects:     []map[string]any{mcpServer},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Added)
+		assert.Zero(t, result.Removed)
+	})
+
+	t.Run("missing entries are pruned", func(t *testing.T) {
+		report.Objects = []map[string]any{mcpServer}
+		report.Records = nil
+		result, err := ingester.Ingest(ctx, report)
+		require.NoError(t, err)
+		assert.Equal(t, &InventoryResult{Updated: 1, Removed: 3}, result)
+
+		var servers agentregistryv1alpha1.MCPServerCatalogList
+		require.NoError(t, local.List(ctx, &servers, client.MatchingLabels{discoveryConfigLabel: InventoryReportConfig}))
+		assert.Len(t, servers.Items, 2, "one entry per reporting cluster")
+		var agents agentregistryv1alpha1.AgentCatalogList
+		require.NoError(t, local.List(ctx, &agents))
+		assert.Empty(t, agents.Items)
+		_, err = discovered.GetAgent(scope, "agents", "helper")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid reports are rejected", func(t *testing.T) {
+		reports := map[string]*InventoryReport{
+			"missing environment": {Cluster: "edge-1"},
+			"unsupported kind": {
+				Environment: "edge",
+				Cluster:     "edge-1",
+				Objects: []map[string]any{{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata":   map[string]any{"name": "cm", "namespace": "tools"},
+				}},
+			},
+			"record without name": {
+				Environment: "edge",
+				Cluster:     "edge-1",
+				Records:     []InventoryRecord{{Kind: "Agent", Namespace: "agents"}},
+			},
+		}
+		for name, report := range reports {
+			_, err := ingester.Ingest(ctx, report)
+			assert.True(t, apierrors.IsBadRequest(err), "%s: %v", name, err)
+		}
+
+		var servers agentregistryv1alpha1.MCPServerCatalogList
+		require.NoError(t, local.List(ctx, &servers))
+		assert.Len(t, servers.Items, 2, "rejected reports don't prune")
+	})
+
+	t.Run("environments discovered by a DiscoveryConfig conflict", func(t *testing.T) {
+		_, err := ingester.Ingest(ctx, &InventoryReport{Environment: "dev", Cluster: "dev-cluster"})
+		assert.True(t, apierrors.IsConflict(err), "got %v", err)
+	})
+
+	t.Run("satellite environments report inventory", func(t *testing.T) {
+		require.NoError(t, local.Create(ctx, &agentregistryv1alpha1.DiscoveryConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "satellites", Namespace: testNamespace},
+			Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+				Environments: []agentregistryv1alpha1.Environment{{
+					Name:          "edge",
+					Provider:      cluster.ProviderSatellite,
+					Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "edge-1"},
+					DeployEnabled: true,
+				}},
+			},
+		}))
+		_, err := ingester.Ingest(ctx, report)
+		assert.NoError(t, err)
+	})
+}
+
+func TestInventoryIngester_ApplyFailure(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kagentv1alpha2.AddToScheme(scheme))
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+
+	var failing bool
+	local := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(
+			&agentregistryv1alpha1.MCPServerCatalog{},
+			&agentregistryv1alpha1.ModelCatalog{},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if failing {
+					return assert.AnError
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if failing {
+					return assert.AnError
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+	ingester := NewInventoryIngester(local, scheme, NewDiscoveredResourceCache(), zerolog.Nop())
+	ctx := context.Background()
+
+	mcpServer := inventoryObjectContent(t,
+		&kmcpv1alpha1.MCPServer{ObjectMeta: metav1.ObjectMeta{Name: "fs", Namespace: "tools"}},
+		kmcpv1alpha1.GroupVersion.WithKind("MCPServer"))
+	report := &InventoryReport{
+		Environment: "edge",
+		Cluster:     "edge-1",
+		Objects:     []map[string]any{mcpServer},
+	}
+	result, err := ingester.Ingest(ctx, report)
+	require.NoError(t, err)
+	require.Equal(t, &InventoryResult{Added: 1}, result)
+
+	failing = true
+	report.Objects = []map[string]any{mcpServer}
+	report.Records = []InventoryRecord{{
+		Kind:      "Model",
+		Namespace: "ml",
+		Name:      "llama",
+		Provider:  "openai",
+		Model:     "llama-3-8b",
+	}}
+	result, err = ingester.Ingest(ctx, report)
+	require.NoError(t, err)
+	assert.Zero(t, result.Added, "failed applies are not counted")
+	assert.Zero(t, result.Updated, "failed applies are not counted")
+	assert.Zero(t, result.Removed, "entries that failed to apply are not pruned")
+	assert.Len(t, result.Errors, 2)
+
+	var server agentregistryv1alpha1.MCPServerCatalog
+	assert.NoError(t, local.Get(ctx, client.ObjectKey{
+		Namespace: testNamespace,
+		Name:      generateDiscoveredCatalogName("MCPServer", "edge", "edge-1", "tools", "fs"),
+	}, &server))
+}
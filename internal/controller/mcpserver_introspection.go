@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rs/zerolog"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+const (
+	// defaultIntrospectionTimeout bounds a single probe, from connect to the last list call
+	defaultIntrospectionTimeout = 15 * time.Second
+	// introspectionConcurrency is the number of servers probed at the same time
+	introspectionConcurrency = 8
+)
+
+// MCPServerIntrospector periodically connects to the MCP endpoint of every discovered or
+// deployed server and records its server info, capabilities, tools, prompts and resources in
+// status.introspection
+type MCPServerIntrospector struct {
+	client.Client
+	Logger zerolog.Logger
+
+	// Interval between two probes of the catalog
+	Interval time.Duration
+	// Timeout bounds a single probe; zero uses defaultIntrospectionTimeout
+	Timeout time.Duration
+}
+
+// +kubebuilder:rbac:groups=agentregistry.dev,resources=mcpservercatalogs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=agentregistry.dev,resources=mcpservercatalogs/status,verbs=get;update;patch
+
+// SetupWithManager runs the prober while the manager holds the leader lease
+func (p *MCPServerIntrospector) SetupWithManager(mgr ctrl.Manager) error {
+	return mgr.Add(manager.RunnableFunc(p.run))
+}
+
+// run probes the catalog immediately and then every Interval until ctx is cancelled
+func (p *MCPServerIntrospector) run(ctx context.Context) error {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.probeAll(ctx); err != nil {
+			p.Logger.Error().Err(err).Msg("failed to introspect MCP servers")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeAll probes every discovered or deployed catalog entry that has an MCP endpoint
+func (p *MCPServerIntrospector) probeAll(ctx context.Context) error {
+	var servers agentregistryv1alpha1.MCPServerCatalogList
+	if err := p.List(ctx, &servers); err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, introspectionConcurrency)
+	var wg sync.WaitGroup
+	for i := range servers.Items {
+		server := &servers.Items[i]
+		if !introspectable(server) {
+			continue
+		}
+		if url, _ := introspectionEndpoint(server); url == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := p.probe(ctx, server); err != nil {
+				p.Logger.Error().Err(err).Str("server", server.Name).Msg("failed to record MCP introspection")
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// probe introspects one server and patches its status. A failed probe keeps the tools,
+// prompts and resources of the last successful one, so a restarting server does not lose
+// them, and records the error.
+func (p *MCPServerIntrospector) probe(ctx context.Context, server *agentregistryv1alpha1.MCPServerCatalog) error {
+	url, transport := introspectionEndpoint(server)
+	now := metav1.Now()
+
+	result, err := p.introspect(ctx, url, transport)
+	if err != nil {
+		p.Logger.Debug().Err(err).Str("server", server.Name).Str("url", url).Msg("MCP introspection failed")
+		result = server.Status.Introspection.DeepCopy()
+		if result == nil || result.URL != url {
+			result = &agentregistryv1alpha1.MCPServerIntrospection{URL: url, Transport: transport}
+		}
+		result.Error = err.Error()
+	} else {
+		result.LastSuccess = &now
+	}
+	result.LastProbed = &now
+
+	// A merge patch leaves status fields written by other controllers (usedBy) alone
+	patch := client.MergeFrom(server.DeepCopy())
+	server.Status.Introspection = result
+	return p.Status().Patch(ctx, server, patch)
+}
+
+// introspect connects to an MCP endpoint, initializes a session and lists what the server
+// exposes. Lists are only requested for capabilities the server advertised.
+func (p *MCPServerIntrospector) introspect(ctx context.Context, url, transport string) (*agentregistryv1alpha1.MCPServerIntrospection, error) {
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = defaultIntrospectionTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var t mcp.Transport = &mcp.StreamableClientTransport{Endpoint: url}
+	if transport == "sse" {
+		t = &mcp.SSEClientTransport{Endpoint: url}
+	}
+
+	mcpClient := mcp.NewClient(&mcp.Implementation{
+		Name:    "agentregistry",
+		Version: "1.0.0",
+	}, nil)
+
+	session, err := mcpClient.Connect(ctx, t, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MCP server at %s: %w", url, err)
+	}
+	defer session.Close()
+
+	init := session.InitializeResult()
+	result := &agentregistryv1alpha1.MCPServerIntrospection{
+		URL:             url,
+		Transport:       transport,
+		ProtocolVersion: init.ProtocolVersion,
+	}
+	if init.ServerInfo != nil {
+		result.ServerInfo = &agentregistryv1alpha1.MCPImplementation{
+			Name:    init.ServerInfo.Name,
+			Title:   init.ServerInfo.Title,
+			Version: init.ServerInfo.Version,
+		}
+	}
+
+	caps := init.Capabilities
+	if caps == nil {
+		caps = &mcp.ServerCapabilities{}
+	}
+	result.Capabilities = serverCapabilityNames(caps)
+
+	if caps.Tools != nil {
+		for tool, err := range session.Tools(ctx, nil) {
+			if err != nil {
+				return nil, fmt.Errorf("tools/list failed: %w", err)
+			}
+			converted, err := convertMCPTool(tool)
+			if err != nil {
+				return nil, err
+			}
+			result.Tools = append(result.Tools, converted)
+		}
+	}
+	if caps.Prompts != nil {
+		for prompt, err := range session.Prompts(ctx, nil) {
+			if err != nil {
+				return nil, fmt.Errorf("prompts/list failed: %w", err)
+			}
+			converted := agentregistryv1alpha1.MCPPrompt{
+				Name:        prompt.Name,
+				Title:       prompt.Title,
+				Description: prompt.Description,
+			}
+			for _, arg := range prompt.Arguments {
+				converted.Arguments = append(converted.Arguments, agentregistryv1alpha1.MCPPromptArgument{
+					Name:        arg.Name,
+					Description: arg.Description,
+					Required:    arg.Required,
+				})
+			}
+			result.Prompts = append(result.Prompts, converted)
+		}
+	}
+	if caps.Resources != nil {
+		for resource, err := range session.Resources(ctx, nil) {
+			if err != nil {
+				return nil, fmt.Errorf("resources/list failed: %w", err)
+			}
+			result.Resources = append(result.Resources, agentregistryv1alpha1.MCPResource{
+				URI:         resource.URI,
+				Name:        resource.Name,
+				Description: resource.Description,
+				MIMEType:    resource.MIMEType,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// introspectable reports whether the controller may connect to a server: it was discovered in
+// a cluster or is deployed by a RegistryDeployment. The URLs of published entries come from
+// whoever published them, so probing them would let anyone make the controller send requests
+// to addresses only it can reach.
+func introspectable(server *agentregistryv1alpha1.MCPServerCatalog) bool {
+	return server.Labels[discoveryLabel] == "true" ||
+		server.Status.ManagementType == agentregistryv1alpha1.ManagementTypeManaged
+}
+
+// introspectionEndpoint returns the URL and transport to probe a server at: its first
+// HTTP remote, else the URL of its deployment. It returns "" for stdio-only servers.
+func introspectionEndpoint(server *agentregistryv1alpha1.MCPServerCatalog) (url, transport string) {
+	for _, remote := range server.Spec.Remotes {
+		if remote.URL != "" && (remote.Type == "streamable-http" || remote.Type == "sse") {
+			return remote.URL, remote.Type
+		}
+	}
+	if d := server.Status.Deployment; d != nil && d.URL != "" {
+		return d.URL, "streamable-http"
+	}
+	return "", ""
+}
+
+// serverCapabilityNames lists the capabilities a server advertised during initialization
+func serverCapabilityNames(caps *mcp.ServerCapabilities) []string {
+	var names []string
+	if caps.Completions != nil {
+		names = append(names, "completions")
+	}
+	if caps.Logging != nil {
+		names = append(names, "logging")
+	}
+	if caps.Prompts != nil {
+		names = append(names, "prompts")
+	}
+	if caps.Resources != nil {
+		names = append(names, "resources")
+	}
+	if caps.Tools != nil {
+		names = append(names, "tools")
+	}
+	return names
+}
+
+// convertMCPTool converts a listed tool, keeping its schemas as raw JSON
+func convertMCPTool(tool *mcp.Tool) (agentregistryv1alpha1.MCPTool, error) {
+	converted := agentregistryv1alpha1.MCPTool{
+		Name:        tool.Name,
+		Title:       tool.Title,
+		Description: tool.Description,
+	}
+	if tool.InputSchema != nil {
+		raw, err := json.Marshal(tool.InputSchema)
+		if err != nil {
+			return converted, fmt.Errorf("tool %s: invalid input schema: %w", tool.Name, err)
+		}
+		converted.InputSchema = &apiextensionsv1.JSON{Raw: raw}
+	}
+	if tool.OutputSchema != nil {
+		raw, err := json.Marshal(tool.OutputSchema)
+		if err != nil {
+			return converted, fmt.Errorf("tool %s: invalid output schema: %w", tool.Name, err)
+		}
+		converted.OutputSchema = &apiextensionsv1.JSON{Raw: raw}
+	}
+	return converted, nil
+}
+
+// introspectedToolNames returns the tools a server exposed at its last successful probe.
+// ok is false if it has never been probed successfully.
+func introspectedToolNames(server *agentregistryv1alpha1.MCPServerCatalog) (names map[string]struct{}, ok bool) {
+	intro := server.Status.Introspection
+	if intro == nil || intro.LastSuccess == nil {
+		return nil, false
+	}
+	names = make(map[string]struct{}, len(intro.Tools))
+	for _, tool := range intro.Tools {
+		names[tool.Name] = struct{}{}
+	}
+	return names, true
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+type searchInput struct {
+	Query string `json:"query" jsonschema:"the search query"`
+}
+
+// newTestMCPServer serves an MCP server with one tool, one prompt and one resource
+func newTestMCPServer(t *testing.T, sse bool) *httptest.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "search-server", Version: "1.2.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "search", Description: "Search documents"},
+		func(context.Context, *mcp.CallToolRequest, searchInput) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{}, nil, nil
+		})
+	server.AddPrompt(&mcp.Prompt{
+		Name:      "summarize",
+		Arguments: []*mcp.PromptArgument{{Name: "topic", Required: true}},
+	}, func(context.Context, *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return &mcp.GetPromptResult{}, nil
+	})
+	server.AddResource(&mcp.Resource{URI: "file:///readme.md", Name: "readme", MIMEType: "text/markdown"},
+		func(context.Context, *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+			return &mcp.ReadResourceResult{}, nil
+		})
+
+	getServer := func(*http.Request) *mcp.Server { return server }
+	var handler http.Handler = mcp.NewStreamableHTTPHandler(getServer, nil)
+	if sse {
+		handler = mcp.NewSSEHandler(getServer, nil)
+	}
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestIntrospectionEndpoint(t *testing.T) {
+	tests := []struct {
+		name          string
+		server        agentregistryv1alpha1.MCPServerCatalog
+		wantURL       string
+		wantTransport string
+	}{
+		{
+			name: "remote",
+			server: agentregistryv1alpha1.MCPServerCatalog{Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Remotes: []agentregistryv1alpha1.Transport{{Type: "sse", URL: "http://search/sse"}},
+			}},
+			wantURL:       "http://search/sse",
+			wantTransport: "sse",
+		},
+		{
+			name: "deployment",
+			server: agentregistryv1alpha1.MCPServerCatalog{
+				Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+					Packages: []agentregistryv1alpha1.Package{{Transport: agentregistryv1alpha1.Transport{Type: "streamable-http"}}},
+				},
+				Status: agentregistryv1alpha1.MCPServerCatalogStatus{
+					Deployment: &agentregistryv1alpha1.DeploymentRef{URL: "http://search.tools.svc.cluster.local:3000/mcp"},
+				},
+			},
+			wantURL:       "http://search.tools.svc.cluster.local:3000/mcp",
+			wantTransport: "streamable-http",
+		},
+		{
+			name: "stdio only",
+			server: agentregistryv1alpha1.MCPServerCatalog{Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Packages: []agentregistryv1alpha1.Package{{Transport: agentregistryv1alpha1.Transport{Type: "stdio"}}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, transport := introspectionEndpoint(&tt.server)
+			assert.Equal(t, tt.wantURL, url)
+			assert.Equal(t, tt.wantTransport, transport)
+		})
+	}
+}
+
+func TestMCPServerIntrospector_ProbeAll(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	streamable := newTestMCPServer(t, false)
+	sse := newTestMCPServer(t, true)
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	catalog := func(name string, remotes ...agentregistryv1alpha1.Transport) *agentregistryv1alpha1.MCPServerCatalog {
+		return &agentregistryv1alpha1.MCPServerCatalog{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:    "tools/" + name,
+				Version: "1.0.0",
+				Remotes: remotes,
+			},
+			Status: agentregistryv1alpha1.MCPServerCatalogStatus{ManagementType: agentregistryv1alpha1.ManagementTypeManaged},
+		}
+	}
+	discovered := catalog("sse", agentregistryv1alpha1.Transport{Type: "sse", URL: sse.URL})
+	discovered.Labels = map[string]string{discoveryLabel: "true"}
+	discovered.Status.ManagementType = agentregistryv1alpha1.ManagementTypeExternal
+	// Published entries point wherever their publisher wants
+	published := catalog("published", agentregistryv1alpha1.Transport{Type: "streamable-http", URL: streamable.URL})
+	published.Status.ManagementType = ""
+	// The unreachable server was introspected before and keeps its tools
+	lastSuccess := metav1.NewTime(time.Now().Add(-time.Hour))
+	down := catalog("down", agentregistryv1alpha1.Transport{Type: "streamable-http", URL: unreachable.URL})
+	down.Status.Introspection = &agentregistryv1alpha1.MCPServerIntrospection{
+		URL:         unreachable.URL,
+		Transport:   "streamable-http",
+		Tools:       []agentregistryv1alpha1.MCPTool{{Name: "old-tool"}},
+		LastSuccess: &lastSuccess,
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&agentregistryv1alpha1.MCPServerCatalog{}).
+		WithObjects(
+			catalog("streamable", agentregistryv1alpha1.Transport{Type: "streamable-http", URL: streamable.URL}),
+			discovered,
+			published,
+			catalog("stdio"),
+			down,
+		).
+		Build()
+	p := &MCPServerIntrospector{Client: c, Logger: zerolog.Nop(), Timeout: 5 * time.Second}
+	ctx := context.Background()
+	require.NoError(t, p.probeAll(ctx))
+
+	get := func(name string) *agentregistryv1alpha1.MCPServerCatalog {
+		var server agentregistryv1alpha1.MCPServerCatalog
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: name, Namespace: testNamespace}, &server))
+		return &server
+	}
+
+	for _, name := range []string{"streamable", "sse"} {
+		t.Run(name, func(t *testing.T) {
+			intro := get(name).Status.Introspection
+			require.NotNil(t, intro)
+			assert.Empty(t, intro.Error)
+			assert.NotNil(t, intro.LastSuccess)
+			require.NotNil(t, intro.ServerInfo)
+			assert.Equal(t, "search-server", intro.ServerInfo.Name)
+			assert.Equal(t, "1.2.0", intro.ServerInfo.Version)
+			assert.NotEmpty(t, intro.ProtocolVersion)
+			assert.Subset(t, intro.Capabilities, []string{"prompts", "resources", "tools"})
+
+			require.Len(t, intro.Tools, 1)
+			assert.Equal(t, "search", intro.Tools[0].Name)
+			require.NotNil(t, intro.Tools[0].InputSchema)
+			var schema map[string]interface{}
+			require.NoError(t, json.Unmarshal(intro.Tools[0].InputSchema.Raw, &schema))
+			assert.Contains(t, schema["properties"], "query")
+
+			assert.Equal(t, []agentregistryv1alpha1.MCPPrompt{{
+				Name:      "summarize",
+				Arguments: []agentregistryv1alpha1.MCPPromptArgument{{Name: "topic", Required: true}},
+			}}, intro.Prompts)
+			assert.Equal(t, []agentregistryv1alpha1.MCPResource{{
+				URI: "file:///readme.md", Name: "readme", MIMEType: "text/markdown",
+			}}, intro.Resources)
+		})
+	}
+
+	t.Run("stdio servers are not probed", func(t *testing.T) {
+		assert.Nil(t, get("stdio").Status.Introspection)
+	})
+
+	t.Run("published servers are not probed", func(t *testing.T) {
+		assert.Nil(t, get("published").Status.Introspection)
+	})
+
+	t.Run("failed probe keeps the last result", func(t *testing.T) {
+		intro := get("down").Status.Introspection
+		require.NotNil(t, intro)
+		assert.Contains(t, intro.Error, "failed to connect")
+		assert.NotNil(t, intro.LastProbed)
+		assert.Equal(t, lastSuccess.Unix(), intro.LastSuccess.Unix())
+		assert.Equal(t, []agentregistryv1alpha1.MCPTool{{Name: "old-tool"}}, intro.Tools)
+	})
+}
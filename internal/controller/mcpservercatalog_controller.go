@@ -2,14 +2,15 @@ package controller
 
 import (
 	"context"
-	"time"
 
 	"github.com/rs/zerolog"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
 )
@@ -19,12 +20,25 @@ type MCPServerCatalogReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Logger zerolog.Logger
+
+	// DiscoveredCache holds resources seen by DiscoveryConfig informers for SourceRef lookups
+	DiscoveredCache *DiscoveredResourceCache
+
+	// Shards is set when discovery is sharded across replicas. The reconciler then runs on every
+	// replica and only reconciles the entries it owns; see ownsCatalogEntry.
+	Shards *DiscoveryShards
+
+	// Recorder records lifecycle transitions of discovered entries
+	Recorder record.EventRecorder
+	// Lifecycle is the grace period policy for discovered entries whose source is gone
+	Lifecycle CatalogLifecycle
 }
 
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=mcpservercatalogs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=mcpservercatalogs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=agentregistry.dev,resources=mcpservercatalogs/finalizers,verbs=update
 // +kubebuilder:rbac:groups=kagent.dev,resources=mcpservers,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile handles MCPServerCatalog reconciliation
 func (r *MCPServerCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -37,6 +51,11 @@ func (r *MCPServerCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// Entries of other replicas are checked again in case they move to this one
+	if !ownsCatalogEntry(r.Shards, &server) {
+		return ctrl.Result{RequeueAfter: discoveredSourceCheckInterval}, nil
+	}
+
 	logger.Trace().
 		Str("specName", server.Spec.Name).
 		Str("version", server.Spec.Version).
@@ -47,28 +66,28 @@ func (r *MCPServerCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	// Sync from sourceRef only for external resources (discovered)
 	// Managed resources get their status from RegistryDeployment
 	if server.Spec.SourceRef != nil && server.Status.ManagementType == agentregistryv1alpha1.ManagementTypeExternal {
-		if err := r.syncFromSource(ctx, &server, &statusChanged); err != nil {
-			if apierrors.IsNotFound(err) {
-				// Source was deleted — mark as deprecated so users know the source is gone
-				if server.Status.Status != agentregistryv1alpha1.CatalogStatusDeprecated {
-					server.Status.Status = agentregistryv1alpha1.CatalogStatusDeprecated
-					statusChanged = true
-					logger.Info().
-						Str("sourceKind", server.Spec.SourceRef.Kind).
-						Str("sourceName", server.Spec.SourceRef.Name).
-						Str("sourceNamespace", server.Spec.SourceRef.Namespace).
-						Msg("source resource not found, marking catalog entry as deprecated")
-				}
-			} else {
-				logger.Warn().Err(err).Msg("failed to sync from source")
-			}
+		if err := r.syncFromSource(ctx, &server, &statusChanged); client.IgnoreNotFound(err) != nil {
 			// Don't fail reconciliation
-		} else if server.Status.Status == agentregistryv1alpha1.CatalogStatusDeprecated {
-			// Source reappeared — restore to active
-			server.Status.Status = agentregistryv1alpha1.CatalogStatusActive
-			statusChanged = true
-			logger.Info().Msg("source resource reappeared, restoring catalog entry to active")
+			logger.Warn().Err(err).Msg("failed to sync from source")
 		}
+
+		lifecycle, err := r.Lifecycle.advance(ctx, r.Client, r.DiscoveredCache, r.Recorder, lifecycleEntry{
+			obj:        &server,
+			status:     &server.Status.Status,
+			conditions: &server.Status.Conditions,
+			deployedAs: agentregistryv1alpha1.ResourceTypeMCP,
+			specName:   server.Spec.Name,
+			version:    server.Spec.Version,
+			usedBy:     mcpServerUsers(server.Status.UsedBy),
+		}, logger)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to advance catalog entry lifecycle")
+			return ctrl.Result{}, err
+		}
+		if lifecycle.removed {
+			return ctrl.Result{}, nil
+		}
+		statusChanged = statusChanged || lifecycle.statusChanged
 	}
 
 	// Update isLatest status for all versions of this server
@@ -93,7 +112,7 @@ func (r *MCPServerCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 	// Requeue to periodically sync sourceRef status (only for external resources)
 	if server.Spec.SourceRef != nil && server.Status.ManagementType == agentregistryv1alpha1.ManagementTypeExternal {
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: discoveredSourceCheckInterval}, nil
 	}
 
 	return ctrl.Result{}, nil
@@ -108,8 +127,9 @@ func (r *MCPServerCatalogReconciler) syncFromSource(ctx context.Context, server
 		return nil
 	}
 
-	// Get the MCPServer resource from discovery cache (populated by DiscoveryConfig informers)
-	mcpServer, err := GetMCPServer(ctx, ref.Namespace, ref.Name)
+	// Get the MCPServer resource from discovery cache (populated by DiscoveryConfig informers),
+	// scoped to the environment/cluster the catalog entry was discovered in
+	mcpServer, err := r.DiscoveredCache.GetMCPServer(scopeFromLabels(server.Labels), ref.Namespace, ref.Name)
 	if err != nil {
 		// Update status to reflect source not found
 		now := metav1.Now()
@@ -141,6 +161,7 @@ func (r *MCPServerCatalogReconciler) syncFromSource(ctx context.Context, server
 	newDeployment := &agentregistryv1alpha1.DeploymentRef{
 		Namespace:   ref.Namespace,
 		ServiceName: ref.Name,
+		URL:         kmcpServerURL(mcpServer),
 		Ready:       ready,
 		Message:     message,
 		LastChecked: &now,
@@ -154,6 +175,15 @@ func (r *MCPServerCatalogReconciler) syncFromSource(ctx context.Context, server
 	return nil
 }
 
+// mcpServerUsers names the agents in a UsedBy list
+func mcpServerUsers(refs []agentregistryv1alpha1.MCPServerUsageRef) []string {
+	users := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		users = append(users, ref.Namespace+"/"+ref.Name)
+	}
+	return users
+}
+
 // deploymentRefEqual compares two DeploymentRef pointers (ignoring LastChecked)
 func deploymentRefEqual(a, b *agentregistryv1alpha1.DeploymentRef) bool {
 	if a == nil && b == nil {
@@ -176,7 +206,9 @@ func (r *MCPServerCatalogReconciler) updateLatestVersion(ctx context.Context, se
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *MCPServerCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	needLeaderElection := r.Shards == nil
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&agentregistryv1alpha1.MCPServerCatalog{}).
+		WithOptions(crcontroller.Options{NeedLeaderElection: &needLeaderElection}).
 		Complete(r)
 }
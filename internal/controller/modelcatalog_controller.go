@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+// ModelCatalogReconciler reconciles a ModelCatalog object
+type ModelCatalogReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger zerolog.Logger
+
+	// DiscoveredCache holds resources seen by DiscoveryConfig informers, to tell when the source
+	// of a discovered entry is gone
+	DiscoveredCache *DiscoveredResourceCache
+	// Shards is set when discovery is sharded across replicas. The reconciler then runs on every
+	// replica and only reconciles the entries it owns; see ownsCatalogEntry.
+	Shards *DiscoveryShards
+	// Recorder records lifecycle transitions of discovered entries
+	Recorder record.EventRecorder
+	// Lifecycle is the grace period policy for discovered entries whose source is gone
+	Lifecycle CatalogLifecycle
+}
+
+// +kubebuilder:rbac:groups=agentregistry.dev,resources=modelcatalogs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=agentregistry.dev,resources=modelcatalogs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile handles ModelCatalog reconciliation
+func (r *ModelCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.With().Str("name", req.Name).Str("namespace", req.Namespace).Logger()
+
+	// Fetch the ModelCatalog
+	var model agentregistryv1alpha1.ModelCatalog
+	if err := r.Get(ctx, req.NamespacedName, &model); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Entries of other replicas are checked again in case they move to this one
+	if !ownsCatalogEntry(r.Shards, &model) {
+		return ctrl.Result{RequeueAfter: discoveredSourceCheckInterval}, nil
+	}
+
+	logger.Trace().
+		Str("specName", model.Spec.Name).
+		Msg("reconciling ModelCatalog")
+
+	// Move discovered entries whose source is gone through the lifecycle
+	external := model.Status.ManagementType == agentregistryv1alpha1.ManagementTypeExternal
+	statusChanged := false
+	if external {
+		users := make([]string, 0, len(model.Status.UsedBy))
+		for _, ref := range model.Status.UsedBy {
+			users = append(users, ref.Namespace+"/"+ref.Name)
+		}
+		lifecycle, err := r.Lifecycle.advance(ctx, r.Client, r.DiscoveredCache, r.Recorder, lifecycleEntry{
+			obj:        &model,
+			status:     &model.Status.Status,
+			conditions: &model.Status.Conditions,
+			usedBy:     users,
+		}, logger)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to advance catalog entry lifecycle")
+			return ctrl.Result{}, err
+		}
+		if lifecycle.removed {
+			return ctrl.Result{}, nil
+		}
+		statusChanged = lifecycle.statusChanged
+	}
+
+	// Update observed generation
+	if statusChanged || model.Status.ObservedGeneration != model.Generation {
+		model.Status.ObservedGeneration = model.Generation
+		if err := r.Status().Update(ctx, &model); err != nil {
+			if apierrors.IsConflict(err) {
+				logger.Debug().Msg("conflict updating status, will retry")
+				return ctrl.Result{Requeue: true}, nil
+			}
+			logger.Error().Err(err).Msg("failed to update status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Requeue discovered entries to notice when their source goes away
+	if external && model.Labels[discoveryLabel] == "true" {
+		return ctrl.Result{RequeueAfter: discoveredSourceCheckInterval}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ModelCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	needLeaderElection := r.Shards == nil
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&agentregistryv1alpha1.ModelCatalog{}).
+		WithOptions(crcontroller.Options{NeedLeaderElection: &needLeaderElection}).
+		Complete(r)
+}
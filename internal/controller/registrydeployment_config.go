@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+	"github.com/agentregistry-dev/agentregistry/internal/runtime/translation/api"
+)
+
+// deploymentConfig is the config of a deployment with its configFrom entries read
+type deploymentConfig struct {
+	// values holds every config value by name
+	values map[string]string
+	// secret holds the names of the values read from Secrets, which are never inlined into
+	// the deployed resources
+	secret map[string]bool
+}
+
+// resolveConfig reads the configFrom entries of a deployment from the Secrets and ConfigMaps in
+// its namespace and merges them over its config. Optional entries whose source is missing are
+// left out.
+func (r *RegistryDeploymentReconciler) resolveConfig(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (*deploymentConfig, error) {
+	config := &deploymentConfig{
+		values: maps.Clone(deployment.Spec.Config),
+		secret: make(map[string]bool),
+	}
+	if config.values == nil {
+		config.values = make(map[string]string)
+	}
+	for _, entry := range deployment.Spec.ConfigFrom {
+		if r.secretsByReference && entry.ValueFrom.SecretKeyRef != nil && entry.ValueFrom.ConfigMapKeyRef == nil {
+			// An empty placeholder marks where the satellite puts the value; see fillSecretConfig
+			config.values[entry.Name] = ""
+			config.secret[entry.Name] = true
+			continue
+		}
+		value, found, err := r.configValue(ctx, deployment.Namespace, entry.ValueFrom)
+		if err != nil {
+			return nil, fmt.Errorf("config %q: %w", entry.Name, err)
+		}
+		if !found {
+			continue
+		}
+		config.values[entry.Name] = value
+		config.secret[entry.Name] = entry.ValueFrom.SecretKeyRef != nil
+	}
+	return config, nil
+}
+
+// secretConfigRefs returns the configFrom entries of a deployment read from Secrets and not
+// overridden by a later entry
+func secretConfigRefs(deployment *agentregistryv1alpha1.RegistryDeployment) []agentregistryv1alpha1.ConfigValueFrom {
+	last := make(map[string]int, len(deployment.Spec.ConfigFrom))
+	for i, entry := range deployment.Spec.ConfigFrom {
+		last[entry.Name] = i
+	}
+	var refs []agentregistryv1alpha1.ConfigValueFrom
+	for i, entry := range deployment.Spec.ConfigFrom {
+		if entry.ValueFrom.SecretKeyRef != nil && last[entry.Name] == i {
+			refs = append(refs, entry)
+		}
+	}
+	return refs
+}
+
+// fillSecretConfig reads the Secret references of a deployment resolved with
+// secretsByReference and puts their values in the placeholders of desiredState. Values of
+// optional references whose source is missing are left out.
+func (r *RegistryDeploymentReconciler) fillSecretConfig(ctx context.Context, namespace string, refs []agentregistryv1alpha1.ConfigValueFrom, desiredState *api.DesiredState) error {
+	for _, entry := range refs {
+		value, found, err := r.configValue(ctx, namespace, entry.ValueFrom)
+		if err != nil {
+			return fmt.Errorf("config %q: %w", entry.Name, err)
+		}
+		fillSecretEnv := func(env map[string]string) {
+			if _, ok := env[entry.Name]; !ok {
+				return
+			}
+			if found {
+				env[entry.Name] = value
+			} else {
+				delete(env, entry.Name)
+			}
+		}
+		for _, agent := range desiredState.Agents {
+			fillSecretEnv(agent.Deployment.SecretEnv)
+		}
+		for _, server := range desiredState.MCPServers {
+			if server.Local != nil {
+				fillSecretEnv(server.Local.Deployment.SecretEnv)
+			}
+			if server.Remote == nil {
+				continue
+			}
+			headers := server.Remote.Headers[:0]
+			for _, h := range server.Remote.Headers {
+				if h.Secret && h.Name == entry.Name {
+					if !found {
+						continue
+					}
+					h.Value = value
+				}
+				headers = append(headers, h)
+			}
+			server.Remote.Headers = headers
+		}
+	}
+	return nil
+}
+
+// ValidateConfigFrom rejects configFrom entries that read the registry's own Secrets, so such
+// requests fail before a deployment is created. Secrets are also checked when they are read;
+// see configValue.
+func ValidateConfigFrom(entries []agentregistryv1alpha1.ConfigValueFrom) error {
+	for _, entry := range entries {
+		if ref := entry.ValueFrom.SecretKeyRef; ref != nil && ref.Name == config.APITokensSecretName {
+			return fmt.Errorf("config %q: secret %q belongs to the registry and cannot be read as deployment config", entry.Name, ref.Name)
+		}
+	}
+	return nil
+}
+
+// configValue reads the key a config value source selects. found is false when an optional
+// source is missing. Secrets are only read if they opt in with LabelDeploymentConfig and do not
+// belong to the registry, so a deployment cannot copy credentials such as the API tokens into
+// resources its creator can read. Errors never include the value.
+func (r *RegistryDeploymentReconciler) configValue(ctx context.Context, namespace string, source agentregistryv1alpha1.ConfigValueSource) (value string, found bool, err error) {
+	switch {
+	case source.SecretKeyRef != nil && source.ConfigMapKeyRef != nil:
+		return "", false, fmt.Errorf("only one of secretKeyRef and configMapKeyRef may be set")
+	case source.SecretKeyRef != nil:
+		ref := source.SecretKeyRef
+		optional := ref.Optional != nil && *ref.Optional
+		if ref.Name == config.APITokensSecretName {
+			return "", false, fmt.Errorf("secret %q belongs to the registry and cannot be read as deployment config", ref.Name)
+		}
+		var secret corev1.Secret
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &secret); err != nil {
+			if apierrors.IsNotFound(err) && optional {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("failed to get secret %q: %w", ref.Name, err)
+		}
+		if isManagedByRegistry(secret.Labels) {
+			return "", false, fmt.Errorf("secret %q belongs to the registry and cannot be read as deployment config", ref.Name)
+		}
+		if secret.Labels[agentregistryv1alpha1.LabelDeploymentConfig] != "true" {
+			return "", false, fmt.Errorf("secret %q cannot be read as deployment config; label it %s=true to allow it",
+				ref.Name, agentregistryv1alpha1.LabelDeploymentConfig)
+		}
+		data, ok := secret.Data[ref.Key]
+		if !ok {
+			if optional {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("key %q not found in secret %q", ref.Key, ref.Name)
+		}
+		return string(data), true, nil
+	case source.ConfigMapKeyRef != nil:
+		ref := source.ConfigMapKeyRef
+		optional := ref.Optional != nil && *ref.Optional
+		var cm corev1.ConfigMap
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &cm); err != nil {
+			if apierrors.IsNotFound(err) && optional {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("failed to get configmap %q: %w", ref.Name, err)
+		}
+		if data, ok := cm.Data[ref.Key]; ok {
+			return data, true, nil
+		}
+		if data, ok := cm.BinaryData[ref.Key]; ok {
+			return string(data), true, nil
+		}
+		if optional {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("key %q not found in configmap %q", ref.Key, ref.Name)
+	default:
+		return "", false, fmt.Errorf("one of secretKeyRef and configMapKeyRef must be set")
+	}
+}
+
+// deploymentsReadingConfigFrom returns requests for the deployments in the namespace of obj
+// that read config from it, so rotated values are rolled out. isSecret tells whether obj is a
+// Secret, which is only watched by metadata, or a ConfigMap.
+func (r *RegistryDeploymentReconciler) deploymentsReadingConfigFrom(ctx context.Context, obj client.Object, isSecret bool) []reconcile.Request {
+	var deployments agentregistryv1alpha1.RegistryDeploymentList
+	if err := r.List(ctx, &deployments, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Logger.Error().Err(err).Msg("failed to list deployments for config source")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, deployment := range deployments.Items {
+		for _, entry := range deployment.Spec.ConfigFrom {
+			source := entry.ValueFrom
+			if (isSecret && source.SecretKeyRef != nil && source.SecretKeyRef.Name == obj.GetName()) ||
+				(!isSecret && source.ConfigMapKeyRef != nil && source.ConfigMapKeyRef.Name == obj.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/runtime/translation/api"
+)
+
+func secretRef(name, key string) agentregistryv1alpha1.ConfigValueSource {
+	return agentregistryv1alpha1.ConfigValueSource{SecretKeyRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: name},
+		Key:                  key,
+	}}
+}
+
+func newConfigTestReconciler(t *testing.T) *RegistryDeploymentReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "agentregistry", Labels: map[string]string{agentregistryv1alpha1.LabelDeploymentConfig: "true"}},
+				Data:       map[string][]byte{"token": []byte("ghp_secret")},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "unlabeled", Namespace: "agentregistry"},
+				Data:       map[string][]byte{"token": []byte("unlabeled_secret")},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "agentregistry-api-tokens", Namespace: "agentregistry", Labels: map[string]string{agentregistryv1alpha1.LabelDeploymentConfig: "true"}},
+				Data:       map[string][]byte{"admin": []byte("api_token")},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "generated", Namespace: "agentregistry", Labels: map[string]string{
+					agentregistryv1alpha1.LabelDeploymentConfig: "true",
+					agentregistryv1alpha1.LabelManagedBy:        "agentregistry",
+				}},
+				Data: map[string][]byte{"token": []byte("generated_secret")},
+			},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "agentregistry"},
+				Data:       map[string]string{"level": "debug"},
+			},
+		).
+		Build()
+	return &RegistryDeploymentReconciler{Client: c, Scheme: scheme, Logger: zerolog.Nop()}
+}
+
+func TestRegistryDeploymentReconciler_ConfigFrom_LocalMCP(t *testing.T) {
+	r := newConfigTestReconciler(t)
+	optional := true
+	catalog := &agentregistryv1alpha1.MCPServerCatalog{
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+			Name:    "github",
+			Version: "1.0.0",
+			Packages: []agentregistryv1alpha1.Package{{
+				RegistryType: "npm",
+				Identifier:   "@modelcontextprotocol/server-github",
+				Transport:    agentregistryv1alpha1.Transport{Type: "stdio"},
+				EnvironmentVariables: []agentregistryv1alpha1.KeyValueInput{
+					{Name: "GITHUB_TOKEN"}, {Name: "LOG_LEVEL"}, {Name: "REGION"}, {Name: "OPTIONAL_KEY"},
+				},
+			}},
+		},
+	}
+	deployment := &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "agentregistry"},
+		Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+			Namespace: "tools",
+			Config:    map[string]string{"GITHUB_TOKEN": "plaintext", "REGION": "eu"},
+			ConfigFrom: []agentregistryv1alpha1.ConfigValueFrom{
+				{Name: "GITHUB_TOKEN", ValueFrom: secretRef("github", "token")},
+				{Name: "LOG_LEVEL", ValueFrom: agentregistryv1alpha1.ConfigValueSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "settings"},
+					Key:                  "level",
+				}}},
+				{Name: "OPTIONAL_KEY", ValueFrom: agentregistryv1alpha1.ConfigValueSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "missing"},
+					Key:                  "key",
+					Optional:             &optional,
+				}}},
+			},
+		},
+	}
+
+	server, err := r.convertCatalogToMCPServer(context.Background(), catalog, deployment)
+	require.NoError(t, err)
+	require.NotNil(t, server.Local)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "debug", "REGION": "eu"}, server.Local.Deployment.Env)
+	assert.Equal(t, map[string]string{"GITHUB_TOKEN": "ghp_secret"}, server.Local.Deployment.SecretEnv)
+
+	t.Run("missing secret", func(t *testing.T) {
+		missing := deployment.DeepCopy()
+		missing.Spec.ConfigFrom = []agentregistryv1alpha1.ConfigValueFrom{{Name: "GITHUB_TOKEN", ValueFrom: secretRef("missing", "token")}}
+		_, err := r.convertCatalogToMCPServer(context.Background(), catalog, missing)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `config "GITHUB_TOKEN"`)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		missing := deployment.DeepCopy()
+		missing.Spec.ConfigFrom = []agentregistryv1alpha1.ConfigValueFrom{{Name: "GITHUB_TOKEN", ValueFrom: secretRef("github", "other")}}
+		_, err := r.convertCatalogToMCPServer(context.Background(), catalog, missing)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `key "other" not found in secret "github"`)
+	})
+
+	t.Run("secret argument", func(t *testing.T) {
+		withArg := catalog.DeepCopy()
+		withArg.Spec.Packages[0].PackageArguments = []agentregistryv1alpha1.Argument{{Name: "GITHUB_TOKEN"}}
+		_, err := r.convertCatalogToMCPServer(context.Background(), withArg, deployment)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "ghp_secret")
+	})
+}
+
+func TestRegistryDeploymentReconciler_ConfigFrom_RestrictedSecrets(t *testing.T) {
+	r := newConfigTestReconciler(t)
+	ctx := context.Background()
+	optional := true
+
+	for name, test := range map[string]struct {
+		ref  agentregistryv1alpha1.ConfigValueSource
+		want string
+	}{
+		"api tokens":       {ref: secretRef("agentregistry-api-tokens", "admin"), want: "belongs to the registry"},
+		"registry managed": {ref: secretRef("generated", "token"), want: "belongs to the registry"},
+		"without opt-in":   {ref: secretRef("unlabeled", "token"), want: "label it agentregistry.dev/deployment-config=true"},
+		"optional without opt-in": {
+			ref: agentregistryv1alpha1.ConfigValueSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "unlabeled"},
+				Key:                  "token",
+				Optional:             &optional,
+			}},
+			want: "label it",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			value, found, err := r.configValue(ctx, "agentregistry", test.ref)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.want)
+			assert.False(t, found)
+			assert.Empty(t, value)
+			assert.NotContains(t, err.Error(), "_secret")
+			assert.NotContains(t, err.Error(), "api_token")
+		})
+	}
+
+	err := ValidateConfigFrom([]agentregistryv1alpha1.ConfigValueFrom{
+		{Name: "GITHUB_TOKEN", ValueFrom: secretRef("github", "token")},
+		{Name: "TOKEN", ValueFrom: secretRef("agentregistry-api-tokens", "admin")},
+	})
+	assert.ErrorContains(t, err, `config "TOKEN": secret "agentregistry-api-tokens" belongs to the registry`)
+	assert.NoError(t, ValidateConfigFrom([]agentregistryv1alpha1.ConfigValueFrom{{Name: "GITHUB_TOKEN", ValueFrom: secretRef("github", "token")}}))
+}
+
+func TestRegistryDeploymentReconciler_ConfigFrom_RemoteMCP(t *testing.T) {
+	r := newConfigTestReconciler(t)
+	catalog := &agentregistryv1alpha1.MCPServerCatalog{
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+			Name:    "github",
+			Version: "1.0.0",
+			Remotes: []agentregistryv1alpha1.Transport{{
+				Type:    "streamable-http",
+				URL:     "https://api.githubcopilot.com/mcp/",
+				Headers: []agentregistryv1alpha1.KeyValueInput{{Name: "Authorization"}, {Name: "X-Toolsets", Value: "repos"}},
+			}},
+		},
+	}
+	deployment := &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "agentregistry"},
+		Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+			ConfigFrom: []agentregistryv1alpha1.ConfigValueFrom{{Name: "Authorization", ValueFrom: secretRef("github", "token")}},
+		},
+	}
+
+	server, err := r.convertCatalogToMCPServer(context.Background(), catalog, deployment)
+	require.NoError(t, err)
+	require.NotNil(t, server.Remote)
+	assert.Equal(t, []api.HeaderValue{
+		{Name: "Authorization", Value: "ghp_secret", Secret: true},
+		{Name: "X-Toolsets", Value: "repos"},
+	}, server.Remote.Headers)
+}
+
+func TestRegistryDeploymentReconciler_ConfigFrom_Agent(t *testing.T) {
+	r := newConfigTestReconciler(t)
+	catalog := &agentregistryv1alpha1.AgentCatalog{
+		Spec: agentregistryv1alpha1.AgentCatalogSpec{Name: "triage", Version: "1.0.0", Image: "agent:1.0.0"},
+	}
+	deployment := &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "triage", Namespace: "agentregistry"},
+		Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+			Config: map[string]string{"TEAM": "platform"},
+			ConfigFrom: []agentregistryv1alpha1.ConfigValueFrom{
+				{Name: "GITHUB_TOKEN", ValueFrom: secretRef("github", "token")},
+				{Name: "AGENT_NAME", ValueFrom: secretRef("github", "token")},
+			},
+		},
+	}
+
+	agent, err := r.convertCatalogToAgent(context.Background(), catalog, deployment)
+	require.NoError(t, err)
+	assert.Equal(t, "platform", agent.Deployment.Env["TEAM"])
+	assert.Equal(t, "triage", agent.Deployment.Env["AGENT_NAME"])
+	assert.NotContains(t, agent.Deployment.Env, "GITHUB_TOKEN")
+	assert.Equal(t, map[string]string{"GITHUB_TOKEN": "ghp_secret"}, agent.Deployment.SecretEnv)
+}
+
+func TestRegistryDeploymentReconciler_DeploymentsReadingConfigFrom(t *testing.T) {
+	r := newConfigTestReconciler(t)
+	ctx := context.Background()
+	for _, d := range []*agentregistryv1alpha1.RegistryDeployment{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "reads-secret", Namespace: "agentregistry"},
+			Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+				ConfigFrom: []agentregistryv1alpha1.ConfigValueFrom{{Name: "TOKEN", ValueFrom: secretRef("github", "token")}},
+			},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "agentregistry"}},
+	} {
+		require.NoError(t, r.Create(ctx, d))
+	}
+
+	requests := r.deploymentsReadingConfigFrom(ctx, &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "agentregistry"}}, true)
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "reads-secret", Namespace: "agentregistry"}}}, requests)
+
+	// A ConfigMap of the same name is a different source
+	assert.Empty(t, r.deploymentsReadingConfigFrom(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: "agentregistry"}}, false))
+}
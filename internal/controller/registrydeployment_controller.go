@@ -3,11 +3,12 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"maps"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
 	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
@@ -20,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -27,6 +29,7 @@ import (
 	sigyaml "sigs.k8s.io/yaml"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
 	"github.com/agentregistry-dev/agentregistry/internal/runtime/translation/api"
 	"github.com/agentregistry-dev/agentregistry/internal/runtime/translation/kagent"
 )
@@ -36,7 +39,11 @@ type RegistryDeploymentReconciler struct {
 	client.Client
 	Scheme              *runtime.Scheme
 	Logger              zerolog.Logger
-	RemoteClientFactory func(env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error)
+	RemoteClientFactory func(scope DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error)
+
+	// secretsByReference leaves config read from Secrets unresolved, for deployments applied by
+	// a satellite that reads the Secrets in its own cluster
+	secretsByReference bool
 }
 
 const (
@@ -54,6 +61,7 @@ const (
 // +kubebuilder:rbac:groups=kagent.dev,resources=remotemcpservers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=kmcp.io,resources=mcpservers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile handles RegistryDeployment reconciliation
 func (r *RegistryDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -96,21 +104,54 @@ func (r *RegistryDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.R
 		}
 	}
 
-	// Reconcile based on resource type
-	var err error
-	switch deployment.Spec.ResourceType {
-	case agentregistryv1alpha1.ResourceTypeMCP:
-		err = r.reconcileMCPDeployment(ctx, &deployment)
-	case agentregistryv1alpha1.ResourceTypeAgent:
-		err = r.reconcileAgentDeployment(ctx, &deployment)
+	// Satellites apply deployments to their environment themselves and report the status
+	if deployment.Spec.Environment != "" {
+		if env, err := r.findEnvironment(ctx, &deployment); err == nil && isSatelliteEnvironment(env) {
+			return r.awaitSatellite(ctx, &deployment)
+		}
+	}
+
+	// Deployments that cannot run, such as ones missing a required input, are not applied
+	preflight, err := r.preflight(ctx, &deployment)
+	switch {
+	case err != nil:
+	case !preflight.Passed:
+		deployment.Status.Conditions = setCatalogCondition(deployment.Status.Conditions,
+			agentregistryv1alpha1.CatalogConditionPreflight, metav1.ConditionFalse, "PreflightFailed", preflight.Message())
 	default:
-		err = fmt.Errorf("unknown resource type: %s", deployment.Spec.ResourceType)
+		deployment.Status.Conditions = setCatalogCondition(deployment.Status.Conditions,
+			agentregistryv1alpha1.CatalogConditionPreflight, metav1.ConditionTrue, "PreflightPassed", "All preflight checks passed")
+
+		// Reconcile based on resource type
+		switch deployment.Spec.ResourceType {
+		case agentregistryv1alpha1.ResourceTypeMCP:
+			err = r.reconcileMCPDeployment(ctx, &deployment)
+		case agentregistryv1alpha1.ResourceTypeAgent:
+			err = r.reconcileAgentDeployment(ctx, &deployment)
+		default:
+			err = fmt.Errorf("unknown resource type: %s", deployment.Spec.ResourceType)
+		}
 	}
 
-	if err != nil {
+	// An unreachable target cluster is retried when its circuit breaker allows it, without
+	// piling up error backoff in the meantime
+	var result ctrl.Result
+	var circuitOpen *cluster.CircuitOpenError
+	if errors.As(err, &circuitOpen) {
+		logger.Warn().Err(err).Msg("target cluster unavailable, skipping deployment")
+		deployment.Status.Phase = agentregistryv1alpha1.DeploymentPhaseFailed
+		deployment.Status.Message = circuitOpen.Error()
+		result.RequeueAfter = max(time.Until(circuitOpen.RetryAt), time.Second)
+		err = nil
+	} else if err != nil {
 		logger.Error().Err(err).Msg("failed to reconcile deployment")
 		deployment.Status.Phase = agentregistryv1alpha1.DeploymentPhaseFailed
 		deployment.Status.Message = err.Error()
+	} else if !preflight.Passed {
+		logger.Info().Str("reason", preflight.Message()).Msg("deployment failed preflight checks")
+		deployment.Status.Phase = agentregistryv1alpha1.DeploymentPhaseFailed
+		deployment.Status.Message = "preflight checks failed: " + preflight.Message()
+		result.RequeueAfter = preflightRetryInterval
 	} else {
 		// Check if managed resources are actually ready
 		ready, message := r.checkManagedResourcesReady(ctx, &deployment)
@@ -136,44 +177,47 @@ func (r *RegistryDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, err
+	return result, err
 }
 
 // reconcileMCPDeployment reconciles an MCP server deployment
 func (r *RegistryDeploymentReconciler) reconcileMCPDeployment(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) error {
-	// Look up the MCPServerCatalog
-	var serverList agentregistryv1alpha1.MCPServerCatalogList
-	if err := r.List(ctx, &serverList, client.MatchingFields{
-		IndexMCPServerName: deployment.Spec.ResourceName,
-	}); err != nil {
-		return fmt.Errorf("failed to list MCP servers: %w", err)
+	catalogEntry, err := r.lookupMCPServer(ctx, deployment)
+	if err != nil {
+		return err
 	}
 
-	// Find the specific version
-	var catalogEntry *agentregistryv1alpha1.MCPServerCatalog
-	for i := range serverList.Items {
-		s := &serverList.Items[i]
-		if s.Spec.Version == deployment.Spec.Version {
-			catalogEntry = s
-			break
-		}
+	// Resolve the target client and environment
+	env, targetClient, clusterName, err := r.getTargetClientAndEnv(ctx, deployment)
+	if err != nil {
+		return fmt.Errorf("failed to resolve target: %w", err)
+	}
+	mcpURL := ""
+	if env != nil {
+		mcpURL = env.MCPToolServerURL
 	}
 
-	if catalogEntry == nil {
-		return fmt.Errorf("MCP server %s version %s not found", deployment.Spec.ResourceName, deployment.Spec.Version)
+	// Convert catalog to runtime format
+	mcpServer, err := r.convertCatalogToMCPServer(ctx, catalogEntry, deployment)
+	if err != nil {
+		return fmt.Errorf("failed to convert catalog to MCP server: %w", err)
 	}
 
-	// Validate publisher identity before deploying
-	if err := validatePublisherIdentity(catalogEntry.Spec.Metadata); err != nil {
-		return fmt.Errorf("deployment blocked for %s %s: %w", deployment.Spec.ResourceName, deployment.Spec.Version, err)
+	managedResources, err := r.applyDesiredState(ctx, deployment, &api.DesiredState{
+		MCPServers: []*api.MCPServer{mcpServer},
+	}, mcpURL, targetClient, clusterName)
+	if err != nil {
+		return err
 	}
+	deployment.Status.ManagedResources = managedResources
+	return nil
+}
 
-	// Mark as managed if not already set
-	if catalogEntry.Status.ManagementType != agentregistryv1alpha1.ManagementTypeManaged {
-		catalogEntry.Status.ManagementType = agentregistryv1alpha1.ManagementTypeManaged
-		if err := r.Status().Update(ctx, catalogEntry); err != nil {
-			return fmt.Errorf("failed to update catalog management type: %w", err)
-		}
+// reconcileAgentDeployment reconciles an Agent deployment
+func (r *RegistryDeploymentReconciler) reconcileAgentDeployment(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) error {
+	catalogEntry, err := r.lookupAgent(ctx, deployment)
+	if err != nil {
+		return err
 	}
 
 	// Resolve the target client and environment
@@ -187,183 +231,278 @@ func (r *RegistryDeploymentReconciler) reconcileMCPDeployment(ctx context.Contex
 	}
 
 	// Convert catalog to runtime format
-	mcpServer, err := r.convertCatalogToMCPServer(catalogEntry, deployment)
+	agent, err := r.convertCatalogToAgent(ctx, catalogEntry, deployment)
 	if err != nil {
-		return fmt.Errorf("failed to convert catalog to MCP server: %w", err)
+		return fmt.Errorf("failed to convert catalog to agent: %w", err)
 	}
 
-	// Use KAgent translator to create Kubernetes resources
-	translator := kagent.NewTranslator()
-	desiredState := &api.DesiredState{
-		MCPServers: []*api.MCPServer{mcpServer},
+	managedResources, err := r.applyDesiredState(ctx, deployment, &api.DesiredState{
+		Agents: []*api.Agent{agent},
+	}, mcpURL, targetClient, clusterName)
+	if err != nil {
+		return err
 	}
+	deployment.Status.ManagedResources = managedResources
+	return nil
+}
 
-	runtimeConfig, err := translator.TranslateRuntimeConfig(ctx, desiredState)
-	if err != nil {
-		return fmt.Errorf("failed to translate runtime config: %w", err)
+// desiredState resolves the catalog entry of a deployment into the runtime format, without
+// applying it
+func (r *RegistryDeploymentReconciler) desiredState(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (*api.DesiredState, error) {
+	switch deployment.Spec.ResourceType {
+	case agentregistryv1alpha1.ResourceTypeMCP:
+		catalogEntry, err := r.lookupMCPServer(ctx, deployment)
+		if err != nil {
+			return nil, err
+		}
+		mcpServer, err := r.convertCatalogToMCPServer(ctx, catalogEntry, deployment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert catalog to MCP server: %w", err)
+		}
+		return &api.DesiredState{MCPServers: []*api.MCPServer{mcpServer}}, nil
+	case agentregistryv1alpha1.ResourceTypeAgent:
+		catalogEntry, err := r.lookupAgent(ctx, deployment)
+		if err != nil {
+			return nil, err
+		}
+		agent, err := r.convertCatalogToAgent(ctx, catalogEntry, deployment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert catalog to agent: %w", err)
+		}
+		return &api.DesiredState{Agents: []*api.Agent{agent}}, nil
+	default:
+		return nil, fmt.Errorf("unknown resource type: %s", deployment.Spec.ResourceType)
 	}
+}
 
-	// Apply Kubernetes resources
-	managedResources := []agentregistryv1alpha1.ManagedResource{}
+// lookupMCPServer finds the MCPServerCatalog version of a deployment, checks its publisher and
+// marks it managed
+func (r *RegistryDeploymentReconciler) lookupMCPServer(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (*agentregistryv1alpha1.MCPServerCatalog, error) {
+	catalogEntry, err := r.findMCPServer(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
 
-	// Apply MCPServers (local)
-	for _, mcpServer := range runtimeConfig.Kubernetes.MCPServers {
-		r.setOwnerLabels(mcpServer, deployment)
-		if err := r.applyObj(ctx, mcpURL, targetClient, mcpServer); err != nil {
-			return fmt.Errorf("failed to apply MCPServer: %w", err)
-		}
-		managedResources = append(managedResources, agentregistryv1alpha1.ManagedResource{
-			APIVersion: mcpServer.APIVersion,
-			Kind:       mcpServer.Kind,
-			Name:       mcpServer.Name,
-			Namespace:  mcpServer.Namespace,
-			Cluster:    clusterName,
-		})
+	// Validate publisher identity before deploying
+	if err := validatePublisherIdentity(catalogEntry.Spec.Metadata); err != nil {
+		return nil, fmt.Errorf("deployment blocked for %s %s: %w", deployment.Spec.ResourceName, deployment.Spec.Version, err)
 	}
 
-	// Apply RemoteMCPServers
-	for _, remoteMCP := range runtimeConfig.Kubernetes.RemoteMCPServers {
-		r.setOwnerLabels(remoteMCP, deployment)
-		if err := r.applyObj(ctx, mcpURL, targetClient, remoteMCP); err != nil {
-			return fmt.Errorf("failed to apply RemoteMCPServer: %w", err)
+	// Mark as managed if not already set
+	if catalogEntry.Status.ManagementType != agentregistryv1alpha1.ManagementTypeManaged {
+		catalogEntry.Status.ManagementType = agentregistryv1alpha1.ManagementTypeManaged
+		if err := r.Status().Update(ctx, catalogEntry); err != nil {
+			return nil, fmt.Errorf("failed to update catalog management type: %w", err)
 		}
-		managedResources = append(managedResources, agentregistryv1alpha1.ManagedResource{
-			APIVersion: remoteMCP.APIVersion,
-			Kind:       remoteMCP.Kind,
-			Name:       remoteMCP.Name,
-			Namespace:  remoteMCP.Namespace,
-			Cluster:    clusterName,
-		})
 	}
-
-	deployment.Status.ManagedResources = managedResources
-	return nil
+	return catalogEntry, nil
 }
 
-// reconcileAgentDeployment reconciles an Agent deployment
-func (r *RegistryDeploymentReconciler) reconcileAgentDeployment(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) error {
-	// Look up the AgentCatalog
-	var agentList agentregistryv1alpha1.AgentCatalogList
-	if err := r.List(ctx, &agentList, client.MatchingFields{
-		IndexAgentName: deployment.Spec.ResourceName,
+// findMCPServer returns the MCPServerCatalog version of a deployment
+func (r *RegistryDeploymentReconciler) findMCPServer(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (*agentregistryv1alpha1.MCPServerCatalog, error) {
+	// Look up the MCPServerCatalog
+	var serverList agentregistryv1alpha1.MCPServerCatalogList
+	if err := r.List(ctx, &serverList, client.MatchingFields{
+		IndexMCPServerName: deployment.Spec.ResourceName,
 	}); err != nil {
-		return fmt.Errorf("failed to list agents: %w", err)
+		return nil, fmt.Errorf("failed to list MCP servers: %w", err)
 	}
 
 	// Find the specific version
-	var catalogEntry *agentregistryv1alpha1.AgentCatalog
-	for i := range agentList.Items {
-		a := &agentList.Items[i]
-		if a.Spec.Version == deployment.Spec.Version {
-			catalogEntry = a
+	var catalogEntry *agentregistryv1alpha1.MCPServerCatalog
+	for i := range serverList.Items {
+		s := &serverList.Items[i]
+		if s.Spec.Version == deployment.Spec.Version {
+			catalogEntry = s
 			break
 		}
 	}
 
 	if catalogEntry == nil {
-		return fmt.Errorf("agent %s version %s not found", deployment.Spec.ResourceName, deployment.Spec.Version)
+		return nil, fmt.Errorf("MCP server %s version %s not found", deployment.Spec.ResourceName, deployment.Spec.Version)
+	}
+	return catalogEntry, nil
+}
+
+// lookupAgent finds the AgentCatalog version of a deployment, checks its publisher and marks
+// it managed
+func (r *RegistryDeploymentReconciler) lookupAgent(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (*agentregistryv1alpha1.AgentCatalog, error) {
+	catalogEntry, err := r.findAgent(ctx, deployment)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate publisher identity before deploying
 	if err := validatePublisherIdentity(catalogEntry.Spec.Metadata); err != nil {
-		return fmt.Errorf("deployment blocked for %s %s: %w", deployment.Spec.ResourceName, deployment.Spec.Version, err)
+		return nil, fmt.Errorf("deployment blocked for %s %s: %w", deployment.Spec.ResourceName, deployment.Spec.Version, err)
 	}
 
 	// Mark as managed if not already set
 	if catalogEntry.Status.ManagementType != agentregistryv1alpha1.ManagementTypeManaged {
 		catalogEntry.Status.ManagementType = agentregistryv1alpha1.ManagementTypeManaged
 		if err := r.Status().Update(ctx, catalogEntry); err != nil {
-			return fmt.Errorf("failed to update catalog management type: %w", err)
+			return nil, fmt.Errorf("failed to update catalog management type: %w", err)
 		}
 	}
+	return catalogEntry, nil
+}
 
-	// Resolve the target client and environment
-	env, targetClient, clusterName, err := r.getTargetClientAndEnv(ctx, deployment)
-	if err != nil {
-		return fmt.Errorf("failed to resolve target: %w", err)
+// findAgent returns the AgentCatalog version of a deployment
+func (r *RegistryDeploymentReconciler) findAgent(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (*agentregistryv1alpha1.AgentCatalog, error) {
+	// Look up the AgentCatalog
+	var agentList agentregistryv1alpha1.AgentCatalogList
+	if err := r.List(ctx, &agentList, client.MatchingFields{
+		IndexAgentName: deployment.Spec.ResourceName,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
 	}
-	mcpURL := ""
-	if env != nil {
-		mcpURL = env.MCPToolServerURL
+
+	// Find the specific version
+	var catalogEntry *agentregistryv1alpha1.AgentCatalog
+	for i := range agentList.Items {
+		a := &agentList.Items[i]
+		if a.Spec.Version == deployment.Spec.Version {
+			catalogEntry = a
+			break
+		}
 	}
 
-	// Convert catalog to runtime format
-	agent, err := r.convertCatalogToAgent(catalogEntry, deployment)
-	if err != nil {
-		return fmt.Errorf("failed to convert catalog to agent: %w", err)
+	if catalogEntry == nil {
+		return nil, fmt.Errorf("agent %s version %s not found", deployment.Spec.ResourceName, deployment.Spec.Version)
 	}
+	return catalogEntry, nil
+}
 
+// applyDesiredState translates desiredState with the kagent translator, applies the resulting
+// resources to the target and returns them
+func (r *RegistryDeploymentReconciler) applyDesiredState(
+	ctx context.Context,
+	deployment *agentregistryv1alpha1.RegistryDeployment,
+	desiredState *api.DesiredState,
+	mcpURL string,
+	targetClient client.Client,
+	clusterName string,
+) ([]agentregistryv1alpha1.ManagedResource, error) {
 	// Use KAgent translator to create Kubernetes resources
 	translator := kagent.NewTranslator()
-	desiredState := &api.DesiredState{
-		Agents: []*api.Agent{agent},
-	}
-
 	runtimeConfig, err := translator.TranslateRuntimeConfig(ctx, desiredState)
 	if err != nil {
-		return fmt.Errorf("failed to translate runtime config: %w", err)
+		return nil, fmt.Errorf("failed to translate runtime config: %w", err)
 	}
 
-	// Apply Kubernetes resources
+	// Apply Kubernetes resources. Each resource is recorded before it is applied, as applying
+	// may clear its type meta.
 	managedResources := []agentregistryv1alpha1.ManagedResource{}
 
 	// Apply ConfigMaps
 	for _, cm := range runtimeConfig.Kubernetes.ConfigMaps {
 		r.setOwnerLabels(cm, deployment)
-		if err := r.applyObj(ctx, mcpURL, targetClient, cm); err != nil {
-			return fmt.Errorf("failed to apply ConfigMap: %w", err)
-		}
-		managedResources = append(managedResources, agentregistryv1alpha1.ManagedResource{
+		res := agentregistryv1alpha1.ManagedResource{
 			APIVersion: "v1",
 			Kind:       "ConfigMap",
 			Name:       cm.Name,
 			Namespace:  cm.Namespace,
 			Cluster:    clusterName,
-		})
+		}
+		if err := r.applyObj(ctx, mcpURL, targetClient, cm); err != nil {
+			return nil, fmt.Errorf("failed to apply ConfigMap: %w", err)
+		}
+		managedResources = append(managedResources, res)
+	}
+
+	// Apply Secrets holding config read from Secrets, before the resources referencing them
+	for _, secret := range runtimeConfig.Kubernetes.Secrets {
+		r.setOwnerLabels(secret, deployment)
+		res := agentregistryv1alpha1.ManagedResource{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Name:       secret.Name,
+			Namespace:  secret.Namespace,
+			Cluster:    clusterName,
+		}
+		if err := r.applyObj(ctx, mcpURL, targetClient, secret); err != nil {
+			return nil, fmt.Errorf("failed to apply Secret: %w", err)
+		}
+		managedResources = append(managedResources, res)
+	}
+
+	// Apply MCPServers (local)
+	for _, mcpServer := range runtimeConfig.Kubernetes.MCPServers {
+		r.setOwnerLabels(mcpServer, deployment)
+		res := agentregistryv1alpha1.ManagedResource{
+			APIVersion: mcpServer.APIVersion,
+			Kind:       mcpServer.Kind,
+			Name:       mcpServer.Name,
+			Namespace:  mcpServer.Namespace,
+			Cluster:    clusterName,
+		}
+		if err := r.applyObj(ctx, mcpURL, targetClient, mcpServer); err != nil {
+			return nil, fmt.Errorf("failed to apply MCPServer: %w", err)
+		}
+		managedResources = append(managedResources, res)
+	}
+
+	// Apply RemoteMCPServers
+	for _, remoteMCP := range runtimeConfig.Kubernetes.RemoteMCPServers {
+		r.setOwnerLabels(remoteMCP, deployment)
+		res := agentregistryv1alpha1.ManagedResource{
+			APIVersion: remoteMCP.APIVersion,
+			Kind:       remoteMCP.Kind,
+			Name:       remoteMCP.Name,
+			Namespace:  remoteMCP.Namespace,
+			Cluster:    clusterName,
+		}
+		if err := r.applyObj(ctx, mcpURL, targetClient, remoteMCP); err != nil {
+			return nil, fmt.Errorf("failed to apply RemoteMCPServer: %w", err)
+		}
+		managedResources = append(managedResources, res)
 	}
 
 	// Apply Agents
 	for _, agent := range runtimeConfig.Kubernetes.Agents {
 		r.setOwnerLabels(agent, deployment)
-		if err := r.applyObj(ctx, mcpURL, targetClient, agent); err != nil {
-			return fmt.Errorf("failed to apply Agent: %w", err)
-		}
-		managedResources = append(managedResources, agentregistryv1alpha1.ManagedResource{
+		res := agentregistryv1alpha1.ManagedResource{
 			APIVersion: agent.APIVersion,
 			Kind:       agent.Kind,
 			Name:       agent.Name,
 			Namespace:  agent.Namespace,
 			Cluster:    clusterName,
-		})
+		}
+		if err := r.applyObj(ctx, mcpURL, targetClient, agent); err != nil {
+			return nil, fmt.Errorf("failed to apply Agent: %w", err)
+		}
+		managedResources = append(managedResources, res)
 	}
 
-	deployment.Status.ManagedResources = managedResources
-	return nil
+	return managedResources, nil
 }
 
 // convertCatalogToMCPServer converts an MCPServerCatalog to the runtime API format
-func (r *RegistryDeploymentReconciler) convertCatalogToMCPServer(catalog *agentregistryv1alpha1.MCPServerCatalog, deployment *agentregistryv1alpha1.RegistryDeployment) (*api.MCPServer, error) {
-	// Determine if we should use remote or local
-	useRemote := len(catalog.Spec.Remotes) > 0 && (deployment.Spec.PreferRemote || len(catalog.Spec.Packages) == 0)
+func (r *RegistryDeploymentReconciler) convertCatalogToMCPServer(ctx context.Context, catalog *agentregistryv1alpha1.MCPServerCatalog, deployment *agentregistryv1alpha1.RegistryDeployment) (*api.MCPServer, error) {
+	config, err := r.resolveConfig(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
 
 	targetNamespace := deployment.Spec.Namespace
 	if targetNamespace == "" {
 		targetNamespace = defaultNamespace
 	}
 
-	if useRemote {
+	if useRemote(catalog, deployment) {
 		// Use remote transport
 		remote := catalog.Spec.Remotes[0]
 		headers := make([]api.HeaderValue, 0, len(remote.Headers))
 		for _, h := range remote.Headers {
 			value := h.Value
 			// Substitute from config
-			if v, ok := deployment.Spec.Config[h.Name]; ok {
+			if v, ok := config.values[h.Name]; ok {
 				value = v
 			}
 			headers = append(headers, api.HeaderValue{
-				Name:  h.Name,
-				Value: value,
+				Name:   h.Name,
+				Value:  value,
+				Secret: config.secret[h.Name],
 			})
 		}
 
@@ -390,18 +529,27 @@ func (r *RegistryDeploymentReconciler) convertCatalogToMCPServer(catalog *agentr
 
 	// Build environment variables from package spec and deployment config
 	env := make(map[string]string)
+	secretEnv := make(map[string]string)
 	for _, envVar := range pkg.EnvironmentVariables {
-		if v, ok := deployment.Spec.Config[envVar.Name]; ok {
-			env[envVar.Name] = v
+		if v, ok := config.values[envVar.Name]; ok {
+			if config.secret[envVar.Name] {
+				secretEnv[envVar.Name] = v
+			} else {
+				env[envVar.Name] = v
+			}
 		} else if envVar.Value != "" {
 			env[envVar.Name] = envVar.Value
 		}
 	}
 
-	// Build arguments
+	// Build arguments. Arguments are part of the MCPServer spec, so values read from Secrets
+	// cannot be passed as arguments.
 	var args []string
 	for _, arg := range pkg.RuntimeArguments {
-		if v, ok := deployment.Spec.Config[arg.Name]; ok {
+		if v, ok := config.values[arg.Name]; ok {
+			if config.secret[arg.Name] {
+				return nil, fmt.Errorf("config %q is read from a secret and cannot be passed as an argument", arg.Name)
+			}
 			args = append(args, v)
 		} else if arg.Value != "" {
 			args = append(args, arg.Value)
@@ -410,7 +558,10 @@ func (r *RegistryDeploymentReconciler) convertCatalogToMCPServer(catalog *agentr
 	// Add package identifier based on registry type
 	args = append(args, pkg.Identifier)
 	for _, arg := range pkg.PackageArguments {
-		if v, ok := deployment.Spec.Config[arg.Name]; ok {
+		if v, ok := config.values[arg.Name]; ok {
+			if config.secret[arg.Name] {
+				return nil, fmt.Errorf("config %q is read from a secret and cannot be passed as an argument", arg.Name)
+			}
 			args = append(args, v)
 		} else if arg.Value != "" {
 			args = append(args, arg.Value)
@@ -454,10 +605,11 @@ func (r *RegistryDeploymentReconciler) convertCatalogToMCPServer(catalog *agentr
 		Namespace:     targetNamespace,
 		Local: &api.LocalMCPServer{
 			Deployment: api.MCPServerDeployment{
-				Image: image,
-				Cmd:   cmd,
-				Args:  args,
-				Env:   env,
+				Image:     image,
+				Cmd:       cmd,
+				Args:      args,
+				Env:       env,
+				SecretEnv: secretEnv,
 			},
 			TransportType: transportType,
 			HTTP:          httpTransport,
@@ -465,8 +617,19 @@ func (r *RegistryDeploymentReconciler) convertCatalogToMCPServer(catalog *agentr
 	}, nil
 }
 
+// useRemote reports whether a deployment of catalog routes to its first remote rather than
+// running its first package
+func useRemote(catalog *agentregistryv1alpha1.MCPServerCatalog, deployment *agentregistryv1alpha1.RegistryDeployment) bool {
+	return len(catalog.Spec.Remotes) > 0 && (deployment.Spec.PreferRemote || len(catalog.Spec.Packages) == 0)
+}
+
 // convertCatalogToAgent converts an AgentCatalog to the runtime API format
-func (r *RegistryDeploymentReconciler) convertCatalogToAgent(catalog *agentregistryv1alpha1.AgentCatalog, deployment *agentregistryv1alpha1.RegistryDeployment) (*api.Agent, error) {
+func (r *RegistryDeploymentReconciler) convertCatalogToAgent(ctx context.Context, catalog *agentregistryv1alpha1.AgentCatalog, deployment *agentregistryv1alpha1.RegistryDeployment) (*api.Agent, error) {
+	config, err := r.resolveConfig(ctx, deployment)
+	if err != nil {
+		return nil, err
+	}
+
 	targetNamespace := deployment.Spec.Namespace
 	if targetNamespace == "" {
 		targetNamespace = defaultNamespace
@@ -474,8 +637,13 @@ func (r *RegistryDeploymentReconciler) convertCatalogToAgent(catalog *agentregis
 
 	// Build environment variables
 	env := make(map[string]string)
-	if deployment.Spec.Config != nil {
-		env = maps.Clone(deployment.Spec.Config)
+	secretEnv := make(map[string]string)
+	for name, value := range config.values {
+		if config.secret[name] {
+			secretEnv[name] = value
+		} else {
+			env[name] = value
+		}
 	}
 
 	// Set standard agent environment variables
@@ -489,13 +657,18 @@ func (r *RegistryDeploymentReconciler) convertCatalogToAgent(catalog *agentregis
 	if catalog.Spec.ModelName != "" {
 		env["MODEL_NAME"] = catalog.Spec.ModelName
 	}
+	// The standard variables take precedence over config read from Secrets too
+	for name := range env {
+		delete(secretEnv, name)
+	}
 
 	return &api.Agent{
 		Name:    catalog.Spec.Name,
 		Version: catalog.Spec.Version,
 		Deployment: api.AgentDeployment{
-			Image: catalog.Spec.Image,
-			Env:   env,
+			Image:     catalog.Spec.Image,
+			Env:       env,
+			SecretEnv: secretEnv,
 		},
 	}, nil
 }
@@ -506,8 +679,22 @@ func (r *RegistryDeploymentReconciler) handleDeletion(ctx context.Context, deplo
 		return ctrl.Result{}, nil
 	}
 
+	// The satellite removes the resources of deployments the registry no longer serves
+	if deployment.Spec.Environment != "" {
+		if env, err := r.findEnvironment(ctx, deployment); err == nil && isSatelliteEnvironment(env) {
+			controllerutil.RemoveFinalizer(deployment, finalizerName)
+			return ctrl.Result{}, r.Update(ctx, deployment)
+		}
+	}
+
 	// Resolve the target client and environment for deletion
 	env, targetClient, _, err := r.getTargetClientAndEnv(ctx, deployment)
+	var circuitOpen *cluster.CircuitOpenError
+	if errors.As(err, &circuitOpen) {
+		// Keep the finalizer until the target cluster can be cleaned up
+		r.Logger.Warn().Err(err).Msg("target cluster unavailable, delaying deletion")
+		return ctrl.Result{RequeueAfter: max(time.Until(circuitOpen.RetryAt), time.Second)}, nil
+	}
 	if err != nil {
 		r.Logger.Error().Err(err).Msg("failed to resolve target for deletion, falling back to local client")
 		targetClient = r.Client
@@ -547,10 +734,46 @@ func (r *RegistryDeploymentReconciler) getTargetClientAndEnv(ctx context.Context
 		return nil, r.Client, "", nil
 	}
 
+	scope, env, err := r.findDiscoveryEnvironment(ctx, deployment)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	// If MCP tool server is available, we don't need a K8s client
+	if env.MCPToolServerURL != "" {
+		return env, nil, env.Cluster.Name, nil
+	}
+
+	factory := r.RemoteClientFactory
+	if factory == nil {
+		factory = RemoteClientFactory
+	}
+	if factory == nil {
+		return nil, nil, "", fmt.Errorf("remote client factory not configured, cannot deploy to environment %q", envName)
+	}
+	remoteClient, err := factory(scope, env, r.Scheme)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to create remote client for environment %q: %w", envName, err)
+	}
+	return env, remoteClient, env.Cluster.Name, nil
+}
+
+// findEnvironment returns the deploy-enabled environment a deployment targets, from the
+// DiscoveryConfigs in the deployment's namespace
+func (r *RegistryDeploymentReconciler) findEnvironment(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (*agentregistryv1alpha1.Environment, error) {
+	_, env, err := r.findDiscoveryEnvironment(ctx, deployment)
+	return env, err
+}
+
+// findDiscoveryEnvironment is findEnvironment that also returns the discovery scope of the
+// environment, which keys the client of its cluster
+func (r *RegistryDeploymentReconciler) findDiscoveryEnvironment(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (DiscoveryScope, *agentregistryv1alpha1.Environment, error) {
+	envName := deployment.Spec.Environment
+
 	// List DiscoveryConfigs in the same namespace to find the environment
 	var dcList agentregistryv1alpha1.DiscoveryConfigList
 	if err := r.List(ctx, &dcList, client.InNamespace(deployment.Namespace)); err != nil {
-		return nil, nil, "", fmt.Errorf("failed to list DiscoveryConfigs: %w", err)
+		return DiscoveryScope{}, nil, fmt.Errorf("failed to list DiscoveryConfigs: %w", err)
 	}
 
 	for i := range dcList.Items {
@@ -558,31 +781,15 @@ func (r *RegistryDeploymentReconciler) getTargetClientAndEnv(ctx context.Context
 			env := &dcList.Items[i].Spec.Environments[j]
 			if env.Name == envName {
 				if !env.DeployEnabled {
-					return nil, nil, "", fmt.Errorf("deployment to environment %q is not allowed (deployEnabled is false)", envName)
-				}
-
-				// If MCP tool server is available, we don't need a K8s client
-				if env.MCPToolServerURL != "" {
-					return env, nil, env.Cluster.Name, nil
-				}
-
-				factory := r.RemoteClientFactory
-				if factory == nil {
-					factory = RemoteClientFactory
-				}
-				if factory == nil {
-					return nil, nil, "", fmt.Errorf("remote client factory not configured, cannot deploy to environment %q", envName)
+					return DiscoveryScope{}, nil, fmt.Errorf("deployment to environment %q is not allowed (deployEnabled is false)", envName)
 				}
-				remoteClient, err := factory(env, r.Scheme)
-				if err != nil {
-					return nil, nil, "", fmt.Errorf("failed to create remote client for environment %q: %w", envName, err)
-				}
-				return env, remoteClient, env.Cluster.Name, nil
+				scope := DiscoveryScope{Config: dcList.Items[i].Name, Environment: env.Name, Cluster: env.Cluster.Name}
+				return scope, env, nil
 			}
 		}
 	}
 
-	return nil, nil, "", fmt.Errorf("environment %q not found in any DiscoveryConfig in namespace %q", envName, deployment.Namespace)
+	return DiscoveryScope{}, nil, fmt.Errorf("environment %q not found in any DiscoveryConfig in namespace %q", envName, deployment.Namespace)
 }
 
 // applyObj dispatches to MCP or direct K8s apply based on the mcpURL.
@@ -702,6 +909,8 @@ func (r *RegistryDeploymentReconciler) deleteResource(ctx context.Context, targe
 		obj = &kmcpv1alpha1.MCPServer{}
 	case "ConfigMap":
 		obj = &corev1.ConfigMap{}
+	case "Secret":
+		obj = &corev1.Secret{}
 	default:
 		return fmt.Errorf("unknown resource kind: %s", res.Kind)
 	}
@@ -753,6 +962,15 @@ func (r *RegistryDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error
 		}}
 	}
 
+	enqueueFromConfigSource := func(isSecret bool) handler.MapFunc {
+		return func(ctx context.Context, obj client.Object) []reconcile.Request {
+			if requests := enqueueFromManagedResource(ctx, obj); len(requests) > 0 {
+				return requests
+			}
+			return r.deploymentsReadingConfigFrom(ctx, obj, isSecret)
+		}
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&agentregistryv1alpha1.RegistryDeployment{}).
 		// Watch Agents managed by this controller
@@ -770,10 +988,16 @@ func (r *RegistryDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error
 			&kagentv1alpha2.RemoteMCPServer{},
 			handler.EnqueueRequestsFromMapFunc(enqueueFromManagedResource),
 		).
-		// Watch ConfigMaps managed by this controller
+		// Watch ConfigMaps and Secrets managed by this controller or that deployments read
+		// config from. Secrets are watched by metadata, so their data is not cached.
 		Watches(
 			&corev1.ConfigMap{},
-			handler.EnqueueRequestsFromMapFunc(enqueueFromManagedResource),
+			handler.EnqueueRequestsFromMapFunc(enqueueFromConfigSource(false)),
+		).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(enqueueFromConfigSource(true)),
+			builder.OnlyMetadata,
 		).
 		Complete(r)
 }
@@ -993,16 +1217,19 @@ func (r *RegistryDeploymentReconciler) checkManagedResourcesReady(ctx context.Co
 				return false, "Pending"
 			}
 
-		case "ConfigMap":
-			var cm corev1.ConfigMap
+		case "ConfigMap", "Secret":
+			var obj client.Object = &corev1.ConfigMap{}
+			if res.Kind == "Secret" {
+				obj = &corev1.Secret{}
+			}
 			key := client.ObjectKey{Namespace: res.Namespace, Name: res.Name}
-			if err := targetClient.Get(ctx, key, &cm); err != nil {
+			if err := targetClient.Get(ctx, key, obj); err != nil {
 				if apierrors.IsNotFound(err) {
 					return false, fmt.Sprintf("Managed %s %s/%s not found - will recreate", res.Kind, res.Namespace, res.Name)
 				}
 				return false, fmt.Sprintf("Error checking %s %s/%s: %v", res.Kind, res.Namespace, res.Name, err)
 			}
-			// ConfigMaps don't have conditions, just existence check
+			// ConfigMaps and Secrets don't have conditions, just existence check
 			continue
 		}
 	}
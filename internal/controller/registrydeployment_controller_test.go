@@ -3,17 +3,21 @@ package controller
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
 	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
 	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
 )
@@ -331,7 +335,7 @@ func TestRegistryDeploymentReconciler_ConvertCatalogToMCPServer_Remote(t *testin
 		},
 	}
 
-	server, err := r.convertCatalogToMCPServer(catalog, deployment)
+	server, err := r.convertCatalogToMCPServer(context.Background(), catalog, deployment)
 	require.NoError(t, err)
 	require.NotNil(t, server)
 	assert.Equal(t, "target-ns", server.Namespace)
@@ -372,7 +376,7 @@ func TestRegistryDeploymentReconciler_ConvertCatalogToMCPServer_Local(t *testing
 		},
 	}
 
-	server, err := r.convertCatalogToMCPServer(catalog, deployment)
+	server, err := r.convertCatalogToMCPServer(context.Background(), catalog, deployment)
 	require.NoError(t, err)
 	require.NotNil(t, server)
 	assert.Equal(t, "default", server.Namespace)
@@ -402,7 +406,7 @@ func TestRegistryDeploymentReconciler_ConvertCatalogToMCPServer_NoPackagesOrRemo
 		},
 	}
 
-	server, err := r.convertCatalogToMCPServer(catalog, deployment)
+	server, err := r.convertCatalogToMCPServer(context.Background(), catalog, deployment)
 	assert.Error(t, err)
 	assert.Nil(t, server)
 	assert.Contains(t, err.Error(), "no packages available")
@@ -432,7 +436,7 @@ func TestRegistryDeploymentReconciler_ConvertCatalogToAgent_Basic(t *testing.T)
 		},
 	}
 
-	agent, err := r.convertCatalogToAgent(catalog, deployment)
+	agent, err := r.convertCatalogToAgent(context.Background(), catalog, deployment)
 	require.NoError(t, err)
 	require.NotNil(t, agent)
 	assert.Equal(t, "registry.io/agent:1.0.0", agent.Deployment.Image)
@@ -471,10 +475,94 @@ func TestRegistryDeploymentReconciler_ConvertCatalogToAgent_WithPackages(t *test
 		},
 	}
 
-	agent, err := r.convertCatalogToAgent(catalog, deployment)
+	agent, err := r.convertCatalogToAgent(context.Background(), catalog, deployment)
 	require.NoError(t, err)
 	require.NotNil(t, agent)
 	// Agent structure doesn't expose packages directly in the test
 	// It gets translated to k8s resources through the runtime layer
 	assert.NotEmpty(t, agent.Name)
 }
+
+func TestRegistryDeploymentReconciler_CircuitOpen(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = agentregistryv1alpha1.AddToScheme(scheme)
+
+	deployment := &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "remote-deployment",
+			Namespace:  "default",
+			Finalizers: []string{finalizerName},
+		},
+		Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "test-server",
+			Version:      "1.0.0",
+			ResourceType: agentregistryv1alpha1.ResourceTypeMCP,
+			Runtime:      agentregistryv1alpha1.RuntimeTypeKubernetes,
+			Environment:  "prod",
+		},
+	}
+	server := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-1-0-0", Namespace: "default"},
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+			Name:    "test-server",
+			Version: "1.0.0",
+			Metadata: &apiextensionsv1.JSON{Raw: []byte(`{"io.modelcontextprotocol.registry/publisher-provided":` +
+				`{"aregistry.ai/metadata":{"identity":{"org_is_verified":true,"publisher_identity_verified_by_jwt":true}}}}`)},
+		},
+	}
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: "default"},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{{
+				Name:          "prod",
+				Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "prod", Endpoint: "https://10.0.0.1:6443"},
+				DeployEnabled: true,
+			}},
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&agentregistryv1alpha1.MCPServerCatalog{}, IndexMCPServerName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.MCPServerCatalog).Spec.Name}
+		}).
+		WithObjects(deployment, server, dc).
+		WithStatusSubresource(&agentregistryv1alpha1.RegistryDeployment{}, &agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+
+	retryAt := time.Now().Add(time.Minute)
+	r := &RegistryDeploymentReconciler{
+		Client: c,
+		Scheme: scheme,
+		Logger: zerolog.Nop(),
+		RemoteClientFactory: func(_ DiscoveryScope, env *agentregistryv1alpha1.Environment, _ *runtime.Scheme) (client.WithWatch, error) {
+			return nil, &cluster.CircuitOpenError{
+				Environment: env.Name,
+				Failures:    3,
+				RetryAt:     retryAt,
+				LastError:   "connection refused",
+			}
+		},
+	}
+	ctx := context.Background()
+	key := types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}
+
+	t.Run("reconcile fails fast and requeues at the retry time", func(t *testing.T) {
+		result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		require.NoError(t, err)
+		assert.Greater(t, result.RequeueAfter, 50*time.Second)
+		assert.LessOrEqual(t, result.RequeueAfter, time.Minute)
+
+		var updated agentregistryv1alpha1.RegistryDeployment
+		require.NoError(t, c.Get(ctx, key, &updated))
+		assert.Equal(t, agentregistryv1alpha1.DeploymentPhaseFailed, updated.Status.Phase)
+		assert.Contains(t, updated.Status.Message, `cluster of environment "prod" is unavailable after 3 consecutive failures`)
+	})
+
+	t.Run("deletion keeps the finalizer until the cluster is reachable", func(t *testing.T) {
+		result, err := r.handleDeletion(ctx, deployment)
+		require.NoError(t, err)
+		assert.Positive(t, result.RequeueAfter)
+		assert.Contains(t, deployment.Finalizers, finalizerName)
+	})
+}
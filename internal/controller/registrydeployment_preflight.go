@@ -0,0 +1,326 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+)
+
+// preflightRetryInterval is how often a deployment that failed its preflight checks is checked
+// again. Changes to its config sources are picked up right away.
+const preflightRetryInterval = time.Minute
+
+// Preflight check names
+const (
+	preflightCheckCatalog        = "Catalog"
+	preflightCheckRequiredConfig = "RequiredConfig"
+	preflightCheckTransport      = "Transport"
+	preflightCheckImage          = "Image"
+	preflightCheckTarget         = "Target"
+	preflightCheckNamespace      = "Namespace"
+	preflightCheckCRDs           = "CRDs"
+)
+
+// PreflightCheck is the result of one check run before a deployment is applied
+type PreflightCheck struct {
+	Name    string `json:"name" doc:"Check name" example:"RequiredConfig"`
+	Passed  bool   `json:"passed" doc:"Whether the check passed"`
+	Message string `json:"message,omitempty" doc:"What failed and how to fix it, or why the check was skipped"`
+}
+
+// PreflightResult is the result of the checks run before a deployment is applied
+type PreflightResult struct {
+	Passed bool             `json:"passed" doc:"Whether every check passed"`
+	Checks []PreflightCheck `json:"checks" doc:"Results of the individual checks"`
+}
+
+// pass records a passed check, with an optional note
+func (p *PreflightResult) pass(name, note string) {
+	p.Checks = append(p.Checks, PreflightCheck{Name: name, Passed: true, Message: note})
+}
+
+// fail records a failed check
+func (p *PreflightResult) fail(name, message string) {
+	p.Checks = append(p.Checks, PreflightCheck{Name: name, Message: message})
+	p.Passed = false
+}
+
+// Message joins the messages of the failed checks
+func (p *PreflightResult) Message() string {
+	var messages []string
+	for _, check := range p.Checks {
+		if !check.Passed {
+			messages = append(messages, check.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// preflight checks that a deployment can run before it is applied: the catalog entry exists and
+// its publisher is verified, every required input has a value, the package or remote it uses is
+// supported, and the target namespace and the kagent/kmcp CRDs exist in the target cluster. Failed
+// checks are reported in the result; an error is only returned when the checks could not be
+// run, such as when the target cluster's circuit breaker is open.
+func (r *RegistryDeploymentReconciler) preflight(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (*PreflightResult, error) {
+	result := &PreflightResult{Passed: true}
+
+	// The kind applied to the target cluster, when the catalog entry is known
+	var gvk *schema.GroupVersionKind
+	switch deployment.Spec.ResourceType {
+	case agentregistryv1alpha1.ResourceTypeMCP:
+		catalogEntry, err := r.findMCPServer(ctx, deployment)
+		if err != nil {
+			result.fail(preflightCheckCatalog, err.Error())
+			break
+		}
+		if err := validatePublisherIdentity(catalogEntry.Spec.Metadata); err != nil {
+			result.fail(preflightCheckCatalog, fmt.Sprintf("deployment blocked for %s %s: %v", deployment.Spec.ResourceName, deployment.Spec.Version, err))
+		} else {
+			result.pass(preflightCheckCatalog, "")
+		}
+		r.checkRequiredConfig(ctx, result, catalogEntry, deployment)
+		checkTransport(result, catalogEntry, deployment)
+		if useRemote(catalogEntry, deployment) {
+			gvk = &schema.GroupVersionKind{Group: "kagent.dev", Version: "v1alpha2", Kind: "RemoteMCPServer"}
+		} else {
+			gvk = &schema.GroupVersionKind{Group: "kagent.dev", Version: "v1alpha1", Kind: "MCPServer"}
+		}
+	case agentregistryv1alpha1.ResourceTypeAgent:
+		catalogEntry, err := r.findAgent(ctx, deployment)
+		if err != nil {
+			result.fail(preflightCheckCatalog, err.Error())
+			break
+		}
+		if err := validatePublisherIdentity(catalogEntry.Spec.Metadata); err != nil {
+			result.fail(preflightCheckCatalog, fmt.Sprintf("deployment blocked for %s %s: %v", deployment.Spec.ResourceName, deployment.Spec.Version, err))
+		} else {
+			result.pass(preflightCheckCatalog, "")
+		}
+		if _, err := r.resolveConfig(ctx, deployment); err != nil {
+			result.fail(preflightCheckRequiredConfig, err.Error())
+		} else {
+			result.pass(preflightCheckRequiredConfig, "")
+		}
+		if catalogEntry.Spec.Image == "" {
+			result.fail(preflightCheckImage, fmt.Sprintf("agent %s version %s has no container image", catalogEntry.Spec.Name, catalogEntry.Spec.Version))
+		} else {
+			result.pass(preflightCheckImage, "")
+		}
+		gvk = &schema.GroupVersionKind{Group: "kagent.dev", Version: "v1alpha2", Kind: "Agent"}
+	default:
+		result.fail(preflightCheckCatalog, fmt.Sprintf("unknown resource type %q; use %q or %q",
+			deployment.Spec.ResourceType, agentregistryv1alpha1.ResourceTypeMCP, agentregistryv1alpha1.ResourceTypeAgent))
+	}
+
+	if err := r.checkTarget(ctx, result, deployment, gvk); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkRequiredConfig checks that every required input of the remote or package a deployment of
+// catalog uses has a value from the catalog or the deployment config, and that values read from
+// Secrets are not passed as arguments
+func (r *RegistryDeploymentReconciler) checkRequiredConfig(ctx context.Context, result *PreflightResult, catalog *agentregistryv1alpha1.MCPServerCatalog, deployment *agentregistryv1alpha1.RegistryDeployment) {
+	config, err := r.resolveConfig(ctx, deployment)
+	if err != nil {
+		result.fail(preflightCheckRequiredConfig, err.Error())
+		return
+	}
+
+	var missing, secretArgs []string
+	checkInput := func(name, kind, value string, required bool) {
+		if _, ok := config.values[name]; !ok && required && value == "" {
+			missing = append(missing, fmt.Sprintf("%s (%s)", name, kind))
+		}
+	}
+	checkArguments := func(args []agentregistryv1alpha1.Argument) {
+		for _, arg := range args {
+			checkInput(arg.Name, "argument", arg.Value, arg.Required)
+			if config.secret[arg.Name] {
+				secretArgs = append(secretArgs, arg.Name)
+			}
+		}
+	}
+	switch {
+	case useRemote(catalog, deployment):
+		for _, h := range catalog.Spec.Remotes[0].Headers {
+			checkInput(h.Name, "header", h.Value, h.Required)
+		}
+	case len(catalog.Spec.Packages) > 0:
+		pkg := catalog.Spec.Packages[0]
+		for _, envVar := range pkg.EnvironmentVariables {
+			checkInput(envVar.Name, "environment variable", envVar.Value, envVar.Required)
+		}
+		// OCI images run their own entrypoint, so arguments are not passed
+		if pkg.RegistryType != "oci" {
+			checkArguments(pkg.RuntimeArguments)
+			checkArguments(pkg.PackageArguments)
+		}
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("missing required config %s; set it in spec.config or spec.configFrom",
+			strings.Join(missing, ", ")))
+	}
+	if len(secretArgs) > 0 {
+		problems = append(problems, fmt.Sprintf("config %s is read from a secret and cannot be passed as an argument; use spec.config or a configMapKeyRef",
+			strings.Join(secretArgs, ", ")))
+	}
+	if len(problems) > 0 {
+		result.fail(preflightCheckRequiredConfig, strings.Join(problems, "; "))
+		return
+	}
+	result.pass(preflightCheckRequiredConfig, "")
+}
+
+// checkTransport checks that the remote or package a deployment of catalog uses can be
+// translated into kagent resources
+func checkTransport(result *PreflightResult, catalog *agentregistryv1alpha1.MCPServerCatalog, deployment *agentregistryv1alpha1.RegistryDeployment) {
+	switch {
+	case useRemote(catalog, deployment):
+		// RemoteMCPServers are created with the streamable HTTP protocol
+		remote := catalog.Spec.Remotes[0]
+		if !slices.Contains([]string{"", "streamable-http", "http"}, remote.Type) {
+			result.fail(preflightCheckTransport, fmt.Sprintf("remote transport %q is not supported; only streamable-http remotes can be deployed", remote.Type))
+			return
+		}
+		if remote.URL == "" {
+			result.fail(preflightCheckTransport, "remote has no URL")
+			return
+		}
+		result.pass(preflightCheckTransport, "")
+	case len(catalog.Spec.Packages) > 0:
+		pkg := catalog.Spec.Packages[0]
+		if !slices.Contains([]string{"npm", "pypi", "oci"}, pkg.RegistryType) {
+			result.fail(preflightCheckTransport, fmt.Sprintf("package registry type %q is not supported; supported types are npm, pypi and oci", pkg.RegistryType))
+			return
+		}
+		if !slices.Contains([]string{"", "stdio", "http", "streamable-http"}, pkg.Transport.Type) {
+			hint := ""
+			if len(catalog.Spec.Remotes) > 0 {
+				hint = " or set spec.preferRemote to use its remote"
+			}
+			result.fail(preflightCheckTransport, fmt.Sprintf("package transport %q is not supported; supported transports are stdio and streamable-http%s", pkg.Transport.Type, hint))
+			return
+		}
+		result.pass(preflightCheckTransport, "")
+	default:
+		result.fail(preflightCheckTransport, fmt.Sprintf("MCP server %s version %s has no packages or remotes to deploy", catalog.Spec.Name, catalog.Spec.Version))
+	}
+}
+
+// checkTarget checks that the target cluster of a deployment can be reached and has its target
+// namespace and the CRD of gvk installed. A nil gvk skips the CRD check. It only returns the
+// error of an open circuit breaker.
+func (r *RegistryDeploymentReconciler) checkTarget(ctx context.Context, result *PreflightResult, deployment *agentregistryv1alpha1.RegistryDeployment, gvk *schema.GroupVersionKind) error {
+	skip := func(note string) {
+		result.pass(preflightCheckNamespace, note)
+		result.pass(preflightCheckCRDs, note)
+	}
+
+	if deployment.Spec.Environment != "" {
+		if env, err := r.findEnvironment(ctx, deployment); err == nil && isSatelliteEnvironment(env) {
+			result.pass(preflightCheckTarget, "")
+			skip(fmt.Sprintf("skipped: checked by the satellite of environment %q", env.Name))
+			return nil
+		}
+	}
+	env, targetClient, clusterName, err := r.getTargetClientAndEnv(ctx, deployment)
+	if err != nil {
+		var circuitOpen *cluster.CircuitOpenError
+		if errors.As(err, &circuitOpen) {
+			return err
+		}
+		result.fail(preflightCheckTarget, err.Error())
+		skip("skipped: target unavailable")
+		return nil
+	}
+	result.pass(preflightCheckTarget, "")
+	if env != nil && env.MCPToolServerURL != "" {
+		skip(fmt.Sprintf("skipped: resources are applied through the MCP tool server %s", env.MCPToolServerURL))
+		return nil
+	}
+
+	clusterDesc := "the local cluster"
+	if clusterName != "" {
+		clusterDesc = fmt.Sprintf("cluster %q", clusterName)
+	}
+
+	targetNamespace := deployment.Spec.Namespace
+	if targetNamespace == "" {
+		targetNamespace = defaultNamespace
+	}
+	var ns corev1.Namespace
+	switch err := targetClient.Get(ctx, client.ObjectKey{Name: targetNamespace}, &ns); {
+	case apierrors.IsNotFound(err):
+		result.fail(preflightCheckNamespace, fmt.Sprintf("namespace %q does not exist in %s; create it or set spec.namespace", targetNamespace, clusterDesc))
+	case err != nil:
+		result.fail(preflightCheckNamespace, fmt.Sprintf("failed to get namespace %q in %s: %v", targetNamespace, clusterDesc, err))
+	default:
+		result.pass(preflightCheckNamespace, "")
+	}
+
+	if gvk == nil {
+		result.pass(preflightCheckCRDs, "skipped: catalog entry not found")
+		return nil
+	}
+	_, err = targetClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	switch {
+	case meta.IsNoMatchError(err):
+		project := "kagent"
+		if gvk.Version == "v1alpha1" {
+			project = "kmcp"
+		}
+		result.fail(preflightCheckCRDs, fmt.Sprintf("%s %s is not installed in %s; install the %s CRDs",
+			gvk.Kind, gvk.GroupVersion(), clusterDesc, project))
+	case err != nil:
+		result.fail(preflightCheckCRDs, fmt.Sprintf("failed to look up %s %s in %s: %v", gvk.Kind, gvk.GroupVersion(), clusterDesc, err))
+	default:
+		result.pass(preflightCheckCRDs, "")
+	}
+	return nil
+}
+
+// DeploymentValidator runs the preflight checks of a deployment spec without applying it, so a
+// deployment can be checked before it is created
+type DeploymentValidator struct {
+	reconciler *RegistryDeploymentReconciler
+}
+
+// NewDeploymentValidator creates a DeploymentValidator reading the catalog with c
+func NewDeploymentValidator(c client.Client, scheme *runtime.Scheme, logger zerolog.Logger) *DeploymentValidator {
+	return &DeploymentValidator{
+		reconciler: &RegistryDeploymentReconciler{Client: c, Scheme: scheme, Logger: logger},
+	}
+}
+
+// Validate runs the preflight checks of spec as a deployment in the registry namespace, where
+// its configFrom entries and environment are read from
+func (v *DeploymentValidator) Validate(ctx context.Context, spec *agentregistryv1alpha1.RegistryDeploymentSpec) (*PreflightResult, error) {
+	if spec.ResourceName == "" || spec.Version == "" {
+		return nil, apierrors.NewBadRequest("resourceName and version are required")
+	}
+	deployment := &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: generateInternalName(spec.ResourceName), Namespace: config.GetNamespace()},
+		Spec:       *spec,
+	}
+	return v.reconciler.preflight(ctx, deployment)
+}
@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+// newPreflightTestClient returns a client whose cluster has the tools namespace, a github
+// Secret and the kagent CRDs, but not the kmcp ones
+func newPreflightTestClient(t *testing.T, objs ...client.Object) (client.Client, *runtime.Scheme) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, kagentv1alpha2.AddToScheme(scheme))
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(kagentv1alpha2.GroupVersion.WithKind("Agent"), meta.RESTScopeNamespace)
+	mapper.Add(kagentv1alpha2.GroupVersion.WithKind("RemoteMCPServer"), meta.RESTScopeNamespace)
+
+	verified := &apiextensionsv1.JSON{Raw: []byte(`{"io.modelcontextprotocol.registry/publisher-provided":` +
+		`{"aregistry.ai/metadata":{"identity":{"org_is_verified":true,"publisher_identity_verified_by_jwt":true}}}}`)}
+	objs = append(objs,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tools"}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "github", Namespace: testNamespace, Labels: map[string]string{agentregistryv1alpha1.LabelDeploymentConfig: "true"}},
+			Data:       map[string][]byte{"token": []byte("ghp_secret")},
+		},
+		&agentregistryv1alpha1.MCPServerCatalog{
+			ObjectMeta: metav1.ObjectMeta{Name: "github-1-0-0", Namespace: testNamespace},
+			Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:     "github",
+				Version:  "1.0.0",
+				Metadata: verified,
+				Packages: []agentregistryv1alpha1.Package{{
+					RegistryType: "npm",
+					Identifier:   "@modelcontextprotocol/server-github",
+					Transport:    agentregistryv1alpha1.Transport{Type: "stdio"},
+					EnvironmentVariables: []agentregistryv1alpha1.KeyValueInput{
+						{Name: "GITHUB_TOKEN", Required: true},
+						{Name: "GITHUB_HOST", Value: "github.com", Required: true},
+					},
+					PackageArguments: []agentregistryv1alpha1.Argument{{Name: "toolsets", Required: true}},
+				}},
+				Remotes: []agentregistryv1alpha1.Transport{{
+					Type:    "streamable-http",
+					URL:     "https://api.githubcopilot.com/mcp/",
+					Headers: []agentregistryv1alpha1.KeyValueInput{{Name: "Authorization", Required: true}},
+				}},
+			},
+		},
+		&agentregistryv1alpha1.MCPServerCatalog{
+			ObjectMeta: metav1.ObjectMeta{Name: "events-1-0-0", Namespace: testNamespace},
+			Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:     "events",
+				Version:  "1.0.0",
+				Metadata: verified,
+				Remotes:  []agentregistryv1alpha1.Transport{{Type: "sse", URL: "https://events.example.com/sse"}},
+			},
+		},
+		&agentregistryv1alpha1.AgentCatalog{
+			ObjectMeta: metav1.ObjectMeta{Name: "triage-1-0-0", Namespace: testNamespace},
+			Spec:       agentregistryv1alpha1.AgentCatalogSpec{Name: "triage", Version: "1.0.0", Image: "agent:1.0.0", Metadata: verified},
+		},
+	)
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(mapper).
+		WithIndex(&agentregistryv1alpha1.MCPServerCatalog{}, IndexMCPServerName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.MCPServerCatalog).Spec.Name}
+		}).
+		WithIndex(&agentregistryv1alpha1.AgentCatalog{}, IndexAgentName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.AgentCatalog).Spec.Name}
+		}).
+		WithObjects(objs...).
+		WithStatusSubresource(&agentregistryv1alpha1.RegistryDeployment{}, &agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+	return c, scheme
+}
+
+// checksByName indexes the checks of a preflight result by name
+func checksByName(result *PreflightResult) map[string]PreflightCheck {
+	checks := make(map[string]PreflightCheck)
+	for _, check := range result.Checks {
+		checks[check.Name] = check
+	}
+	return checks
+}
+
+func TestRegistryDeploymentReconciler_Preflight(t *testing.T) {
+	c, scheme := newPreflightTestClient(t)
+	r := &RegistryDeploymentReconciler{Client: c, Scheme: scheme, Logger: zerolog.Nop()}
+	ctx := context.Background()
+
+	run := func(spec agentregistryv1alpha1.RegistryDeploymentSpec) (*PreflightResult, map[string]PreflightCheck) {
+		t.Helper()
+		spec.Runtime = agentregistryv1alpha1.RuntimeTypeKubernetes
+		result, err := r.preflight(ctx, &agentregistryv1alpha1.RegistryDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployment", Namespace: testNamespace},
+			Spec:       spec,
+		})
+		require.NoError(t, err)
+		return result, checksByName(result)
+	}
+
+	t.Run("missing required package inputs", func(t *testing.T) {
+		result, checks := run(agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "github", Version: "1.0.0", ResourceType: agentregistryv1alpha1.ResourceTypeMCP, Namespace: "tools",
+		})
+		assert.False(t, result.Passed)
+		assert.True(t, checks[preflightCheckCatalog].Passed)
+		assert.Equal(t, "missing required config GITHUB_TOKEN (environment variable), toolsets (argument); "+
+			"set it in spec.config or spec.configFrom", checks[preflightCheckRequiredConfig].Message)
+		assert.True(t, checks[preflightCheckTransport].Passed)
+		assert.True(t, checks[preflightCheckNamespace].Passed)
+		assert.False(t, checks[preflightCheckCRDs].Passed)
+		assert.Equal(t, "MCPServer kagent.dev/v1alpha1 is not installed in the local cluster; install the kmcp CRDs",
+			checks[preflightCheckCRDs].Message)
+		assert.Contains(t, result.Message(), "GITHUB_TOKEN")
+		assert.Contains(t, result.Message(), "install the kmcp CRDs")
+	})
+
+	t.Run("secret argument", func(t *testing.T) {
+		_, checks := run(agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "github", Version: "1.0.0", ResourceType: agentregistryv1alpha1.ResourceTypeMCP, Namespace: "tools",
+			Config:     map[string]string{"GITHUB_TOKEN": "token"},
+			ConfigFrom: []agentregistryv1alpha1.ConfigValueFrom{{Name: "toolsets", ValueFrom: secretRef("github", "token")}},
+		})
+		assert.Equal(t, "config toolsets is read from a secret and cannot be passed as an argument; "+
+			"use spec.config or a configMapKeyRef", checks[preflightCheckRequiredConfig].Message)
+	})
+
+	t.Run("remote with required header from a secret", func(t *testing.T) {
+		result, checks := run(agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "github", Version: "1.0.0", ResourceType: agentregistryv1alpha1.ResourceTypeMCP, Namespace: "tools",
+			PreferRemote: true,
+			ConfigFrom:   []agentregistryv1alpha1.ConfigValueFrom{{Name: "Authorization", ValueFrom: secretRef("github", "token")}},
+		})
+		assert.True(t, result.Passed, result.Message())
+		assert.Len(t, checks, 6)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		_, checks := run(agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "github", Version: "1.0.0", ResourceType: agentregistryv1alpha1.ResourceTypeMCP, Namespace: "tools",
+			PreferRemote: true,
+			ConfigFrom:   []agentregistryv1alpha1.ConfigValueFrom{{Name: "Authorization", ValueFrom: secretRef("missing", "token")}},
+		})
+		assert.Contains(t, checks[preflightCheckRequiredConfig].Message, `config "Authorization": failed to get secret "missing"`)
+	})
+
+	t.Run("unsupported remote transport and missing namespace", func(t *testing.T) {
+		_, checks := run(agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "events", Version: "1.0.0", ResourceType: agentregistryv1alpha1.ResourceTypeMCP, Namespace: "missing",
+		})
+		assert.Equal(t, `remote transport "sse" is not supported; only streamable-http remotes can be deployed`,
+			checks[preflightCheckTransport].Message)
+		assert.Equal(t, `namespace "missing" does not exist in the local cluster; create it or set spec.namespace`,
+			checks[preflightCheckNamespace].Message)
+		assert.True(t, checks[preflightCheckCRDs].Passed, "RemoteMCPServer is installed")
+	})
+
+	t.Run("agent", func(t *testing.T) {
+		result, checks := run(agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "triage", Version: "1.0.0", ResourceType: agentregistryv1alpha1.ResourceTypeAgent, Namespace: "tools",
+		})
+		assert.True(t, result.Passed, result.Message())
+		assert.True(t, checks[preflightCheckImage].Passed)
+	})
+
+	t.Run("catalog entry not found", func(t *testing.T) {
+		result, checks := run(agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "triage", Version: "2.0.0", ResourceType: agentregistryv1alpha1.ResourceTypeAgent, Namespace: "tools",
+		})
+		assert.False(t, result.Passed)
+		assert.Equal(t, "agent triage version 2.0.0 not found", checks[preflightCheckCatalog].Message)
+		assert.Equal(t, "skipped: catalog entry not found", checks[preflightCheckCRDs].Message)
+	})
+
+	t.Run("unknown environment", func(t *testing.T) {
+		_, checks := run(agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "triage", Version: "1.0.0", ResourceType: agentregistryv1alpha1.ResourceTypeAgent, Environment: "prod",
+		})
+		assert.Contains(t, checks[preflightCheckTarget].Message, `environment "prod" not found`)
+		assert.Equal(t, "skipped: target unavailable", checks[preflightCheckNamespace].Message)
+	})
+}
+
+func TestRegistryDeploymentReconciler_Reconcile_PreflightFailed(t *testing.T) {
+	deployment := &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "github",
+			Namespace:  testNamespace,
+			Finalizers: []string{finalizerName},
+		},
+		Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "github",
+			Version:      "1.0.0",
+			ResourceType: agentregistryv1alpha1.ResourceTypeMCP,
+			Runtime:      agentregistryv1alpha1.RuntimeTypeKubernetes,
+			Namespace:    "tools",
+		},
+	}
+	c, scheme := newPreflightTestClient(t, deployment)
+	r := &RegistryDeploymentReconciler{Client: c, Scheme: scheme, Logger: zerolog.Nop()}
+	ctx := context.Background()
+	key := types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}
+
+	result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, result.RequeueAfter)
+
+	var updated agentregistryv1alpha1.RegistryDeployment
+	require.NoError(t, c.Get(ctx, key, &updated))
+	assert.Equal(t, agentregistryv1alpha1.DeploymentPhaseFailed, updated.Status.Phase)
+	assert.Contains(t, updated.Status.Message, "preflight checks failed: missing required config GITHUB_TOKEN")
+	assert.Empty(t, updated.Status.ManagedResources)
+	require.Len(t, updated.Status.Conditions, 1)
+	assert.Equal(t, agentregistryv1alpha1.CatalogConditionPreflight, updated.Status.Conditions[0].Type)
+	assert.Equal(t, metav1.ConditionFalse, updated.Status.Conditions[0].Status)
+	assert.Equal(t, "PreflightFailed", updated.Status.Conditions[0].Reason)
+
+	// Nothing is applied and the catalog entry is not marked managed
+	var mcpServers kmcpv1alpha1.MCPServerList
+	require.NoError(t, c.List(ctx, &mcpServers))
+	assert.Empty(t, mcpServers.Items)
+	var catalog agentregistryv1alpha1.MCPServerCatalog
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "github-1-0-0", Namespace: testNamespace}, &catalog))
+	assert.Empty(t, catalog.Status.ManagementType)
+}
+
+func TestDeploymentValidator_Validate(t *testing.T) {
+	c, scheme := newPreflightTestClient(t)
+	v := NewDeploymentValidator(c, scheme, zerolog.Nop())
+	ctx := context.Background()
+
+	result, err := v.Validate(ctx, &agentregistryv1alpha1.RegistryDeploymentSpec{
+		ResourceName: "github",
+		Version:      "1.0.0",
+		ResourceType: agentregistryv1alpha1.ResourceTypeMCP,
+		Runtime:      agentregistryv1alpha1.RuntimeTypeKubernetes,
+		Namespace:    "tools",
+		PreferRemote: true,
+		ConfigFrom:   []agentregistryv1alpha1.ConfigValueFrom{{Name: "Authorization", ValueFrom: secretRef("github", "token")}},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Passed, result.Message())
+
+	_, err = v.Validate(ctx, &agentregistryv1alpha1.RegistryDeploymentSpec{ResourceType: agentregistryv1alpha1.ResourceTypeMCP})
+	assert.True(t, apierrors.IsBadRequest(err))
+}
@@ -0,0 +1,456 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+	"github.com/agentregistry-dev/agentregistry/internal/runtime/translation/api"
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+const (
+	// DefaultSatellitePollInterval is how often a satellite pulls its deployments
+	DefaultSatellitePollInterval = 30 * time.Second
+	// satelliteRequestTimeout bounds a single call to the registry
+	satelliteRequestTimeout = 30 * time.Second
+)
+
+// SatelliteDeployment is a RegistryDeployment resolved by the registry for a satellite
+type SatelliteDeployment struct {
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Generation int64  `json:"generation"`
+	// DesiredState is translated and applied by the satellite. Config read from Secrets is left
+	// empty in it.
+	DesiredState *api.DesiredState `json:"desiredState,omitempty"`
+	// SecretConfig references the Secrets the satellite reads that config from. They are read
+	// from the deployment's namespace in the satellite cluster, so secret values never leave
+	// the cluster they are stored in.
+	SecretConfig []agentregistryv1alpha1.ConfigValueFrom `json:"secretConfig,omitempty"`
+	// Error is set when the registry cannot resolve the deployment. The satellite reports it
+	// and keeps the resources it applied before.
+	Error string `json:"error,omitempty"`
+}
+
+// SatelliteDeploymentList is every deployment targeted at a satellite environment
+type SatelliteDeploymentList struct {
+	Deployments []SatelliteDeployment `json:"deployments"`
+}
+
+// SatelliteDeploymentStatus is the status of a deployment as applied by a satellite
+type SatelliteDeploymentStatus struct {
+	// ObservedGeneration is the deployment generation the satellite applied
+	ObservedGeneration int64                                 `json:"observedGeneration"`
+	Phase              agentregistryv1alpha1.DeploymentPhase `json:"phase" enum:"Pending,Running,Failed"`
+	Message            string                                `json:"message,omitempty"`
+	// ManagedResources are the resources the satellite applied. They are ignored when the
+	// phase is Failed, like a failed reconcile keeps the previous ones.
+	ManagedResources []agentregistryv1alpha1.ManagedResource `json:"managedResources,omitempty"`
+}
+
+// SatelliteHub serves the deployments of satellite environments and records the status the
+// satellites report
+type SatelliteHub struct {
+	reconciler *RegistryDeploymentReconciler
+}
+
+// NewSatelliteHub creates a SatelliteHub
+func NewSatelliteHub(c client.Client, scheme *runtime.Scheme, logger zerolog.Logger) *SatelliteHub {
+	return &SatelliteHub{
+		reconciler: &RegistryDeploymentReconciler{Client: c, Scheme: scheme, Logger: logger, secretsByReference: true},
+	}
+}
+
+// isSatelliteEnvironment reports whether env is reached through a satellite
+func isSatelliteEnvironment(env *agentregistryv1alpha1.Environment) bool {
+	name, err := cluster.ProviderName(env)
+	return err == nil && name == cluster.ProviderSatellite
+}
+
+// Deployments returns every RegistryDeployment targeted at the satellite environment, resolved
+// into the runtime format. Deployments that fail their preflight checks are served with the
+// failure instead, and ones being deleted are left out so the satellite removes their resources. It returns a NotFound error if no DiscoveryConfig declares the environment
+// as a satellite environment, so a misconfigured satellite never prunes its cluster.
+func (h *SatelliteHub) Deployments(ctx context.Context, environment string) (*SatelliteDeploymentList, error) {
+	r := h.reconciler
+	var configs agentregistryv1alpha1.DiscoveryConfigList
+	if err := r.List(ctx, &configs, client.InNamespace(config.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("list discovery configs: %w", err)
+	}
+	declared := false
+	for _, dc := range configs.Items {
+		for i := range dc.Spec.Environments {
+			env := &dc.Spec.Environments[i]
+			if env.Name == environment && isSatelliteEnvironment(env) {
+				declared = true
+			}
+		}
+	}
+	if !declared {
+		return nil, apierrors.NewNotFound(
+			schema.GroupResource{Group: agentregistryv1alpha1.GroupVersion.Group, Resource: "environments"},
+			environment,
+		)
+	}
+
+	var deployments agentregistryv1alpha1.RegistryDeploymentList
+	if err := r.List(ctx, &deployments); err != nil {
+		return nil, fmt.Errorf("list deployments: %w", err)
+	}
+	list := &SatelliteDeploymentList{Deployments: []SatelliteDeployment{}}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if deployment.Spec.Environment != environment || !deployment.DeletionTimestamp.IsZero() {
+			continue
+		}
+		entry := SatelliteDeployment{
+			Namespace:  deployment.Namespace,
+			Name:       deployment.Name,
+			Generation: deployment.Generation,
+		}
+		env, err := r.findEnvironment(ctx, deployment)
+		if err == nil && !isSatelliteEnvironment(env) {
+			// The environment of this namespace is reached directly
+			continue
+		}
+		if err == nil {
+			err = r.satellitePreflight(ctx, deployment)
+		}
+		if err == nil {
+			entry.DesiredState, err = r.desiredState(ctx, deployment)
+			entry.SecretConfig = secretConfigRefs(deployment)
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		list.Deployments = append(list.Deployments, entry)
+	}
+	return list, nil
+}
+
+// UpdateStatus records the status a satellite reports for a deployment. It returns a NotFound
+// error if the deployment no longer exists and a Conflict error if it doesn't target the
+// satellite environment.
+func (h *SatelliteHub) UpdateStatus(
+	ctx context.Context,
+	environment, namespace, name string,
+	status *SatelliteDeploymentStatus,
+) error {
+	r := h.reconciler
+	var deployment agentregistryv1alpha1.RegistryDeployment
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &deployment); err != nil {
+		return err
+	}
+	env, err := r.findEnvironment(ctx, &deployment)
+	if deployment.Spec.Environment != environment || err != nil || !isSatelliteEnvironment(env) {
+		return apierrors.NewConflict(
+			schema.GroupResource{Group: agentregistryv1alpha1.GroupVersion.Group, Resource: "registrydeployments"},
+			name,
+			fmt.Errorf("deployment does not target satellite environment %q", environment),
+		)
+	}
+
+	now := metav1.Now()
+	deployment.Status.Phase = status.Phase
+	deployment.Status.Message = status.Message
+	deployment.Status.ObservedGeneration = status.ObservedGeneration
+	deployment.Status.UpdatedAt = &now
+	if deployment.Status.DeployedAt == nil {
+		deployment.Status.DeployedAt = &now
+	}
+	if status.Phase != agentregistryv1alpha1.DeploymentPhaseFailed {
+		managed := make([]agentregistryv1alpha1.ManagedResource, len(status.ManagedResources))
+		for i, res := range status.ManagedResources {
+			res.Cluster = env.Cluster.Name
+			managed[i] = res
+		}
+		deployment.Status.ManagedResources = managed
+	}
+	return r.Status().Update(ctx, &deployment)
+}
+
+// satellitePreflight runs the preflight checks of a deployment to a satellite environment and
+// returns the failed ones as an error. Secrets are read by the satellite in its own cluster, so
+// only their references are checked.
+func (r *RegistryDeploymentReconciler) satellitePreflight(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) error {
+	checker := &RegistryDeploymentReconciler{Client: r.Client, Scheme: r.Scheme, Logger: r.Logger, secretsByReference: true}
+	preflight, err := checker.preflight(ctx, deployment)
+	if err != nil {
+		return err
+	}
+	if !preflight.Passed {
+		return &preflightError{result: preflight}
+	}
+	return nil
+}
+
+// preflightError is returned for a deployment that failed its preflight checks
+type preflightError struct {
+	result *PreflightResult
+}
+
+func (e *preflightError) Error() string {
+	return "preflight checks failed: " + e.result.Message()
+}
+
+// awaitSatellite runs the preflight checks of a deployment to a satellite environment and marks
+// it pending until the satellite reports the current generation. A deployment that fails them
+// is marked failed and checked again after preflightRetryInterval.
+func (r *RegistryDeploymentReconciler) awaitSatellite(ctx context.Context, deployment *agentregistryv1alpha1.RegistryDeployment) (ctrl.Result, error) {
+	var result ctrl.Result
+	status := deployment.Status.DeepCopy()
+	var failed *preflightError
+	switch err := r.satellitePreflight(ctx, deployment); {
+	case errors.As(err, &failed):
+		status.Conditions = setCatalogCondition(status.Conditions,
+			agentregistryv1alpha1.CatalogConditionPreflight, metav1.ConditionFalse, "PreflightFailed", failed.result.Message())
+		status.Phase = agentregistryv1alpha1.DeploymentPhaseFailed
+		status.Message = failed.Error()
+		result.RequeueAfter = preflightRetryInterval
+	case err != nil:
+		return result, err
+	default:
+		status.Conditions = setCatalogCondition(status.Conditions,
+			agentregistryv1alpha1.CatalogConditionPreflight, metav1.ConditionTrue, "PreflightPassed", "All preflight checks passed")
+		if status.ObservedGeneration != deployment.Generation {
+			status.Phase = agentregistryv1alpha1.DeploymentPhasePending
+			status.Message = fmt.Sprintf("Waiting for the satellite of environment %q to apply the deployment", deployment.Spec.Environment)
+		}
+	}
+	if equality.Semantic.DeepEqual(status, &deployment.Status) {
+		return result, nil
+	}
+	now := metav1.Now()
+	status.UpdatedAt = &now
+	deployment.Status = *status
+	return result, r.Status().Update(ctx, deployment)
+}
+
+// Satellite runs in a cluster the registry cannot reach. It pulls the deployments of its
+// environment from the registry, translates and applies them locally, reports their status
+// back and removes the resources of deployments that are gone.
+type Satellite struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Logger zerolog.Logger
+
+	// RegistryURL is the base URL of the registry HTTP API
+	RegistryURL string
+	// Environment is the satellite environment this cluster serves
+	Environment string
+	// TokenFile holds the API token. It is read before every poll so rotated tokens apply.
+	TokenFile string
+	// Interval between two polls; zero uses DefaultSatellitePollInterval
+	Interval time.Duration
+	// HTTPClient calls the registry; nil uses a client with satelliteRequestTimeout
+	HTTPClient *http.Client
+}
+
+// SetupWithManager runs the satellite while the manager holds the leader lease
+func (s *Satellite) SetupWithManager(mgr ctrl.Manager) error {
+	return mgr.Add(manager.RunnableFunc(s.run))
+}
+
+// run syncs immediately and then every Interval until ctx is cancelled
+func (s *Satellite) run(ctx context.Context) error {
+	interval := s.Interval
+	if interval == 0 {
+		interval = DefaultSatellitePollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.sync(ctx); err != nil {
+			s.Logger.Error().Err(err).Msg("failed to sync deployments from the registry")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync applies every deployment of the environment, reports its status and prunes the
+// resources of deployments the registry no longer lists
+func (s *Satellite) sync(ctx context.Context) error {
+	var list SatelliteDeploymentList
+	path := "/admin/v0/satellite/environments/" + url.PathEscape(s.Environment) + "/deployments"
+	if err := s.call(ctx, http.MethodGet, path, nil, &list); err != nil {
+		return fmt.Errorf("fetch deployments: %w", err)
+	}
+
+	r := &RegistryDeploymentReconciler{Client: s.Client, Scheme: s.Scheme, Logger: s.Logger}
+	desired := make(map[string]bool)
+	for _, d := range list.Deployments {
+		desired[d.Namespace+"/"+d.Name] = true
+		status := s.apply(ctx, r, d)
+		path := fmt.Sprintf("/admin/v0/satellite/environments/%s/deployments/%s/%s/status",
+			url.PathEscape(s.Environment), url.PathEscape(d.Namespace), url.PathEscape(d.Name))
+		if err := s.call(ctx, http.MethodPut, path, status, nil); err != nil {
+			s.Logger.Error().Err(err).Str("deployment", d.Namespace+"/"+d.Name).Msg("failed to report deployment status")
+		}
+	}
+	s.prune(ctx, r, desired)
+	return nil
+}
+
+// apply translates and applies one deployment with the local client
+func (s *Satellite) apply(ctx context.Context, r *RegistryDeploymentReconciler, d SatelliteDeployment) *SatelliteDeploymentStatus {
+	status := &SatelliteDeploymentStatus{ObservedGeneration: d.Generation}
+	if d.Error != "" || d.DesiredState == nil {
+		status.Phase = agentregistryv1alpha1.DeploymentPhaseFailed
+		status.Message = d.Error
+		return status
+	}
+
+	// The deployment is local from here on: empty environment resolves to the local client
+	deployment := &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: d.Namespace, Name: d.Name},
+	}
+	if err := r.fillSecretConfig(ctx, d.Namespace, d.SecretConfig, d.DesiredState); err != nil {
+		status.Phase = agentregistryv1alpha1.DeploymentPhaseFailed
+		status.Message = err.Error()
+		return status
+	}
+	managed, err := r.applyDesiredState(ctx, deployment, d.DesiredState, "", s.Client, "")
+	if err != nil {
+		s.Logger.Error().Err(err).Str("deployment", d.Namespace+"/"+d.Name).Msg("failed to apply deployment")
+		status.Phase = agentregistryv1alpha1.DeploymentPhaseFailed
+		status.Message = err.Error()
+		return status
+	}
+	deployment.Status.ManagedResources = managed
+	status.ManagedResources = managed
+	if ready, message := r.checkManagedResourcesReady(ctx, deployment); ready {
+		status.Phase = agentregistryv1alpha1.DeploymentPhaseRunning
+	} else {
+		status.Phase = agentregistryv1alpha1.DeploymentPhasePending
+		status.Message = message
+	}
+	return status
+}
+
+// prune deletes resources applied for deployments that are not in desired. Kinds whose CRDs
+// are not installed are skipped.
+func (s *Satellite) prune(ctx context.Context, r *RegistryDeploymentReconciler, desired map[string]bool) {
+	lists := []client.ObjectList{
+		&kagentv1alpha2.AgentList{},
+		&kagentv1alpha2.RemoteMCPServerList{},
+		&kmcpv1alpha1.MCPServerList{},
+		&corev1.ConfigMapList{},
+		&corev1.SecretList{},
+	}
+	for _, list := range lists {
+		if err := s.List(ctx, list, client.MatchingLabels{managedByLabel: "agentregistry"}); err != nil {
+			if !meta.IsNoMatchError(err) {
+				s.Logger.Error().Err(err).Msg("failed to list applied resources")
+			}
+			continue
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			continue
+		}
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok {
+				continue
+			}
+			labels := obj.GetLabels()
+			if labels[deploymentNameLabel] == "" || desired[labels[deploymentNSLabel]+"/"+labels[deploymentNameLabel]] {
+				continue
+			}
+			gvk, err := s.GroupVersionKindFor(obj)
+			if err != nil {
+				continue
+			}
+			res := agentregistryv1alpha1.ManagedResource{
+				APIVersion: gvk.GroupVersion().String(),
+				Kind:       gvk.Kind,
+				Name:       obj.GetName(),
+				Namespace:  obj.GetNamespace(),
+			}
+			if err := r.deleteResource(ctx, s.Client, res); err != nil {
+				s.Logger.Error().Err(err).Str("kind", res.Kind).Str("name", res.Name).Msg("failed to remove resource of deleted deployment")
+				continue
+			}
+			s.Logger.Info().
+				Str("kind", res.Kind).
+				Str("name", res.Name).
+				Str("namespace", res.Namespace).
+				Str("deployment", labels[deploymentNSLabel]+"/"+labels[deploymentNameLabel]).
+				Msg("removed resource of deleted deployment")
+		}
+	}
+}
+
+// call sends a JSON request to the registry and decodes the JSON response into out
+func (s *Satellite) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(s.RegistryURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.TokenFile != "" {
+		token, err := os.ReadFile(s.TokenFile)
+		if err != nil {
+			return fmt.Errorf("read token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: satelliteRequestTimeout}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,411 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	"github.com/agentregistry-dev/agentregistry/internal/runtime/translation/api"
+	"github.com/agentregistry-dev/agentregistry/internal/runtime/translation/kagent"
+	kagentv1alpha2 "github.com/kagent-dev/kagent/go/api/v1alpha2"
+	kmcpv1alpha1 "github.com/kagent-dev/kmcp/api/v1alpha1"
+)
+
+func satelliteTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+	require.NoError(t, kagentv1alpha2.AddToScheme(scheme))
+	require.NoError(t, kmcpv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func satelliteDeployment(name, environment, version string) *agentregistryv1alpha1.RegistryDeployment {
+	return &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  testNamespace,
+			Generation: 2,
+			Finalizers: []string{finalizerName},
+		},
+		Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "test-server",
+			Version:      version,
+			ResourceType: agentregistryv1alpha1.ResourceTypeMCP,
+			Runtime:      agentregistryv1alpha1.RuntimeTypeKubernetes,
+			Environment:  environment,
+			Namespace:    "tools",
+			PreferRemote: true,
+		},
+	}
+}
+
+func TestSatelliteHub(t *testing.T) {
+	scheme := satelliteTestScheme(t)
+	server := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-1-0-0", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+			Name:    "test-server",
+			Version: "1.0.0",
+			Remotes: []agentregistryv1alpha1.Transport{{Type: "streamable-http", URL: "https://api.example.com/mcp"}},
+			Metadata: &apiextensionsv1.JSON{Raw: []byte(`{"io.modelcontextprotocol.registry/publisher-provided":` +
+				`{"aregistry.ai/metadata":{"identity":{"org_is_verified":true,"publisher_identity_verified_by_jwt":true}}}}`)},
+		},
+	}
+	secured := server.DeepCopy()
+	secured.Name = "test-server-3-0-0"
+	secured.Spec.Version = "3.0.0"
+	secured.Spec.Remotes[0].Headers = []agentregistryv1alpha1.KeyValueInput{{Name: "Authorization", Required: true}}
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{
+				{
+					Name:          "edge",
+					Provider:      cluster.ProviderSatellite,
+					Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "edge-1"},
+					DeployEnabled: true,
+				},
+				{
+					Name:          "prod",
+					Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "prod", Endpoint: "https://10.0.0.1:6443"},
+					DeployEnabled: true,
+				},
+			},
+		},
+	}
+	// Satellite environments are only declared by DiscoveryConfigs of the controller namespace
+	elsewhere := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: "team-a"},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{{
+				Name:          "store",
+				Provider:      cluster.ProviderSatellite,
+				Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "store-1"},
+				DeployEnabled: true,
+			}},
+		},
+	}
+	valid := satelliteDeployment("valid", "edge", "1.0.0")
+	missing := satelliteDeployment("missing", "edge", "2.0.0")
+	direct := satelliteDeployment("direct", "prod", "1.0.0")
+	unconfigured := satelliteDeployment("unconfigured", "edge", "3.0.0")
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&agentregistryv1alpha1.MCPServerCatalog{}, IndexMCPServerName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.MCPServerCatalog).Spec.Name}
+		}).
+		WithObjects(server, secured, dc, elsewhere, valid, missing, direct, unconfigured).
+		WithStatusSubresource(&agentregistryv1alpha1.RegistryDeployment{}, &agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+	hub := NewSatelliteHub(c, scheme, zerolog.Nop())
+	ctx := context.Background()
+
+	t.Run("deployments of the environment are resolved", func(t *testing.T) {
+		list, err := hub.Deployments(ctx, "edge")
+		require.NoError(t, err)
+		require.Len(t, list.Deployments, 3, "deployments to directly reached environments are left out")
+
+		byName := make(map[string]SatelliteDeployment)
+		for _, d := range list.Deployments {
+			byName[d.Name] = d
+		}
+		resolved := byName["valid"]
+		assert.Equal(t, int64(2), resolved.Generation)
+		assert.Empty(t, resolved.Error)
+		require.NotNil(t, resolved.DesiredState)
+		require.Len(t, resolved.DesiredState.MCPServers, 1)
+		assert.Equal(t, "api.example.com", resolved.DesiredState.MCPServers[0].Remote.Host)
+		assert.Contains(t, byName["missing"].Error, "version 2.0.0 not found")
+		assert.Contains(t, byName["unconfigured"].Error, "preflight checks failed: missing required config Authorization (header)")
+		assert.Nil(t, byName["unconfigured"].DesiredState)
+	})
+
+	t.Run("undeclared environments are not found", func(t *testing.T) {
+		_, err := hub.Deployments(ctx, "edeg")
+		assert.True(t, apierrors.IsNotFound(err), "got %v", err)
+		_, err = hub.Deployments(ctx, "prod")
+		assert.True(t, apierrors.IsNotFound(err), "got %v", err)
+		_, err = hub.Deployments(ctx, "store")
+		assert.True(t, apierrors.IsNotFound(err), "got %v", err)
+	})
+
+	t.Run("reconcile waits for the satellite", func(t *testing.T) {
+		r := &RegistryDeploymentReconciler{Client: c, Scheme: scheme, Logger: zerolog.Nop()}
+		key := types.NamespacedName{Name: "valid", Namespace: testNamespace}
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		require.NoError(t, err)
+
+		var updated agentregistryv1alpha1.RegistryDeployment
+		require.NoError(t, c.Get(ctx, key, &updated))
+		assert.Equal(t, agentregistryv1alpha1.DeploymentPhasePending, updated.Status.Phase)
+		assert.Contains(t, updated.Status.Message, `satellite of environment "edge"`)
+		assert.Empty(t, updated.Status.ManagedResources)
+		require.Len(t, updated.Status.Conditions, 1)
+		assert.Equal(t, "PreflightPassed", updated.Status.Conditions[0].Reason)
+	})
+
+	t.Run("reconcile runs the preflight checks", func(t *testing.T) {
+		r := &RegistryDeploymentReconciler{Client: c, Scheme: scheme, Logger: zerolog.Nop()}
+		key := client.ObjectKeyFromObject(unconfigured)
+		result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+		require.NoError(t, err)
+		assert.Equal(t, preflightRetryInterval, result.RequeueAfter)
+
+		var updated agentregistryv1alpha1.RegistryDeployment
+		require.NoError(t, c.Get(ctx, key, &updated))
+		assert.Equal(t, agentregistryv1alpha1.DeploymentPhaseFailed, updated.Status.Phase)
+		assert.Contains(t, updated.Status.Message, "missing required config Authorization (header)")
+		require.Len(t, updated.Status.Conditions, 1)
+		assert.Equal(t, agentregistryv1alpha1.CatalogConditionPreflight, updated.Status.Conditions[0].Type)
+		assert.Equal(t, metav1.ConditionFalse, updated.Status.Conditions[0].Status)
+		assert.Equal(t, "PreflightFailed", updated.Status.Conditions[0].Reason)
+	})
+
+	t.Run("reported status is recorded", func(t *testing.T) {
+		managed := []agentregistryv1alpha1.ManagedResource{{
+			APIVersion: "kagent.dev/v1alpha2",
+			Kind:       "RemoteMCPServer",
+			Name:       "test-server",
+			Namespace:  "tools",
+		}}
+		require.NoError(t, hub.UpdateStatus(ctx, "edge", testNamespace, "valid", &SatelliteDeploymentStatus{
+			ObservedGeneration: 2,
+			Phase:              agentregistryv1alpha1.DeploymentPhaseRunning,
+			ManagedResources:   managed,
+		}))
+
+		var updated agentregistryv1alpha1.RegistryDeployment
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(valid), &updated))
+		assert.Equal(t, agentregistryv1alpha1.DeploymentPhaseRunning, updated.Status.Phase)
+		assert.Equal(t, int64(2), updated.Status.ObservedGeneration)
+		require.Len(t, updated.Status.ManagedResources, 1)
+		assert.Equal(t, "edge-1", updated.Status.ManagedResources[0].Cluster)
+
+		require.NoError(t, hub.UpdateStatus(ctx, "edge", testNamespace, "valid", &SatelliteDeploymentStatus{
+			ObservedGeneration: 2,
+			Phase:              agentregistryv1alpha1.DeploymentPhaseFailed,
+			Message:            "apply failed",
+		}))
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(valid), &updated))
+		assert.Equal(t, "apply failed", updated.Status.Message)
+		assert.Len(t, updated.Status.ManagedResources, 1, "failed reports keep the applied resources")
+	})
+
+	t.Run("status of other deployments is rejected", func(t *testing.T) {
+		err := hub.UpdateStatus(ctx, "edge", testNamespace, "direct", &SatelliteDeploymentStatus{})
+		assert.True(t, apierrors.IsConflict(err), "got %v", err)
+		err = hub.UpdateStatus(ctx, "edge", testNamespace, "gone", &SatelliteDeploymentStatus{})
+		assert.True(t, apierrors.IsNotFound(err), "got %v", err)
+	})
+
+	t.Run("deletion leaves cleanup to the satellite", func(t *testing.T) {
+		r := &RegistryDeploymentReconciler{Client: c, Scheme: scheme, Logger: zerolog.Nop()}
+		var deployment agentregistryv1alpha1.RegistryDeployment
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(valid), &deployment))
+		_, err := r.handleDeletion(ctx, &deployment)
+		require.NoError(t, err)
+		assert.NotContains(t, deployment.Finalizers, finalizerName)
+	})
+}
+
+func TestSatellite_Sync(t *testing.T) {
+	scheme := satelliteTestScheme(t)
+	stale := &kagentv1alpha2.RemoteMCPServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "old-server",
+			Namespace: "tools",
+			Labels: map[string]string{
+				managedByLabel:      "agentregistry",
+				deploymentNameLabel: "removed",
+				deploymentNSLabel:   testNamespace,
+			},
+		},
+	}
+	unmanaged := &kagentv1alpha2.RemoteMCPServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "hand-made", Namespace: "tools"},
+	}
+	local := fake.NewClientBuilder().WithScheme(scheme).WithObjects(stale, unmanaged).Build()
+
+	list := SatelliteDeploymentList{Deployments: []SatelliteDeployment{
+		{
+			Namespace:  testNamespace,
+			Name:       "valid",
+			Generation: 3,
+			DesiredState: &api.DesiredState{MCPServers: []*api.MCPServer{{
+				Name:          "test-server",
+				MCPServerType: api.MCPServerTypeRemote,
+				Namespace:     "tools",
+				Remote:        &api.RemoteMCPServer{Host: "api.example.com", Port: 443, Path: "/mcp"},
+			}}},
+		},
+		{Namespace: testNamespace, Name: "broken", Generation: 1, Error: "MCP server test-server version 2.0.0 not found"},
+	}}
+	var mu sync.Mutex
+	reports := make(map[string]SatelliteDeploymentStatus)
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/admin/v0/satellite/environments/edge/deployments":
+			_ = json.NewEncoder(w).Encode(list)
+		case req.Method == http.MethodPut:
+			var status SatelliteDeploymentStatus
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&status))
+			mu.Lock()
+			reports[req.URL.Path] = status
+			mu.Unlock()
+			_, _ = w.Write([]byte("{}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer registry.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret-token\n"), 0o600))
+	s := &Satellite{
+		Client:      local,
+		Scheme:      scheme,
+		Logger:      zerolog.Nop(),
+		RegistryURL: registry.URL + "/",
+		Environment: "edge",
+		TokenFile:   tokenFile,
+	}
+	ctx := context.Background()
+	require.NoError(t, s.sync(ctx))
+
+	var applied kagentv1alpha2.RemoteMCPServer
+	require.NoError(t, local.Get(ctx, client.ObjectKey{Namespace: "tools", Name: "test-server"}, &applied))
+	assert.Equal(t, "valid", applied.Labels[deploymentNameLabel])
+	assert.Equal(t, testNamespace, applied.Labels[deploymentNSLabel])
+
+	err := local.Get(ctx, client.ObjectKeyFromObject(stale), &kagentv1alpha2.RemoteMCPServer{})
+	assert.True(t, apierrors.IsNotFound(err), "resources of removed deployments are pruned")
+	assert.NoError(t, local.Get(ctx, client.ObjectKeyFromObject(unmanaged), &kagentv1alpha2.RemoteMCPServer{}))
+
+	status := reports["/admin/v0/satellite/environments/edge/deployments/"+testNamespace+"/valid/status"]
+	assert.Equal(t, int64(3), status.ObservedGeneration)
+	assert.Equal(t, agentregistryv1alpha1.DeploymentPhasePending, status.Phase, "the RemoteMCPServer is not ready yet")
+	require.Len(t, status.ManagedResources, 1)
+	assert.Equal(t, "RemoteMCPServer", status.ManagedResources[0].Kind)
+
+	broken := reports["/admin/v0/satellite/environments/edge/deployments/"+testNamespace+"/broken/status"]
+	assert.Equal(t, agentregistryv1alpha1.DeploymentPhaseFailed, broken.Phase)
+	assert.Contains(t, broken.Message, "not found")
+
+	t.Run("registry errors don't prune", func(t *testing.T) {
+		s.Environment = "unknown"
+		assert.Error(t, s.sync(ctx))
+		assert.NoError(t, local.Get(ctx, client.ObjectKey{Namespace: "tools", Name: "test-server"}, &applied))
+	})
+}
+
+func TestSatellite_SecretConfig(t *testing.T) {
+	scheme := satelliteTestScheme(t)
+	server := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-1-0-0", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+			Name:    "test-server",
+			Version: "1.0.0",
+			Remotes: []agentregistryv1alpha1.Transport{{
+				Type:    "streamable-http",
+				URL:     "https://api.example.com/mcp",
+				Headers: []agentregistryv1alpha1.KeyValueInput{{Name: "Authorization", Required: true}},
+			}},
+			Metadata: &apiextensionsv1.JSON{Raw: []byte(`{"io.modelcontextprotocol.registry/publisher-provided":` +
+				`{"aregistry.ai/metadata":{"identity":{"org_is_verified":true,"publisher_identity_verified_by_jwt":true}}}}`)},
+		},
+	}
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: testNamespace},
+		Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+			Environments: []agentregistryv1alpha1.Environment{{
+				Name:          "edge",
+				Provider:      cluster.ProviderSatellite,
+				Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "edge-1"},
+				DeployEnabled: true,
+			}},
+		},
+	}
+	deployment := satelliteDeployment("with-secret", "edge", "1.0.0")
+	deployment.Spec.ConfigFrom = []agentregistryv1alpha1.ConfigValueFrom{{
+		Name: "Authorization",
+		ValueFrom: agentregistryv1alpha1.ConfigValueSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "api-credentials"},
+			Key:                  "token",
+		}},
+	}}
+	hubSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-credentials", Namespace: testNamespace, Labels: map[string]string{agentregistryv1alpha1.LabelDeploymentConfig: "true"}},
+		Data:       map[string][]byte{"token": []byte("Bearer hub-secret")},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&agentregistryv1alpha1.MCPServerCatalog{}, IndexMCPServerName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.MCPServerCatalog).Spec.Name}
+		}).
+		WithObjects(server, dc, deployment, hubSecret).
+		WithStatusSubresource(&agentregistryv1alpha1.RegistryDeployment{}, &agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+	ctx := context.Background()
+
+	list, err := NewSatelliteHub(c, scheme, zerolog.Nop()).Deployments(ctx, "edge")
+	require.NoError(t, err)
+	require.Len(t, list.Deployments, 1)
+	d := list.Deployments[0]
+	require.Empty(t, d.Error)
+	body, err := json.Marshal(list)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "hub-secret", "secret values are never served")
+	assert.Equal(t, deployment.Spec.ConfigFrom, d.SecretConfig)
+
+	t.Run("the satellite reads the secret in its own cluster", func(t *testing.T) {
+		local := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-credentials", Namespace: testNamespace, Labels: map[string]string{agentregistryv1alpha1.LabelDeploymentConfig: "true"}},
+			Data:       map[string][]byte{"token": []byte("Bearer edge-secret")},
+		}).Build()
+		s := &Satellite{Client: local, Scheme: scheme, Logger: zerolog.Nop(), Environment: "edge"}
+		r := &RegistryDeploymentReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop()}
+		status := s.apply(ctx, r, d)
+		require.NotEqual(t, agentregistryv1alpha1.DeploymentPhaseFailed, status.Phase, status.Message)
+
+		name := kagent.ConfigSecretName(kagent.RemoteMCPResourceName(generateInternalName("test-server")))
+		var applied corev1.Secret
+		require.NoError(t, local.Get(ctx, client.ObjectKey{Namespace: "tools", Name: name}, &applied))
+		assert.Equal(t, "Bearer edge-secret", string(applied.Data["Authorization"])+applied.StringData["Authorization"])
+	})
+
+	t.Run("a missing secret fails the deployment", func(t *testing.T) {
+		local := fake.NewClientBuilder().WithScheme(scheme).Build()
+		s := &Satellite{Client: local, Scheme: scheme, Logger: zerolog.Nop(), Environment: "edge"}
+		r := &RegistryDeploymentReconciler{Client: local, Scheme: scheme, Logger: zerolog.Nop()}
+		status := s.apply(ctx, r, d)
+		assert.Equal(t, agentregistryv1alpha1.DeploymentPhaseFailed, status.Phase)
+		assert.Contains(t, status.Message, `failed to get secret "api-credentials"`)
+	})
+}
@@ -0,0 +1,386 @@
+// Package gitrepo reads the files committed to local git repositories, clones or bare, without
+// a git binary. Only SHA-1 repositories are supported.
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxFileSize bounds the files returned by Files; larger blobs are skipped
+	maxFileSize = 1 << 20
+	// maxObjectSize bounds the commits, trees, tags and delta bases read from a repository
+	maxObjectSize = 64 << 20
+)
+
+// ErrNotFound is returned when a ref or object does not exist in the repository
+var ErrNotFound = errors.New("not found")
+
+// errTooLarge is returned for objects whose declared size is over the limit of the read.
+// Their content is never inflated.
+var errTooLarge = errors.New("object too large")
+
+// Repository is a git repository on the local filesystem
+type Repository struct {
+	gitDir string
+	packs  []*packFile
+}
+
+// File is a file committed to a repository
+type File struct {
+	// Path is the slash-separated path of the file in the repository
+	Path string
+	Data []byte
+}
+
+// Open opens the repository at location, a filesystem path or file:// URL of a clone or a bare
+// repository. Errors do not repeat the location.
+func Open(location string) (*Repository, error) {
+	dir := location
+	if strings.Contains(location, "://") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme != "file" {
+			return nil, fmt.Errorf("unsupported repository URL scheme %q, only file:// is supported", u.Scheme)
+		}
+		dir = u.Path
+	}
+
+	gitDir, err := findGitDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo := &Repository{gitDir: gitDir}
+	if err := repo.loadPacks(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// findGitDir returns the git directory of a clone (its .git directory, or the directory a .git
+// file points to) or of a bare repository
+func findGitDir(dir string) (string, error) {
+	dotGit := filepath.Join(dir, ".git")
+	if info, err := os.Stat(dotGit); err == nil {
+		if info.IsDir() {
+			return dotGit, nil
+		}
+		// Worktrees and submodules have a .git file with the path of the git directory
+		data, err := os.ReadFile(dotGit)
+		if err != nil {
+			return "", err
+		}
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !ok {
+			return "", errors.New("unrecognized .git file")
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		return target, nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err == nil {
+		if _, err := os.Stat(filepath.Join(dir, "objects")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", errors.New("not a git repository")
+}
+
+// Resolve returns the commit a ref points to. ref may be empty or HEAD, a branch, a tag, a
+// remote branch of origin, a full ref name or a full commit hash.
+func (r *Repository) Resolve(ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	var hash string
+	if isHash(ref) {
+		hash = ref
+	} else {
+		if err := checkRefName(ref); err != nil {
+			return "", err
+		}
+		candidates := []string{ref}
+		if ref != "HEAD" && !strings.HasPrefix(ref, "refs/") {
+			candidates = []string{"refs/heads/" + ref, "refs/tags/" + ref, "refs/remotes/origin/" + ref}
+		}
+		var err error
+		for _, name := range candidates {
+			if hash, err = r.readRef(name, 0); !errors.Is(err, ErrNotFound) {
+				break
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", ref, err)
+		}
+	}
+
+	// Peel annotated tags down to the commit
+	for {
+		kind, data, err := r.readObject(hash, maxObjectSize)
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", ref, err)
+		}
+		switch kind {
+		case "commit":
+			return hash, nil
+		case "tag":
+			if hash, err = headerField(data, "object"); err != nil {
+				return "", fmt.Errorf("resolve %s: %w", ref, err)
+			}
+		default:
+			return "", fmt.Errorf("resolve %s: %s is a %s, not a commit", ref, hash, kind)
+		}
+	}
+}
+
+// readRef reads a loose or packed ref, following symbolic refs
+func (r *Repository) readRef(name string, depth int) (string, error) {
+	if depth > 5 {
+		return "", fmt.Errorf("ref %s: too many levels of symbolic refs", name)
+	}
+	// Symbolic refs are read from the repository, so their targets are checked too
+	if err := checkRefName(name); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(r.gitDir, filepath.FromSlash(name)))
+	if err == nil {
+		value := strings.TrimSpace(string(data))
+		if target, ok := strings.CutPrefix(value, "ref: "); ok {
+			return r.readRef(target, depth+1)
+		}
+		if !isHash(value) {
+			// The value is not repeated, as the file may not be a ref
+			return "", fmt.Errorf("ref %s: invalid value", name)
+		}
+		return value, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	packed, err := os.ReadFile(filepath.Join(r.gitDir, "packed-refs"))
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(packed), "\n") {
+		if hash, refName, ok := strings.Cut(line, " "); ok && refName == name && isHash(hash) {
+			return hash, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// checkRefName checks a ref name with the rules of git check-ref-format, so a name never
+// reads a file outside the refs of the repository. One-level names such as HEAD and main are
+// allowed.
+func checkRefName(name string) error {
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid ref name %q: %s", name, reason)
+	}
+	switch {
+	case name == "" || name == "@":
+		return invalid("empty or @")
+	case strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") || strings.Contains(name, "//"):
+		return invalid("empty path component")
+	case strings.HasSuffix(name, "."):
+		return invalid("ends with a dot")
+	case strings.Contains(name, ".."):
+		return invalid("contains ..")
+	case strings.Contains(name, "@{"):
+		return invalid("contains @{")
+	}
+	for _, c := range name {
+		if c < 0x20 || c == 0x7f || strings.ContainsRune(" ~^:?*[\\", c) {
+			return invalid(fmt.Sprintf("contains %q", c))
+		}
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return invalid("path component starts with a dot or ends with .lock")
+		}
+	}
+	return nil
+}
+
+// Files returns the files of a commit whose path satisfies match. Symlinks, submodules and
+// files over 1 MiB are skipped.
+func (r *Repository) Files(commit string, match func(path string) bool) ([]File, error) {
+	kind, data, err := r.readObject(commit, maxObjectSize)
+	if err != nil {
+		return nil, err
+	}
+	if kind != "commit" {
+		return nil, fmt.Errorf("%s is a %s, not a commit", commit, kind)
+	}
+	tree, err := headerField(data, "tree")
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	if err := r.walkTree(tree, "", match, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// walkTree collects the matching blobs of a tree and its subtrees
+func (r *Repository) walkTree(hash, dir string, match func(string) bool, files *[]File) error {
+	kind, data, err := r.readObject(hash, maxObjectSize)
+	if err != nil {
+		return err
+	}
+	if kind != "tree" {
+		return fmt.Errorf("%s is a %s, not a tree", hash, kind)
+	}
+	for len(data) > 0 {
+		space := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if space < 0 || nul < space || len(data) < nul+21 {
+			return fmt.Errorf("tree %s: malformed entry", hash)
+		}
+		mode := string(data[:space])
+		name := path.Join(dir, string(data[space+1:nul]))
+		entry := hex.EncodeToString(data[nul+1 : nul+21])
+		data = data[nul+21:]
+
+		switch mode {
+		case "40000":
+			if err := r.walkTree(entry, name, match, files); err != nil {
+				return err
+			}
+		case "100644", "100755", "100664":
+			if !match(name) {
+				continue
+			}
+			kind, content, err := r.readObject(entry, maxFileSize)
+			if errors.Is(err, errTooLarge) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if kind == "blob" {
+				*files = append(*files, File{Path: name, Data: content})
+			}
+		}
+	}
+	return nil
+}
+
+// readObject returns the type and content of an object, loose or packed. Objects over limit
+// bytes return errTooLarge.
+func (r *Repository) readObject(hash string, limit uint64) (string, []byte, error) {
+	return r.readObjectDepth(hash, limit, 0)
+}
+
+// readObjectDepth reads an object that is depth deltas down a delta chain, so chains that
+// continue in another pack are bounded as well
+func (r *Repository) readObjectDepth(hash string, limit uint64, depth int) (string, []byte, error) {
+	if depth > maxDeltaDepth {
+		return "", nil, errors.New("delta chain too deep")
+	}
+	if !isHash(hash) {
+		return "", nil, fmt.Errorf("invalid object id %q", hash)
+	}
+	f, err := os.Open(filepath.Join(r.gitDir, "objects", hash[:2], hash[2:]))
+	if err == nil {
+		defer f.Close()
+		return readLooseObject(f, limit)
+	}
+	if !os.IsNotExist(err) {
+		return "", nil, err
+	}
+
+	raw, err := hex.DecodeString(hash)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, p := range r.packs {
+		if offset, ok := p.find(raw); ok {
+			return p.readAt(r, offset, limit, depth)
+		}
+	}
+	return "", nil, fmt.Errorf("object %s: %w", hash, ErrNotFound)
+}
+
+// readLooseObject decodes a zlib-compressed "type size\0content" object. The header is read
+// first, so objects over limit bytes are rejected before their content is inflated.
+func readLooseObject(f io.Reader, limit uint64) (string, []byte, error) {
+	z, err := zlib.NewReader(f)
+	if err != nil {
+		return "", nil, err
+	}
+	defer z.Close()
+	// The header is read a byte at a time so no content is consumed past the NUL
+	var header []byte
+	b := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(z, b); err != nil || len(header) > 32 {
+			return "", nil, errors.New("malformed loose object")
+		}
+		if b[0] == 0 {
+			break
+		}
+		header = append(header, b[0])
+	}
+	kind, size, ok := strings.Cut(string(header), " ")
+	if !ok {
+		return "", nil, errors.New("malformed loose object header")
+	}
+	n, err := strconv.ParseUint(size, 10, 64)
+	if err != nil {
+		return "", nil, errors.New("malformed loose object header")
+	}
+	if n > limit {
+		return "", nil, errTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(z, int64(n)+1))
+	if err != nil {
+		return "", nil, err
+	}
+	if uint64(len(data)) != n {
+		return "", nil, errors.New("loose object size mismatch")
+	}
+	return kind, data, nil
+}
+
+// headerField returns the value of the first "name value" header line of a commit or tag
+func headerField(data []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, name+" "); ok {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("object has no %s header", name)
+}
+
+// isHash reports whether s is a full SHA-1 object id
+func isHash(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,234 @@
+package gitrepo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// newTestRepo creates a clone with two commits on main and an annotated tag on the first
+func newTestRepo(t *testing.T) (dir, first, second string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir = t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+
+	large := strings.Repeat("line of a file that compresses into deltas\n", 200)
+	writeFile(t, filepath.Join(dir, ".vscode", "mcp.json"), `{"servers": {}}`)
+	writeFile(t, filepath.Join(dir, "docs", "large.txt"), large)
+	writeFile(t, filepath.Join(dir, "README.md"), "# test\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "first")
+	first = runGit(t, dir, "rev-parse", "HEAD")
+	runGit(t, dir, "tag", "-a", "v1", "-m", "v1")
+
+	writeFile(t, filepath.Join(dir, ".vscode", "mcp.json"), `{"servers": {"github": {}}}`)
+	writeFile(t, filepath.Join(dir, "docs", "large.txt"), large+"one more line\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "second")
+	second = runGit(t, dir, "rev-parse", "HEAD")
+	return dir, first, second
+}
+
+func fileMap(files []File) map[string]string {
+	m := make(map[string]string, len(files))
+	for _, f := range files {
+		m[f.Path] = string(f.Data)
+	}
+	return m
+}
+
+func TestRepository_LooseObjects(t *testing.T) {
+	dir, first, second := newTestRepo(t)
+
+	repo, err := Open(dir)
+	require.NoError(t, err)
+
+	for ref, want := range map[string]string{"": second, "HEAD": second, "main": second, "refs/heads/main": second, "v1": first, first: first} {
+		got, err := repo.Resolve(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, want, got, ref)
+	}
+	_, err = repo.Resolve("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	for _, ref := range []string{"../config", "refs/heads/../../config", "/etc/passwd", "main.lock", ".git", "a b", "main@{1}", "refs//heads"} {
+		_, err = repo.Resolve(ref)
+		assert.ErrorContains(t, err, "invalid ref name", ref)
+	}
+
+	// Files that are not refs are not echoed back
+	writeFile(t, filepath.Join(dir, ".git", "refs", "heads", "notes"), "not a hash")
+	_, err = repo.Resolve("notes")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "not a hash")
+
+	files, err := repo.Files(second, func(path string) bool { return strings.HasSuffix(path, ".json") })
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{".vscode/mcp.json": `{"servers": {"github": {}}}`}, fileMap(files))
+
+	files, err = repo.Files(first, func(string) bool { return true })
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+	assert.Equal(t, `{"servers": {}}`, fileMap(files)[".vscode/mcp.json"])
+}
+
+func TestRepository_PackedBare(t *testing.T) {
+	dir, first, second := newTestRepo(t)
+	bare := filepath.Join(t.TempDir(), "repo.git")
+	runGit(t, dir, "clone", "-q", "--bare", dir, bare)
+	runGit(t, bare, "gc", "-q", "--aggressive")
+	entries, err := os.ReadDir(filepath.Join(bare, "objects", "pack"))
+	require.NoError(t, err)
+	require.NotEmpty(t, entries, "objects are packed")
+
+	repo, err := Open("file://" + bare)
+	require.NoError(t, err)
+
+	head, err := repo.Resolve("main")
+	require.NoError(t, err)
+	assert.Equal(t, second, head)
+	tagged, err := repo.Resolve("v1")
+	require.NoError(t, err)
+	assert.Equal(t, first, tagged)
+
+	files, err := repo.Files(head, func(string) bool { return true })
+	require.NoError(t, err)
+	got := fileMap(files)
+	assert.Len(t, got, 3)
+	assert.True(t, strings.HasSuffix(got["docs/large.txt"], "one more line\n"))
+
+	files, err = repo.Files(first, func(path string) bool { return path == "docs/large.txt" })
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.False(t, strings.HasSuffix(string(files[0].Data), "one more line\n"))
+}
+
+func TestRepository_LargeFilesSkipped(t *testing.T) {
+	dir, _, _ := newTestRepo(t)
+	// Compresses to a few KiB, so only the declared size shows it is over the limit
+	writeFile(t, filepath.Join(dir, "docs", "huge.txt"), strings.Repeat("x", maxFileSize+1))
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "huge")
+	head := runGit(t, dir, "rev-parse", "HEAD")
+	bare := filepath.Join(t.TempDir(), "repo.git")
+	runGit(t, dir, "clone", "-q", "--bare", dir, bare)
+	runGit(t, bare, "gc", "-q")
+
+	for _, location := range []string{dir, bare} {
+		repo, err := Open(location)
+		require.NoError(t, err)
+		files, err := repo.Files(head, func(string) bool { return true })
+		require.NoError(t, err, location)
+		got := fileMap(files)
+		assert.Len(t, got, 3, location)
+		assert.NotContains(t, got, "docs/huge.txt", location)
+	}
+}
+
+func TestApplyDelta_Bounds(t *testing.T) {
+	// Base size 0 and a declared result size of 1 TiB
+	_, err := applyDelta(nil, []byte{0x00, 0x80, 0x80, 0x80, 0x80, 0x80, 0x20}, maxFileSize)
+	assert.ErrorIs(t, err, errTooLarge)
+
+	// Base size 0, result size 2 and an insert of 3 bytes
+	_, err = applyDelta(nil, []byte{0x00, 0x02, 0x03, 'a', 'b', 'c'}, maxFileSize)
+	assert.ErrorContains(t, err, "out of range")
+
+	got, err := applyDelta([]byte("base"), []byte{0x04, 0x06, 0x90, 0x04, 0x02, '!', '!'}, maxFileSize)
+	require.NoError(t, err)
+	assert.Equal(t, "base!!", string(got))
+}
+
+// writeRefDeltaPack writes a pack and version 2 index holding a single object, id, stored as
+// a delta against base
+func writeRefDeltaPack(t *testing.T, gitDir, name string, id, base []byte) {
+	t.Helper()
+	// Base size 4, result size 4, copy all 4 bytes of the base
+	delta := []byte{0x04, 0x04, 0x90, 0x04}
+	var compressed bytes.Buffer
+	z := zlib.NewWriter(&compressed)
+	_, err := z.Write(delta)
+	require.NoError(t, err)
+	require.NoError(t, z.Close())
+
+	var pack bytes.Buffer
+	pack.WriteString("PACK")
+	require.NoError(t, binary.Write(&pack, binary.BigEndian, [2]uint32{2, 1}))
+	pack.WriteByte(objRefDelta<<4 | byte(len(delta)))
+	pack.Write(base)
+	pack.Write(compressed.Bytes())
+	pack.Write(make([]byte, 20)) // checksum, not verified
+
+	var idx bytes.Buffer
+	idx.Write([]byte{0xff, 't', 'O', 'c', 0, 0, 0, 2})
+	for i := 0; i < 256; i++ {
+		var count uint32
+		if i >= int(id[0]) {
+			count = 1
+		}
+		require.NoError(t, binary.Write(&idx, binary.BigEndian, count))
+	}
+	idx.Write(id)
+	idx.Write(make([]byte, 4)) // CRC32, not verified
+	require.NoError(t, binary.Write(&idx, binary.BigEndian, uint32(12)))
+	idx.Write(make([]byte, 40)) // checksums, not verified
+
+	dir := filepath.Join(gitDir, "objects", "pack")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pack"), pack.Bytes(), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".idx"), idx.Bytes(), 0o644))
+}
+
+func TestRepository_CyclicDeltasAcrossPacks(t *testing.T) {
+	// Each pack holds a delta whose base is the object in the other pack
+	first := bytes.Repeat([]byte{0x11}, 20)
+	second := bytes.Repeat([]byte{0x22}, 20)
+	gitDir := t.TempDir()
+	writeRefDeltaPack(t, gitDir, "pack-a", first, second)
+	writeRefDeltaPack(t, gitDir, "pack-b", second, first)
+
+	repo := &Repository{gitDir: gitDir}
+	require.NoError(t, repo.loadPacks())
+	require.Len(t, repo.packs, 2)
+
+	_, _, err := repo.readObject(hex.EncodeToString(first), maxFileSize)
+	assert.ErrorContains(t, err, "delta chain too deep")
+}
+
+func TestOpen_NotARepository(t *testing.T) {
+	_, err := Open(t.TempDir())
+	assert.Error(t, err)
+	_, err = Open("https://github.com/example/repo.git")
+	assert.ErrorContains(t, err, "only file://")
+}
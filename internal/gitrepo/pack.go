@@ -0,0 +1,300 @@
+package gitrepo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Packed object types
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+// maxDeltaDepth bounds delta chains so a corrupt pack cannot recurse forever
+const maxDeltaDepth = 64
+
+var packTypeNames = map[int]string{objCommit: "commit", objTree: "tree", objBlob: "blob", objTag: "tag"}
+
+// packFile is a packfile with its version 2 index loaded into memory
+type packFile struct {
+	path    string
+	ids     [][]byte
+	offsets []uint64
+}
+
+// loadPacks loads the index of every packfile of the repository
+func (r *Repository) loadPacks() error {
+	indexes, err := filepath.Glob(filepath.Join(r.gitDir, "objects", "pack", "*.idx"))
+	if err != nil {
+		return err
+	}
+	for _, idx := range indexes {
+		p, err := loadPackIndex(idx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(idx), err)
+		}
+		r.packs = append(r.packs, p)
+	}
+	return nil
+}
+
+// loadPackIndex reads a version 2 pack index
+func loadPackIndex(path string) (*packFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	const header = 8 + 256*4
+	if len(data) < header || !bytes.Equal(data[:8], []byte{0xff, 't', 'O', 'c', 0, 0, 0, 2}) {
+		return nil, errors.New("unsupported pack index version")
+	}
+	count := int(binary.BigEndian.Uint32(data[8+255*4:]))
+	idsAt := header
+	offsetsAt := idsAt + count*20 + count*4
+	largeAt := offsetsAt + count*4
+	if len(data) < largeAt {
+		return nil, errors.New("truncated pack index")
+	}
+
+	p := &packFile{
+		path:    strings.TrimSuffix(path, ".idx") + ".pack",
+		ids:     make([][]byte, count),
+		offsets: make([]uint64, count),
+	}
+	for i := 0; i < count; i++ {
+		p.ids[i] = data[idsAt+i*20 : idsAt+(i+1)*20]
+		offset := binary.BigEndian.Uint32(data[offsetsAt+i*4:])
+		if offset&0x80000000 == 0 {
+			p.offsets[i] = uint64(offset)
+			continue
+		}
+		// The offset is an index into the table of 64-bit offsets
+		at := largeAt + int(offset&0x7fffffff)*8
+		if len(data) < at+8 {
+			return nil, errors.New("truncated pack index")
+		}
+		p.offsets[i] = binary.BigEndian.Uint64(data[at:])
+	}
+	return p, nil
+}
+
+// find returns the offset of an object in the pack
+func (p *packFile) find(id []byte) (uint64, bool) {
+	i := sort.Search(len(p.ids), func(i int) bool { return bytes.Compare(p.ids[i], id) >= 0 })
+	if i < len(p.ids) && bytes.Equal(p.ids[i], id) {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// readAt returns the type and content of the object at offset, applying deltas. Objects over
+// limit bytes return errTooLarge. depth is the position of the object in a delta chain.
+func (p *packFile) readAt(r *Repository, offset, limit uint64, depth int) (string, []byte, error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	return p.readEntry(r, f, offset, limit, depth)
+}
+
+// readEntry reads the object at offset. Declared sizes are checked against limit, and delta
+// bases against maxObjectSize, before anything is inflated or allocated.
+func (p *packFile) readEntry(r *Repository, f *os.File, offset, limit uint64, depth int) (string, []byte, error) {
+	if depth > maxDeltaDepth {
+		return "", nil, errors.New("delta chain too deep")
+	}
+	rd := &byteReader{r: io.NewSectionReader(f, int64(offset), 1<<62)}
+
+	// Type and size header: 3 type bits and a variable-length size
+	b, err := rd.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	kind := int(b>>4) & 7
+	size := uint64(b & 0x0f)
+	for shift := 4; b&0x80 != 0; shift += 7 {
+		if b, err = rd.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		size |= uint64(b&0x7f) << shift
+	}
+	// The size of a delta is not that of its result, which applyDelta checks against limit
+	if size > maxObjectSize || (kind != objOfsDelta && kind != objRefDelta && size > limit) {
+		return "", nil, errTooLarge
+	}
+
+	var baseKind string
+	var base []byte
+	switch kind {
+	case objCommit, objTree, objBlob, objTag:
+	case objOfsDelta:
+		// The base is at a negative offset encoded with git's offset varint
+		b, err := rd.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		distance := uint64(b & 0x7f)
+		for b&0x80 != 0 {
+			if b, err = rd.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			distance = (distance+1)<<7 | uint64(b&0x7f)
+		}
+		if distance > offset {
+			return "", nil, errors.New("invalid delta base offset")
+		}
+		if baseKind, base, err = p.readEntry(r, f, offset-distance, maxObjectSize, depth+1); err != nil {
+			return "", nil, err
+		}
+	case objRefDelta:
+		id := make([]byte, 20)
+		if _, err := io.ReadFull(rd, id); err != nil {
+			return "", nil, err
+		}
+		if baseOffset, ok := p.find(id); ok {
+			baseKind, base, err = p.readEntry(r, f, baseOffset, maxObjectSize, depth+1)
+		} else {
+			baseKind, base, err = r.readObjectDepth(hex.EncodeToString(id), maxObjectSize, depth+1)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	default:
+		return "", nil, fmt.Errorf("unsupported pack object type %d", kind)
+	}
+
+	z, err := zlib.NewReader(rd)
+	if err != nil {
+		return "", nil, err
+	}
+	defer z.Close()
+	data, err := io.ReadAll(io.LimitReader(z, int64(size)+1))
+	if err != nil {
+		return "", nil, err
+	}
+	if uint64(len(data)) != size {
+		return "", nil, errors.New("pack object size mismatch")
+	}
+
+	if base == nil {
+		return packTypeNames[kind], data, nil
+	}
+	patched, err := applyDelta(base, data, limit)
+	if err != nil {
+		return "", nil, err
+	}
+	return baseKind, patched, nil
+}
+
+// applyDelta applies a git delta to its base object. Results over limit bytes return
+// errTooLarge before the result is allocated.
+func applyDelta(base, delta []byte, limit uint64) ([]byte, error) {
+	rd := bytes.NewReader(delta)
+	readSize := func() (uint64, error) {
+		var size uint64
+		for shift := 0; ; shift += 7 {
+			b, err := rd.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+			size |= uint64(b&0x7f) << shift
+			if b&0x80 == 0 {
+				return size, nil
+			}
+		}
+	}
+	baseSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != uint64(len(base)) {
+		return nil, errors.New("delta base size mismatch")
+	}
+	resultSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+	if resultSize > limit {
+		return nil, errTooLarge
+	}
+
+	out := make([]byte, 0, resultSize)
+	for rd.Len() > 0 {
+		op, _ := rd.ReadByte()
+		if op&0x80 != 0 {
+			// Copy from the base: the low bits select which offset and size bytes follow
+			var offset, n uint64
+			for i := 0; i < 4; i++ {
+				if op&(1<<i) != 0 {
+					b, err := rd.ReadByte()
+					if err != nil {
+						return nil, err
+					}
+					offset |= uint64(b) << (8 * i)
+				}
+			}
+			for i := 0; i < 3; i++ {
+				if op&(0x10<<i) != 0 {
+					b, err := rd.ReadByte()
+					if err != nil {
+						return nil, err
+					}
+					n |= uint64(b) << (8 * i)
+				}
+			}
+			if n == 0 {
+				n = 0x10000
+			}
+			if offset+n > uint64(len(base)) || uint64(len(out))+n > resultSize {
+				return nil, errors.New("delta copy out of range")
+			}
+			out = append(out, base[offset:offset+n]...)
+		} else if op != 0 {
+			// Insert the next op bytes of the delta
+			if uint64(len(out))+uint64(op) > resultSize {
+				return nil, errors.New("delta insert out of range")
+			}
+			insert := make([]byte, op)
+			if _, err := io.ReadFull(rd, insert); err != nil {
+				return nil, err
+			}
+			out = append(out, insert...)
+		} else {
+			return nil, errors.New("invalid delta opcode")
+		}
+	}
+	if uint64(len(out)) != resultSize {
+		return nil, errors.New("delta result size mismatch")
+	}
+	return out, nil
+}
+
+// byteReader adds io.ByteReader to a reader so zlib does not read ahead past the object
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (b *byteReader) Read(p []byte) (int, error) { return b.r.Read(p) }
+
+func (b *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(b.r, b.buf[:]); err != nil {
+		return 0, err
+	}
+	return b.buf[0], nil
+}
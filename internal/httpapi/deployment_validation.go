@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/cluster"
+	"github.com/agentregistry-dev/agentregistry/internal/controller"
+)
+
+// WithDeploymentValidator enables the deployment validation endpoint
+func WithDeploymentValidator(validator *controller.DeploymentValidator) ServerOption {
+	return func(s *Server) {
+		s.deploymentValidator = validator
+	}
+}
+
+type ValidateDeploymentInput struct {
+	Body agentregistryv1alpha1.RegistryDeploymentSpec
+}
+
+type ValidateDeploymentResponse struct {
+	Body controller.PreflightResult
+}
+
+// registerDeploymentValidationRoutes registers the deployment validation endpoint. It is a
+// write under /admin/v0/deployments, so it requires the same access as creating a deployment.
+func (s *Server) registerDeploymentValidationRoutes() {
+	if s.deploymentValidator == nil {
+		return
+	}
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "admin-validate-deployment",
+		Method:      http.MethodPost,
+		Path:        "/admin/v0/deployments/validate",
+		Summary:     "Run the preflight checks of a deployment",
+		Description: "Runs the checks the controller runs before applying a deployment: the catalog entry exists, " +
+			"every required input has a value, the package transport is supported, and the target namespace and " +
+			"kagent/kmcp CRDs exist in the target cluster. Nothing is written.",
+		Tags: []string{"admin", "deployments"},
+	}, func(ctx context.Context, input *ValidateDeploymentInput) (*ValidateDeploymentResponse, error) {
+		result, err := s.deploymentValidator.Validate(ctx, &input.Body)
+		var circuitOpen *cluster.CircuitOpenError
+		switch {
+		case apierrors.IsBadRequest(err):
+			return nil, huma.Error400BadRequest(err.Error())
+		case errors.As(err, &circuitOpen):
+			return nil, huma.Error503ServiceUnavailable(err.Error())
+		case err != nil:
+			return nil, huma.Error500InternalServerError("Failed to validate deployment", err)
+		}
+		return &ValidateDeploymentResponse{Body: *result}, nil
+	})
+}
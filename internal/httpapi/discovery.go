@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/controller"
+)
+
+// WithDiscoveryPreviewer enables the discovery preview endpoint
+func WithDiscoveryPreviewer(previewer *controller.DiscoveryPreviewer) ServerOption {
+	return func(s *Server) {
+		s.discoveryPreviewer = previewer
+	}
+}
+
+type DiscoveryPreviewInput struct {
+	Name string `query:"name" doc:"Existing DiscoveryConfig the spec would replace; its entries the spec would remove are listed under prune"`
+	Body agentregistryv1alpha1.DiscoveryConfigSpec
+}
+
+type DiscoveryPreviewResponse struct {
+	Body controller.DiscoveryPreview
+}
+
+// registerDiscoveryRoutes registers the discovery preview endpoint. The preview connects to
+// clusters with the controller's credentials, so it is restricted to the admin group.
+func (s *Server) registerDiscoveryRoutes() {
+	if s.discoveryPreviewer == nil {
+		return
+	}
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "admin-preview-discovery",
+		Method:      http.MethodPost,
+		Path:        "/admin/v0/discovery/preview",
+		Summary:     "Preview the catalog entries a DiscoveryConfig would produce",
+		Description: "Connects to each environment once, lists the resources the spec selects and returns the " +
+			"catalog entries that would be created, updated or pruned. Connection and permission errors are " +
+			"reported per environment. Nothing is written.",
+		Tags:        []string{"admin", "discovery"},
+		Middlewares: huma.Middlewares{s.adminGroupMiddleware},
+	}, func(ctx context.Context, input *DiscoveryPreviewInput) (*DiscoveryPreviewResponse, error) {
+		preview, err := s.discoveryPreviewer.Preview(ctx, input.Name, &input.Body)
+		switch {
+		case apierrors.IsBadRequest(err):
+			return nil, huma.Error400BadRequest(err.Error())
+		case err != nil:
+			return nil, huma.Error500InternalServerError("Failed to preview discovery", err)
+		}
+		return &DiscoveryPreviewResponse{Body: *preview}, nil
+	})
+}
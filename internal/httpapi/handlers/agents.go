@@ -106,6 +106,10 @@ type AgentMeta struct {
 	Deployment        *DeploymentInfo        `json:"deployment,omitempty"`
 	Source            string                 `json:"source,omitempty"` // discovery, manual, deployment
 	IsDiscovered      bool                   `json:"isDiscovered,omitempty"`
+	Environment       string                 `json:"environment,omitempty"`
+	Cluster           string                 `json:"cluster,omitempty"`
+	UnresolvedTools   []AgentToolRefJSON     `json:"unresolvedTools,omitempty"`
+	Instances         []InstanceJSON         `json:"instances,omitempty"`
 }
 
 type AgentResponse struct {
@@ -120,14 +124,16 @@ type AgentListResponse struct {
 
 // Input types
 type ListAgentsInput struct {
-	Cursor  string `query:"cursor" json:"cursor,omitempty"`
-	Limit   int    `query:"limit" json:"limit,omitempty" default:"30" minimum:"1" maximum:"100"`
-	Search  string `query:"search" json:"search,omitempty"`
-	Version string `query:"version" json:"version,omitempty"`
+	Cursor      string `query:"cursor" json:"cursor,omitempty"`
+	Limit       int    `query:"limit" json:"limit,omitempty" default:"30" minimum:"1" maximum:"100"`
+	Search      string `query:"search" json:"search,omitempty"`
+	Version     string `query:"version" json:"version,omitempty"`
+	Environment string `query:"environment" json:"environment,omitempty"`
 }
 
 type AgentDetailInput struct {
-	AgentName string `path:"agentName" json:"agentName"`
+	AgentName   string `path:"agentName" json:"agentName"`
+	Environment string `query:"environment" json:"environment,omitempty"`
 }
 
 type AgentVersionDetailInput struct {
@@ -248,6 +254,10 @@ func (h *AgentHandler) listAgents(ctx context.Context, input *ListAgentsInput, i
 			continue
 		}
 
+		if !InEnvironment(&a, input.Environment) {
+			continue
+		}
+
 		// Get deployment status for this agent
 		key := a.Spec.Name + "/" + a.Spec.Version
 		deployment := deploymentMap[key]
@@ -316,11 +326,11 @@ func (h *AgentHandler) getAgent(ctx context.Context, input *AgentDetailInput, is
 		return nil, huma.Error400BadRequest("Invalid agent name encoding", err)
 	}
 
+	// List every entry of this agent so per-environment instances can be reported
 	var agentList agentregistryv1alpha1.AgentCatalogList
 	listOpts := []client.ListOption{
 		client.MatchingFields{
-			controller.IndexAgentName:     agentName,
-			controller.IndexAgentIsLatest: "true",
+			controller.IndexAgentName: agentName,
 		},
 	}
 
@@ -328,11 +338,23 @@ func (h *AgentHandler) getAgent(ctx context.Context, input *AgentDetailInput, is
 		return nil, huma.Error500InternalServerError("Failed to get agent", err)
 	}
 
-	if len(agentList.Items) == 0 {
-		return nil, huma.Error404NotFound("Agent not found")
+	// Prefer the latest version; within an environment fall back to any entry there
+	var agent *agentregistryv1alpha1.AgentCatalog
+	instances := make([]InstanceJSON, 0, len(agentList.Items))
+	for i := range agentList.Items {
+		a := &agentList.Items[i]
+		instances = append(instances, NewInstanceJSON(a, a.Spec.Version, a.Status.Status, a.Status.IsLatest, a.Status.Deployment))
+		if !InEnvironment(a, input.Environment) {
+			continue
+		}
+		if agent == nil || (a.Status.IsLatest && !agent.Status.IsLatest) {
+			agent = a
+		}
 	}
 
-	agent := &agentList.Items[0]
+	if agent == nil || (input.Environment == "" && !agent.Status.IsLatest) {
+		return nil, huma.Error404NotFound("Agent not found")
+	}
 
 	// Fetch deployment for this agent
 	deployment, err := h.getDeploymentForAgent(ctx, agent.Spec.Name, agent.Spec.Version)
@@ -340,8 +362,10 @@ func (h *AgentHandler) getAgent(ctx context.Context, input *AgentDetailInput, is
 		h.logger.Warn().Err(err).Str("agent", agent.Spec.Name).Str("version", agent.Spec.Version).Msg("Failed to get deployment for agent")
 	}
 
+	resp := h.convertToAgentResponse(agent, deployment)
+	resp.Meta.Instances = instances
 	return &Response[AgentResponse]{
-		Body: h.convertToAgentResponse(agent, deployment),
+		Body: resp,
 	}, nil
 }
 
@@ -633,6 +657,8 @@ func (h *AgentHandler) convertToAgentResponse(a *agentregistryv1alpha1.AgentCata
 		} else if source := a.Labels["agentregistry.dev/resource-source"]; source != "" {
 			resp.Meta.Source = source
 		}
+		resp.Meta.Environment = a.Labels[agentregistryv1alpha1.LabelEnvironment]
+		resp.Meta.Cluster = a.Labels[agentregistryv1alpha1.LabelCluster]
 	}
 
 	// Include publisher-provided metadata if available
@@ -649,17 +675,16 @@ func (h *AgentHandler) convertToAgentResponse(a *agentregistryv1alpha1.AgentCata
 	if deployment != nil {
 		resp.Meta.Deployment = h.convertRegistryDeploymentToDeploymentInfo(deployment)
 	} else if a.Status.Deployment != nil {
-		resp.Meta.Deployment = &DeploymentInfo{
-			Namespace:   a.Status.Deployment.Namespace,
-			ServiceName: a.Status.Deployment.ServiceName,
-			URL:         a.Status.Deployment.URL,
-			Ready:       a.Status.Deployment.Ready,
-			Message:     a.Status.Deployment.Message,
-		}
-		if a.Status.Deployment.LastChecked != nil {
-			t := a.Status.Deployment.LastChecked.Time
-			resp.Meta.Deployment.LastChecked = &t
-		}
+		resp.Meta.Deployment = deploymentInfoFromRef(a.Status.Deployment)
+	}
+
+	// Referenced tools that the MCP servers did not expose when introspected
+	for _, t := range a.Status.UnresolvedTools {
+		resp.Meta.UnresolvedTools = append(resp.Meta.UnresolvedTools, AgentToolRefJSON{
+			Type:      t.Type,
+			Name:      t.Name,
+			ToolNames: t.ToolNames,
+		})
 	}
 
 	return resp
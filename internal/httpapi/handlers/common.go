@@ -24,6 +24,19 @@ type DeploymentInfo struct {
 	LastChecked *time.Time `json:"lastChecked,omitempty"`
 }
 
+// InstanceJSON describes one environment-specific catalog entry of a logical resource.
+// Discovered resources with the same namespace/name in several environments share
+// Spec.Name and are reported as instances of one resource.
+type InstanceJSON struct {
+	CatalogName string          `json:"catalogName"`
+	Environment string          `json:"environment,omitempty"`
+	Cluster     string          `json:"cluster,omitempty"`
+	Version     string          `json:"version"`
+	Status      string          `json:"status,omitempty"`
+	IsLatest    bool            `json:"isLatest"`
+	Deployment  *DeploymentInfo `json:"deployment,omitempty"`
+}
+
 // EmptyResponse represents an empty response
 type EmptyResponse struct {
 	Message string `json:"message,omitempty"`
@@ -69,3 +82,42 @@ func SetCatalogCondition(conditions []agentregistryv1alpha1.CatalogCondition, co
 		Message:            message,
 	})
 }
+
+// NewInstanceJSON builds the instance view of a single catalog entry
+func NewInstanceJSON(obj metav1.Object, version string, status agentregistryv1alpha1.CatalogStatus, isLatest bool, deployment *agentregistryv1alpha1.DeploymentRef) InstanceJSON {
+	labels := obj.GetLabels()
+	return InstanceJSON{
+		CatalogName: obj.GetName(),
+		Environment: labels[agentregistryv1alpha1.LabelEnvironment],
+		Cluster:     labels[agentregistryv1alpha1.LabelCluster],
+		Version:     version,
+		Status:      string(status),
+		IsLatest:    isLatest,
+		Deployment:  deploymentInfoFromRef(deployment),
+	}
+}
+
+// InEnvironment reports whether a catalog entry belongs to the given environment.
+// An empty environment matches every entry.
+func InEnvironment(obj metav1.Object, environment string) bool {
+	return environment == "" || obj.GetLabels()[agentregistryv1alpha1.LabelEnvironment] == environment
+}
+
+// deploymentInfoFromRef converts a catalog DeploymentRef to DeploymentInfo
+func deploymentInfoFromRef(ref *agentregistryv1alpha1.DeploymentRef) *DeploymentInfo {
+	if ref == nil {
+		return nil
+	}
+	info := &DeploymentInfo{
+		Namespace:   ref.Namespace,
+		ServiceName: ref.ServiceName,
+		URL:         ref.URL,
+		Ready:       ref.Ready,
+		Message:     ref.Message,
+	}
+	if ref.LastChecked != nil {
+		t := ref.LastChecked.Time
+		info.LastChecked = &t
+	}
+	return info
+}
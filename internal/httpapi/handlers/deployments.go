@@ -5,6 +5,7 @@ import (
 	"maps"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -38,20 +39,21 @@ func NewDeploymentHandler(c client.Client, cache cache.Cache, logger zerolog.Log
 
 // Deployment response types
 type DeploymentJSON struct {
-	ResourceName    string            `json:"resourceName"`
-	Version         string            `json:"version"`
-	ResourceType    string            `json:"resourceType"`              // "mcp" or "agent" (catalog type)
-	K8sResourceType string            `json:"k8sResourceType,omitempty"` // "MCPServer", "RemoteMCPServer", "Agent" (actual K8s resource)
-	Runtime         string            `json:"runtime"`
-	PreferRemote    bool              `json:"preferRemote,omitempty"`
-	Config          map[string]string `json:"config,omitempty"`
-	Namespace       string            `json:"namespace,omitempty"`
-	Environment     string            `json:"environment,omitempty"` // Environment label (dev, staging, prod, etc.)
-	Status          string            `json:"status,omitempty"`
-	DeployedAt      *time.Time        `json:"deployedAt,omitempty"`
-	UpdatedAt       *time.Time        `json:"updatedAt,omitempty"`
-	Message         string            `json:"message,omitempty"`
-	IsExternal      bool              `json:"isExternal,omitempty"`
+	ResourceName    string                                  `json:"resourceName"`
+	Version         string                                  `json:"version"`
+	ResourceType    string                                  `json:"resourceType"`              // "mcp" or "agent" (catalog type)
+	K8sResourceType string                                  `json:"k8sResourceType,omitempty"` // "MCPServer", "RemoteMCPServer", "Agent" (actual K8s resource)
+	Runtime         string                                  `json:"runtime"`
+	PreferRemote    bool                                    `json:"preferRemote,omitempty"`
+	Config          map[string]string                       `json:"config,omitempty"`
+	ConfigFrom      []agentregistryv1alpha1.ConfigValueFrom `json:"configFrom,omitempty"` // Secret and ConfigMap keys config is read from, never their values
+	Namespace       string                                  `json:"namespace,omitempty"`
+	Environment     string                                  `json:"environment,omitempty"` // Environment label (dev, staging, prod, etc.)
+	Status          string                                  `json:"status,omitempty"`
+	DeployedAt      *time.Time                              `json:"deployedAt,omitempty"`
+	UpdatedAt       *time.Time                              `json:"updatedAt,omitempty"`
+	Message         string                                  `json:"message,omitempty"`
+	IsExternal      bool                                    `json:"isExternal,omitempty"`
 }
 
 type DeploymentResponse struct {
@@ -77,14 +79,15 @@ type DeploymentDetailInput struct {
 
 type CreateDeploymentInput struct {
 	Body struct {
-		ResourceName string            `json:"resourceName"`
-		Version      string            `json:"version"`
-		ResourceType string            `json:"resourceType"`
-		Runtime      string            `json:"runtime"`
-		PreferRemote bool              `json:"preferRemote,omitempty"`
-		Config       map[string]string `json:"config,omitempty"`
-		Namespace    string            `json:"namespace,omitempty"`
-		Environment  string            `json:"environment,omitempty"`
+		ResourceName string                                  `json:"resourceName"`
+		Version      string                                  `json:"version"`
+		ResourceType string                                  `json:"resourceType"`
+		Runtime      string                                  `json:"runtime"`
+		PreferRemote bool                                    `json:"preferRemote,omitempty"`
+		Config       map[string]string                       `json:"config,omitempty"`
+		ConfigFrom   []agentregistryv1alpha1.ConfigValueFrom `json:"configFrom,omitempty"` // Read from Secrets and ConfigMaps in the agentregistry namespace
+		Namespace    string                                  `json:"namespace,omitempty"`
+		Environment  string                                  `json:"environment,omitempty"`
 	}
 }
 
@@ -92,6 +95,8 @@ type UpdateDeploymentConfigInput struct {
 	DeploymentName string `path:"deploymentName" json:"deploymentName"`
 	Body           struct {
 		Config map[string]string `json:"config"`
+		// ConfigFrom entries replace the config of the same name
+		ConfigFrom []agentregistryv1alpha1.ConfigValueFrom `json:"configFrom,omitempty"`
 	}
 }
 
@@ -274,6 +279,9 @@ func (h *DeploymentHandler) getDeployment(ctx context.Context, input *Deployment
 }
 
 func (h *DeploymentHandler) createDeployment(ctx context.Context, input *CreateDeploymentInput) (*Response[DeploymentResponse], error) {
+	if err := controller.ValidateConfigFrom(input.Body.ConfigFrom); err != nil {
+		return nil, huma.Error400BadRequest(err.Error())
+	}
 	crName := GenerateCRName(input.Body.ResourceName, input.Body.Version)
 
 	// Always use kubernetes runtime
@@ -303,6 +311,7 @@ func (h *DeploymentHandler) createDeployment(ctx context.Context, input *CreateD
 			Runtime:      runtime,
 			PreferRemote: input.Body.PreferRemote,
 			Config:       input.Body.Config,
+			ConfigFrom:   input.Body.ConfigFrom,
 			Namespace:    targetNamespace, // Target namespace for deployed resources
 			Environment:  input.Body.Environment,
 		},
@@ -327,18 +336,32 @@ func (h *DeploymentHandler) updateDeploymentConfig(ctx context.Context, input *U
 	if err != nil {
 		return nil, huma.Error400BadRequest("Invalid deployment name encoding", err)
 	}
+	if err := controller.ValidateConfigFrom(input.Body.ConfigFrom); err != nil {
+		return nil, huma.Error400BadRequest(err.Error())
+	}
 
 	var deployment agentregistryv1alpha1.RegistryDeployment
 	if err := h.client.Get(ctx, client.ObjectKey{Namespace: "agentregistry", Name: deploymentName}, &deployment); err != nil {
 		return nil, huma.Error404NotFound("Deployment not found")
 	}
 
-	// Merge config
+	// Merge config. A value replaces a configFrom entry of the same name and vice versa, as
+	// configFrom would otherwise take precedence.
 	if deployment.Spec.Config == nil {
 		deployment.Spec.Config = maps.Clone(input.Body.Config)
 	} else {
 		maps.Copy(deployment.Spec.Config, input.Body.Config)
 	}
+	deployment.Spec.ConfigFrom = slices.DeleteFunc(deployment.Spec.ConfigFrom, func(entry agentregistryv1alpha1.ConfigValueFrom) bool {
+		_, replaced := input.Body.Config[entry.Name]
+		return replaced || slices.ContainsFunc(input.Body.ConfigFrom, func(in agentregistryv1alpha1.ConfigValueFrom) bool {
+			return in.Name == entry.Name
+		})
+	})
+	for _, entry := range input.Body.ConfigFrom {
+		delete(deployment.Spec.Config, entry.Name)
+		deployment.Spec.ConfigFrom = append(deployment.Spec.ConfigFrom, entry)
+	}
 
 	if err := h.client.Update(ctx, &deployment); err != nil {
 		return nil, huma.Error500InternalServerError("Failed to update deployment", err)
@@ -423,6 +446,7 @@ func (h *DeploymentHandler) convertToDeploymentJSON(d *agentregistryv1alpha1.Reg
 		Runtime:      string(d.Spec.Runtime),
 		PreferRemote: d.Spec.PreferRemote,
 		Config:       d.Spec.Config,
+		ConfigFrom:   d.Spec.ConfigFrom,
 		Namespace:    d.Spec.Namespace,
 		Environment:  d.Spec.Environment,
 		Status:       string(d.Status.Phase),
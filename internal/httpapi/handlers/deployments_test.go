@@ -7,6 +7,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -127,6 +128,76 @@ func TestDeploymentHandler_CreateDeployment_WithConfig(t *testing.T) {
 	assert.Equal(t, "https://api.example.com", resp.Body.Deployment.Config["ENDPOINT"])
 }
 
+func TestDeploymentHandler_UpdateDeploymentConfig_ConfigFrom(t *testing.T) {
+	c := setupDeploymentTestClient(t)
+	ctx := context.Background()
+	handler := NewDeploymentHandler(c, nil, zerolog.Nop())
+
+	require.NoError(t, c.Create(ctx, &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "github-1-0-0", Namespace: "agentregistry"},
+		Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "github",
+			Version:      "1.0.0",
+			Config:       map[string]string{"GITHUB_TOKEN": "plaintext", "LOG_LEVEL": "info"},
+			ConfigFrom: []agentregistryv1alpha1.ConfigValueFrom{{
+				Name: "REGION",
+				ValueFrom: agentregistryv1alpha1.ConfigValueSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "settings"},
+					Key:                  "region",
+				}},
+			}},
+		},
+	}))
+
+	input := &UpdateDeploymentConfigInput{DeploymentName: "github-1-0-0"}
+	input.Body.Config = map[string]string{"REGION": "eu"}
+	input.Body.ConfigFrom = []agentregistryv1alpha1.ConfigValueFrom{{
+		Name: "GITHUB_TOKEN",
+		ValueFrom: agentregistryv1alpha1.ConfigValueSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "github"},
+			Key:                  "token",
+		}},
+	}}
+
+	resp, err := handler.updateDeploymentConfig(ctx, input)
+	require.NoError(t, err)
+	// The plaintext token is replaced by the reference, which is returned without its value
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info", "REGION": "eu"}, resp.Body.Deployment.Config)
+	require.Len(t, resp.Body.Deployment.ConfigFrom, 1)
+	assert.Equal(t, "GITHUB_TOKEN", resp.Body.Deployment.ConfigFrom[0].Name)
+	assert.Equal(t, "github", resp.Body.Deployment.ConfigFrom[0].ValueFrom.SecretKeyRef.Name)
+}
+
+func TestDeploymentHandler_ConfigFrom_RegistrySecretsRefused(t *testing.T) {
+	c := setupDeploymentTestClient(t)
+	ctx := context.Background()
+	handler := NewDeploymentHandler(c, nil, zerolog.Nop())
+	apiTokens := []agentregistryv1alpha1.ConfigValueFrom{{
+		Name: "TOKEN",
+		ValueFrom: agentregistryv1alpha1.ConfigValueSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "agentregistry-api-tokens"},
+			Key:                  "admin",
+		}},
+	}}
+
+	create := &CreateDeploymentInput{}
+	create.Body.ResourceName = "test-server"
+	create.Body.Version = "1.0.0"
+	create.Body.ResourceType = "mcp"
+	create.Body.ConfigFrom = apiTokens
+	_, err := handler.createDeployment(ctx, create)
+	assert.ErrorContains(t, err, "belongs to the registry")
+
+	var deployments agentregistryv1alpha1.RegistryDeploymentList
+	require.NoError(t, c.List(ctx, &deployments))
+	assert.Empty(t, deployments.Items)
+
+	update := &UpdateDeploymentConfigInput{DeploymentName: "test-server-1-0-0"}
+	update.Body.ConfigFrom = apiTokens
+	_, err = handler.updateDeploymentConfig(ctx, update)
+	assert.ErrorContains(t, err, "belongs to the registry")
+}
+
 func TestDeploymentHandler_CreateDeployment_InvalidRuntime(t *testing.T) {
 	c := setupDeploymentTestClient(t)
 	ctx := context.Background()
@@ -7,6 +7,7 @@ import (
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/rs/zerolog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -87,6 +88,29 @@ type DiscoveryMapConfig struct {
 	Name         string                    `json:"name"`
 	Environments []DiscoveryMapEnvironment `json:"environments"`
 	LastSyncTime *time.Time                `json:"lastSyncTime,omitempty"`
+	LastResync   *ResyncReportJSON         `json:"lastResync,omitempty"`
+}
+
+// ResyncReportJSON is the outcome of a forced discovery resync
+type ResyncReportJSON struct {
+	CompletedAt time.Time `json:"completedAt"`
+	Added       int       `json:"added"`
+	Updated     int       `json:"updated"`
+	Removed     int       `json:"removed"`
+	Errors      []string  `json:"errors,omitempty"`
+}
+
+// DiscoveryResyncInput identifies the DiscoveryConfig to resync
+type DiscoveryResyncInput struct {
+	Name string `path:"name" json:"name"`
+}
+
+// DiscoveryResyncResponse acknowledges a resync request. LastResync is the report of the
+// previous resync; the new one appears in the DiscoveryConfig status once it completes.
+type DiscoveryResyncResponse struct {
+	Name       string            `json:"name"`
+	Requested  bool              `json:"requested"`
+	LastResync *ResyncReportJSON `json:"lastResync,omitempty"`
 }
 
 // RegisterRoutes registers environment endpoints
@@ -117,6 +141,64 @@ func (h *EnvironmentHandler) RegisterRoutes(api huma.API, pathPrefix string, isA
 	}, func(ctx context.Context, input *struct{}) (*Response[DiscoveryMapResponse], error) {
 		return h.getDiscoveryMap(ctx)
 	})
+
+	// Admin-only endpoints
+	if isAdmin {
+		// Force a full relist of every environment
+		huma.Register(api, huma.Operation{
+			OperationID:   "resync-discovery",
+			Method:        http.MethodPost,
+			Path:          pathPrefix + "/discovery/{name}/resync",
+			Summary:       "Force a full discovery resync of a DiscoveryConfig",
+			Tags:          tags,
+			DefaultStatus: http.StatusAccepted,
+		}, func(ctx context.Context, input *DiscoveryResyncInput) (*Response[DiscoveryResyncResponse], error) {
+			return h.resyncDiscovery(ctx, input)
+		})
+	}
+}
+
+func (h *EnvironmentHandler) resyncDiscovery(ctx context.Context, input *DiscoveryResyncInput) (*Response[DiscoveryResyncResponse], error) {
+	var dc agentregistryv1alpha1.DiscoveryConfig
+	if err := h.client.Get(ctx, client.ObjectKey{Name: input.Name, Namespace: "agentregistry"}, &dc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, huma.Error404NotFound("DiscoveryConfig not found")
+		}
+		return nil, huma.Error500InternalServerError("failed to get DiscoveryConfig", err)
+	}
+
+	patch := client.MergeFrom(dc.DeepCopy())
+	if dc.Annotations == nil {
+		dc.Annotations = make(map[string]string)
+	}
+	dc.Annotations[agentregistryv1alpha1.AnnotationTriggerDiscovery] = "true"
+	if err := h.client.Patch(ctx, &dc, patch); err != nil {
+		return nil, huma.Error500InternalServerError("failed to request resync", err)
+	}
+
+	h.logger.Info().Str("discoveryconfig", dc.Name).Msg("discovery resync requested")
+
+	return &Response[DiscoveryResyncResponse]{
+		Body: DiscoveryResyncResponse{
+			Name:       dc.Name,
+			Requested:  true,
+			LastResync: convertResyncReport(dc.Status.LastResync),
+		},
+	}, nil
+}
+
+// convertResyncReport converts a ResyncReport to its JSON form
+func convertResyncReport(report *agentregistryv1alpha1.ResyncReport) *ResyncReportJSON {
+	if report == nil {
+		return nil
+	}
+	return &ResyncReportJSON{
+		CompletedAt: report.CompletedAt.Time,
+		Added:       report.Added,
+		Updated:     report.Updated,
+		Removed:     report.Removed,
+		Errors:      report.Errors,
+	}
 }
 
 func (h *EnvironmentHandler) listEnvironments(ctx context.Context) (*Response[EnvironmentListResponse], error) {
@@ -215,6 +297,7 @@ func (h *EnvironmentHandler) getDiscoveryMap(ctx context.Context) (*Response[Dis
 			Name:         dc.Name,
 			Environments: envs,
 			LastSyncTime: lastSync,
+			LastResync:   convertResyncReport(dc.Status.LastResync),
 		})
 	}
 
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
@@ -110,3 +111,44 @@ func TestEnvironmentHandler_ListEnvironments_FallbackToNamespaces(t *testing.T)
 	// Should fall back to first namespace
 	assert.Equal(t, "dev-ns", resp.Body.Environments[0].Namespace)
 }
+
+func TestEnvironmentHandler_ResyncDiscovery(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	dc := &agentregistryv1alpha1.DiscoveryConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-discovery",
+			Namespace: "agentregistry",
+		},
+		Status: agentregistryv1alpha1.DiscoveryConfigStatus{
+			LastResync: &agentregistryv1alpha1.ResyncReport{
+				CompletedAt: metav1.Now(),
+				Added:       2,
+				Removed:     1,
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(dc).
+		Build()
+
+	handler := NewEnvironmentHandler(c, nil, zerolog.Nop())
+	ctx := context.Background()
+
+	resp, err := handler.resyncDiscovery(ctx, &DiscoveryResyncInput{Name: "test-discovery"})
+	require.NoError(t, err)
+	assert.True(t, resp.Body.Requested)
+	require.NotNil(t, resp.Body.LastResync)
+	assert.Equal(t, 2, resp.Body.LastResync.Added)
+	assert.Equal(t, 1, resp.Body.LastResync.Removed)
+
+	var updated agentregistryv1alpha1.DiscoveryConfig
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(dc), &updated))
+	assert.Equal(t, "true", updated.Annotations[agentregistryv1alpha1.AnnotationTriggerDiscovery])
+
+	_, err = handler.resyncDiscovery(ctx, &DiscoveryResyncInput{Name: "missing"})
+	assert.Error(t, err)
+}
@@ -39,6 +39,7 @@ type ModelJSON struct {
 	Provider    string `json:"provider"`
 	Model       string `json:"model"`
 	BaseURL     string `json:"baseUrl,omitempty"`
+	Runtime     string `json:"runtime,omitempty"`
 	Description string `json:"description,omitempty"`
 }
 
@@ -48,11 +49,27 @@ type ModelUsageRefJSON struct {
 	Kind      string `json:"kind,omitempty"`
 }
 
+// ModelLinkJSON references a related model catalog entry
+type ModelLinkJSON struct {
+	Name        string `json:"name"`
+	Runtime     string `json:"runtime,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Cluster     string `json:"cluster,omitempty"`
+}
+
 type ModelMeta struct {
 	Official *OfficialMeta       `json:"io.modelcontextprotocol.registry/official,omitempty"`
 	UsedBy   []ModelUsageRefJSON `json:"usedBy,omitempty"`
 	Ready    bool                `json:"ready"`
 	Message  string              `json:"message,omitempty"`
+	// Environment and Cluster are set for discovered models
+	Environment string         `json:"environment,omitempty"`
+	Cluster     string         `json:"cluster,omitempty"`
+	Instances   []InstanceJSON `json:"instances,omitempty"`
+	// ServedBy lists the self-hosted models behind a model config's base URL
+	ServedBy []ModelLinkJSON `json:"servedBy,omitempty"`
+	// ModelConfigs lists the model configs pointing at a self-hosted model
+	ModelConfigs []ModelLinkJSON `json:"modelConfigs,omitempty"`
 }
 
 type ModelResponse struct {
@@ -67,14 +84,17 @@ type ModelListResponse struct {
 
 // Input types
 type ListModelsInput struct {
-	Cursor   string `query:"cursor" json:"cursor,omitempty"`
-	Limit    int    `query:"limit" json:"limit,omitempty" default:"30" minimum:"1" maximum:"100"`
-	Search   string `query:"search" json:"search,omitempty"`
-	Provider string `query:"provider" json:"provider,omitempty"`
+	Cursor      string `query:"cursor" json:"cursor,omitempty"`
+	Limit       int    `query:"limit" json:"limit,omitempty" default:"30" minimum:"1" maximum:"100"`
+	Search      string `query:"search" json:"search,omitempty"`
+	Provider    string `query:"provider" json:"provider,omitempty"`
+	Runtime     string `query:"runtime" json:"runtime,omitempty"`
+	Environment string `query:"environment" json:"environment,omitempty"`
 }
 
 type ModelDetailInput struct {
-	ModelName string `path:"modelName" json:"modelName"`
+	ModelName   string `path:"modelName" json:"modelName"`
+	Environment string `query:"environment" json:"environment,omitempty"`
 }
 
 type CreateModelInput struct {
@@ -134,6 +154,7 @@ func (h *ModelHandler) listModels(ctx context.Context, input *ListModelsInput, i
 		return nil, huma.Error500InternalServerError("Failed to list models", err)
 	}
 
+	links := linkModels(modelList.Items)
 	models := make([]ModelResponse, 0, len(modelList.Items))
 	for _, m := range modelList.Items {
 		if input.Search != "" && !strings.Contains(strings.ToLower(m.Spec.Name), strings.ToLower(input.Search)) {
@@ -144,7 +165,17 @@ func (h *ModelHandler) listModels(ctx context.Context, input *ListModelsInput, i
 			continue
 		}
 
-		models = append(models, h.convertToModelResponse(&m))
+		if input.Runtime != "" && !strings.EqualFold(m.Spec.Runtime, input.Runtime) {
+			continue
+		}
+
+		if !InEnvironment(&m, input.Environment) {
+			continue
+		}
+
+		resp := h.convertToModelResponse(&m)
+		links.apply(&m, &resp)
+		models = append(models, resp)
 	}
 
 	limit := input.Limit
@@ -182,12 +213,31 @@ func (h *ModelHandler) getModel(ctx context.Context, input *ModelDetailInput, is
 		return nil, huma.Error500InternalServerError("Failed to get model", err)
 	}
 
-	if len(modelList.Items) == 0 {
+	var model *agentregistryv1alpha1.ModelCatalog
+	instances := make([]InstanceJSON, 0, len(modelList.Items))
+	for i := range modelList.Items {
+		m := &modelList.Items[i]
+		instances = append(instances, NewInstanceJSON(m, "", m.Status.Status, true, nil))
+		if model == nil && InEnvironment(m, input.Environment) {
+			model = m
+		}
+	}
+
+	if model == nil {
 		return nil, huma.Error404NotFound("Model not found")
 	}
 
+	// Links may point at models of any name
+	var allModels agentregistryv1alpha1.ModelCatalogList
+	if err := h.cache.List(ctx, &allModels); err != nil {
+		return nil, huma.Error500InternalServerError("Failed to get model", err)
+	}
+
+	resp := h.convertToModelResponse(model)
+	resp.Meta.Instances = instances
+	linkModels(allModels.Items).apply(model, &resp)
 	return &Response[ModelResponse]{
-		Body: h.convertToModelResponse(&modelList.Items[0]),
+		Body: resp,
 	}, nil
 }
 
@@ -225,6 +275,7 @@ func (h *ModelHandler) convertToModelResponse(m *agentregistryv1alpha1.ModelCata
 		Provider:    m.Spec.Provider,
 		Model:       m.Spec.Model,
 		BaseURL:     m.Spec.BaseURL,
+		Runtime:     m.Spec.Runtime,
 		Description: m.Spec.Description,
 	}
 
@@ -254,9 +305,79 @@ func (h *ModelHandler) convertToModelResponse(m *agentregistryv1alpha1.ModelCata
 				IsLatest:    true, // Models don't have versions currently
 				Published:   true,
 			},
-			UsedBy:  usedBy,
-			Ready:   m.Status.Ready,
-			Message: m.Status.Message,
+			UsedBy:      usedBy,
+			Ready:       m.Status.Ready,
+			Message:     m.Status.Message,
+			Environment: m.Labels[agentregistryv1alpha1.LabelEnvironment],
+			Cluster:     m.Labels[agentregistryv1alpha1.LabelCluster],
 		},
 	}
 }
+
+// modelLinks relates model configs to the self-hosted models serving them, keyed by catalog
+// entry name
+type modelLinks struct {
+	servedBy     map[string][]ModelLinkJSON
+	modelConfigs map[string][]ModelLinkJSON
+}
+
+// linkModels links each model config to the self-hosted models whose serving URL has the
+// same host as its base URL. In-cluster hosts only match within the same cluster.
+func linkModels(models []agentregistryv1alpha1.ModelCatalog) modelLinks {
+	links := modelLinks{
+		servedBy:     make(map[string][]ModelLinkJSON),
+		modelConfigs: make(map[string][]ModelLinkJSON),
+	}
+	for i := range models {
+		served := &models[i]
+		host := modelEndpointHost(served.Spec.BaseURL)
+		if served.Spec.Runtime == "" || host == "" {
+			continue
+		}
+		for j := range models {
+			cfg := &models[j]
+			if cfg.Spec.Runtime != "" || modelEndpointHost(cfg.Spec.BaseURL) != host {
+				continue
+			}
+			servedCluster := served.Labels[agentregistryv1alpha1.LabelCluster]
+			cfgCluster := cfg.Labels[agentregistryv1alpha1.LabelCluster]
+			if servedCluster != "" && cfgCluster != "" && servedCluster != cfgCluster {
+				continue
+			}
+			links.servedBy[cfg.Name] = append(links.servedBy[cfg.Name], modelLink(served))
+			links.modelConfigs[served.Name] = append(links.modelConfigs[served.Name], modelLink(cfg))
+		}
+	}
+	return links
+}
+
+// apply sets the links of catalog entry m on its response
+func (l modelLinks) apply(m *agentregistryv1alpha1.ModelCatalog, resp *ModelResponse) {
+	resp.Meta.ServedBy = l.servedBy[m.Name]
+	resp.Meta.ModelConfigs = l.modelConfigs[m.Name]
+}
+
+func modelLink(m *agentregistryv1alpha1.ModelCatalog) ModelLinkJSON {
+	return ModelLinkJSON{
+		Name:        m.Spec.Name,
+		Runtime:     m.Spec.Runtime,
+		Environment: m.Labels[agentregistryv1alpha1.LabelEnvironment],
+		Cluster:     m.Labels[agentregistryv1alpha1.LabelCluster],
+	}
+}
+
+// modelEndpointHost returns the host of a model endpoint with the port and the in-cluster
+// DNS suffixes removed, so "llama.models", "llama.models.svc" and
+// "llama.models.svc.cluster.local:8000" compare equal
+func modelEndpointHost(baseURL string) string {
+	if baseURL == "" {
+		return ""
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimSuffix(host, ".cluster.local")
+	return strings.TrimSuffix(host, ".svc")
+}
@@ -249,3 +249,47 @@ func TestModelHandler_ConvertToModelResponse_NotReady(t *testing.T) {
 	assert.False(t, resp.Meta.Ready)
 	assert.Equal(t, "Waiting for configuration", resp.Meta.Message)
 }
+
+// ---------------------------------------------------------------------------
+// linkModels
+// ---------------------------------------------------------------------------
+
+func TestLinkModels(t *testing.T) {
+	catalog := func(name, baseURL, runtime, cluster string) agentregistryv1alpha1.ModelCatalog {
+		return agentregistryv1alpha1.ModelCatalog{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{agentregistryv1alpha1.LabelCluster: cluster},
+			},
+			Spec: agentregistryv1alpha1.ModelCatalogSpec{
+				Name:     "models/" + name,
+				Provider: "OpenAI",
+				BaseURL:  baseURL,
+				Runtime:  runtime,
+			},
+		}
+	}
+	models := []agentregistryv1alpha1.ModelCatalog{
+		catalog("llama", "http://llama.models.svc.cluster.local", "kserve", "dev"),
+		catalog("llama-config", "http://llama.models.svc:80/openai/v1", "", "dev"),
+		catalog("llama-other-cluster", "http://llama.models/v1", "", "prod"),
+		catalog("gpt", "https://api.openai.com/v1", "", "dev"),
+		catalog("no-url", "", "", ""),
+	}
+
+	links := linkModels(models)
+
+	var resp ModelResponse
+	links.apply(&models[0], &resp)
+	assert.Equal(t, []ModelLinkJSON{{Name: "models/llama-config", Cluster: "dev"}}, resp.Meta.ModelConfigs)
+	assert.Empty(t, resp.Meta.ServedBy)
+
+	links.apply(&models[1], &resp)
+	assert.Equal(t, []ModelLinkJSON{{Name: "models/llama", Runtime: "kserve", Cluster: "dev"}}, resp.Meta.ServedBy)
+	assert.Empty(t, resp.Meta.ModelConfigs)
+
+	for _, m := range models[2:] {
+		links.apply(&m, &resp)
+		assert.Empty(t, resp.Meta.ServedBy, m.Name)
+	}
+}
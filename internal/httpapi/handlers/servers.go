@@ -10,6 +10,7 @@ import (
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/rs/zerolog"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -111,7 +112,60 @@ type ServerMeta struct {
 	Deployment        *DeploymentInfo        `json:"deployment,omitempty"`
 	Source            string                 `json:"source,omitempty"` // discovery, manual, deployment
 	IsDiscovered      bool                   `json:"isDiscovered,omitempty"`
+	Environment       string                 `json:"environment,omitempty"`
+	Cluster           string                 `json:"cluster,omitempty"`
 	UsedBy            []ServerUsageRefJSON   `json:"usedBy,omitempty"`
+	Introspection     *IntrospectionJSON     `json:"introspection,omitempty"`
+	Instances         []InstanceJSON         `json:"instances,omitempty"`
+}
+
+// IntrospectionJSON is what the live server exposed when it was last probed
+type IntrospectionJSON struct {
+	URL             string          `json:"url"`
+	Transport       string          `json:"transport,omitempty"`
+	ServerInfo      *ServerInfoJSON `json:"serverInfo,omitempty"`
+	ProtocolVersion string          `json:"protocolVersion,omitempty"`
+	Capabilities    []string        `json:"capabilities,omitempty"`
+	Tools           []ToolJSON      `json:"tools,omitempty"`
+	Prompts         []PromptJSON    `json:"prompts,omitempty"`
+	Resources       []ResourceJSON  `json:"resources,omitempty"`
+	LastProbed      *time.Time      `json:"lastProbed,omitempty"`
+	LastSuccess     *time.Time      `json:"lastSuccess,omitempty"`
+	Error           string          `json:"error,omitempty"`
+}
+
+type ServerInfoJSON struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type ToolJSON struct {
+	Name         string                 `json:"name"`
+	Title        string                 `json:"title,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+	InputSchema  map[string]interface{} `json:"inputSchema,omitempty"`
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+}
+
+type PromptJSON struct {
+	Name        string               `json:"name"`
+	Title       string               `json:"title,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Arguments   []PromptArgumentJSON `json:"arguments,omitempty"`
+}
+
+type PromptArgumentJSON struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type ResourceJSON struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mimeType,omitempty"`
 }
 
 type OfficialMeta struct {
@@ -134,14 +188,16 @@ type ServerListResponse struct {
 
 // Input types
 type ListServersInput struct {
-	Cursor  string `query:"cursor" json:"cursor,omitempty"`
-	Limit   int    `query:"limit" json:"limit,omitempty" default:"30" minimum:"1" maximum:"100"`
-	Search  string `query:"search" json:"search,omitempty"`
-	Version string `query:"version" json:"version,omitempty"`
+	Cursor      string `query:"cursor" json:"cursor,omitempty"`
+	Limit       int    `query:"limit" json:"limit,omitempty" default:"30" minimum:"1" maximum:"100"`
+	Search      string `query:"search" json:"search,omitempty"`
+	Version     string `query:"version" json:"version,omitempty"`
+	Environment string `query:"environment" json:"environment,omitempty"`
 }
 
 type ServerDetailInput struct {
-	ServerName string `path:"serverName" json:"serverName"`
+	ServerName  string `path:"serverName" json:"serverName"`
+	Environment string `query:"environment" json:"environment,omitempty"`
 }
 
 type ServerVersionDetailInput struct {
@@ -266,6 +322,11 @@ func (h *ServerHandler) listServers(ctx context.Context, input *ListServersInput
 			continue
 		}
 
+		// Filter by discovery environment
+		if !InEnvironment(&s, input.Environment) {
+			continue
+		}
+
 		// Get deployment status for this server
 		key := s.Spec.Name + "/" + s.Spec.Version
 		deployment := deploymentMap[key]
@@ -335,11 +396,11 @@ func (h *ServerHandler) getServer(ctx context.Context, input *ServerDetailInput,
 		return nil, huma.Error400BadRequest("Invalid server name encoding", err)
 	}
 
+	// List every entry of this server so per-environment instances can be reported
 	var serverList agentregistryv1alpha1.MCPServerCatalogList
 	listOpts := []client.ListOption{
 		client.MatchingFields{
-			controller.IndexMCPServerName:     serverName,
-			controller.IndexMCPServerIsLatest: "true",
+			controller.IndexMCPServerName: serverName,
 		},
 	}
 
@@ -347,11 +408,23 @@ func (h *ServerHandler) getServer(ctx context.Context, input *ServerDetailInput,
 		return nil, huma.Error500InternalServerError("Failed to get server", err)
 	}
 
-	if len(serverList.Items) == 0 {
-		return nil, huma.Error404NotFound("Server not found")
+	// Prefer the latest version; within an environment fall back to any entry there
+	var server *agentregistryv1alpha1.MCPServerCatalog
+	instances := make([]InstanceJSON, 0, len(serverList.Items))
+	for i := range serverList.Items {
+		s := &serverList.Items[i]
+		instances = append(instances, NewInstanceJSON(s, s.Spec.Version, s.Status.Status, s.Status.IsLatest, s.Status.Deployment))
+		if !InEnvironment(s, input.Environment) {
+			continue
+		}
+		if server == nil || (s.Status.IsLatest && !server.Status.IsLatest) {
+			server = s
+		}
 	}
 
-	server := &serverList.Items[0]
+	if server == nil || (input.Environment == "" && !server.Status.IsLatest) {
+		return nil, huma.Error404NotFound("Server not found")
+	}
 
 	// Fetch deployment for this server
 	deployment, err := h.getDeploymentForServer(ctx, server.Spec.Name, server.Spec.Version)
@@ -359,8 +432,10 @@ func (h *ServerHandler) getServer(ctx context.Context, input *ServerDetailInput,
 		h.logger.Warn().Err(err).Str("server", server.Spec.Name).Str("version", server.Spec.Version).Msg("Failed to get deployment for server")
 	}
 
+	resp := h.convertToServerResponse(server, deployment)
+	resp.Meta.Instances = instances
 	return &Response[ServerResponse]{
-		Body: h.convertToServerResponse(server, deployment),
+		Body: resp,
 	}, nil
 }
 
@@ -638,6 +713,8 @@ func (h *ServerHandler) convertToServerResponse(s *agentregistryv1alpha1.MCPServ
 		} else if source := s.Labels["agentregistry.dev/resource-source"]; source != "" {
 			resp.Meta.Source = source
 		}
+		resp.Meta.Environment = s.Labels[agentregistryv1alpha1.LabelEnvironment]
+		resp.Meta.Cluster = s.Labels[agentregistryv1alpha1.LabelCluster]
 	}
 
 	// Include publisher-provided metadata if available
@@ -654,17 +731,7 @@ func (h *ServerHandler) convertToServerResponse(s *agentregistryv1alpha1.MCPServ
 	if deployment != nil {
 		resp.Meta.Deployment = h.convertRegistryDeploymentToDeploymentInfo(deployment)
 	} else if s.Status.Deployment != nil {
-		resp.Meta.Deployment = &DeploymentInfo{
-			Namespace:   s.Status.Deployment.Namespace,
-			ServiceName: s.Status.Deployment.ServiceName,
-			URL:         s.Status.Deployment.URL,
-			Ready:       s.Status.Deployment.Ready,
-			Message:     s.Status.Deployment.Message,
-		}
-		if s.Status.Deployment.LastChecked != nil {
-			t := s.Status.Deployment.LastChecked.Time
-			resp.Meta.Deployment.LastChecked = &t
-		}
+		resp.Meta.Deployment = deploymentInfoFromRef(s.Status.Deployment)
 	}
 
 	// Convert usedBy references
@@ -677,9 +744,81 @@ func (h *ServerHandler) convertToServerResponse(s *agentregistryv1alpha1.MCPServ
 		})
 	}
 
+	resp.Meta.Introspection = NewIntrospectionJSON(s.Status.Introspection)
+
 	return resp
 }
 
+// NewIntrospectionJSON converts the introspection recorded in a server's status
+func NewIntrospectionJSON(in *agentregistryv1alpha1.MCPServerIntrospection) *IntrospectionJSON {
+	if in == nil {
+		return nil
+	}
+	out := &IntrospectionJSON{
+		URL:             in.URL,
+		Transport:       in.Transport,
+		ProtocolVersion: in.ProtocolVersion,
+		Capabilities:    in.Capabilities,
+		Error:           in.Error,
+	}
+	if in.ServerInfo != nil {
+		out.ServerInfo = &ServerInfoJSON{
+			Name:    in.ServerInfo.Name,
+			Title:   in.ServerInfo.Title,
+			Version: in.ServerInfo.Version,
+		}
+	}
+	for _, t := range in.Tools {
+		out.Tools = append(out.Tools, ToolJSON{
+			Name:         t.Name,
+			Title:        t.Title,
+			Description:  t.Description,
+			InputSchema:  schemaFromJSON(t.InputSchema),
+			OutputSchema: schemaFromJSON(t.OutputSchema),
+		})
+	}
+	for _, p := range in.Prompts {
+		prompt := PromptJSON{Name: p.Name, Title: p.Title, Description: p.Description}
+		for _, a := range p.Arguments {
+			prompt.Arguments = append(prompt.Arguments, PromptArgumentJSON{
+				Name:        a.Name,
+				Description: a.Description,
+				Required:    a.Required,
+			})
+		}
+		out.Prompts = append(out.Prompts, prompt)
+	}
+	for _, r := range in.Resources {
+		out.Resources = append(out.Resources, ResourceJSON{
+			URI:         r.URI,
+			Name:        r.Name,
+			Description: r.Description,
+			MIMEType:    r.MIMEType,
+		})
+	}
+	if in.LastProbed != nil {
+		t := in.LastProbed.Time
+		out.LastProbed = &t
+	}
+	if in.LastSuccess != nil {
+		t := in.LastSuccess.Time
+		out.LastSuccess = &t
+	}
+	return out
+}
+
+// schemaFromJSON decodes a stored JSON Schema; invalid schemas are dropped
+func schemaFromJSON(raw *apiextensionsv1.JSON) map[string]interface{} {
+	if raw == nil || len(raw.Raw) == 0 {
+		return nil
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(raw.Raw, &schema); err != nil {
+		return nil
+	}
+	return schema
+}
+
 // convertRegistryDeploymentToDeploymentInfo converts a RegistryDeployment to DeploymentInfo
 func (h *ServerHandler) convertRegistryDeploymentToDeploymentInfo(d *agentregistryv1alpha1.RegistryDeployment) *DeploymentInfo {
 	info := &DeploymentInfo{
@@ -16,6 +16,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/controller"
 )
 
 func setupTestClient(t *testing.T) client.Client {
@@ -325,3 +326,124 @@ func TestServerHandler_ConvertToServerResponse_WithDeployment(t *testing.T) {
 	assert.True(t, resp.Meta.Deployment.Ready)
 	assert.Equal(t, "running", resp.Meta.Deployment.Message)
 }
+
+func TestServerHandler_ConvertToServerResponse_WithIntrospection(t *testing.T) {
+	c := setupTestClient(t)
+	handler := NewServerHandler(c, nil, zerolog.Nop())
+
+	now := metav1.Now()
+	server := &agentregistryv1alpha1.MCPServerCatalog{
+		ObjectMeta: metav1.ObjectMeta{Name: "search-1-0-0"},
+		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+			Name:    "search",
+			Version: "1.0.0",
+		},
+		Status: agentregistryv1alpha1.MCPServerCatalogStatus{
+			Introspection: &agentregistryv1alpha1.MCPServerIntrospection{
+				URL:          "http://search/mcp",
+				ServerInfo:   &agentregistryv1alpha1.MCPImplementation{Name: "search-server", Version: "1.2.0"},
+				Capabilities: []string{"tools"},
+				Tools: []agentregistryv1alpha1.MCPTool{{
+					Name:        "search",
+					InputSchema: &apiextensionsv1.JSON{Raw: []byte(`{"type":"object","properties":{"query":{"type":"string"}}}`)},
+				}},
+				LastProbed:  &now,
+				LastSuccess: &now,
+			},
+		},
+	}
+
+	resp := handler.convertToServerResponse(server, nil)
+	intro := resp.Meta.Introspection
+	require.NotNil(t, intro)
+	assert.Equal(t, "http://search/mcp", intro.URL)
+	assert.Equal(t, "search-server", intro.ServerInfo.Name)
+	assert.Equal(t, []string{"tools"}, intro.Capabilities)
+	require.Len(t, intro.Tools, 1)
+	assert.Equal(t, "object", intro.Tools[0].InputSchema["type"])
+	assert.NotNil(t, intro.LastSuccess)
+}
+
+func TestServerHandler_GetServer_Instances(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, agentregistryv1alpha1.AddToScheme(scheme))
+
+	newServer := func(name, env, cluster string, isLatest bool) *agentregistryv1alpha1.MCPServerCatalog {
+		return &agentregistryv1alpha1.MCPServerCatalog{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					"agentregistry.dev/discovered":         "true",
+					agentregistryv1alpha1.LabelEnvironment: env,
+					agentregistryv1alpha1.LabelCluster:     cluster,
+				},
+			},
+			Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:    "default/fetch",
+				Version: "1.0.0",
+			},
+			Status: agentregistryv1alpha1.MCPServerCatalogStatus{
+				IsLatest: isLatest,
+				Status:   agentregistryv1alpha1.CatalogStatusActive,
+			},
+		}
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			newServer("dev-default-fetch-aaaa", "dev", "dev-cluster", true),
+			newServer("prod-default-fetch-bbbb", "prod", "prod-cluster", false),
+		).
+		WithIndex(&agentregistryv1alpha1.MCPServerCatalog{}, controller.IndexMCPServerName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.MCPServerCatalog).Spec.Name}
+		}).
+		Build()
+	handler := NewServerHandler(c, nil, zerolog.Nop())
+	ctx := context.Background()
+
+	resp, err := handler.getServer(ctx, &ServerDetailInput{ServerName: "default%2Ffetch"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, "dev", resp.Body.Meta.Environment)
+	assert.Equal(t, "dev-cluster", resp.Body.Meta.Cluster)
+	require.Len(t, resp.Body.Meta.Instances, 2)
+
+	envs := []string{resp.Body.Meta.Instances[0].Environment, resp.Body.Meta.Instances[1].Environment}
+	assert.ElementsMatch(t, []string{"dev", "prod"}, envs)
+
+	resp, err = handler.getServer(ctx, &ServerDetailInput{ServerName: "default%2Ffetch", Environment: "prod"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, "prod", resp.Body.Meta.Environment)
+	assert.Len(t, resp.Body.Meta.Instances, 2)
+
+	_, err = handler.getServer(ctx, &ServerDetailInput{ServerName: "default%2Ffetch", Environment: "staging"}, false)
+	assert.Error(t, err)
+}
+
+func TestServerHandler_ListServers_EnvironmentFilter(t *testing.T) {
+	c := setupTestClient(t)
+	handler := NewServerHandler(c, nil, zerolog.Nop())
+	ctx := context.Background()
+
+	for _, env := range []string{"dev", "prod"} {
+		require.NoError(t, c.Create(ctx, &agentregistryv1alpha1.MCPServerCatalog{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   env + "-default-fetch",
+				Labels: map[string]string{agentregistryv1alpha1.LabelEnvironment: env},
+			},
+			Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:    "default/fetch",
+				Version: "1.0.0",
+			},
+		}))
+	}
+
+	resp, err := handler.listServers(ctx, &ListServersInput{Environment: "prod"}, false)
+	require.NoError(t, err)
+	require.Len(t, resp.Body.Servers, 1)
+	assert.Equal(t, "prod", resp.Body.Servers[0].Meta.Environment)
+
+	resp, err = handler.listServers(ctx, &ListServersInput{}, false)
+	require.NoError(t, err)
+	assert.Len(t, resp.Body.Servers, 2)
+}
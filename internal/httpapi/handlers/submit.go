@@ -11,11 +11,11 @@ import (
 	"time"
 
 	"github.com/rs/zerolog"
-	"gopkg.in/yaml.v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/manifest"
 )
 
 // SubmitHandler handles resource submission from external repositories
@@ -48,62 +48,7 @@ type SubmitResponse struct {
 }
 
 // AgentRegistryManifest represents the .agentregistry.yaml file structure
-type AgentRegistryManifest struct {
-	Kind        string `yaml:"kind"` // mcp-server, agent, skill
-	Name        string `yaml:"name"`
-	Version     string `yaml:"version"`
-	Title       string `yaml:"title,omitempty"`
-	Description string `yaml:"description,omitempty"`
-
-	// Package info
-	Packages []ManifestPackage `yaml:"packages,omitempty"`
-
-	// Remote endpoints
-	Remotes []ManifestRemote `yaml:"remotes,omitempty"`
-
-	// Agent-specific
-	Agent *ManifestAgent `yaml:"agent,omitempty"`
-
-	// Skill-specific
-	Skill *ManifestSkill `yaml:"skill,omitempty"`
-
-	// Config requirements
-	Config *ManifestConfig `yaml:"config,omitempty"`
-}
-
-type ManifestPackage struct {
-	Type       string `yaml:"type"` // oci, npm, pypi
-	Image      string `yaml:"image,omitempty"`
-	Identifier string `yaml:"identifier,omitempty"`
-	Transport  string `yaml:"transport,omitempty"`
-}
-
-type ManifestRemote struct {
-	URL  string `yaml:"url"`
-	Type string `yaml:"type,omitempty"`
-}
-
-type ManifestAgent struct {
-	Framework     string `yaml:"framework,omitempty"`
-	Language      string `yaml:"language,omitempty"`
-	ModelProvider string `yaml:"modelProvider,omitempty"`
-	ModelName     string `yaml:"modelName,omitempty"`
-}
-
-type ManifestSkill struct {
-	Category string `yaml:"category,omitempty"`
-}
-
-type ManifestConfig struct {
-	Required []ManifestConfigVar `yaml:"required,omitempty"`
-	Optional []ManifestConfigVar `yaml:"optional,omitempty"`
-}
-
-type ManifestConfigVar struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description,omitempty"`
-	Default     string `yaml:"default,omitempty"`
-}
+type AgentRegistryManifest = manifest.AgentRegistryManifest
 
 // Submit handles POST /admin/v0/submit
 func (h *SubmitHandler) Submit(w http.ResponseWriter, r *http.Request) {
@@ -267,58 +212,21 @@ func (h *SubmitHandler) fetchManifest(ctx context.Context, repo *repoInfo) (*Age
 		return nil, fmt.Errorf("failed to read manifest: %w", err)
 	}
 
-	var manifest AgentRegistryManifest
-	if err := yaml.Unmarshal(body, &manifest); err != nil {
-		return nil, fmt.Errorf("failed to parse manifest YAML: %w", err)
-	}
-
-	return &manifest, nil
+	return manifest.Parse(body)
 }
 
 func validateManifest(m *AgentRegistryManifest) error {
-	if m.Kind == "" {
-		return fmt.Errorf("kind is required")
-	}
-	if m.Kind != "mcp-server" && m.Kind != "agent" && m.Kind != "skill" {
-		return fmt.Errorf("kind must be one of: mcp-server, agent, skill")
-	}
-	if m.Name == "" {
-		return fmt.Errorf("name is required")
-	}
-	if m.Version == "" {
-		return fmt.Errorf("version is required")
-	}
-	return nil
+	return manifest.Validate(m)
 }
 
 func (h *SubmitHandler) createMCPServerCatalog(ctx context.Context, m *AgentRegistryManifest, repo *repoInfo) (string, error) {
 	// Generate a sanitized name for the CR
 	crName := sanitizeCRName(fmt.Sprintf("submit-%s-%s-%s", repo.Owner, m.Name, m.Version))
 
-	// Convert manifest packages to catalog packages
-	var packages []agentregistryv1alpha1.Package
-	for _, p := range m.Packages {
-		pkg := agentregistryv1alpha1.Package{
-			RegistryType: p.Type,
-			Transport: agentregistryv1alpha1.Transport{
-				Type: p.Transport,
-			},
-		}
-		if p.Image != "" {
-			pkg.Identifier = p.Image
-		} else {
-			pkg.Identifier = p.Identifier
-		}
-		packages = append(packages, pkg)
-	}
-
-	// Convert remotes
-	var remotes []agentregistryv1alpha1.Transport
-	for _, r := range m.Remotes {
-		remotes = append(remotes, agentregistryv1alpha1.Transport{
-			Type: r.Type,
-			URL:  r.URL,
-		})
+	spec := m.MCPServerCatalogSpec()
+	spec.Repository = &agentregistryv1alpha1.Repository{
+		URL:    repo.URL,
+		Source: repo.Host,
 	}
 
 	catalog := &agentregistryv1alpha1.MCPServerCatalog{
@@ -333,18 +241,7 @@ func (h *SubmitHandler) createMCPServerCatalog(ctx context.Context, m *AgentRegi
 				"agentregistry.dev/repository-url": repo.URL,
 			},
 		},
-		Spec: agentregistryv1alpha1.MCPServerCatalogSpec{
-			Name:        m.Name,
-			Version:     m.Version,
-			Title:       m.Title,
-			Description: m.Description,
-			Repository: &agentregistryv1alpha1.Repository{
-				URL:    repo.URL,
-				Source: repo.Host,
-			},
-			Packages: packages,
-			Remotes:  remotes,
-		},
+		Spec: spec,
 	}
 
 	if err := h.client.Create(ctx, catalog); err != nil {
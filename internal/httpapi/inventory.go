@@ -0,0 +1,56 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/agentregistry-dev/agentregistry/internal/controller"
+)
+
+// WithInventoryIngester enables the inventory report ingest endpoint
+func WithInventoryIngester(ingester *controller.InventoryIngester) ServerOption {
+	return func(s *Server) {
+		s.inventoryIngester = ingester
+	}
+}
+
+type InventoryReportInput struct {
+	Body controller.InventoryReport
+}
+
+type InventoryReportResponse struct {
+	Body controller.InventoryResult
+}
+
+// registerInventoryRoutes registers the inventory report ingest endpoint. Reporting clusters
+// authenticate with API tokens rather than OIDC.
+func (s *Server) registerInventoryRoutes() {
+	if s.inventoryIngester == nil {
+		return
+	}
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "admin-ingest-inventory-report",
+		Method:      http.MethodPost,
+		Path:        "/admin/v0/inventory/reports",
+		Summary:     "Ingest the inventory of a cluster the registry cannot reach",
+		Description: "Replaces the discovered catalog entries of the report's environment and cluster " +
+			"with the reported objects and records. Entries missing from the report are removed.",
+		Tags:        []string{"admin", "inventory"},
+		Middlewares: huma.Middlewares{s.authMiddleware},
+	}, func(ctx context.Context, input *InventoryReportInput) (*InventoryReportResponse, error) {
+		result, err := s.inventoryIngester.Ingest(ctx, &input.Body)
+		switch {
+		case apierrors.IsBadRequest(err):
+			return nil, huma.Error400BadRequest(err.Error())
+		case apierrors.IsConflict(err):
+			return nil, huma.Error409Conflict(err.Error())
+		case err != nil:
+			return nil, huma.Error500InternalServerError("Failed to ingest inventory report", err)
+		}
+		return &InventoryReportResponse{Body: *result}, nil
+	})
+}
@@ -0,0 +1,81 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/agentregistry-dev/agentregistry/internal/controller"
+)
+
+// WithSatelliteHub enables the endpoints satellites pull deployments from and report status to
+func WithSatelliteHub(hub *controller.SatelliteHub) ServerOption {
+	return func(s *Server) {
+		s.satelliteHub = hub
+	}
+}
+
+type SatelliteDeploymentsInput struct {
+	Environment string `path:"environment" doc:"Satellite environment name"`
+}
+
+type SatelliteDeploymentsResponse struct {
+	Body controller.SatelliteDeploymentList
+}
+
+type SatelliteStatusInput struct {
+	Environment string `path:"environment" doc:"Satellite environment name"`
+	Namespace   string `path:"namespace" doc:"Namespace of the RegistryDeployment"`
+	Name        string `path:"name" doc:"Name of the RegistryDeployment"`
+	Body        controller.SatelliteDeploymentStatus
+}
+
+// registerSatelliteRoutes registers the satellite endpoints. Satellites authenticate with API
+// tokens rather than OIDC.
+func (s *Server) registerSatelliteRoutes() {
+	if s.satelliteHub == nil {
+		return
+	}
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "admin-list-satellite-deployments",
+		Method:      http.MethodGet,
+		Path:        "/admin/v0/satellite/environments/{environment}/deployments",
+		Summary:     "List the deployments a satellite applies",
+		Description: "Returns every RegistryDeployment targeted at the satellite environment, resolved " +
+			"into the runtime format. The satellite removes the resources of deployments missing from the list.",
+		Tags:        []string{"admin", "satellite"},
+		Middlewares: huma.Middlewares{s.authMiddleware},
+	}, func(ctx context.Context, input *SatelliteDeploymentsInput) (*SatelliteDeploymentsResponse, error) {
+		list, err := s.satelliteHub.Deployments(ctx, input.Environment)
+		switch {
+		case apierrors.IsNotFound(err):
+			return nil, huma.Error404NotFound(err.Error())
+		case err != nil:
+			return nil, huma.Error500InternalServerError("Failed to list satellite deployments", err)
+		}
+		return &SatelliteDeploymentsResponse{Body: *list}, nil
+	})
+
+	huma.Register(s.api, huma.Operation{
+		OperationID: "admin-update-satellite-deployment-status",
+		Method:      http.MethodPut,
+		Path:        "/admin/v0/satellite/environments/{environment}/deployments/{namespace}/{name}/status",
+		Summary:     "Report the status of a deployment applied by a satellite",
+		Tags:        []string{"admin", "satellite"},
+		Middlewares: huma.Middlewares{s.authMiddleware},
+	}, func(ctx context.Context, input *SatelliteStatusInput) (*struct{}, error) {
+		err := s.satelliteHub.UpdateStatus(ctx, input.Environment, input.Namespace, input.Name, &input.Body)
+		switch {
+		case apierrors.IsNotFound(err):
+			return nil, huma.Error404NotFound(err.Error())
+		case apierrors.IsConflict(err):
+			return nil, huma.Error409Conflict(err.Error())
+		case err != nil:
+			return nil, huma.Error500InternalServerError("Failed to update deployment status", err)
+		}
+		return &struct{}{}, nil
+	})
+}
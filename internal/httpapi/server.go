@@ -25,6 +25,7 @@ import (
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
 	"github.com/agentregistry-dev/agentregistry/internal/config"
+	"github.com/agentregistry-dev/agentregistry/internal/controller"
 	"github.com/agentregistry-dev/agentregistry/internal/httpapi/handlers"
 	"github.com/agentregistry-dev/agentregistry/internal/version"
 )
@@ -46,6 +47,11 @@ type Server struct {
 	allowedTokens  map[string]bool // Simple token allowlist for now
 	oidcVerifier   *OIDCVerifier
 	wrappedHandler http.Handler // Wrapped handler with UI serving
+
+	inventoryIngester   *controller.InventoryIngester
+	satelliteHub        *controller.SatelliteHub
+	discoveryPreviewer  *controller.DiscoveryPreviewer
+	deploymentValidator *controller.DeploymentValidator
 }
 
 // NewServer creates a new HTTP API server
@@ -145,6 +151,15 @@ func (s *Server) deployAuthMiddleware(ctx huma.Context, next func(huma.Context))
 	next(ctx)
 }
 
+// adminGroupMiddleware restricts an operation to the OIDC admin group. It runs after
+// deployAuthMiddleware, which already rejects requests when OIDC is not configured.
+func (s *Server) adminGroupMiddleware(ctx huma.Context, next func(huma.Context)) {
+	if s.authEnabled && s.oidcVerifier != nil && !s.oidcVerifier.RequireAdminGroup(ctx) {
+		return
+	}
+	next(ctx)
+}
+
 func (s *Server) isDeployWriteRequest(ctx huma.Context) bool {
 	path := ctx.URL().Path
 	if !strings.HasPrefix(path, "/admin/v0/deployments") {
@@ -179,7 +194,7 @@ func (s *Server) loadTokensFromSecret() {
 	secret := &corev1.Secret{}
 	err := s.client.Get(context.Background(), client.ObjectKey{
 		Namespace: namespace,
-		Name:      "agentregistry-api-tokens",
+		Name:      config.APITokensSecretName,
 	}, secret)
 
 	if err != nil {
@@ -221,6 +236,10 @@ func (s *Server) registerRoutes() {
 	deploymentHandler.RegisterRoutes(s.api, "/v0", false)
 	environmentHandler.RegisterRoutes(s.api, "/v0", false)
 
+	// Inventory reports and satellites use token auth, so register them before the OIDC middleware
+	s.registerInventoryRoutes()
+	s.registerSatelliteRoutes()
+
 	// Register admin API endpoints with auth middleware
 	if s.authEnabled {
 		s.api.UseMiddleware(s.deployAuthMiddleware)
@@ -234,6 +253,8 @@ func (s *Server) registerRoutes() {
 
 	// Register admin utility endpoints
 	s.registerAdminUtilityRoutes()
+	s.registerDiscoveryRoutes()
+	s.registerDeploymentValidationRoutes()
 
 	// Register submit endpoint
 	submitHandler := handlers.NewSubmitHandler(s.client, s.logger)
@@ -746,6 +767,12 @@ func (s *Server) registerStaticFiles() {
 	s.logger.Info().Msg("serving embedded UI files")
 }
 
+// NeedLeaderElection implements manager.LeaderElectionRunnable. The API serves on every
+// replica, not only on the leader.
+func (r *serverRunnable) NeedLeaderElection() bool {
+	return false
+}
+
 func (r *serverRunnable) Start(ctx context.Context) error {
 	// Load tokens and OIDC now that the cache is started
 	r.server.loadTokensFromSecret()
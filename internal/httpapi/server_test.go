@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -21,6 +22,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+	"github.com/agentregistry-dev/agentregistry/internal/config"
+	"github.com/agentregistry-dev/agentregistry/internal/controller"
 )
 
 // mockCache implements cache.Cache for testing
@@ -527,3 +530,196 @@ func TestConvertExternalToSpec_EmptyOptionalFields(t *testing.T) {
 	assert.Empty(t, spec.Packages)
 	assert.Empty(t, spec.Remotes)
 }
+
+func TestServer_InventoryReportEndpoint(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = agentregistryv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(&agentregistryv1alpha1.DiscoveryConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: config.GetNamespace()},
+			Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+				Environments: []agentregistryv1alpha1.Environment{{
+					Name:    "dev",
+					Cluster: agentregistryv1alpha1.ClusterConfig{Name: "dev-cluster"},
+				}},
+			},
+		}).
+		WithStatusSubresource(&agentregistryv1alpha1.MCPServerCatalog{}).
+		Build()
+	logger := zerolog.Nop()
+	ingester := controller.NewInventoryIngester(c, scheme, controller.NewDiscoveredResourceCache(), logger)
+	server := NewServer(c, &mockCache{client: c}, logger, WithInventoryIngester(ingester))
+	// Reporting clusters use API tokens; OIDC is not configured
+	server.authEnabled = true
+	server.allowedTokens["satellite-token"] = true
+
+	post := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/v0/inventory/reports", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.mux.ServeHTTP(rec, req)
+		return rec
+	}
+	report := `{"environment":"edge","cluster":"edge-1","records":[{"kind":"MCPServer","namespace":"tools","name":"fs","url":"http://fs.tools:8080/mcp"}]}`
+
+	assert.Equal(t, http.StatusUnauthorized, post("", report).Code)
+
+	rec := post("satellite-token", report)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var result controller.InventoryResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Added)
+
+	var servers agentregistryv1alpha1.MCPServerCatalogList
+	require.NoError(t, c.List(context.Background(), &servers))
+	require.Len(t, servers.Items, 1)
+	assert.Equal(t, "tools/fs", servers.Items[0].Spec.Name)
+
+	assert.Equal(t, http.StatusConflict, post("satellite-token", `{"environment":"dev","cluster":"dev-cluster"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post("satellite-token", `{"environment":"edge","cluster":"edge-1","objects":[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm","namespace":"tools"}}]}`).Code)
+}
+
+func TestServer_SatelliteEndpoints(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = agentregistryv1alpha1.AddToScheme(scheme)
+	deployment := &agentregistryv1alpha1.RegistryDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge-server", Namespace: config.GetNamespace(), Generation: 1},
+		Spec: agentregistryv1alpha1.RegistryDeploymentSpec{
+			ResourceName: "test-server",
+			Version:      "1.0.0",
+			ResourceType: agentregistryv1alpha1.ResourceTypeMCP,
+			Runtime:      agentregistryv1alpha1.RuntimeTypeKubernetes,
+			Environment:  "edge",
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(deployment, &agentregistryv1alpha1.DiscoveryConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "discovery", Namespace: config.GetNamespace()},
+			Spec: agentregistryv1alpha1.DiscoveryConfigSpec{
+				Environments: []agentregistryv1alpha1.Environment{{
+					Name:          "edge",
+					Provider:      "satellite",
+					Cluster:       agentregistryv1alpha1.ClusterConfig{Name: "edge-1"},
+					DeployEnabled: true,
+				}},
+			},
+		}).
+		WithIndex(&agentregistryv1alpha1.MCPServerCatalog{}, controller.IndexMCPServerName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.MCPServerCatalog).Spec.Name}
+		}).
+		WithStatusSubresource(&agentregistryv1alpha1.RegistryDeployment{}).
+		Build()
+	logger := zerolog.Nop()
+	server := NewServer(c, &mockCache{client: c}, logger, WithSatelliteHub(controller.NewSatelliteHub(c, scheme, logger)))
+	// Satellites use API tokens; OIDC is not configured
+	server.authEnabled = true
+	server.allowedTokens["satellite-token"] = true
+
+	call := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		server.mux.ServeHTTP(rec, req)
+		return rec
+	}
+	list := "/admin/v0/satellite/environments/edge/deployments"
+
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodGet, list, "", "").Code)
+	assert.Equal(t, http.StatusNotFound, call(http.MethodGet, "/admin/v0/satellite/environments/other/deployments", "satellite-token", "").Code)
+
+	rec := call(http.MethodGet, list, "satellite-token", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var deployments controller.SatelliteDeploymentList
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deployments))
+	require.Len(t, deployments.Deployments, 1)
+	assert.Equal(t, "edge-server", deployments.Deployments[0].Name)
+	assert.Contains(t, deployments.Deployments[0].Error, "not found", "the catalog entry is missing")
+
+	status := list + "/" + config.GetNamespace() + "/edge-server/status"
+	rec = call(http.MethodPut, status, "satellite-token", `{"observedGeneration":1,"phase":"Running"}`)
+	require.Less(t, rec.Code, 300, rec.Body.String())
+	var updated agentregistryv1alpha1.RegistryDeployment
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(deployment), &updated))
+	assert.Equal(t, agentregistryv1alpha1.DeploymentPhaseRunning, updated.Status.Phase)
+
+	assert.Equal(t, http.StatusNotFound, call(http.MethodPut, list+"/"+config.GetNamespace()+"/gone/status", "satellite-token", `{"observedGeneration":1,"phase":"Running"}`).Code)
+}
+
+func TestServer_DiscoveryPreviewEndpoint(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = agentregistryv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	logger := zerolog.Nop()
+	server := NewServer(c, &mockCache{client: c}, logger, WithDiscoveryPreviewer(controller.NewDiscoveryPreviewer(c, scheme, logger)))
+
+	oldFactory := controller.RemoteClientFactory
+	controller.RemoteClientFactory = func(_ controller.DiscoveryScope, env *agentregistryv1alpha1.Environment, scheme *runtime.Scheme) (client.WithWatch, error) {
+		return nil, assert.AnError
+	}
+	defer func() { controller.RemoteClientFactory = oldFactory }()
+
+	call := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/v0/discovery/preview?name=discovery", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		server.mux.ServeHTTP(rec, req)
+		return rec
+	}
+	spec := `{"environments":[{"name":"dev","cluster":{"name":"dev-cluster"},"namespaces":["tools"]}]}`
+
+	// Admin routes require OIDC when auth is enabled
+	assert.Equal(t, http.StatusUnauthorized, call(spec).Code)
+
+	server.authEnabled = false
+	rec := call(spec)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var preview controller.DiscoveryPreview
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &preview))
+	assert.Empty(t, preview.Create)
+	require.Len(t, preview.Environments, 1)
+	assert.False(t, preview.Environments[0].Connected)
+	assert.Contains(t, preview.Environments[0].Errors[0], assert.AnError.Error())
+
+	assert.Equal(t, http.StatusBadRequest, call(`{"environments":[]}`).Code)
+}
+
+func TestServer_ValidateDeploymentEndpoint(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = agentregistryv1alpha1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&agentregistryv1alpha1.MCPServerCatalog{}, controller.IndexMCPServerName, func(obj client.Object) []string {
+			return []string{obj.(*agentregistryv1alpha1.MCPServerCatalog).Spec.Name}
+		}).
+		Build()
+	logger := zerolog.Nop()
+	server := NewServer(c, &mockCache{client: c}, logger, WithDeploymentValidator(controller.NewDeploymentValidator(c, scheme, logger)))
+	server.authEnabled = false
+
+	call := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/v0/deployments/validate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		server.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call(`{"resourceName":"github","version":"1.0.0","resourceType":"mcp","runtime":"kubernetes"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var result controller.PreflightResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.False(t, result.Passed)
+	require.NotEmpty(t, result.Checks)
+	assert.Equal(t, "Catalog", result.Checks[0].Name)
+	assert.Equal(t, "MCP server github version 1.0.0 not found", result.Checks[0].Message)
+
+	assert.Equal(t, http.StatusBadRequest, call(`{"resourceName":"","version":"","resourceType":"mcp","runtime":"kubernetes"}`).Code)
+}
@@ -0,0 +1,136 @@
+// Package manifest parses the files repositories use to describe MCP servers: the
+// .agentregistry.yaml submission manifest and the MCP client configs of editors and desktop apps.
+package manifest
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+// FileName is the name of the manifest in a repository root
+const FileName = ".agentregistry.yaml"
+
+// AgentRegistryManifest represents the .agentregistry.yaml file structure
+type AgentRegistryManifest struct {
+	Kind        string `yaml:"kind"` // mcp-server, agent, skill
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	Title       string `yaml:"title,omitempty"`
+	Description string `yaml:"description,omitempty"`
+
+	// Package info
+	Packages []ManifestPackage `yaml:"packages,omitempty"`
+
+	// Remote endpoints
+	Remotes []ManifestRemote `yaml:"remotes,omitempty"`
+
+	// Agent-specific
+	Agent *ManifestAgent `yaml:"agent,omitempty"`
+
+	// Skill-specific
+	Skill *ManifestSkill `yaml:"skill,omitempty"`
+
+	// Config requirements
+	Config *ManifestConfig `yaml:"config,omitempty"`
+}
+
+type ManifestPackage struct {
+	Type       string `yaml:"type"` // oci, npm, pypi
+	Image      string `yaml:"image,omitempty"`
+	Identifier string `yaml:"identifier,omitempty"`
+	Transport  string `yaml:"transport,omitempty"`
+}
+
+type ManifestRemote struct {
+	URL  string `yaml:"url"`
+	Type string `yaml:"type,omitempty"`
+}
+
+type ManifestAgent struct {
+	Framework     string `yaml:"framework,omitempty"`
+	Language      string `yaml:"language,omitempty"`
+	ModelProvider string `yaml:"modelProvider,omitempty"`
+	ModelName     string `yaml:"modelName,omitempty"`
+}
+
+type ManifestSkill struct {
+	Category string `yaml:"category,omitempty"`
+}
+
+type ManifestConfig struct {
+	Required []ManifestConfigVar `yaml:"required,omitempty"`
+	Optional []ManifestConfigVar `yaml:"optional,omitempty"`
+}
+
+type ManifestConfigVar struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Default     string `yaml:"default,omitempty"`
+}
+
+// Parse parses a .agentregistry.yaml manifest
+func Parse(data []byte) (*AgentRegistryManifest, error) {
+	var m AgentRegistryManifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest YAML: %w", err)
+	}
+	return &m, nil
+}
+
+// Validate checks the fields every manifest needs
+func Validate(m *AgentRegistryManifest) error {
+	if m.Kind == "" {
+		return fmt.Errorf("kind is required")
+	}
+	if m.Kind != "mcp-server" && m.Kind != "agent" && m.Kind != "skill" {
+		return fmt.Errorf("kind must be one of: mcp-server, agent, skill")
+	}
+	if m.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if m.Version == "" {
+		return fmt.Errorf("version is required")
+	}
+	return nil
+}
+
+// MCPServerCatalogSpec converts an mcp-server manifest to a catalog spec
+func (m *AgentRegistryManifest) MCPServerCatalogSpec() agentregistryv1alpha1.MCPServerCatalogSpec {
+	// Convert manifest packages to catalog packages
+	var packages []agentregistryv1alpha1.Package
+	for _, p := range m.Packages {
+		pkg := agentregistryv1alpha1.Package{
+			RegistryType: p.Type,
+			Transport: agentregistryv1alpha1.Transport{
+				Type: p.Transport,
+			},
+		}
+		if p.Image != "" {
+			pkg.Identifier = p.Image
+		} else {
+			pkg.Identifier = p.Identifier
+		}
+		packages = append(packages, pkg)
+	}
+
+	// Convert remotes
+	var remotes []agentregistryv1alpha1.Transport
+	for _, r := range m.Remotes {
+		remotes = append(remotes, agentregistryv1alpha1.Transport{
+			Type: r.Type,
+			URL:  r.URL,
+		})
+	}
+
+	return agentregistryv1alpha1.MCPServerCatalogSpec{
+		Name:        m.Name,
+		Version:     m.Version,
+		Title:       m.Title,
+		Description: m.Description,
+		Packages:    packages,
+		Remotes:     remotes,
+	}
+}
@@ -0,0 +1,202 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(`
+kind: mcp-server
+name: acme/weather
+version: 1.2.0
+packages:
+  - type: oci
+    image: ghcr.io/acme/weather:1.2.0
+    transport: stdio
+remotes:
+  - url: https://weather.acme.dev/mcp
+    type: streamable-http
+`))
+	require.NoError(t, err)
+	require.NoError(t, Validate(m))
+
+	spec := m.MCPServerCatalogSpec()
+	assert.Equal(t, "acme/weather", spec.Name)
+	assert.Equal(t, "1.2.0", spec.Version)
+	assert.Equal(t, []agentregistryv1alpha1.Package{{
+		RegistryType: "oci",
+		Identifier:   "ghcr.io/acme/weather:1.2.0",
+		Transport:    agentregistryv1alpha1.Transport{Type: "stdio"},
+	}}, spec.Packages)
+	assert.Equal(t, []agentregistryv1alpha1.Transport{{Type: "streamable-http", URL: "https://weather.acme.dev/mcp"}}, spec.Remotes)
+
+	_, err = Parse([]byte("kind: ["))
+	assert.Error(t, err)
+}
+
+func TestIsMCPClientConfig(t *testing.T) {
+	for p, want := range map[string]bool{
+		".vscode/mcp.json":                    true,
+		".cursor/mcp.json":                    true,
+		"mcp.json":                            true,
+		"claude_desktop_config.json":          true,
+		"examples/claude_desktop_config.json": true,
+		"package.json":                        false,
+		"mcp.json.example":                    false,
+	} {
+		assert.Equal(t, want, IsMCPClientConfig(p), p)
+	}
+}
+
+func TestParseMCPClientConfig(t *testing.T) {
+	t.Run("vscode", func(t *testing.T) {
+		servers, err := ParseMCPClientConfig([]byte(`{
+  // Servers used by the team
+  "inputs": [{"type": "promptString", "id": "token", "password": true}],
+  "servers": {
+    "github": {
+      "type": "http",
+      "url": "https://api.githubcopilot.com/mcp/",
+      "headers": {"Authorization": "Bearer ${input:token}"},
+    },
+    /* local tools */
+    "fetch": {"command": "uvx", "args": ["mcp-server-fetch==2025.4.7"]},
+  },
+}`))
+		require.NoError(t, err)
+		assert.Equal(t, []MCPClientServer{
+			{Name: "fetch", Type: "stdio", Command: "uvx", Args: []string{"mcp-server-fetch==2025.4.7"}},
+			{Name: "github", Type: "streamable-http", URL: "https://api.githubcopilot.com/mcp/", Headers: map[string]string{"Authorization": "Bearer ${input:token}"}},
+		}, servers)
+	})
+
+	t.Run("mcpServers", func(t *testing.T) {
+		servers, err := ParseMCPClientConfig([]byte(`{"mcpServers": {
+  "linear": {"url": "https://mcp.linear.app/sse"},
+  "remote": {"serverUrl": "https://example.com/mcp"},
+  "broken": {}
+}}`))
+		require.NoError(t, err)
+		require.Len(t, servers, 2)
+		assert.Equal(t, "linear", servers[0].Name)
+		assert.Equal(t, "sse", servers[0].Type)
+		assert.Equal(t, "remote", servers[1].Name)
+		assert.Equal(t, "streamable-http", servers[1].Type)
+		assert.Equal(t, "https://example.com/mcp", servers[1].URL)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseMCPClientConfig([]byte(`{"servers": [}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestMCPClientServer_MCPServerCatalogSpec(t *testing.T) {
+	tests := []struct {
+		name   string
+		server MCPClientServer
+		want   agentregistryv1alpha1.MCPServerCatalogSpec
+	}{
+		{
+			name: "npx",
+			server: MCPClientServer{Name: "gh", Type: "stdio", Command: "npx",
+				Args: []string{"-y", "@modelcontextprotocol/server-github@0.6.2"},
+				Env:  map[string]string{"GITHUB_PERSONAL_ACCESS_TOKEN": "ghp_secret"}},
+			want: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:    "@modelcontextprotocol/server-github",
+				Version: "0.6.2",
+				Packages: []agentregistryv1alpha1.Package{{
+					RegistryType:         "npm",
+					Identifier:           "@modelcontextprotocol/server-github",
+					Version:              "0.6.2",
+					RuntimeHint:          "npx",
+					Transport:            agentregistryv1alpha1.Transport{Type: "stdio"},
+					EnvironmentVariables: []agentregistryv1alpha1.KeyValueInput{{Name: "GITHUB_PERSONAL_ACCESS_TOKEN"}},
+				}},
+			},
+		},
+		{
+			name:   "uvx from",
+			server: MCPClientServer{Name: "git", Type: "stdio", Command: "uvx", Args: []string{"--from", "mcp-server-git@1.0.0", "mcp-server-git"}},
+			want: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:    "mcp-server-git",
+				Version: "1.0.0",
+				Packages: []agentregistryv1alpha1.Package{{
+					RegistryType: "pypi",
+					Identifier:   "mcp-server-git",
+					Version:      "1.0.0",
+					RuntimeHint:  "uvx",
+					Transport:    agentregistryv1alpha1.Transport{Type: "stdio"},
+				}},
+			},
+		},
+		{
+			name: "docker",
+			server: MCPClientServer{Name: "github", Type: "stdio", Command: "docker",
+				Args: []string{"run", "-i", "--rm", "-e", "GITHUB_TOKEN", "-v", "/tmp:/tmp", "ghcr.io/github/github-mcp-server"}},
+			want: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:    "ghcr.io/github/github-mcp-server",
+				Version: "latest",
+				Packages: []agentregistryv1alpha1.Package{{
+					RegistryType:         "oci",
+					Identifier:           "ghcr.io/github/github-mcp-server",
+					RuntimeHint:          "docker",
+					Transport:            agentregistryv1alpha1.Transport{Type: "stdio"},
+					EnvironmentVariables: []agentregistryv1alpha1.KeyValueInput{{Name: "GITHUB_TOKEN"}},
+				}},
+			},
+		},
+		{
+			name:   "docker with registry port and tag",
+			server: MCPClientServer{Name: "local", Type: "stdio", Command: "podman", Args: []string{"run", "registry.local:5000/tools/mcp:v2"}},
+			want: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:    "registry.local:5000/tools/mcp",
+				Version: "v2",
+				Packages: []agentregistryv1alpha1.Package{{
+					RegistryType: "oci",
+					Identifier:   "registry.local:5000/tools/mcp",
+					Version:      "v2",
+					RuntimeHint:  "podman",
+					Transport:    agentregistryv1alpha1.Transport{Type: "stdio"},
+				}},
+			},
+		},
+		{
+			name:   "other command",
+			server: MCPClientServer{Name: "notes", Type: "stdio", Command: "./bin/notes-mcp", Args: []string{"--db", "notes.db"}},
+			want: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:    "notes",
+				Version: "latest",
+				Packages: []agentregistryv1alpha1.Package{{
+					RegistryType: "command",
+					Identifier:   "./bin/notes-mcp",
+					Transport:    agentregistryv1alpha1.Transport{Type: "stdio"},
+				}},
+			},
+		},
+		{
+			name: "remote",
+			server: MCPClientServer{Name: "search", Type: "streamable-http", URL: "https://search.example.com/mcp?apiKey=secret",
+				Headers: map[string]string{"X-Api-Key": "secret", "Authorization": "Bearer secret"}},
+			want: agentregistryv1alpha1.MCPServerCatalogSpec{
+				Name:    "search",
+				Version: "latest",
+				Remotes: []agentregistryv1alpha1.Transport{{
+					Type:    "streamable-http",
+					URL:     "https://search.example.com/mcp",
+					Headers: []agentregistryv1alpha1.KeyValueInput{{Name: "Authorization"}, {Name: "X-Api-Key"}},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.server.MCPServerCatalogSpec())
+		})
+	}
+}
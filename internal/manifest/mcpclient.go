@@ -0,0 +1,305 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	agentregistryv1alpha1 "github.com/agentregistry-dev/agentregistry/api/v1alpha1"
+)
+
+// defaultVersion is the version of servers whose config does not pin one
+const defaultVersion = "latest"
+
+// MCPClientServer is a server configured in an MCP client config such as .vscode/mcp.json,
+// .cursor/mcp.json or claude_desktop_config.json
+type MCPClientServer struct {
+	// Name is the key the server is configured under
+	Name string
+	// Type is stdio, streamable-http or sse
+	Type    string
+	Command string
+	Args    []string
+	Env     map[string]string
+	URL     string
+	Headers map[string]string
+}
+
+// mcpClientConfig covers both layouts in use: VS Code keys servers by "servers", Cursor, Claude
+// Desktop and most other clients by "mcpServers"
+type mcpClientConfig struct {
+	Servers    map[string]mcpClientServerConfig `json:"servers"`
+	MCPServers map[string]mcpClientServerConfig `json:"mcpServers"`
+}
+
+type mcpClientServerConfig struct {
+	Type      string            `json:"type"`
+	Command   string            `json:"command"`
+	Args      []string          `json:"args"`
+	Env       map[string]string `json:"env"`
+	URL       string            `json:"url"`
+	ServerURL string            `json:"serverUrl"`
+	Headers   map[string]string `json:"headers"`
+}
+
+// IsMCPClientConfig reports whether a repository path is named like an MCP client config
+func IsMCPClientConfig(p string) bool {
+	switch path.Base(p) {
+	case "mcp.json", "claude_desktop_config.json":
+		return true
+	}
+	return false
+}
+
+// ParseMCPClientConfig returns the servers of an MCP client config, sorted by name. Comments and
+// trailing commas are accepted, as editors write JSONC.
+func ParseMCPClientConfig(data []byte) ([]MCPClientServer, error) {
+	var config mcpClientConfig
+	if err := json.Unmarshal(stripJSONC(data), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse MCP client config: %w", err)
+	}
+
+	var servers []MCPClientServer
+	for _, configured := range []map[string]mcpClientServerConfig{config.Servers, config.MCPServers} {
+		for name, s := range configured {
+			server := MCPClientServer{
+				Name:    name,
+				Command: s.Command,
+				Args:    s.Args,
+				Env:     s.Env,
+				URL:     s.URL,
+				Headers: s.Headers,
+			}
+			if server.URL == "" {
+				server.URL = s.ServerURL
+			}
+			server.Type = transportType(s.Type, server.Command, server.URL)
+			if server.Type == "" {
+				continue
+			}
+			servers = append(servers, server)
+		}
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Name < servers[j].Name })
+	return servers, nil
+}
+
+// transportType normalizes the configured transport, inferring it when the config leaves it out.
+// An empty result means the entry is neither a command nor a URL.
+func transportType(configured, command, rawURL string) string {
+	switch strings.ToLower(configured) {
+	case "stdio":
+		return "stdio"
+	case "sse":
+		return "sse"
+	case "http", "streamable-http", "streamablehttp":
+		return "streamable-http"
+	}
+	switch {
+	case command != "":
+		return "stdio"
+	case strings.HasSuffix(strings.TrimSuffix(rawURL, "/"), "/sse"):
+		return "sse"
+	case rawURL != "":
+		return "streamable-http"
+	}
+	return ""
+}
+
+// MCPServerCatalogSpec converts a configured server to a catalog spec. Servers launched through
+// npx, uvx or docker are indexed by their package, so the same server configured in several
+// repositories is one entry; other commands and remotes by the name they are configured under.
+// Only the names of environment variables and headers are kept, their values are often
+// credentials.
+func (s MCPClientServer) MCPServerCatalogSpec() agentregistryv1alpha1.MCPServerCatalogSpec {
+	spec := agentregistryv1alpha1.MCPServerCatalogSpec{Name: s.Name, Version: defaultVersion}
+
+	if s.Type != "stdio" {
+		spec.Remotes = []agentregistryv1alpha1.Transport{{
+			Type:    s.Type,
+			URL:     stripQuery(s.URL),
+			Headers: inputNames(s.Headers, nil),
+		}}
+		return spec
+	}
+
+	pkg := commandPackage(s.Command, s.Args)
+	pkg.Transport = agentregistryv1alpha1.Transport{Type: "stdio"}
+	pkg.EnvironmentVariables = inputNames(s.Env, pkg.EnvironmentVariables)
+	if pkg.RegistryType != "command" {
+		spec.Name = pkg.Identifier
+	}
+	if pkg.Version != "" {
+		spec.Version = pkg.Version
+	}
+	spec.Packages = []agentregistryv1alpha1.Package{pkg}
+	return spec
+}
+
+// dockerValueFlags are the docker and podman run flags that take a separate value
+var dockerValueFlags = map[string]bool{
+	"-e": true, "--env": true, "--env-file": true, "-v": true, "--volume": true, "--mount": true,
+	"--name": true, "-p": true, "--publish": true, "--network": true, "--net": true, "-w": true,
+	"--workdir": true, "-u": true, "--user": true, "--entrypoint": true, "-l": true, "--label": true,
+	"--platform": true, "--pull": true,
+}
+
+// commandPackage identifies the package a stdio command runs
+func commandPackage(command string, args []string) agentregistryv1alpha1.Package {
+	runtime := strings.TrimSuffix(path.Base(command), ".cmd")
+	switch runtime {
+	case "npx", "bunx":
+		if identifier := firstPositional(args, map[string]bool{"-p": true, "--package": true}); identifier != "" {
+			name, version := splitVersion(identifier, "@")
+			return agentregistryv1alpha1.Package{RegistryType: "npm", Identifier: name, Version: version, RuntimeHint: runtime}
+		}
+	case "uvx", "pipx":
+		identifier := flagValue(args, "--from")
+		if identifier == "" {
+			identifier = firstPositional(args, map[string]bool{"--with": true, "--python": true})
+		}
+		if identifier != "" {
+			name, version := splitVersion(identifier, "==")
+			if version == "" {
+				name, version = splitVersion(identifier, "@")
+			}
+			return agentregistryv1alpha1.Package{RegistryType: "pypi", Identifier: name, Version: version, RuntimeHint: runtime}
+		}
+	case "docker", "podman":
+		if len(args) > 0 && args[0] == "run" {
+			if image := firstPositional(args[1:], dockerValueFlags); image != "" {
+				pkg := agentregistryv1alpha1.Package{RegistryType: "oci", Identifier: image, RuntimeHint: runtime}
+				// Image tags follow the last colon after the last slash, which a registry port precedes
+				if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+					pkg.Identifier, pkg.Version = image[:i], image[i+1:]
+				}
+				// Variables passed with -e NAME or -e NAME=value
+				for i := 1; i < len(args)-1; i++ {
+					if args[i] == "-e" || args[i] == "--env" {
+						name, _, _ := strings.Cut(args[i+1], "=")
+						pkg.EnvironmentVariables = append(pkg.EnvironmentVariables, agentregistryv1alpha1.KeyValueInput{Name: name})
+					}
+				}
+				return pkg
+			}
+		}
+	}
+	return agentregistryv1alpha1.Package{RegistryType: "command", Identifier: command}
+}
+
+// firstPositional returns the first argument that is not a flag or the value of one of
+// valueFlags
+func firstPositional(args []string, valueFlags map[string]bool) string {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case valueFlags[args[i]]:
+			i++
+		case strings.HasPrefix(args[i], "-"):
+		default:
+			return args[i]
+		}
+	}
+	return ""
+}
+
+// flagValue returns the value of a flag given as "--flag value" or "--flag=value"
+func flagValue(args []string, flag string) string {
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// splitVersion splits "name<sep>version". A leading separator, as in scoped npm packages, is part
+// of the name.
+func splitVersion(identifier, sep string) (string, string) {
+	if i := strings.LastIndex(identifier, sep); i > 0 {
+		return identifier[:i], identifier[i+len(sep):]
+	}
+	return identifier, ""
+}
+
+// inputNames appends the names of values to inputs, skipping names already present
+func inputNames(values map[string]string, inputs []agentregistryv1alpha1.KeyValueInput) []agentregistryv1alpha1.KeyValueInput {
+	seen := make(map[string]bool, len(inputs))
+	for _, input := range inputs {
+		seen[input.Name] = true
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		inputs = append(inputs, agentregistryv1alpha1.KeyValueInput{Name: name})
+	}
+	return inputs
+}
+
+// stripQuery drops the query and fragment of a URL, where API keys are often passed
+func stripQuery(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery, u.Fragment, u.User = "", "", nil
+	return u.String()
+}
+
+// stripJSONC removes comments and trailing commas outside of strings
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && (data[i] != '*' || data[i+1] != '/') {
+				i++
+			}
+			i++
+		case c == '}' || c == ']':
+			// Drop a comma left dangling before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && strings.ContainsRune(" \t\r\n", rune(out[j])) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -75,9 +75,10 @@ func (s *MCPServer) Handler() http.Handler {
 	return s.authMiddleware(s.httpServer)
 }
 
-// Runnable returns a manager.Runnable that starts the MCP server on its own port.
+// Runnable returns a manager.Runnable that starts the MCP server on its own port. It serves
+// on every replica, not only on the leader.
 func (s *MCPServer) Runnable(addr string) manager.Runnable {
-	return manager.RunnableFunc(func(ctx context.Context) error {
+	return everyReplica(func(ctx context.Context) error {
 		// Load tokens now that the cache is started
 		s.loadTokensFromSecret()
 
@@ -115,6 +116,19 @@ func (s *MCPServer) Runnable(addr string) manager.Runnable {
 	})
 }
 
+// everyReplica is a runnable that runs whether or not the replica is the leader
+type everyReplica func(context.Context) error
+
+// Start implements manager.Runnable
+func (f everyReplica) Start(ctx context.Context) error {
+	return f(ctx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (everyReplica) NeedLeaderElection() bool {
+	return false
+}
+
 // loadTokensFromSecret loads API tokens from the Kubernetes Secret "agentregistry-api-tokens".
 // Each key in the secret data is treated as a valid token. This is the same secret used by the HTTP API.
 func (s *MCPServer) loadTokensFromSecret() {
@@ -123,7 +137,7 @@ func (s *MCPServer) loadTokensFromSecret() {
 	secret := &corev1.Secret{}
 	err := s.client.Get(context.Background(), client.ObjectKey{
 		Namespace: namespace,
-		Name:      "agentregistry-api-tokens",
+		Name:      config.APITokensSecretName,
 	}, secret)
 
 	if err != nil {
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -25,14 +28,16 @@ func (s *MCPServer) registerTools() {
 		mcp.WithString("version", mcp.Description("Filter by version or 'latest' (servers/agents/skills)")),
 		mcp.WithString("category", mcp.Description("Filter by category (skills only)")),
 		mcp.WithString("provider", mcp.Description("Filter by provider (models only)")),
+		mcp.WithString("environment", mcp.Description("Filter by discovery environment (servers/agents/models)")),
 		mcp.WithNumber("limit", mcp.Description("Max results (default 30)")),
 	), s.handleListCatalog)
 
 	s.mcpServer.AddTool(mcp.NewTool("get_catalog",
-		mcp.WithDescription("Get catalog entry details by type and name"),
+		mcp.WithDescription("Get catalog entry details by type and name. Servers include the tools, prompts and resources the live server exposed when last introspected; agents list referenced tools their servers do not expose."),
 		mcp.WithString("type", mcp.Description("Resource type: servers, agents, skills, or models"), mcp.Required()),
 		mcp.WithString("name", mcp.Description("Resource name"), mcp.Required()),
 		mcp.WithString("version", mcp.Description("Specific version (default: latest)")),
+		mcp.WithString("environment", mcp.Description("Select the instance from this discovery environment (servers/agents/models)")),
 	), s.handleGetCatalog)
 
 	s.mcpServer.AddTool(mcp.NewTool("get_registry_stats",
@@ -58,6 +63,7 @@ func (s *MCPServer) registerTools() {
 		mcp.WithString("resourceType", mcp.Description("Resource type: mcp or agent"), mcp.Required()),
 		mcp.WithString("namespace", mcp.Description("Target namespace (default: agentregistry)")),
 		mcp.WithObject("config", mcp.Description("Key-value deployment configuration")),
+		mcp.WithObject("secretConfig", mcp.Description(secretConfigDescription)),
 	), s.handleDeployCatalogItem)
 
 	s.mcpServer.AddTool(mcp.NewTool("delete_deployment",
@@ -68,7 +74,8 @@ func (s *MCPServer) registerTools() {
 	s.mcpServer.AddTool(mcp.NewTool("update_deployment_config",
 		mcp.WithDescription("Update deployment configuration"),
 		mcp.WithString("name", mcp.Description("Deployment name"), mcp.Required()),
-		mcp.WithObject("config", mcp.Description("Key-value configuration to merge"), mcp.Required()),
+		mcp.WithObject("config", mcp.Description("Key-value configuration to merge")),
+		mcp.WithObject("secretConfig", mcp.Description(secretConfigDescription)),
 	), s.handleUpdateDeploymentConfig)
 
 	// Discovery tools
@@ -159,6 +166,44 @@ func getStringArg(args map[string]interface{}, key string) string {
 	return ""
 }
 
+// secretConfigDescription describes the secretConfig argument of the deployment tools
+const secretConfigDescription = "Configuration read from Secrets in the agentregistry namespace " +
+	"labeled agentregistry.dev/deployment-config=true, mapping each key to \"<secret name>/<secret key>\". " +
+	"Values are never returned."
+
+// getSecretConfigArg parses a secretConfig argument into configFrom entries. References to the
+// registry's own Secrets are rejected.
+func getSecretConfigArg(args map[string]interface{}) ([]agentregistryv1alpha1.ConfigValueFrom, error) {
+	raw, ok := args["secretConfig"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := make([]agentregistryv1alpha1.ConfigValueFrom, 0, len(names))
+	for _, name := range names {
+		ref, _ := raw[name].(string)
+		secretName, key, ok := strings.Cut(ref, "/")
+		if !ok || secretName == "" || key == "" {
+			return nil, fmt.Errorf("secretConfig %q must be \"<secret name>/<secret key>\"", name)
+		}
+		entries = append(entries, agentregistryv1alpha1.ConfigValueFrom{
+			Name: name,
+			ValueFrom: agentregistryv1alpha1.ConfigValueSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			}},
+		})
+	}
+	if err := controller.ValidateConfigFrom(entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
 func getIntArg(args map[string]interface{}, key string, defaultVal int) int {
 	if v, ok := args[key]; ok {
 		switch n := v.(type) {
@@ -180,6 +225,7 @@ func (s *MCPServer) handleListCatalog(ctx context.Context, request mcp.CallToolR
 	version := getStringArg(args, "version")
 	category := getStringArg(args, "category")
 	provider := getStringArg(args, "provider")
+	environment := getStringArg(args, "environment")
 	limit := getIntArg(args, "limit", 30)
 
 	switch catalogType {
@@ -200,6 +246,7 @@ func (s *MCPServer) handleListCatalog(ctx context.Context, request mcp.CallToolR
 			Title       string `json:"title,omitempty"`
 			Description string `json:"description,omitempty"`
 			Status      string `json:"status,omitempty"`
+			Environment string `json:"environment,omitempty"`
 		}
 		results := make([]serverSummary, 0)
 		for _, item := range list.Items {
@@ -209,12 +256,16 @@ func (s *MCPServer) handleListCatalog(ctx context.Context, request mcp.CallToolR
 			if version != "" && version != "latest" && item.Spec.Version != version {
 				continue
 			}
+			if !handlers.InEnvironment(&item, environment) {
+				continue
+			}
 			results = append(results, serverSummary{
 				Name:        item.Spec.Name,
 				Version:     item.Spec.Version,
 				Title:       item.Spec.Title,
 				Description: item.Spec.Description,
 				Status:      string(item.Status.Status),
+				Environment: item.Labels[agentregistryv1alpha1.LabelEnvironment],
 			})
 			if len(results) >= limit {
 				break
@@ -240,6 +291,7 @@ func (s *MCPServer) handleListCatalog(ctx context.Context, request mcp.CallToolR
 			Description string `json:"description,omitempty"`
 			Framework   string `json:"framework,omitempty"`
 			AgentType   string `json:"agentType,omitempty"`
+			Environment string `json:"environment,omitempty"`
 		}
 		results := make([]agentSummary, 0)
 		for _, item := range list.Items {
@@ -249,6 +301,9 @@ func (s *MCPServer) handleListCatalog(ctx context.Context, request mcp.CallToolR
 			if version != "" && version != "latest" && item.Spec.Version != version {
 				continue
 			}
+			if !handlers.InEnvironment(&item, environment) {
+				continue
+			}
 			results = append(results, agentSummary{
 				Name:        item.Spec.Name,
 				Version:     item.Spec.Version,
@@ -256,6 +311,7 @@ func (s *MCPServer) handleListCatalog(ctx context.Context, request mcp.CallToolR
 				Description: item.Spec.Description,
 				Framework:   item.Spec.Framework,
 				AgentType:   item.Spec.AgentType,
+				Environment: item.Labels[agentregistryv1alpha1.LabelEnvironment],
 			})
 			if len(results) >= limit {
 				break
@@ -306,7 +362,9 @@ func (s *MCPServer) handleListCatalog(ctx context.Context, request mcp.CallToolR
 			Name        string `json:"name"`
 			Provider    string `json:"provider"`
 			Model       string `json:"model"`
+			Runtime     string `json:"runtime,omitempty"`
 			Description string `json:"description,omitempty"`
+			Environment string `json:"environment,omitempty"`
 		}
 		results := make([]modelSummary, 0)
 		for _, item := range list.Items {
@@ -316,11 +374,16 @@ func (s *MCPServer) handleListCatalog(ctx context.Context, request mcp.CallToolR
 			if provider != "" && !strings.EqualFold(item.Spec.Provider, provider) {
 				continue
 			}
+			if !handlers.InEnvironment(&item, environment) {
+				continue
+			}
 			results = append(results, modelSummary{
 				Name:        item.Spec.Name,
 				Provider:    item.Spec.Provider,
 				Model:       item.Spec.Model,
+				Runtime:     item.Spec.Runtime,
 				Description: item.Spec.Description,
+				Environment: item.Labels[agentregistryv1alpha1.LabelEnvironment],
 			})
 			if len(results) >= limit {
 				break
@@ -338,41 +401,76 @@ func (s *MCPServer) handleGetCatalog(ctx context.Context, request mcp.CallToolRe
 	catalogType := getStringArg(args, "type")
 	name := getStringArg(args, "name")
 	version := getStringArg(args, "version")
+	environment := getStringArg(args, "environment")
 
 	switch catalogType {
 	case "servers":
+		// List every entry of this server so per-environment instances can be reported
 		var list agentregistryv1alpha1.MCPServerCatalogList
-		fields := client.MatchingFields{controller.IndexMCPServerName: name}
-		if version == "" {
-			fields[controller.IndexMCPServerIsLatest] = "true"
-		}
-		if err := s.cache.List(ctx, &list, fields); err != nil {
+		if err := s.cache.List(ctx, &list, client.MatchingFields{controller.IndexMCPServerName: name}); err != nil {
 			return errorResult(fmt.Sprintf("Failed to get server: %v", err)), nil
 		}
-		for _, item := range list.Items {
-			if version != "" && item.Spec.Version != version {
+		type serverDetail struct {
+			agentregistryv1alpha1.MCPServerCatalogSpec
+			Environment   string                      `json:"environment,omitempty"`
+			Introspection *handlers.IntrospectionJSON `json:"introspection,omitempty"`
+			Instances     []handlers.InstanceJSON     `json:"instances,omitempty"`
+		}
+		var found *agentregistryv1alpha1.MCPServerCatalog
+		instances := make([]handlers.InstanceJSON, 0, len(list.Items))
+		for i := range list.Items {
+			item := &list.Items[i]
+			instances = append(instances, handlers.NewInstanceJSON(item, item.Spec.Version, item.Status.Status, item.Status.IsLatest, item.Status.Deployment))
+			if !handlers.InEnvironment(item, environment) || (version != "" && item.Spec.Version != version) {
 				continue
 			}
-			return jsonResult(item.Spec), nil
+			if found == nil || (item.Status.IsLatest && !found.Status.IsLatest) {
+				found = item
+			}
+		}
+		if found == nil || (version == "" && environment == "" && !found.Status.IsLatest) {
+			return errorResult(fmt.Sprintf("Server '%s' not found", name)), nil
 		}
-		return errorResult(fmt.Sprintf("Server '%s' not found", name)), nil
+		return jsonResult(serverDetail{
+			MCPServerCatalogSpec: found.Spec,
+			Environment:          found.Labels[agentregistryv1alpha1.LabelEnvironment],
+			Introspection:        handlers.NewIntrospectionJSON(found.Status.Introspection),
+			Instances:            instances,
+		}), nil
 
 	case "agents":
+		// List every entry of this agent so per-environment instances can be reported
 		var list agentregistryv1alpha1.AgentCatalogList
-		fields := client.MatchingFields{controller.IndexAgentName: name}
-		if version == "" {
-			fields[controller.IndexAgentIsLatest] = "true"
-		}
-		if err := s.cache.List(ctx, &list, fields); err != nil {
+		if err := s.cache.List(ctx, &list, client.MatchingFields{controller.IndexAgentName: name}); err != nil {
 			return errorResult(fmt.Sprintf("Failed to get agent: %v", err)), nil
 		}
-		for _, item := range list.Items {
-			if version != "" && item.Spec.Version != version {
+		type agentDetail struct {
+			agentregistryv1alpha1.AgentCatalogSpec
+			Environment     string                               `json:"environment,omitempty"`
+			UnresolvedTools []agentregistryv1alpha1.AgentToolRef `json:"unresolvedTools,omitempty"`
+			Instances       []handlers.InstanceJSON              `json:"instances,omitempty"`
+		}
+		var found *agentregistryv1alpha1.AgentCatalog
+		instances := make([]handlers.InstanceJSON, 0, len(list.Items))
+		for i := range list.Items {
+			item := &list.Items[i]
+			instances = append(instances, handlers.NewInstanceJSON(item, item.Spec.Version, item.Status.Status, item.Status.IsLatest, item.Status.Deployment))
+			if !handlers.InEnvironment(item, environment) || (version != "" && item.Spec.Version != version) {
 				continue
 			}
-			return jsonResult(item.Spec), nil
+			if found == nil || (item.Status.IsLatest && !found.Status.IsLatest) {
+				found = item
+			}
 		}
-		return errorResult(fmt.Sprintf("Agent '%s' not found", name)), nil
+		if found == nil || (version == "" && environment == "" && !found.Status.IsLatest) {
+			return errorResult(fmt.Sprintf("Agent '%s' not found", name)), nil
+		}
+		return jsonResult(agentDetail{
+			AgentCatalogSpec: found.Spec,
+			Environment:      found.Labels[agentregistryv1alpha1.LabelEnvironment],
+			UnresolvedTools:  found.Status.UnresolvedTools,
+			Instances:        instances,
+		}), nil
 
 	case "skills":
 		var list agentregistryv1alpha1.SkillCatalogList
@@ -398,10 +496,28 @@ func (s *MCPServer) handleGetCatalog(ctx context.Context, request mcp.CallToolRe
 		}); err != nil {
 			return errorResult(fmt.Sprintf("Failed to get model: %v", err)), nil
 		}
-		if len(list.Items) == 0 {
+		type modelDetail struct {
+			agentregistryv1alpha1.ModelCatalogSpec
+			Environment string                  `json:"environment,omitempty"`
+			Instances   []handlers.InstanceJSON `json:"instances,omitempty"`
+		}
+		var found *agentregistryv1alpha1.ModelCatalog
+		instances := make([]handlers.InstanceJSON, 0, len(list.Items))
+		for i := range list.Items {
+			item := &list.Items[i]
+			instances = append(instances, handlers.NewInstanceJSON(item, "", item.Status.Status, true, nil))
+			if found == nil && handlers.InEnvironment(item, environment) {
+				found = item
+			}
+		}
+		if found == nil {
 			return errorResult(fmt.Sprintf("Model '%s' not found", name)), nil
 		}
-		return jsonResult(list.Items[0].Spec), nil
+		return jsonResult(modelDetail{
+			ModelCatalogSpec: found.Spec,
+			Environment:      found.Labels[agentregistryv1alpha1.LabelEnvironment],
+			Instances:        instances,
+		}), nil
 
 	default:
 		return errorResult("Invalid type: must be servers, agents, skills, or models"), nil
@@ -478,11 +594,26 @@ func (s *MCPServer) handleGetDeployment(ctx context.Context, request mcp.CallToo
 		ResourceType     string            `json:"resourceType"`
 		Namespace        string            `json:"namespace"`
 		Config           map[string]string `json:"config,omitempty"`
+		ConfigFrom       map[string]string `json:"configFrom,omitempty"`
 		Phase            string            `json:"phase"`
 		Message          string            `json:"message,omitempty"`
 		ManagedResources []string          `json:"managedResources,omitempty"`
 	}
 
+	// Config read from Secrets and ConfigMaps is shown by reference only
+	var configFrom map[string]string
+	for _, entry := range deployment.Spec.ConfigFrom {
+		if configFrom == nil {
+			configFrom = make(map[string]string)
+		}
+		switch ref := entry.ValueFrom; {
+		case ref.SecretKeyRef != nil:
+			configFrom[entry.Name] = "secret:" + ref.SecretKeyRef.Name + "/" + ref.SecretKeyRef.Key
+		case ref.ConfigMapKeyRef != nil:
+			configFrom[entry.Name] = "configmap:" + ref.ConfigMapKeyRef.Name + "/" + ref.ConfigMapKeyRef.Key
+		}
+	}
+
 	managed := make([]string, 0)
 	for _, r := range deployment.Status.ManagedResources {
 		managed = append(managed, fmt.Sprintf("%s/%s (%s)", r.Namespace, r.Name, r.Kind))
@@ -495,6 +626,7 @@ func (s *MCPServer) handleGetDeployment(ctx context.Context, request mcp.CallToo
 		ResourceType:     string(deployment.Spec.ResourceType),
 		Namespace:        deployment.Spec.Namespace,
 		Config:           deployment.Spec.Config,
+		ConfigFrom:       configFrom,
 		Phase:            string(deployment.Status.Phase),
 		Message:          deployment.Status.Message,
 		ManagedResources: managed,
@@ -532,6 +664,10 @@ func (s *MCPServer) handleDeployCatalogItem(ctx context.Context, request mcp.Cal
 			}
 		}
 	}
+	configFrom, err := getSecretConfigArg(args)
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
 
 	deployment := &agentregistryv1alpha1.RegistryDeployment{}
 	deployment.Name = crName
@@ -548,6 +684,7 @@ func (s *MCPServer) handleDeployCatalogItem(ctx context.Context, request mcp.Cal
 		ResourceType: agentregistryv1alpha1.ResourceType(resourceType),
 		Runtime:      agentregistryv1alpha1.RuntimeTypeKubernetes,
 		Config:       config,
+		ConfigFrom:   configFrom,
 		Namespace:    namespace,
 	}
 
@@ -589,6 +726,13 @@ func (s *MCPServer) handleUpdateDeploymentConfig(ctx context.Context, request mc
 		return errorResult(fmt.Sprintf("Deployment '%s' not found", name)), nil
 	}
 
+	configFrom, err := getSecretConfigArg(args)
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+
+	// A value replaces a configFrom entry of the same name and vice versa
+	replaced := make(map[string]bool)
 	if cfgRaw, ok := args["config"]; ok && cfgRaw != nil {
 		if cfgMap, ok := cfgRaw.(map[string]interface{}); ok {
 			if deployment.Spec.Config == nil {
@@ -596,9 +740,18 @@ func (s *MCPServer) handleUpdateDeploymentConfig(ctx context.Context, request mc
 			}
 			for k, v := range cfgMap {
 				deployment.Spec.Config[k] = fmt.Sprintf("%v", v)
+				replaced[k] = true
 			}
 		}
 	}
+	for _, entry := range configFrom {
+		delete(deployment.Spec.Config, entry.Name)
+		replaced[entry.Name] = true
+	}
+	deployment.Spec.ConfigFrom = slices.DeleteFunc(deployment.Spec.ConfigFrom, func(entry agentregistryv1alpha1.ConfigValueFrom) bool {
+		return replaced[entry.Name]
+	})
+	deployment.Spec.ConfigFrom = append(deployment.Spec.ConfigFrom, configFrom...)
 
 	if err := s.client.Update(ctx, &deployment); err != nil {
 		return errorResult(fmt.Sprintf("Failed to update deployment: %v", err)), nil
@@ -719,7 +872,7 @@ func (s *MCPServer) handleTriggerDiscovery(ctx context.Context, request mcp.Call
 		if dc.Annotations == nil {
 			dc.Annotations = make(map[string]string)
 		}
-		dc.Annotations["agentregistry.dev/trigger-discovery"] = "true"
+		dc.Annotations[agentregistryv1alpha1.AnnotationTriggerDiscovery] = "true"
 		if err := s.client.Update(ctx, &dc); err != nil {
 			return errorResult(fmt.Sprintf("Failed to trigger discovery on %s: %v", dc.Name, err)), nil
 		}
@@ -937,6 +1090,7 @@ type modelBrief struct {
 	Name     string `json:"name"`
 	Provider string `json:"provider"`
 	Model    string `json:"model"`
+	Runtime  string `json:"runtime,omitempty"`
 }
 
 func summarizeModels(items []agentregistryv1alpha1.ModelCatalog) []modelBrief {
@@ -946,6 +1100,7 @@ func summarizeModels(items []agentregistryv1alpha1.ModelCatalog) []modelBrief {
 			Name:     item.Spec.Name,
 			Provider: item.Spec.Provider,
 			Model:    item.Spec.Model,
+			Runtime:  item.Spec.Runtime,
 		})
 	}
 	return result
@@ -196,7 +196,7 @@ func (r *agentRegistryRuntime) ensureKubernetesRuntime(
 		return err
 	}
 
-	// Apply ConfigMaps first
+	// Apply ConfigMaps and Secrets first
 	for _, configMap := range cfg.ConfigMaps {
 		if configMap.Namespace == "" {
 			configMap.Namespace = kagent.DefaultNamespace
@@ -205,6 +205,14 @@ func (r *agentRegistryRuntime) ensureKubernetesRuntime(
 			return fmt.Errorf("ConfigMap %s: %w", configMap.Name, err)
 		}
 	}
+	for _, secret := range cfg.Secrets {
+		if secret.Namespace == "" {
+			secret.Namespace = kagent.DefaultNamespace
+		}
+		if err := applyResource(ctx, c, secret, r.verbose); err != nil {
+			return fmt.Errorf("Secret %s: %w", secret.Name, err)
+		}
+	}
 
 	for _, agent := range cfg.Agents {
 		if agent.Namespace == "" {
@@ -65,6 +65,9 @@ type RemoteMCPServer struct {
 type HeaderValue struct {
 	Name  string
 	Value string
+	// Secret marks a value read from a Secret. It is stored in a Secret in the target
+	// namespace rather than inlined into the RemoteMCPServer.
+	Secret bool
 }
 
 // LocalMCPServer represents the configuration for running an MCPServer locally
@@ -107,12 +110,19 @@ type MCPServerDeployment struct {
 
 	// Env defines the environment variables to set in the container.
 	Env map[string]string `json:"env,omitempty"`
+
+	// SecretEnv defines environment variables read from Secrets. They are stored in a Secret in
+	// the target namespace rather than inlined into the MCPServer.
+	SecretEnv map[string]string `json:"secretEnv,omitempty"`
 }
 
 type AgentDeployment struct {
 	Image string            `json:"image,omitempty"`
 	Env   map[string]string `json:"env,omitempty"`
-	Port  uint16            `json:"port,omitempty"`
+	// SecretEnv defines environment variables read from Secrets, set from a Secret in the
+	// target namespace
+	SecretEnv map[string]string `json:"secretEnv,omitempty"`
+	Port      uint16            `json:"port,omitempty"`
 }
 
 type AIRuntimeConfig struct {
@@ -124,4 +134,5 @@ type KubernetesRuntimeConfig struct {
 	RemoteMCPServers []*v1alpha2.RemoteMCPServer `json:"remoteMCPServers"`
 	MCPServers       []*kmcpv1alpha1.MCPServer   `json:"mcpServers"`
 	ConfigMaps       []*corev1.ConfigMap         `json:"configMaps,omitempty"`
+	Secrets          []*corev1.Secret            `json:"secrets,omitempty"`
 }
@@ -33,6 +33,7 @@ func (t *translator) TranslateRuntimeConfig(
 ) (*api.AIRuntimeConfig, error) {
 	agents := make([]*v1alpha2.Agent, 0, len(desired.Agents))
 	configMaps := make([]*corev1.ConfigMap, 0)
+	secrets := make([]*corev1.Secret, 0)
 
 	for _, agent := range desired.Agents {
 		resource, err := t.translateAgent(agent)
@@ -40,6 +41,9 @@ func (t *translator) TranslateRuntimeConfig(
 			return nil, err
 		}
 		agents = append(agents, resource)
+		if len(agent.Deployment.SecretEnv) > 0 {
+			secrets = append(secrets, translateConfigSecret(resource.Name, resource.Namespace, agent.Deployment.SecretEnv))
+		}
 
 		// Generate ConfigMap for agent's resolved MCP server connections
 		if len(agent.ResolvedMCPServers) > 0 {
@@ -64,6 +68,15 @@ func (t *translator) TranslateRuntimeConfig(
 				return nil, err
 			}
 			remoteMCPs = append(remoteMCPs, resource)
+			secretHeaders := make(map[string]string)
+			for _, h := range server.Remote.Headers {
+				if h.Secret {
+					secretHeaders[h.Name] = h.Value
+				}
+			}
+			if len(secretHeaders) > 0 {
+				secrets = append(secrets, translateConfigSecret(resource.Name, resource.Namespace, secretHeaders))
+			}
 		case api.MCPServerTypeLocal:
 			if server.Local == nil {
 				continue
@@ -73,6 +86,9 @@ func (t *translator) TranslateRuntimeConfig(
 				return nil, err
 			}
 			mcpServers = append(mcpServers, resource)
+			if len(server.Local.Deployment.SecretEnv) > 0 {
+				secrets = append(secrets, translateConfigSecret(resource.Name, resource.Namespace, server.Local.Deployment.SecretEnv))
+			}
 		}
 	}
 
@@ -82,6 +98,7 @@ func (t *translator) TranslateRuntimeConfig(
 			RemoteMCPServers: remoteMCPs,
 			MCPServers:       mcpServers,
 			ConfigMaps:       configMaps,
+			Secrets:          secrets,
 		},
 	}, nil
 }
@@ -97,14 +114,32 @@ func (t *translator) translateAgent(agent *api.Agent) (*v1alpha2.Agent, error) {
 		namespace = value
 	}
 
-	envVars := make([]corev1.EnvVar, 0, len(agent.Deployment.Env))
-	if len(agent.Deployment.Env) > 0 {
-		keys := make([]string, 0, len(agent.Deployment.Env))
+	envVars := make([]corev1.EnvVar, 0, len(agent.Deployment.Env)+len(agent.Deployment.SecretEnv))
+	if len(agent.Deployment.Env) > 0 || len(agent.Deployment.SecretEnv) > 0 {
+		keys := make([]string, 0, len(agent.Deployment.Env)+len(agent.Deployment.SecretEnv))
 		for key := range agent.Deployment.Env {
+			if _, ok := agent.Deployment.SecretEnv[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		for key := range agent.Deployment.SecretEnv {
 			keys = append(keys, key)
 		}
 		slices.Sort(keys)
+		secretName := ConfigSecretName(AgentResourceName(agent.Name, agent.Version))
 		for _, key := range keys {
+			if _, ok := agent.Deployment.SecretEnv[key]; ok {
+				envVars = append(envVars, corev1.EnvVar{
+					Name: key,
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+							Key:                  key,
+						},
+					},
+				})
+				continue
+			}
 			envVars = append(envVars, corev1.EnvVar{
 				Name:  key,
 				Value: agent.Deployment.Env[key],
@@ -183,6 +218,25 @@ func (t *translator) translateRemoteMCPServer(server *api.MCPServer) (*v1alpha2.
 		namespace = server.Namespace
 	}
 
+	// Secret header values are read from the Secret translateConfigSecret creates
+	var headers []v1alpha2.ValueRef
+	for _, h := range server.Remote.Headers {
+		if !h.Secret {
+			if h.Value != "" {
+				headers = append(headers, v1alpha2.ValueRef{Name: h.Name, Value: h.Value})
+			}
+			continue
+		}
+		headers = append(headers, v1alpha2.ValueRef{
+			Name: h.Name,
+			ValueFrom: &v1alpha2.ValueSource{
+				Type: v1alpha2.SecretValueSource,
+				Name: ConfigSecretName(RemoteMCPResourceName(server.Name)),
+				Key:  h.Name,
+			},
+		})
+	}
+
 	return &v1alpha2.RemoteMCPServer{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "kagent.dev/v1alpha2",
@@ -199,6 +253,7 @@ func (t *translator) translateRemoteMCPServer(server *api.MCPServer) (*v1alpha2.
 			Description: server.Name,
 			Protocol:    v1alpha2.RemoteMCPServerProtocolStreamableHttp,
 			URL:         url,
+			HeadersFrom: headers,
 		},
 	}, nil
 }
@@ -228,6 +283,13 @@ func (t *translator) translateLocalMCPServer(server *api.MCPServer) (*kmcpv1alph
 		Args:  server.Local.Deployment.Args,
 		Env:   server.Local.Deployment.Env,
 	}
+	// kmcp has no per-variable secret references, so the Secret translateConfigSecret creates
+	// is keyed by variable name and loaded whole
+	if len(server.Local.Deployment.SecretEnv) > 0 {
+		deployment.SecretRefs = []corev1.LocalObjectReference{{
+			Name: ConfigSecretName(MCPServerResourceName(server.Name)),
+		}}
+	}
 	fmt.Printf("[DEBUG] kagent translateLocalMCPServer: name=%s, image=%s, cmd=%q, args=%v\n",
 		server.Name, deployment.Image, deployment.Cmd, deployment.Args)
 
@@ -305,6 +367,37 @@ func (t *translator) translateAgentConfigMap(agent *api.Agent) (*corev1.ConfigMa
 	}, nil
 }
 
+// translateConfigSecret creates the Secret holding the secret config values of the resource
+// named resourceName, keyed by environment variable or header name
+func translateConfigSecret(resourceName, namespace string, values map[string]string) *corev1.Secret {
+	data := make(map[string][]byte, len(values))
+	for key, value := range values {
+		data[key] = []byte(value)
+	}
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigSecretName(resourceName),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "agentregistry",
+				"app.kubernetes.io/component":  "deployment-config",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+}
+
+// ConfigSecretName returns the name of the Secret holding the secret config values of a
+// deployed resource
+func ConfigSecretName(resourceName string) string {
+	return sanitizeK8sName(resourceName + "-config")
+}
+
 // AgentConfigMapName returns the ConfigMap name for an agent
 func AgentConfigMapName(name, version string) string {
 	base := fmt.Sprintf("%s-mcp-config", name)
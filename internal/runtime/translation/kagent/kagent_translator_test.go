@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+
 	"github.com/agentregistry-dev/agentregistry/internal/runtime/translation/api"
 )
 
@@ -249,3 +251,111 @@ func TestTranslateRuntimeConfig_AgentWithMCPServers(t *testing.T) {
 		t.Error("Agent spec missing '/config' volume mount")
 	}
 }
+
+func TestTranslateRuntimeConfig_SecretConfig(t *testing.T) {
+	translator := NewTranslator()
+	ctx := context.Background()
+
+	desired := &api.DesiredState{
+		Agents: []*api.Agent{
+			{
+				Name:    "test-agent",
+				Version: "v1",
+				Deployment: api.AgentDeployment{
+					Image:     "agent-image:latest",
+					Env:       map[string]string{"MODEL_NAME": "gpt-4o", "OPENAI_API_KEY": "stale"},
+					SecretEnv: map[string]string{"OPENAI_API_KEY": "sk-agent"},
+				},
+			},
+		},
+		MCPServers: []*api.MCPServer{
+			{
+				Name:          "remote-server",
+				MCPServerType: api.MCPServerTypeRemote,
+				Namespace:     "tools",
+				Remote: &api.RemoteMCPServer{
+					Host: "example.com",
+					Path: "/mcp",
+					Headers: []api.HeaderValue{
+						{Name: "X-Tenant", Value: "acme"},
+						{Name: "Authorization", Value: "Bearer token", Secret: true},
+						{Name: "X-Unset"},
+					},
+				},
+			},
+			{
+				Name:          "local-server",
+				MCPServerType: api.MCPServerTypeLocal,
+				Namespace:     "tools",
+				Local: &api.LocalMCPServer{
+					TransportType: api.TransportTypeStdio,
+					Deployment: api.MCPServerDeployment{
+						Image:     "mcp-image:latest",
+						Env:       map[string]string{"LOG_LEVEL": "debug"},
+						SecretEnv: map[string]string{"GITHUB_TOKEN": "ghp_secret"},
+					},
+				},
+			},
+		},
+	}
+
+	config, err := translator.TranslateRuntimeConfig(ctx, desired)
+	if err != nil {
+		t.Fatalf("TranslateRuntimeConfig failed: %v", err)
+	}
+
+	secrets := make(map[string]*corev1.Secret)
+	for _, secret := range config.Kubernetes.Secrets {
+		secrets[secret.Namespace+"/"+secret.Name] = secret
+	}
+	if len(secrets) != 3 {
+		t.Fatalf("Expected 3 Secrets, got %d", len(config.Kubernetes.Secrets))
+	}
+
+	// Agent secret values are referenced from the Secret, never inlined
+	agentSecret := secrets["kagent/test-agent-v1-config"]
+	if agentSecret == nil || string(agentSecret.Data["OPENAI_API_KEY"]) != "sk-agent" {
+		t.Fatalf("Expected agent Secret with OPENAI_API_KEY, got %v", agentSecret)
+	}
+	env := config.Kubernetes.Agents[0].Spec.BYO.Deployment.Env
+	if len(env) != 2 {
+		t.Fatalf("Expected 2 env vars, got %d", len(env))
+	}
+	if env[0].Name != "MODEL_NAME" || env[0].Value != "gpt-4o" {
+		t.Errorf("Expected MODEL_NAME=gpt-4o, got %+v", env[0])
+	}
+	if env[1].Value != "" || env[1].ValueFrom == nil || env[1].ValueFrom.SecretKeyRef == nil ||
+		env[1].ValueFrom.SecretKeyRef.Name != "test-agent-v1-config" || env[1].ValueFrom.SecretKeyRef.Key != "OPENAI_API_KEY" {
+		t.Errorf("Expected OPENAI_API_KEY from Secret test-agent-v1-config, got %+v", env[1])
+	}
+
+	// Remote headers are set inline or from the Secret
+	headerSecret := secrets["tools/remote-server-config"]
+	if headerSecret == nil || len(headerSecret.Data) != 1 || string(headerSecret.Data["Authorization"]) != "Bearer token" {
+		t.Fatalf("Expected header Secret with Authorization only, got %v", headerSecret)
+	}
+	headers := config.Kubernetes.RemoteMCPServers[0].Spec.HeadersFrom
+	if len(headers) != 2 {
+		t.Fatalf("Expected 2 headers, got %d", len(headers))
+	}
+	if headers[0].Name != "X-Tenant" || headers[0].Value != "acme" || headers[0].ValueFrom != nil {
+		t.Errorf("Expected inline X-Tenant header, got %+v", headers[0])
+	}
+	if headers[1].Value != "" || headers[1].ValueFrom == nil || headers[1].ValueFrom.Type != "Secret" ||
+		headers[1].ValueFrom.Name != "remote-server-config" || headers[1].ValueFrom.Key != "Authorization" {
+		t.Errorf("Expected Authorization header from Secret remote-server-config, got %+v", headers[1])
+	}
+
+	// kmcp loads the whole Secret as environment variables
+	serverSecret := secrets["tools/local-server-config"]
+	if serverSecret == nil || string(serverSecret.Data["GITHUB_TOKEN"]) != "ghp_secret" {
+		t.Fatalf("Expected MCP server Secret with GITHUB_TOKEN, got %v", serverSecret)
+	}
+	deployment := config.Kubernetes.MCPServers[0].Spec.Deployment
+	if _, ok := deployment.Env["GITHUB_TOKEN"]; ok {
+		t.Errorf("Expected GITHUB_TOKEN not to be inlined in the MCPServer env")
+	}
+	if len(deployment.SecretRefs) != 1 || deployment.SecretRefs[0].Name != "local-server-config" {
+		t.Errorf("Expected secretRefs [local-server-config], got %v", deployment.SecretRefs)
+	}
+}
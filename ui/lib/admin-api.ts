@@ -151,9 +151,36 @@ export interface ServerResponse {
     source?: string // discovery, manual, deployment
     isDiscovered?: boolean
     usedBy?: Array<{ namespace: string; name: string; kind?: string; toolNames?: string[] }>
+    introspection?: IntrospectionJSON
   }
 }
 
+// What a live MCP server exposed when it was last probed
+export interface IntrospectionJSON {
+  url: string
+  transport?: string
+  serverInfo?: { name: string; title?: string; version?: string }
+  protocolVersion?: string
+  capabilities?: string[]
+  tools?: Array<{
+    name: string
+    title?: string
+    description?: string
+    inputSchema?: Record<string, unknown>
+    outputSchema?: Record<string, unknown>
+  }>
+  prompts?: Array<{
+    name: string
+    title?: string
+    description?: string
+    arguments?: Array<{ name: string; description?: string; required?: boolean }>
+  }>
+  resources?: Array<{ uri: string; name?: string; description?: string; mimeType?: string }>
+  lastProbed?: string
+  lastSuccess?: string
+  error?: string
+}
+
 export interface ServerListResponse {
   servers: ServerResponse[]
   metadata: {
@@ -400,6 +427,7 @@ export interface AgentResponse {
     deployment?: DeploymentInfo
     source?: string // discovery, manual, deployment
     isDiscovered?: boolean
+    unresolvedTools?: AgentToolRef[]
   }
 }
 
@@ -417,6 +445,7 @@ export interface ModelJSON {
   provider: string
   model: string
   baseUrl?: string
+  runtime?: string
   description?: string
 }
 
@@ -426,6 +455,13 @@ export interface ModelUsageRefJSON {
   kind?: string
 }
 
+export interface ModelLinkJSON {
+  name: string
+  runtime?: string
+  environment?: string
+  cluster?: string
+}
+
 export interface ModelRegistryExtensions {
   status: string
   publishedAt?: string
@@ -443,6 +479,8 @@ export interface ModelResponse {
     message?: string
     deployment?: DeploymentInfo
     isDiscovered?: boolean
+    servedBy?: ModelLinkJSON[]
+    modelConfigs?: ModelLinkJSON[]
   }
 }
 